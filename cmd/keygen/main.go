@@ -0,0 +1,78 @@
+// Command keygen creates a validator's keys: it writes each secret key,
+// hex-encoded, to its file (never overwriting one) and prints what the
+// validator directory needs (see p2p.LoadDirectory).
+//
+//	go run ./cmd/keygen -bls data/bls.key -p2p data/p2p.key
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+func main() {
+	blsFile := flag.String("bls", "data/bls.key", "BLS secret key file (BLS_KEY_FILE)")
+	p2pFile := flag.String("p2p", "data/p2p.key", "libp2p identity key file (P2P_KEY_FILE)")
+	flag.Parse()
+
+	signer, err := crypto.GenerateBLSSigner()
+	if err != nil {
+		log.Fatalf("bls key: %v", err)
+	}
+	sk, err := signer.MarshalBinary()
+	if err != nil {
+		log.Fatalf("bls key: %v", err)
+	}
+	pk, err := signer.Pubkey().MarshalBinary()
+	if err != nil {
+		log.Fatalf("bls key: %v", err)
+	}
+	if err := writeKey(*blsFile, sk); err != nil {
+		log.Fatalf("write %s: %v", *blsFile, err)
+	}
+
+	identity, _, err := p2pcrypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		log.Fatalf("p2p key: %v", err)
+	}
+	raw, err := p2pcrypto.MarshalPrivateKey(identity)
+	if err != nil {
+		log.Fatalf("p2p key: %v", err)
+	}
+	pid, err := peer.IDFromPrivateKey(identity)
+	if err != nil {
+		log.Fatalf("p2p key: %v", err)
+	}
+	if err := writeKey(*p2pFile, raw); err != nil {
+		log.Fatalf("write %s: %v", *p2pFile, err)
+	}
+
+	fmt.Printf("peer_id: %s\n", pid)
+	fmt.Printf("bls_pubkey: %x\n", pk)
+}
+
+// writeKey writes key, hex-encoded, to a new file only its owner can read.
+func writeKey(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
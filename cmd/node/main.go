@@ -1,15 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/uhyunpark/hyperlicked/params"
 	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/abci/remote"
 	"github.com/uhyunpark/hyperlicked/pkg/api"
 	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
 	"github.com/uhyunpark/hyperlicked/pkg/consensus"
@@ -38,35 +48,75 @@ func main() {
 	sugar.Infow("logger_initialized", "log_file", logFile)
 
 	// ---- App: Perp DEX (production) ----
-	app := perp.NewApp()
-	// Market initialized in NewApp(): BTC-USDT only
-	//
-	// NOTE: Sample transactions removed - all orders must be signed (EIP-712).
-	// Use frontend wallet or TxFeeder (ENABLE_TXGEN=true) to generate orders.
+	// In process by default. With ABCI_ADDR (unix://<path> or host:port) the
+	// node drives an app served there instead (cmd/perp-app); the mempool,
+	// tx feeder and API server belong to the in-process app and stay off.
+	var (
+		app         *perp.App // nil when the app is remote
+		application abci.Application
+		txPool      p2p.TxPool // nil: proposal txs are fetched from peers
+	)
+	if abciAddr := os.Getenv("ABCI_ADDR"); abciAddr != "" {
+		client, err := remote.Dial(abciAddr)
+		if err != nil {
+			sugar.Fatalw("abci_dial_failed", "addr", abciAddr, "err", err)
+		}
+		defer client.Close()
+		application = client
+		sugar.Infow("abci_remote_app", "addr", abciAddr)
+	} else {
+		app = perp.NewApp()
+		// Market initialized in NewApp(): BTC-USDT only
+		//
+		// NOTE: Sample transactions removed - all orders must be signed (EIP-712).
+		// Use frontend wallet or TxFeeder (ENABLE_TXGEN=true) to generate orders.
+		application, txPool = app, app
+	}
 
-	bridge := &abci.Bridge{App: app}
+	bridge := &abci.Bridge{App: application}
 
 	// ---- Consensus ----
-	selfID := consensus.NodeID(cfg.Consensus.Validators[0])
+	selfID := consensus.NodeID(cfg.Node.ID)
+	if selfID == "" {
+		selfID = consensus.NodeID(cfg.Consensus.Validators[0])
+	}
 
-	// Build validator set from config
-	var ids []consensus.NodeID
-	for _, s := range cfg.Consensus.Validators {
-		ids = append(ids, consensus.NodeID(s))
+	// Validator directory: NodeID -> libp2p peer, addresses and BLS key.
+	// Loaded from the validators file (genesis) when configured; otherwise,
+	// with DEVNET_KEYS=true only, a devnet directory is derived from the
+	// validator names.
+	var dir *p2p.Directory
+	if cfg.Consensus.ValidatorsFile != "" {
+		dir, err = p2p.LoadDirectory(cfg.Consensus.ValidatorsFile)
+	} else if cfg.Node.DevnetKeys {
+		dir, err = devDirectory(cfg.Consensus.Validators)
+	} else {
+		err = errors.New("no VALIDATORS_FILE (DEVNET_KEYS=true derives a devnet directory)")
+	}
+	if err != nil {
+		sugar.Fatalw("validator_directory_failed", "file", cfg.Consensus.ValidatorsFile, "err", err)
 	}
+	self, ok := dir.Lookup(selfID)
+	if !ok {
+		sugar.Fatalw("self_not_in_validator_directory", "self", selfID)
+	}
+	ids := dir.IDs()
 
+	// Genesis validator set: directory order with the configured voting
+	// power. Quorums are >2/3 of the power; the app changes the set at epoch
+	// boundaries (see consensus/epoch.go).
 	// For single-node development: only use this validator
-	// For multi-node: use all validators
-	// TODO: Proper peer discovery & dynamic validator set
+	genesisSet, err := dir.ValidatorSet()
 	singleNodeMode := cfg.Node.SingleNode
 	if singleNodeMode {
 		ids = []consensus.NodeID{selfID}
+		genesisSet, err = consensus.EqualPowerSet(0, ids)
+	}
+	if err != nil {
+		sugar.Fatalw("genesis_validator_set_failed", "err", err)
 	}
 
-	// Quorum: N validators, need 2f+1 = 2*t+1 where N=3t+1
-	// For N=1: t=0, need 1 vote (single-node dev mode)
-	// For N=4: t=1, need 3 votes
-	// For N=7: t=2, need 5 votes
+	// Count-based summary for status reporting (N=3t+1)
 	n := len(ids)
 	t := (n - 1) / 3
 
@@ -84,8 +134,29 @@ func main() {
 	)
 
 	// Network: always use libp2p (works for any number of validators)
-	elec := consensus.RoundRobinElector{IDs: ids}
-	var signer interface{} = crypto.DummySigner{}
+	// BLS: votes are signed and every certificate must carry an aggregate
+	// signature from validators holding >2/3 of the epoch's voting power. The
+	// private key comes from BLS_KEY_FILE (devnet: derived from the name) and
+	// must match the directory's key for this node.
+	pubKeys := dir.PubKeys()
+	signer, err := nodeBLSSigner(cfg.Node, selfID)
+	if err != nil {
+		sugar.Fatalw("bls_key_failed", "file", cfg.Node.BLSKeyFile, "err", err)
+	}
+	if self.BLSKey == nil || !self.BLSKey.Equal(signer.Pubkey()) {
+		sugar.Fatalw("bls_key_not_in_validator_directory", "self", selfID)
+	}
+
+	// libp2p identity: from P2P_KEY_FILE (always, with a validators file;
+	// devnet: derived from the name). Peers authenticate us by its peer ID,
+	// so it must be the directory's entry for this node.
+	identity, err := nodeP2PKey(cfg, selfID)
+	if err != nil {
+		sugar.Fatalw("p2p_key_failed", "file", cfg.Node.P2PKeyFile, "err", err)
+	}
+	if pid, err := peer.IDFromPrivateKey(identity); err != nil || pid != self.PeerID {
+		sugar.Fatalw("p2p_identity_not_in_validator_directory", "self", selfID, "peer", pid.String(), "want", self.PeerID.String())
+	}
 
 	lpn, err := p2p.NewLibp2pNet(context.Background(), p2p.Libp2pConfig{
 		ListenAddr: os.Getenv("LISTEN"),
@@ -93,15 +164,62 @@ func main() {
 		SelfID:     state.SelfID,
 		Quorum:     state.Q,
 		Logger:     sugar,
+		Identity:   identity,
+		Directory:  dir,
+		TxPool:     txPool,
 	})
 	if err != nil {
 		sugar.Fatalw("libp2p_init_failed", "err", err)
 	}
 	net := lpn
 
-	engine := consensus.NewEngine(state, safety, pm, bridge, net, elec, signer)
+	engine := consensus.NewEngine(state, safety, pm, bridge, net, nil, signer)
 	engine.Logger = sugar
-	engine.Store = storage.NewInMemoryBlockStore()
+	engine.EnableBLS = true
+	engine.PubKeys = pubKeys
+	engine.Validators = ids
+	engine.ChainID = cfg.Consensus.ChainID
+	engine.GenesisSet = genesisSet
+	engine.EpochLength = consensus.Height(cfg.Consensus.EpochLength)
+	engine.MaxClockDrift = cfg.Consensus.MaxClockDrift
+	switch cfg.Consensus.LeaderElection {
+	case "round-robin":
+		// Leaders rotate over the active epoch's validators
+		engine.Elector = consensus.ValidatorSetElector{Set: engine.ActiveValidators}
+	case "reputation":
+		// Stake-weighted, skipping validators absent from recent blocks
+		engine.Elector = consensus.NewReputationElector(engine)
+	default:
+		sugar.Fatalw("unknown_leader_election", "value", cfg.Consensus.LeaderElection)
+	}
+
+	// Blocks, certificates and the voting state survive restarts: a recovered
+	// validator resumes after its last vote instead of voting in that view again
+	dbPath := os.Getenv("CONSENSUS_DB")
+	if dbPath == "" {
+		dbPath = "data/consensus.db"
+	}
+	store, err := storage.NewPebbleStore(dbPath)
+	if err != nil {
+		sugar.Fatalw("consensus_db_open_failed", "path", dbPath, "err", err)
+	}
+	defer store.Close()
+	engine.Store = store
+	safety.Store = store
+
+	walDir := os.Getenv("CONSENSUS_WAL")
+	if walDir == "" {
+		walDir = "data/wal"
+	}
+	wal, err := storage.OpenFileWAL(walDir, storage.DefaultWALSegmentSize)
+	if err != nil {
+		sugar.Fatalw("consensus_wal_open_failed", "dir", walDir, "err", err)
+	}
+	defer wal.Close()
+	engine.WAL = wal
+	if err := engine.Recover(); err != nil {
+		sugar.Fatalw("consensus_recover_failed", "err", err)
+	}
 	engine.MinBlockTime = cfg.Node.MinBlockTime // Apply block time throttle from config
 
 	// Control logging verbosity via env var (default: quiet)
@@ -117,7 +235,7 @@ func main() {
 
 	// ---- Transaction Feeder (optional) ----
 	// Enable with: ENABLE_TXGEN=true TXGEN_MODE=default|high|hyperliquid
-	if os.Getenv("ENABLE_TXGEN") == "true" {
+	if os.Getenv("ENABLE_TXGEN") == "true" && app != nil {
 		var txCfg perp.TxFeederConfig
 		mode := os.Getenv("TXGEN_MODE")
 		switch mode {
@@ -146,31 +264,41 @@ func main() {
 		"config_validators", len(cfg.Consensus.Validators),
 		"active_validators", len(ids),
 		"single_node_mode", singleNodeMode,
-		"quorum_need", 2*t+1)
+		"total_power", genesisSet.TotalPower(),
+		"quorum_power", genesisSet.QuorumPower(),
+		"epoch_length", engine.EpochLength,
+		"leader_election", cfg.Consensus.LeaderElection)
 
 	// ---- API Server ----
-	// Start HTTP/WebSocket server for frontend
-	apiServer := api.NewServer(app)
-	apiAddr := os.Getenv("API_ADDR")
-	if apiAddr == "" {
-		apiAddr = ":8080"
-	}
-
-	go func() {
-		sugar.Infow("api_server_starting", "addr", apiAddr)
-		if err := apiServer.Start(apiAddr); err != nil {
-			sugar.Fatalw("api_server_failed", "err", err)
+	// Start HTTP/WebSocket server for frontend (in-process app only: it
+	// reads the app's books and accounts directly)
+	if app != nil {
+		apiServer := api.NewServer(app)
+		apiServer.SetChain(store, state)
+		apiServer.SetProposalMetrics(engine.ProposalMetrics)
+		apiAddr := os.Getenv("API_ADDR")
+		if apiAddr == "" {
+			apiAddr = ":8080"
 		}
-	}()
 
-	// Hook API server to consensus and app: broadcast updates on every block commit
-	engine.OnBlockCommit = func(height consensus.Height) {
-		apiServer.BroadcastOrderbook("BTC-USDT", int64(height))
-	}
+		go func() {
+			sugar.Infow("api_server_starting", "addr", apiAddr)
+			if err := apiServer.Start(apiAddr); err != nil {
+				sugar.Fatalw("api_server_failed", "err", err)
+			}
+		}()
+
+		// Hook API server to consensus and app: broadcast updates on every block commit
+		engine.OnBlockCommit = func(height consensus.Height) {
+			apiServer.BroadcastOrderbook("BTC-USDT", int64(height))
+		}
 
-	// Hook app to API server: broadcast trades when they execute
-	app.OnTrade = func(symbol string, price, size int64, side string, timestamp int64) {
-		apiServer.BroadcastTrade(symbol, price, size, side, timestamp)
+		// Hook app to API server: broadcast trades when they execute
+		app.OnTrade = func(symbol string, price, size int64, side string, timestamp int64) {
+			apiServer.BroadcastTrade(symbol, price, size, side, timestamp)
+		}
+	} else {
+		sugar.Info("api_server_disabled - the app runs out of process")
 	}
 
 	// Start consensus engine (HotStuff Run loop)
@@ -201,3 +329,82 @@ func main() {
 		}
 	}
 }
+
+// nodeBLSSigner loads this validator's BLS key from cfg.BLSKeyFile, or
+// derives it from the name with cfg.DevnetKeys.
+func nodeBLSSigner(cfg params.Node, id consensus.NodeID) (*crypto.BLSSigner, error) {
+	if cfg.BLSKeyFile == "" {
+		if !cfg.DevnetKeys {
+			return nil, errors.New("no BLS_KEY_FILE (DEVNET_KEYS=true derives a devnet key)")
+		}
+		return devBLSSigner(id), nil
+	}
+	key, err := readHexKey(cfg.BLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ParseBLSSigner(key)
+}
+
+// nodeP2PKey loads this validator's libp2p identity from P2PKeyFile. Only
+// the devnet directory (no ValidatorsFile) may derive it from the name.
+func nodeP2PKey(cfg params.Config, id consensus.NodeID) (p2pcrypto.PrivKey, error) {
+	if cfg.Node.P2PKeyFile == "" {
+		if cfg.Consensus.ValidatorsFile != "" || !cfg.Node.DevnetKeys {
+			return nil, errors.New("no P2P_KEY_FILE (only the devnet directory derives identities)")
+		}
+		return devP2PKey(id), nil
+	}
+	key, err := readHexKey(cfg.Node.P2PKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return p2pcrypto.UnmarshalPrivateKey(key)
+}
+
+// readHexKey reads a hex-encoded key file, as cmd/keygen writes it.
+func readHexKey(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// devBLSSigner derives a deterministic devnet BLS key from a validator name.
+// NOT for production: anyone knowing the name can derive the key.
+func devBLSSigner(id consensus.NodeID) *crypto.BLSSigner {
+	seed := sha256.Sum256([]byte("hyperlicked-devnet-bls/" + string(id)))
+	return crypto.NewBLSSignerFromSeed(seed[:])
+}
+
+// devP2PKey derives a deterministic devnet libp2p identity from a validator
+// name, so every node can compute every other node's peer ID.
+// NOT for production, same as devBLSSigner.
+func devP2PKey(id consensus.NodeID) p2pcrypto.PrivKey {
+	seed := sha256.Sum256([]byte("hyperlicked-devnet-p2p/" + string(id)))
+	priv, _, err := p2pcrypto.GenerateEd25519Key(bytes.NewReader(seed[:]))
+	if err != nil {
+		panic(err) // only fails on a short reader
+	}
+	return priv
+}
+
+// devDirectory builds the devnet validator directory from derived keys.
+// Addresses are unknown; peers find each other through bootstrap.
+func devDirectory(names []string) (*p2p.Directory, error) {
+	vals := make([]p2p.ValidatorInfo, 0, len(names))
+	for _, name := range names {
+		id := consensus.NodeID(name)
+		pid, err := peer.IDFromPrivateKey(devP2PKey(id))
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, p2p.ValidatorInfo{ID: id, PeerID: pid, BLSKey: devBLSSigner(id).Pubkey()})
+	}
+	return p2p.NewDirectory(vals)
+}
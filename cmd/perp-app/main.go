@@ -0,0 +1,47 @@
+// Command perp-app runs the perp DEX state machine as an out-of-process
+// application: it serves perp.App over the gRPC service of pkg/abci/abcipb
+// for a node to drive through remote.Client.
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci/remote"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+)
+
+func main() {
+	// unix://<path> or host:port
+	addr := os.Getenv("ABCI_ADDR")
+	if addr == "" {
+		addr = "unix://data/abci.sock"
+	}
+	if err := os.MkdirAll("data", 0o755); err != nil {
+		log.Fatalf("data dir: %v", err)
+	}
+
+	app := perp.NewApp()
+	lis, err := remote.Listen(addr)
+	if err != nil {
+		log.Fatalf("listen %s: %v", addr, err)
+	}
+	srv := remote.NewGRPCServer(app)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		srv.GracefulStop()
+	}()
+
+	log.Printf("[app] serving on %s", addr)
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+	if err := app.Close(); err != nil {
+		log.Printf("[app] close: %v", err)
+	}
+}
@@ -9,15 +9,44 @@ import (
 )
 
 type Consensus struct {
+	ChainID    string // domain-separates signatures between networks (see consensus.VoteSignBytes)
 	Validators []string
-	Ppc        time.Duration // leader status wait (Case-2)
-	Delta      time.Duration // network upper bound
+	// ValidatorsFile is a JSON validator directory (genesis validator set with
+	// peer IDs, addresses and BLS keys). Empty derives a devnet directory
+	// from Validators.
+	ValidatorsFile string
+	Ppc            time.Duration // leader status wait (Case-2)
+	Delta          time.Duration // network upper bound
+	// EpochLength is the number of blocks per epoch; validator set changes
+	// emitted by the app take effect at epoch boundaries. 0 keeps the
+	// genesis set forever.
+	EpochLength uint64
+	// LeaderElection picks the leader elector: "reputation" (stake-weighted,
+	// skipping validators that stopped proposing and voting) or
+	// "round-robin".
+	LeaderElection string
+	// MaxClockDrift is how far a proposed block's time may be from our
+	// clock (see consensus/bfttime.go). 0 disables the check.
+	MaxClockDrift time.Duration
 }
 
 type Node struct {
+	ID         string // this validator's NodeID; defaults to the first validator
 	SingleNode bool
-	// MinBlockTime throttles block production to prevent excessive empty blocks
-	// in single-node devnet with fast-path enabled.
+	// BLSKeyFile holds this validator's hex-encoded BLS secret key (see
+	// cmd/keygen); its public key must be the node's directory entry.
+	BLSKeyFile string
+	// P2PKeyFile holds this validator's hex-encoded libp2p identity key (see
+	// cmd/keygen); its peer ID must be the node's directory entry. Required
+	// with a ValidatorsFile.
+	P2PKeyFile string
+	// DevnetKeys derives every key and the validator directory from the
+	// validator names instead. Anyone knowing the names can sign for every
+	// validator: devnet only.
+	DevnetKeys bool
+	// MinBlockTime throttles empty block production in single-node devnet with
+	// fast-path enabled. Blocks with transactions are proposed as soon as the
+	// previous QC forms.
 	//
 	// Recommended values:
 	//   - Devnet (single node):  200ms (5 blocks/sec, prevents log spam)
@@ -37,9 +66,13 @@ type Config struct {
 func Default() Config {
 	return Config{
 		Consensus: Consensus{
-			Validators: []string{"val1", "val2", "val3", "val4"},
-			Ppc:        150 * time.Millisecond,
-			Delta:      50 * time.Millisecond,
+			ChainID:        "hyperlicked-devnet",
+			Validators:     []string{"val1", "val2", "val3", "val4"},
+			Ppc:            150 * time.Millisecond,
+			Delta:          50 * time.Millisecond,
+			EpochLength:    1000,
+			LeaderElection: "reputation",
+			MaxClockDrift:  5 * time.Second,
 		},
 		Node: Node{
 			SingleNode:   true,
@@ -61,6 +94,13 @@ func LoadFromEnv(envPath string) Config {
 	}
 
 	// Override with environment variables
+	cfg.Consensus.ChainID = getEnv("CHAIN_ID", cfg.Consensus.ChainID)
+	cfg.Consensus.ValidatorsFile = getEnv("VALIDATORS_FILE", cfg.Consensus.ValidatorsFile)
+	cfg.Node.ID = getEnv("NODE_ID", cfg.Node.ID)
+	cfg.Node.BLSKeyFile = getEnv("BLS_KEY_FILE", cfg.Node.BLSKeyFile)
+	cfg.Node.P2PKeyFile = getEnv("P2P_KEY_FILE", cfg.Node.P2PKeyFile)
+	cfg.Consensus.LeaderElection = getEnv("CONSENSUS_LEADER_ELECTION", cfg.Consensus.LeaderElection)
+
 	if ppc := os.Getenv("CONSENSUS_PPC_MS"); ppc != "" {
 		if ms, err := strconv.Atoi(ppc); err == nil {
 			cfg.Consensus.Ppc = time.Duration(ms) * time.Millisecond
@@ -73,6 +113,18 @@ func LoadFromEnv(envPath string) Config {
 		}
 	}
 
+	if epoch := os.Getenv("CONSENSUS_EPOCH_LENGTH"); epoch != "" {
+		if n, err := strconv.ParseUint(epoch, 10, 64); err == nil {
+			cfg.Consensus.EpochLength = n
+		}
+	}
+
+	if drift := os.Getenv("CONSENSUS_MAX_CLOCK_DRIFT_MS"); drift != "" {
+		if ms, err := strconv.Atoi(drift); err == nil {
+			cfg.Consensus.MaxClockDrift = time.Duration(ms) * time.Millisecond
+		}
+	}
+
 	if minBlock := os.Getenv("NODE_MIN_BLOCK_TIME_MS"); minBlock != "" {
 		if ms, err := strconv.Atoi(minBlock); err == nil {
 			cfg.Node.MinBlockTime = time.Duration(ms) * time.Millisecond
@@ -81,6 +133,9 @@ func LoadFromEnv(envPath string) Config {
 	if singleNode := os.Getenv("SINGLE_NODE"); singleNode != "" {
 		cfg.Node.SingleNode = singleNode == "true"
 	}
+	if devnetKeys := os.Getenv("DEVNET_KEYS"); devnetKeys != "" {
+		cfg.Node.DevnetKeys = devnetKeys == "true"
+	}
 
 	// Validators from comma-separated list
 	if vals := os.Getenv("CONSENSUS_VALIDATORS"); vals != "" {
@@ -0,0 +1,923 @@
+// The application interface of pkg/abci (abci.Application) as a gRPC
+// service, so the state machine can run in its own process. Messages mirror
+// the Go request/response types; hashes are 32 bytes.
+//
+// Regenerate with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative abci.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: abci.proto
+
+package abcipb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RequestPrepareProposal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        int64                  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	MaxTxBytes    int64                  `protobuf:"varint,2,opt,name=max_tx_bytes,json=maxTxBytes,proto3" json:"max_tx_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestPrepareProposal) Reset() {
+	*x = RequestPrepareProposal{}
+	mi := &file_abci_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestPrepareProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestPrepareProposal) ProtoMessage() {}
+
+func (x *RequestPrepareProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestPrepareProposal.ProtoReflect.Descriptor instead.
+func (*RequestPrepareProposal) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RequestPrepareProposal) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RequestPrepareProposal) GetMaxTxBytes() int64 {
+	if x != nil {
+		return x.MaxTxBytes
+	}
+	return 0
+}
+
+type ResponsePrepareProposal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Txs           [][]byte               `protobuf:"bytes,1,rep,name=txs,proto3" json:"txs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponsePrepareProposal) Reset() {
+	*x = ResponsePrepareProposal{}
+	mi := &file_abci_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponsePrepareProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponsePrepareProposal) ProtoMessage() {}
+
+func (x *ResponsePrepareProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponsePrepareProposal.ProtoReflect.Descriptor instead.
+func (*ResponsePrepareProposal) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResponsePrepareProposal) GetTxs() [][]byte {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+type RequestProcessProposal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        int64                  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix seconds
+	Txs           [][]byte               `protobuf:"bytes,3,rep,name=txs,proto3" json:"txs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestProcessProposal) Reset() {
+	*x = RequestProcessProposal{}
+	mi := &file_abci_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestProcessProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestProcessProposal) ProtoMessage() {}
+
+func (x *RequestProcessProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestProcessProposal.ProtoReflect.Descriptor instead.
+func (*RequestProcessProposal) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RequestProcessProposal) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RequestProcessProposal) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *RequestProcessProposal) GetTxs() [][]byte {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+type ResponseProcessProposal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accept        bool                   `protobuf:"varint,1,opt,name=accept,proto3" json:"accept,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseProcessProposal) Reset() {
+	*x = ResponseProcessProposal{}
+	mi := &file_abci_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseProcessProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseProcessProposal) ProtoMessage() {}
+
+func (x *ResponseProcessProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseProcessProposal.ProtoReflect.Descriptor instead.
+func (*ResponseProcessProposal) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ResponseProcessProposal) GetAccept() bool {
+	if x != nil {
+		return x.Accept
+	}
+	return false
+}
+
+func (x *ResponseProcessProposal) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RequestExecute struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Hash      []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Parent    []byte                 `protobuf:"bytes,2,opt,name=parent,proto3" json:"parent,omitempty"`
+	Height    int64                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Timestamp int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix seconds
+	Txs       [][]byte               `protobuf:"bytes,5,rep,name=txs,proto3" json:"txs,omitempty"`
+	// Verified evidence, each in its payload encoding (consensus.EvidenceTx)
+	Misbehavior   [][]byte `protobuf:"bytes,6,rep,name=misbehavior,proto3" json:"misbehavior,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestExecute) Reset() {
+	*x = RequestExecute{}
+	mi := &file_abci_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestExecute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestExecute) ProtoMessage() {}
+
+func (x *RequestExecute) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestExecute.ProtoReflect.Descriptor instead.
+func (*RequestExecute) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RequestExecute) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *RequestExecute) GetParent() []byte {
+	if x != nil {
+		return x.Parent
+	}
+	return nil
+}
+
+func (x *RequestExecute) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RequestExecute) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *RequestExecute) GetTxs() [][]byte {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+func (x *RequestExecute) GetMisbehavior() [][]byte {
+	if x != nil {
+		return x.Misbehavior
+	}
+	return nil
+}
+
+type ValidatorUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Power         uint64                 `protobuf:"varint,2,opt,name=power,proto3" json:"power,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidatorUpdate) Reset() {
+	*x = ValidatorUpdate{}
+	mi := &file_abci_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidatorUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorUpdate) ProtoMessage() {}
+
+func (x *ValidatorUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorUpdate.ProtoReflect.Descriptor instead.
+func (*ValidatorUpdate) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ValidatorUpdate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ValidatorUpdate) GetPower() uint64 {
+	if x != nil {
+		return x.Power
+	}
+	return 0
+}
+
+type ResponseExecute struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Events           []string               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	AppHash          []byte                 `protobuf:"bytes,2,opt,name=app_hash,json=appHash,proto3" json:"app_hash,omitempty"`
+	ValidatorUpdates []*ValidatorUpdate     `protobuf:"bytes,3,rep,name=validator_updates,json=validatorUpdates,proto3" json:"validator_updates,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ResponseExecute) Reset() {
+	*x = ResponseExecute{}
+	mi := &file_abci_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseExecute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseExecute) ProtoMessage() {}
+
+func (x *ResponseExecute) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseExecute.ProtoReflect.Descriptor instead.
+func (*ResponseExecute) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ResponseExecute) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ResponseExecute) GetAppHash() []byte {
+	if x != nil {
+		return x.AppHash
+	}
+	return nil
+}
+
+func (x *ResponseExecute) GetValidatorUpdates() []*ValidatorUpdate {
+	if x != nil {
+		return x.ValidatorUpdates
+	}
+	return nil
+}
+
+type RequestCommit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestCommit) Reset() {
+	*x = RequestCommit{}
+	mi := &file_abci_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestCommit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestCommit) ProtoMessage() {}
+
+func (x *RequestCommit) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestCommit.ProtoReflect.Descriptor instead.
+func (*RequestCommit) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RequestCommit) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type ResponseCommit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseCommit) Reset() {
+	*x = ResponseCommit{}
+	mi := &file_abci_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseCommit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseCommit) ProtoMessage() {}
+
+func (x *ResponseCommit) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseCommit.ProtoReflect.Descriptor instead.
+func (*ResponseCommit) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{8}
+}
+
+type RequestRollback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestRollback) Reset() {
+	*x = RequestRollback{}
+	mi := &file_abci_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestRollback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestRollback) ProtoMessage() {}
+
+func (x *RequestRollback) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestRollback.ProtoReflect.Descriptor instead.
+func (*RequestRollback) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RequestRollback) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type ResponseRollback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseRollback) Reset() {
+	*x = ResponseRollback{}
+	mi := &file_abci_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseRollback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseRollback) ProtoMessage() {}
+
+func (x *ResponseRollback) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseRollback.ProtoReflect.Descriptor instead.
+func (*ResponseRollback) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{10}
+}
+
+type RequestQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestQuery) Reset() {
+	*x = RequestQuery{}
+	mi := &file_abci_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestQuery) ProtoMessage() {}
+
+func (x *RequestQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestQuery.ProtoReflect.Descriptor instead.
+func (*RequestQuery) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RequestQuery) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RequestQuery) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ResponseQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseQuery) Reset() {
+	*x = ResponseQuery{}
+	mi := &file_abci_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseQuery) ProtoMessage() {}
+
+func (x *ResponseQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseQuery.ProtoReflect.Descriptor instead.
+func (*ResponseQuery) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ResponseQuery) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type RequestCheckTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tx            []byte                 `protobuf:"bytes,1,opt,name=tx,proto3" json:"tx,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestCheckTx) Reset() {
+	*x = RequestCheckTx{}
+	mi := &file_abci_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestCheckTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestCheckTx) ProtoMessage() {}
+
+func (x *RequestCheckTx) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestCheckTx.ProtoReflect.Descriptor instead.
+func (*RequestCheckTx) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RequestCheckTx) GetTx() []byte {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+type ResponseCheckTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accept        bool                   `protobuf:"varint,1,opt,name=accept,proto3" json:"accept,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseCheckTx) Reset() {
+	*x = ResponseCheckTx{}
+	mi := &file_abci_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseCheckTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseCheckTx) ProtoMessage() {}
+
+func (x *ResponseCheckTx) ProtoReflect() protoreflect.Message {
+	mi := &file_abci_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseCheckTx.ProtoReflect.Descriptor instead.
+func (*ResponseCheckTx) Descriptor() ([]byte, []int) {
+	return file_abci_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResponseCheckTx) GetAccept() bool {
+	if x != nil {
+		return x.Accept
+	}
+	return false
+}
+
+func (x *ResponseCheckTx) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+var File_abci_proto protoreflect.FileDescriptor
+
+const file_abci_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"abci.proto\x12\x13hyperlicked.abci.v1\"R\n" +
+	"\x16RequestPrepareProposal\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x03R\x06height\x12 \n" +
+	"\fmax_tx_bytes\x18\x02 \x01(\x03R\n" +
+	"maxTxBytes\"+\n" +
+	"\x17ResponsePrepareProposal\x12\x10\n" +
+	"\x03txs\x18\x01 \x03(\fR\x03txs\"`\n" +
+	"\x16RequestProcessProposal\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x03R\x06height\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03txs\x18\x03 \x03(\fR\x03txs\"I\n" +
+	"\x17ResponseProcessProposal\x12\x16\n" +
+	"\x06accept\x18\x01 \x01(\bR\x06accept\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xa6\x01\n" +
+	"\x0eRequestExecute\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\x12\x16\n" +
+	"\x06parent\x18\x02 \x01(\fR\x06parent\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x03R\x06height\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03txs\x18\x05 \x03(\fR\x03txs\x12 \n" +
+	"\vmisbehavior\x18\x06 \x03(\fR\vmisbehavior\"7\n" +
+	"\x0fValidatorUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05power\x18\x02 \x01(\x04R\x05power\"\x97\x01\n" +
+	"\x0fResponseExecute\x12\x16\n" +
+	"\x06events\x18\x01 \x03(\tR\x06events\x12\x19\n" +
+	"\bapp_hash\x18\x02 \x01(\fR\aappHash\x12Q\n" +
+	"\x11validator_updates\x18\x03 \x03(\v2$.hyperlicked.abci.v1.ValidatorUpdateR\x10validatorUpdates\"#\n" +
+	"\rRequestCommit\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\"\x10\n" +
+	"\x0eResponseCommit\"%\n" +
+	"\x0fRequestRollback\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\"\x12\n" +
+	"\x10ResponseRollback\"6\n" +
+	"\fRequestQuery\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"%\n" +
+	"\rResponseQuery\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\fR\x05value\" \n" +
+	"\x0eRequestCheckTx\x12\x0e\n" +
+	"\x02tx\x18\x01 \x01(\fR\x02tx\"A\n" +
+	"\x0fResponseCheckTx\x12\x16\n" +
+	"\x06accept\x18\x01 \x01(\bR\x06accept\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason2\x91\x05\n" +
+	"\vApplication\x12l\n" +
+	"\x0fPrepareProposal\x12+.hyperlicked.abci.v1.RequestPrepareProposal\x1a,.hyperlicked.abci.v1.ResponsePrepareProposal\x12l\n" +
+	"\x0fProcessProposal\x12+.hyperlicked.abci.v1.RequestProcessProposal\x1a,.hyperlicked.abci.v1.ResponseProcessProposal\x12T\n" +
+	"\aExecute\x12#.hyperlicked.abci.v1.RequestExecute\x1a$.hyperlicked.abci.v1.ResponseExecute\x12Q\n" +
+	"\x06Commit\x12\".hyperlicked.abci.v1.RequestCommit\x1a#.hyperlicked.abci.v1.ResponseCommit\x12W\n" +
+	"\bRollback\x12$.hyperlicked.abci.v1.RequestRollback\x1a%.hyperlicked.abci.v1.ResponseRollback\x12N\n" +
+	"\x05Query\x12!.hyperlicked.abci.v1.RequestQuery\x1a\".hyperlicked.abci.v1.ResponseQuery\x12T\n" +
+	"\aCheckTx\x12#.hyperlicked.abci.v1.RequestCheckTx\x1a$.hyperlicked.abci.v1.ResponseCheckTxB2Z0github.com/uhyunpark/hyperlicked/pkg/abci/abcipbb\x06proto3"
+
+var (
+	file_abci_proto_rawDescOnce sync.Once
+	file_abci_proto_rawDescData []byte
+)
+
+func file_abci_proto_rawDescGZIP() []byte {
+	file_abci_proto_rawDescOnce.Do(func() {
+		file_abci_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_abci_proto_rawDesc), len(file_abci_proto_rawDesc)))
+	})
+	return file_abci_proto_rawDescData
+}
+
+var file_abci_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_abci_proto_goTypes = []any{
+	(*RequestPrepareProposal)(nil),  // 0: hyperlicked.abci.v1.RequestPrepareProposal
+	(*ResponsePrepareProposal)(nil), // 1: hyperlicked.abci.v1.ResponsePrepareProposal
+	(*RequestProcessProposal)(nil),  // 2: hyperlicked.abci.v1.RequestProcessProposal
+	(*ResponseProcessProposal)(nil), // 3: hyperlicked.abci.v1.ResponseProcessProposal
+	(*RequestExecute)(nil),          // 4: hyperlicked.abci.v1.RequestExecute
+	(*ValidatorUpdate)(nil),         // 5: hyperlicked.abci.v1.ValidatorUpdate
+	(*ResponseExecute)(nil),         // 6: hyperlicked.abci.v1.ResponseExecute
+	(*RequestCommit)(nil),           // 7: hyperlicked.abci.v1.RequestCommit
+	(*ResponseCommit)(nil),          // 8: hyperlicked.abci.v1.ResponseCommit
+	(*RequestRollback)(nil),         // 9: hyperlicked.abci.v1.RequestRollback
+	(*ResponseRollback)(nil),        // 10: hyperlicked.abci.v1.ResponseRollback
+	(*RequestQuery)(nil),            // 11: hyperlicked.abci.v1.RequestQuery
+	(*ResponseQuery)(nil),           // 12: hyperlicked.abci.v1.ResponseQuery
+	(*RequestCheckTx)(nil),          // 13: hyperlicked.abci.v1.RequestCheckTx
+	(*ResponseCheckTx)(nil),         // 14: hyperlicked.abci.v1.ResponseCheckTx
+}
+var file_abci_proto_depIdxs = []int32{
+	5,  // 0: hyperlicked.abci.v1.ResponseExecute.validator_updates:type_name -> hyperlicked.abci.v1.ValidatorUpdate
+	0,  // 1: hyperlicked.abci.v1.Application.PrepareProposal:input_type -> hyperlicked.abci.v1.RequestPrepareProposal
+	2,  // 2: hyperlicked.abci.v1.Application.ProcessProposal:input_type -> hyperlicked.abci.v1.RequestProcessProposal
+	4,  // 3: hyperlicked.abci.v1.Application.Execute:input_type -> hyperlicked.abci.v1.RequestExecute
+	7,  // 4: hyperlicked.abci.v1.Application.Commit:input_type -> hyperlicked.abci.v1.RequestCommit
+	9,  // 5: hyperlicked.abci.v1.Application.Rollback:input_type -> hyperlicked.abci.v1.RequestRollback
+	11, // 6: hyperlicked.abci.v1.Application.Query:input_type -> hyperlicked.abci.v1.RequestQuery
+	13, // 7: hyperlicked.abci.v1.Application.CheckTx:input_type -> hyperlicked.abci.v1.RequestCheckTx
+	1,  // 8: hyperlicked.abci.v1.Application.PrepareProposal:output_type -> hyperlicked.abci.v1.ResponsePrepareProposal
+	3,  // 9: hyperlicked.abci.v1.Application.ProcessProposal:output_type -> hyperlicked.abci.v1.ResponseProcessProposal
+	6,  // 10: hyperlicked.abci.v1.Application.Execute:output_type -> hyperlicked.abci.v1.ResponseExecute
+	8,  // 11: hyperlicked.abci.v1.Application.Commit:output_type -> hyperlicked.abci.v1.ResponseCommit
+	10, // 12: hyperlicked.abci.v1.Application.Rollback:output_type -> hyperlicked.abci.v1.ResponseRollback
+	12, // 13: hyperlicked.abci.v1.Application.Query:output_type -> hyperlicked.abci.v1.ResponseQuery
+	14, // 14: hyperlicked.abci.v1.Application.CheckTx:output_type -> hyperlicked.abci.v1.ResponseCheckTx
+	8,  // [8:15] is the sub-list for method output_type
+	1,  // [1:8] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_abci_proto_init() }
+func file_abci_proto_init() {
+	if File_abci_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_abci_proto_rawDesc), len(file_abci_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_abci_proto_goTypes,
+		DependencyIndexes: file_abci_proto_depIdxs,
+		MessageInfos:      file_abci_proto_msgTypes,
+	}.Build()
+	File_abci_proto = out.File
+	file_abci_proto_goTypes = nil
+	file_abci_proto_depIdxs = nil
+}
@@ -0,0 +1,366 @@
+// The application interface of pkg/abci (abci.Application) as a gRPC
+// service, so the state machine can run in its own process. Messages mirror
+// the Go request/response types; hashes are 32 bytes.
+//
+// Regenerate with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative abci.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: abci.proto
+
+package abcipb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Application_PrepareProposal_FullMethodName = "/hyperlicked.abci.v1.Application/PrepareProposal"
+	Application_ProcessProposal_FullMethodName = "/hyperlicked.abci.v1.Application/ProcessProposal"
+	Application_Execute_FullMethodName         = "/hyperlicked.abci.v1.Application/Execute"
+	Application_Commit_FullMethodName          = "/hyperlicked.abci.v1.Application/Commit"
+	Application_Rollback_FullMethodName        = "/hyperlicked.abci.v1.Application/Rollback"
+	Application_Query_FullMethodName           = "/hyperlicked.abci.v1.Application/Query"
+	Application_CheckTx_FullMethodName         = "/hyperlicked.abci.v1.Application/CheckTx"
+)
+
+// ApplicationClient is the client API for Application service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ApplicationClient interface {
+	PrepareProposal(ctx context.Context, in *RequestPrepareProposal, opts ...grpc.CallOption) (*ResponsePrepareProposal, error)
+	ProcessProposal(ctx context.Context, in *RequestProcessProposal, opts ...grpc.CallOption) (*ResponseProcessProposal, error)
+	// Execute fails (FAILED_PRECONDITION) if the parent is neither committed
+	// nor executed.
+	Execute(ctx context.Context, in *RequestExecute, opts ...grpc.CallOption) (*ResponseExecute, error)
+	Commit(ctx context.Context, in *RequestCommit, opts ...grpc.CallOption) (*ResponseCommit, error)
+	Rollback(ctx context.Context, in *RequestRollback, opts ...grpc.CallOption) (*ResponseRollback, error)
+	// Query reads committed state; UNIMPLEMENTED if the app has no queries.
+	Query(ctx context.Context, in *RequestQuery, opts ...grpc.CallOption) (*ResponseQuery, error)
+	// CheckTx admits a tx to the app's mempool.
+	CheckTx(ctx context.Context, in *RequestCheckTx, opts ...grpc.CallOption) (*ResponseCheckTx, error)
+}
+
+type applicationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewApplicationClient(cc grpc.ClientConnInterface) ApplicationClient {
+	return &applicationClient{cc}
+}
+
+func (c *applicationClient) PrepareProposal(ctx context.Context, in *RequestPrepareProposal, opts ...grpc.CallOption) (*ResponsePrepareProposal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponsePrepareProposal)
+	err := c.cc.Invoke(ctx, Application_PrepareProposal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) ProcessProposal(ctx context.Context, in *RequestProcessProposal, opts ...grpc.CallOption) (*ResponseProcessProposal, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseProcessProposal)
+	err := c.cc.Invoke(ctx, Application_ProcessProposal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) Execute(ctx context.Context, in *RequestExecute, opts ...grpc.CallOption) (*ResponseExecute, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseExecute)
+	err := c.cc.Invoke(ctx, Application_Execute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) Commit(ctx context.Context, in *RequestCommit, opts ...grpc.CallOption) (*ResponseCommit, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseCommit)
+	err := c.cc.Invoke(ctx, Application_Commit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) Rollback(ctx context.Context, in *RequestRollback, opts ...grpc.CallOption) (*ResponseRollback, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseRollback)
+	err := c.cc.Invoke(ctx, Application_Rollback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) Query(ctx context.Context, in *RequestQuery, opts ...grpc.CallOption) (*ResponseQuery, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseQuery)
+	err := c.cc.Invoke(ctx, Application_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationClient) CheckTx(ctx context.Context, in *RequestCheckTx, opts ...grpc.CallOption) (*ResponseCheckTx, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResponseCheckTx)
+	err := c.cc.Invoke(ctx, Application_CheckTx_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ApplicationServer is the server API for Application service.
+// All implementations must embed UnimplementedApplicationServer
+// for forward compatibility.
+type ApplicationServer interface {
+	PrepareProposal(context.Context, *RequestPrepareProposal) (*ResponsePrepareProposal, error)
+	ProcessProposal(context.Context, *RequestProcessProposal) (*ResponseProcessProposal, error)
+	// Execute fails (FAILED_PRECONDITION) if the parent is neither committed
+	// nor executed.
+	Execute(context.Context, *RequestExecute) (*ResponseExecute, error)
+	Commit(context.Context, *RequestCommit) (*ResponseCommit, error)
+	Rollback(context.Context, *RequestRollback) (*ResponseRollback, error)
+	// Query reads committed state; UNIMPLEMENTED if the app has no queries.
+	Query(context.Context, *RequestQuery) (*ResponseQuery, error)
+	// CheckTx admits a tx to the app's mempool.
+	CheckTx(context.Context, *RequestCheckTx) (*ResponseCheckTx, error)
+	mustEmbedUnimplementedApplicationServer()
+}
+
+// UnimplementedApplicationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedApplicationServer struct{}
+
+func (UnimplementedApplicationServer) PrepareProposal(context.Context, *RequestPrepareProposal) (*ResponsePrepareProposal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareProposal not implemented")
+}
+func (UnimplementedApplicationServer) ProcessProposal(context.Context, *RequestProcessProposal) (*ResponseProcessProposal, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessProposal not implemented")
+}
+func (UnimplementedApplicationServer) Execute(context.Context, *RequestExecute) (*ResponseExecute, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Execute not implemented")
+}
+func (UnimplementedApplicationServer) Commit(context.Context, *RequestCommit) (*ResponseCommit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedApplicationServer) Rollback(context.Context, *RequestRollback) (*ResponseRollback, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rollback not implemented")
+}
+func (UnimplementedApplicationServer) Query(context.Context, *RequestQuery) (*ResponseQuery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedApplicationServer) CheckTx(context.Context, *RequestCheckTx) (*ResponseCheckTx, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckTx not implemented")
+}
+func (UnimplementedApplicationServer) mustEmbedUnimplementedApplicationServer() {}
+func (UnimplementedApplicationServer) testEmbeddedByValue()                     {}
+
+// UnsafeApplicationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ApplicationServer will
+// result in compilation errors.
+type UnsafeApplicationServer interface {
+	mustEmbedUnimplementedApplicationServer()
+}
+
+func RegisterApplicationServer(s grpc.ServiceRegistrar, srv ApplicationServer) {
+	// If the following call pancis, it indicates UnimplementedApplicationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Application_ServiceDesc, srv)
+}
+
+func _Application_PrepareProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestPrepareProposal)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).PrepareProposal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_PrepareProposal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).PrepareProposal(ctx, req.(*RequestPrepareProposal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_ProcessProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestProcessProposal)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).ProcessProposal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_ProcessProposal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).ProcessProposal(ctx, req.(*RequestProcessProposal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_Execute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestExecute)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).Execute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_Execute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).Execute(ctx, req.(*RequestExecute))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestCommit)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_Commit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).Commit(ctx, req.(*RequestCommit))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestRollback)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_Rollback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).Rollback(ctx, req.(*RequestRollback))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).Query(ctx, req.(*RequestQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Application_CheckTx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestCheckTx)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationServer).CheckTx(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Application_CheckTx_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationServer).CheckTx(ctx, req.(*RequestCheckTx))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Application_ServiceDesc is the grpc.ServiceDesc for Application service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Application_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hyperlicked.abci.v1.Application",
+	HandlerType: (*ApplicationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PrepareProposal",
+			Handler:    _Application_PrepareProposal_Handler,
+		},
+		{
+			MethodName: "ProcessProposal",
+			Handler:    _Application_ProcessProposal_Handler,
+		},
+		{
+			MethodName: "Execute",
+			Handler:    _Application_Execute_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _Application_Commit_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _Application_Rollback_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Application_Query_Handler,
+		},
+		{
+			MethodName: "CheckTx",
+			Handler:    _Application_CheckTx_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "abci.proto",
+}
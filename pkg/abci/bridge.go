@@ -1,7 +1,9 @@
 package abci
 
 import (
+	"fmt"
 	"log"
+	"maps"
 	"sync"
 
 	"github.com/uhyunpark/hyperlicked/pkg/app/core"
@@ -11,78 +13,226 @@ import (
 type RequestPrepareProposal struct{ Height, MaxTxBytes int64 }
 type ResponsePrepareProposal struct{ Txs [][]byte }
 type RequestProcessProposal struct {
-	Height int64
-	Txs    [][]byte
+	Height    int64
+	Timestamp int64 // Unix timestamp in seconds
+	Txs       [][]byte
 }
-type ResponseProcessProposal struct{ Accept bool }
-type RequestFinalizeBlock struct {
+type ResponseProcessProposal struct {
+	Accept bool
+	Reason string // why not, when Accept is false
+}
+type RequestExecute struct {
+	Hash      consensus.Hash // the block's
+	Parent    consensus.Hash // the block to execute on top of
 	Height    int64
 	Timestamp int64 // Unix timestamp in seconds
 	Txs       [][]byte
+	// Misbehavior is the verified evidence the block carries; the app jails
+	// or penalizes the validators it names. The same offence may appear in
+	// more than one block: apps dedupe by Evidence.Key.
+	Misbehavior []consensus.Evidence
 }
-type ResponseFinalizeBlock struct {
+type ResponseExecute struct {
 	Events  []string
 	AppHash consensus.Hash // Hash of application state after execution
+	// ValidatorUpdates change voting power (0 removes a validator). They take
+	// effect at the start of the epoch after next (see consensus/epoch.go).
+	ValidatorUpdates []consensus.ValidatorUpdate
 }
+type RequestCommit struct{ Hash consensus.Hash }
+type RequestRollback struct{ Hash consensus.Hash }
 
+// Application is the state machine behind the Bridge.
+//
+// Blocks are executed speculatively, before they are final: Execute applies
+// a block to a fork (overlay) of the state after its parent, which is the
+// committed state or another overlay, keyed by block hash. Commit makes an
+// overlay and its ancestors the committed state and discards the overlays
+// that do not descend from it; Rollback discards an overlay and its
+// descendants. Only committed state is persisted or shown to clients.
 type Application interface {
 	PrepareProposal(RequestPrepareProposal) ResponsePrepareProposal
 	ProcessProposal(RequestProcessProposal) ResponseProcessProposal
-	FinalizeBlock(RequestFinalizeBlock) ResponseFinalizeBlock
+	// Execute fails if the parent is neither committed nor executed.
+	Execute(RequestExecute) (ResponseExecute, error)
+	Commit(RequestCommit) error
+	Rollback(RequestRollback)
+}
+
+type RequestQuery struct {
+	Path string // what to read, e.g. "account/<address>"
+	Data []byte
 }
+type ResponseQuery struct{ Value []byte }
+type RequestCheckTx struct{ Tx []byte }
+type ResponseCheckTx struct {
+	Accept bool
+	Reason string // why not, when Accept is false
+}
+
+// Querier is an Application that answers queries on its committed state.
+type Querier interface {
+	Query(RequestQuery) (ResponseQuery, error)
+}
+
+// TxChecker is an Application that admits txs to its mempool: a node whose
+// app runs out of process hands it client txs through CheckTx.
+type TxChecker interface {
+	CheckTx(RequestCheckTx) ResponseCheckTx
+}
+
+// DefaultMaxTxBytes caps the total tx bytes of a block (Bridge.MaxTxBytes).
+const DefaultMaxTxBytes = 1 << 24
 
-type Bridge struct{ App Application }
+type Bridge struct {
+	App Application
 
-func (b *Bridge) PreparePayload(_ consensus.Block, next consensus.Height) []byte {
-	resp := b.App.PrepareProposal(RequestPrepareProposal{Height: int64(next), MaxTxBytes: 1 << 24})
-	// naive payload: concat with 0x00 delimiter
+	// MaxTxBytes caps the total tx bytes of a block: leaders ask the app for
+	// no more, followers reject blocks with more (0 = DefaultMaxTxBytes)
+	MaxTxBytes int64
 
-	var payload []byte
+	lastUpdates []consensus.ValidatorUpdate // from the last Execute
+}
+
+func (b *Bridge) maxTxBytes() int64 {
+	if b.MaxTxBytes > 0 {
+		return b.MaxTxBytes
+	}
+	return DefaultMaxTxBytes
+}
 
+func (b *Bridge) PreparePayload(_ consensus.Block, next consensus.Height, evidence []consensus.Evidence) []byte {
+	resp := b.App.PrepareProposal(RequestPrepareProposal{Height: int64(next), MaxTxBytes: b.maxTxBytes()})
+	// evidence first, then the app's txs (see consensus.EncodePayload)
+	entries := make([][]byte, 0, len(evidence)+len(resp.Txs))
+	for _, ev := range evidence {
+		entries = append(entries, consensus.EvidenceTx(ev))
+	}
 	for _, tx := range resp.Txs {
-		payload = append(payload, tx...)
-		payload = append(payload, 0x00)
+		if consensus.IsEvidenceTx(tx) {
+			continue // only the engine may add evidence
+		}
+		entries = append(entries, tx)
 	}
-	return payload
+	return consensus.EncodePayload(entries)
 }
 
-func (b *Bridge) OnCommit(committed consensus.Block) consensus.Hash {
-	txs := splitPayload(committed.Payload)
-	resp := b.App.FinalizeBlock(RequestFinalizeBlock{
-		Height:    int64(committed.Height),
-		Timestamp: committed.Time.Unix(),
+// ProcessProposal checks a proposed block before the validator votes on it
+// (consensus.ProposalProcessor): its payload must decode, its txs fit
+// MaxTxBytes and its time not precede its parent's; then the app checks
+// the txs (Application.ProcessProposal).
+func (b *Bridge) ProcessProposal(parent, blk consensus.Block) error {
+	entries, err := consensus.DecodePayload(blk.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", consensus.ErrProposalRejected, err)
+	}
+	if blk.Time.Before(parent.Time) {
+		return fmt.Errorf("%w: time %s before parent's %s", consensus.ErrProposalRejected, blk.Time, parent.Time)
+	}
+	var txs [][]byte
+	var size int64
+	for _, e := range entries {
+		if !consensus.IsEvidenceTx(e) {
+			txs = append(txs, e)
+			size += int64(len(e))
+		}
+	}
+	if size > b.maxTxBytes() {
+		return fmt.Errorf("%w: %d tx bytes, max %d", consensus.ErrProposalRejected, size, b.maxTxBytes())
+	}
+	resp := b.App.ProcessProposal(RequestProcessProposal{
+		Height:    int64(blk.Height),
+		Timestamp: blk.Time.Unix(),
 		Txs:       txs,
 	})
-	return resp.AppHash
-}
-
-func splitPayload(p []byte) [][]byte {
-	var out [][]byte
-	cur := make([]byte, 0, len(p))
-	for _, b := range p {
-		if b == 0x00 {
-			if len(cur) > 0 {
-				out = append(out, append([]byte(nil), cur...))
-				cur = cur[:0]
-			}
-			continue
-		}
-		cur = append(cur, b)
+	if !resp.Accept {
+		return fmt.Errorf("%w: %s", consensus.ErrProposalRejected, resp.Reason)
+	}
+	return nil
+}
+
+func (b *Bridge) Execute(blk consensus.Block) (consensus.Hash, error) {
+	// verified by the engine before the block was voted on
+	evidence, _ := consensus.PayloadEvidence(blk.Payload)
+	resp, err := b.App.Execute(RequestExecute{
+		Hash:        consensus.HashOfBlock(blk),
+		Parent:      blk.Parent,
+		Height:      int64(blk.Height),
+		Timestamp:   blk.Time.Unix(),
+		Txs:         consensus.PayloadTxs(blk.Payload),
+		Misbehavior: evidence,
+	})
+	if err != nil {
+		return consensus.Hash{}, err
 	}
-	if len(cur) > 0 {
-		out = append(out, append([]byte(nil), cur...))
+	b.lastUpdates = resp.ValidatorUpdates
+	return resp.AppHash, nil
+}
+
+func (b *Bridge) Commit(blk consensus.Block) error {
+	return b.App.Commit(RequestCommit{Hash: consensus.HashOfBlock(blk)})
+}
+
+func (b *Bridge) Rollback(h consensus.Hash) { b.App.Rollback(RequestRollback{Hash: h}) }
+
+// LastValidatorUpdates returns the validator updates of the block last
+// passed to Execute (consensus.ValidatorUpdater).
+func (b *Bridge) LastValidatorUpdates() []consensus.ValidatorUpdate { return b.lastUpdates }
+
+// txHashes returns the consensus tx hash of every tx.
+func txHashes(txs [][]byte) [][32]byte {
+	out := make([][32]byte, len(txs))
+	for i, tx := range txs {
+		out[i] = consensus.TxHash(tx)
 	}
 	return out
 }
 
+var (
+	_ consensus.AppHook           = (*Bridge)(nil)
+	_ consensus.ValidatorUpdater  = (*Bridge)(nil)
+	_ consensus.ProposalProcessor = (*Bridge)(nil)
+)
+
 // --- MockApp using HL-like mempool ordering ---
 type MockApp struct {
 	mu      sync.Mutex
 	mempool *core.Mempool
 	commits int
+
+	mockState                               // committed
+	committed consensus.Hash                // last committed block (zero: none yet)
+	overlays  map[consensus.Hash]*mockBlock // executed, not committed blocks
+
+	powerUpdates map[int64][]consensus.ValidatorUpdate // height -> updates (SetValidatorPower)
 }
 
-func NewMockApp() *MockApp { return &MockApp{mempool: core.NewMempool()} }
+type mockState struct {
+	// jailed: validator -> height of its first punished offence
+	jailed   map[consensus.NodeID]int64
+	punished map[consensus.Hash]bool // Evidence.Key
+}
+
+func (s mockState) clone() mockState {
+	return mockState{jailed: maps.Clone(s.jailed), punished: maps.Clone(s.punished)}
+}
+
+// mockBlock is the state after an executed block (its overlay).
+type mockBlock struct {
+	mockState
+	parent consensus.Hash
+	txs    [][]byte
+	resp   ResponseExecute
+}
+
+func NewMockApp() *MockApp {
+	return &MockApp{
+		mempool:      core.NewMempool(),
+		mockState:    mockState{jailed: make(map[consensus.NodeID]int64), punished: make(map[consensus.Hash]bool)},
+		overlays:     make(map[consensus.Hash]*mockBlock),
+		powerUpdates: make(map[int64][]consensus.ValidatorUpdate),
+	}
+}
 
 func (m *MockApp) PushTx(b []byte) {
 	m.mu.Lock()
@@ -90,6 +240,13 @@ func (m *MockApp) PushTx(b []byte) {
 	m.mempool.PushRaw(b)
 }
 
+// GetTx returns the pending tx with hash h (p2p.TxPool).
+func (m *MockApp) GetTx(h consensus.Hash) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mempool.Get(h)
+}
+
 func (m *MockApp) PrepareProposal(req RequestPrepareProposal) ResponsePrepareProposal {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -101,12 +258,37 @@ func (m *MockApp) ProcessProposal(_ RequestProcessProposal) ResponseProcessPropo
 	return ResponseProcessProposal{Accept: true}
 }
 
-func (m *MockApp) FinalizeBlock(req RequestFinalizeBlock) ResponseFinalizeBlock {
+func (m *MockApp) Execute(req RequestExecute) (ResponseExecute, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.commits++
+	if o, ok := m.overlays[req.Hash]; ok {
+		return o.resp, nil
+	}
+	base := m.mockState
+	if p, ok := m.overlays[req.Parent]; ok {
+		base = p.mockState
+	} else if m.committed != (consensus.Hash{}) && req.Parent != m.committed {
+		return ResponseExecute{}, fmt.Errorf("execute h=%d: parent %s not executed", req.Height, req.Parent)
+	}
+	o := &mockBlock{mockState: base.clone(), parent: req.Parent, txs: req.Txs}
+	// Txs in a block leave the mempool; they come back if it is orphaned
+	m.mempool.Remove(txHashes(req.Txs))
 
-	// Compute simple hash based on height + tx count (deterministic for tests)
+	// Scheduled power changes, then removal of the newly jailed validators
+	updates := append([]consensus.ValidatorUpdate(nil), m.powerUpdates[req.Height]...)
+	for _, ev := range req.Misbehavior {
+		if o.punished[ev.Key()] {
+			continue
+		}
+		o.punished[ev.Key()] = true
+		if _, ok := o.jailed[ev.Validator]; !ok {
+			o.jailed[ev.Validator] = req.Height
+			updates = append(updates, consensus.ValidatorUpdate{ID: ev.Validator, Power: 0})
+			log.Printf("[app] jailed %s at h=%d (%s in view %d)", ev.Validator, req.Height, ev.Type, ev.View)
+		}
+	}
+
+	// Compute simple hash based on height + tx count + jailed count (deterministic for tests)
 	var hashInput [16]byte
 	hashInput[0] = byte(req.Height >> 56)
 	hashInput[1] = byte(req.Height >> 48)
@@ -117,21 +299,119 @@ func (m *MockApp) FinalizeBlock(req RequestFinalizeBlock) ResponseFinalizeBlock
 	hashInput[6] = byte(req.Height >> 8)
 	hashInput[7] = byte(req.Height)
 	hashInput[8] = byte(len(req.Txs))
+	hashInput[9] = byte(len(o.jailed))
 	appHash := consensus.Hash{}
 	copy(appHash[:], hashInput[:])
 
 	// Quiet logging: only log non-empty blocks
 	if len(req.Txs) > 0 {
-		log.Printf("[app] FinalizeBlock h=%d txs=%d", req.Height, len(req.Txs))
+		log.Printf("[app] Execute h=%d txs=%d", req.Height, len(req.Txs))
+	}
+	o.resp = ResponseExecute{
+		Events:           []string{"execute"},
+		AppHash:          appHash,
+		ValidatorUpdates: updates,
+	}
+	m.overlays[req.Hash] = o
+	return o.resp, nil
+}
+
+func (m *MockApp) Commit(req RequestCommit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.overlays[req.Hash]
+	if !ok {
+		if req.Hash == m.committed {
+			return nil
+		}
+		return fmt.Errorf("commit: block %s not executed", req.Hash)
+	}
+	var committed [][]byte
+	for h, b := req.Hash, o; b != nil; h, b = b.parent, m.overlays[b.parent] {
+		delete(m.overlays, h)
+		committed = append(committed, b.txs...)
+		m.commits++
+	}
+	m.mockState = o.mockState
+	m.committed = req.Hash
+	var orphans []consensus.Hash
+	for h := range m.overlays {
+		if !m.descends(h, req.Hash) {
+			orphans = append(orphans, h)
+		}
+	}
+	m.dropLocked(orphans, committed)
+	return nil
+}
+
+func (m *MockApp) Rollback(req RequestRollback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var dropped []consensus.Hash
+	for h := range m.overlays {
+		if h == req.Hash || m.descends(h, req.Hash) {
+			dropped = append(dropped, h)
+		}
 	}
-	return ResponseFinalizeBlock{
-		Events:  []string{"commit"},
-		AppHash: appHash,
+	m.dropLocked(dropped, nil)
+}
+
+// descends reports whether overlay h extends block anc. Caller holds mu.
+func (m *MockApp) descends(h, anc consensus.Hash) bool {
+	for b, ok := m.overlays[h]; ok; b, ok = m.overlays[b.parent] {
+		if b.parent == anc {
+			return true
+		}
 	}
+	return false
 }
 
+// dropLocked discards the overlays hs, returning their txs to the mempool
+// unless committed or in a remaining overlay. Caller holds mu.
+func (m *MockApp) dropLocked(hs []consensus.Hash, committed [][]byte) {
+	var txs [][]byte
+	for _, h := range hs {
+		txs = append(txs, m.overlays[h].txs...)
+		delete(m.overlays, h)
+	}
+	included := make(map[consensus.Hash]bool)
+	for _, tx := range committed {
+		included[consensus.TxHash(tx)] = true
+	}
+	for _, o := range m.overlays {
+		for _, tx := range o.txs {
+			included[consensus.TxHash(tx)] = true
+		}
+	}
+	for _, tx := range txs {
+		if h := consensus.TxHash(tx); !included[h] {
+			included[h] = true
+			m.mempool.PushRaw(tx)
+		}
+	}
+}
+
+// SetValidatorPower schedules a validator power change, emitted by the
+// Execute of height (power 0 removes the validator). Every node's app
+// must schedule the same changes, as with transactions.
+func (m *MockApp) SetValidatorPower(height int64, id consensus.NodeID, power uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.powerUpdates[height] = append(m.powerUpdates[height], consensus.ValidatorUpdate{ID: id, Power: power})
+}
+
+// CommitCount returns the number of blocks committed.
 func (m *MockApp) CommitCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	return m.commits
 }
+
+// Jailed reports whether id was punished for misbehaviour in the committed
+// state, and at which height.
+func (m *MockApp) Jailed(id consensus.NodeID) (int64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.jailed[id]
+	return h, ok
+}
@@ -0,0 +1,155 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/abci/abcipb"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// DefaultTimeout bounds each call of a Client (Client.Timeout).
+const DefaultTimeout = 10 * time.Second
+
+// Client is an abci.Application served by a remote Server. The calls that
+// cannot fail in abci.Application degrade when the app is unreachable:
+// PrepareProposal proposes no txs, ProcessProposal rejects the block (so the
+// validator does not vote on what it could not check) and Rollback is lost.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  abcipb.ApplicationClient
+
+	// Timeout bounds each call (0 = DefaultTimeout)
+	Timeout time.Duration
+}
+
+// Dial connects to the Server at addr (see the package doc). The connection
+// is made lazily, on the first call.
+func Dial(addr string) (*Client, error) {
+	target := addr
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		target = "unix:" + path // grpc's form, for relative paths too
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dial app %s: %w", addr, err)
+	}
+	return &Client{conn: conn, rpc: abcipb.NewApplicationClient(conn)}, nil
+}
+
+func (c *Client) Close() error { return c.conn.Close() }
+
+func (c *Client) ctx() (context.Context, context.CancelFunc) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func (c *Client) PrepareProposal(req abci.RequestPrepareProposal) abci.ResponsePrepareProposal {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.rpc.PrepareProposal(ctx, &abcipb.RequestPrepareProposal{Height: req.Height, MaxTxBytes: req.MaxTxBytes})
+	if err != nil {
+		log.Printf("[abci] prepare proposal h=%d: %v", req.Height, err)
+		return abci.ResponsePrepareProposal{}
+	}
+	return abci.ResponsePrepareProposal{Txs: resp.Txs}
+}
+
+func (c *Client) ProcessProposal(req abci.RequestProcessProposal) abci.ResponseProcessProposal {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.rpc.ProcessProposal(ctx, &abcipb.RequestProcessProposal{Height: req.Height, Timestamp: req.Timestamp, Txs: req.Txs})
+	if err != nil {
+		return abci.ResponseProcessProposal{Reason: fmt.Sprintf("app unreachable: %v", err)}
+	}
+	return abci.ResponseProcessProposal{Accept: resp.Accept, Reason: resp.Reason}
+}
+
+func (c *Client) Execute(req abci.RequestExecute) (abci.ResponseExecute, error) {
+	misbehavior := make([][]byte, len(req.Misbehavior))
+	for i, ev := range req.Misbehavior {
+		misbehavior[i] = consensus.EvidenceTx(ev)
+	}
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.rpc.Execute(ctx, &abcipb.RequestExecute{
+		Hash:        req.Hash[:],
+		Parent:      req.Parent[:],
+		Height:      req.Height,
+		Timestamp:   req.Timestamp,
+		Txs:         req.Txs,
+		Misbehavior: misbehavior,
+	})
+	if err != nil {
+		return abci.ResponseExecute{}, rpcError("execute", err)
+	}
+	out := abci.ResponseExecute{Events: resp.Events}
+	if len(resp.AppHash) != len(out.AppHash) {
+		return abci.ResponseExecute{}, fmt.Errorf("execute: app hash of %d bytes", len(resp.AppHash))
+	}
+	copy(out.AppHash[:], resp.AppHash)
+	for _, u := range resp.ValidatorUpdates {
+		out.ValidatorUpdates = append(out.ValidatorUpdates, consensus.ValidatorUpdate{ID: consensus.NodeID(u.Id), Power: u.Power})
+	}
+	return out, nil
+}
+
+func (c *Client) Commit(req abci.RequestCommit) error {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	if _, err := c.rpc.Commit(ctx, &abcipb.RequestCommit{Hash: req.Hash[:]}); err != nil {
+		return rpcError("commit", err)
+	}
+	return nil
+}
+
+func (c *Client) Rollback(req abci.RequestRollback) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	if _, err := c.rpc.Rollback(ctx, &abcipb.RequestRollback{Hash: req.Hash[:]}); err != nil {
+		log.Printf("[abci] rollback %s: %v", req.Hash, err)
+	}
+}
+
+func (c *Client) Query(req abci.RequestQuery) (abci.ResponseQuery, error) {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.rpc.Query(ctx, &abcipb.RequestQuery{Path: req.Path, Data: req.Data})
+	if err != nil {
+		return abci.ResponseQuery{}, rpcError("query "+req.Path, err)
+	}
+	return abci.ResponseQuery{Value: resp.Value}, nil
+}
+
+func (c *Client) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	ctx, cancel := c.ctx()
+	defer cancel()
+	resp, err := c.rpc.CheckTx(ctx, &abcipb.RequestCheckTx{Tx: req.Tx})
+	if err != nil {
+		return abci.ResponseCheckTx{Reason: fmt.Sprintf("app unreachable: %v", err)}
+	}
+	return abci.ResponseCheckTx{Accept: resp.Accept, Reason: resp.Reason}
+}
+
+// rpcError returns the app's error message for a failed call.
+func rpcError(call string, err error) error {
+	s := status.Convert(err)
+	return fmt.Errorf("%s: %s (%s)", call, s.Message(), s.Code())
+}
+
+var (
+	_ abci.Application = (*Client)(nil)
+	_ abci.Querier     = (*Client)(nil)
+	_ abci.TxChecker   = (*Client)(nil)
+)
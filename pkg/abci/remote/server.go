@@ -0,0 +1,144 @@
+// Package remote runs an abci.Application out of process: Server exposes an
+// application as the gRPC service of abcipb (abci.proto), and Client, which
+// implements abci.Application, calls it. Addresses are "unix://<path>" for
+// a unix socket or "host:port" for TCP.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/abci/abcipb"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// Server serves an abci.Application. Query and CheckTx are UNIMPLEMENTED
+// unless the app is an abci.Querier or abci.TxChecker.
+type Server struct {
+	abcipb.UnimplementedApplicationServer
+	app abci.Application
+}
+
+func NewServer(app abci.Application) *Server { return &Server{app: app} }
+
+// NewGRPCServer returns a gRPC server with app registered, ready to Serve.
+func NewGRPCServer(app abci.Application, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	abcipb.RegisterApplicationServer(s, NewServer(app))
+	return s
+}
+
+// Listen listens on addr (see the package doc). A stale unix socket file
+// left by a previous run is removed first.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+func (s *Server) PrepareProposal(_ context.Context, req *abcipb.RequestPrepareProposal) (*abcipb.ResponsePrepareProposal, error) {
+	resp := s.app.PrepareProposal(abci.RequestPrepareProposal{Height: req.Height, MaxTxBytes: req.MaxTxBytes})
+	return &abcipb.ResponsePrepareProposal{Txs: resp.Txs}, nil
+}
+
+func (s *Server) ProcessProposal(_ context.Context, req *abcipb.RequestProcessProposal) (*abcipb.ResponseProcessProposal, error) {
+	resp := s.app.ProcessProposal(abci.RequestProcessProposal{Height: req.Height, Timestamp: req.Timestamp, Txs: req.Txs})
+	return &abcipb.ResponseProcessProposal{Accept: resp.Accept, Reason: resp.Reason}, nil
+}
+
+func (s *Server) Execute(_ context.Context, req *abcipb.RequestExecute) (*abcipb.ResponseExecute, error) {
+	hash, err := toHash(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := toHash(req.Parent)
+	if err != nil {
+		return nil, err
+	}
+	evidence := make([]consensus.Evidence, len(req.Misbehavior))
+	for i, tx := range req.Misbehavior {
+		if evidence[i], err = consensus.ParseEvidenceTx(tx); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	resp, err := s.app.Execute(abci.RequestExecute{
+		Hash:        hash,
+		Parent:      parent,
+		Height:      req.Height,
+		Timestamp:   req.Timestamp,
+		Txs:         req.Txs,
+		Misbehavior: evidence,
+	})
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	out := &abcipb.ResponseExecute{Events: resp.Events, AppHash: resp.AppHash[:]}
+	for _, u := range resp.ValidatorUpdates {
+		out.ValidatorUpdates = append(out.ValidatorUpdates, &abcipb.ValidatorUpdate{Id: string(u.ID), Power: u.Power})
+	}
+	return out, nil
+}
+
+func (s *Server) Commit(_ context.Context, req *abcipb.RequestCommit) (*abcipb.ResponseCommit, error) {
+	hash, err := toHash(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.app.Commit(abci.RequestCommit{Hash: hash}); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &abcipb.ResponseCommit{}, nil
+}
+
+func (s *Server) Rollback(_ context.Context, req *abcipb.RequestRollback) (*abcipb.ResponseRollback, error) {
+	hash, err := toHash(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	s.app.Rollback(abci.RequestRollback{Hash: hash})
+	return &abcipb.ResponseRollback{}, nil
+}
+
+func (s *Server) Query(_ context.Context, req *abcipb.RequestQuery) (*abcipb.ResponseQuery, error) {
+	q, ok := s.app.(abci.Querier)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "app does not answer queries")
+	}
+	resp, err := q.Query(abci.RequestQuery{Path: req.Path, Data: req.Data})
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &abcipb.ResponseQuery{Value: resp.Value}, nil
+}
+
+func (s *Server) CheckTx(_ context.Context, req *abcipb.RequestCheckTx) (*abcipb.ResponseCheckTx, error) {
+	c, ok := s.app.(abci.TxChecker)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "app does not check txs")
+	}
+	resp := c.CheckTx(abci.RequestCheckTx{Tx: req.Tx})
+	return &abcipb.ResponseCheckTx{Accept: resp.Accept, Reason: resp.Reason}, nil
+}
+
+// toHash converts a wire hash, which must be 32 bytes.
+func toHash(b []byte) (consensus.Hash, error) {
+	var h consensus.Hash
+	if len(b) != len(h) {
+		return h, status.Error(codes.InvalidArgument, fmt.Sprintf("hash of %d bytes, want %d", len(b), len(h)))
+	}
+	copy(h[:], b)
+	return h, nil
+}
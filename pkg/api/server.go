@@ -3,12 +3,14 @@ package api
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/big"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +18,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
 	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/light"
 )
 
 // Server handles REST API and WebSocket connections
@@ -25,8 +29,18 @@ type Server struct {
 	router *mux.Router
 	hub    *Hub // WebSocket hub
 	txLog  *os.File // Transaction log file
+
+	// Consensus data for the chain endpoints (nil until SetChain)
+	blocks    consensus.BlockStore
+	state     *consensus.State
+	proposals func() consensus.ProposalMetrics // nil until SetProposalMetrics
 }
 
+const (
+	maxBlocksPerRequest = 100 // cap for /blocks?from&to
+	avgBlockTimeWindow  = 20  // committed blocks averaged in /chain/status
+)
+
 // NewServer creates a new API server
 func NewServer(app *perp.App) *Server {
 	// Open transaction log file
@@ -57,6 +71,15 @@ func NewServer(app *perp.App) *Server {
 	return s
 }
 
+// SetChain connects the chain endpoints to the consensus block store and state.
+func (s *Server) SetChain(blocks consensus.BlockStore, state *consensus.State) {
+	s.blocks = blocks
+	s.state = state
+}
+
+// SetProposalMetrics reports the engine's proposal checks in /chain/status.
+func (s *Server) SetProposalMetrics(m func() consensus.ProposalMetrics) { s.proposals = m }
+
 func (s *Server) setupRoutes() {
 	// API v1 routes
 	api := s.router.PathPrefix("/api/v1").Subrouter()
@@ -74,6 +97,9 @@ func (s *Server) setupRoutes() {
 
 	// Chain endpoints
 	api.HandleFunc("/chain/status", s.handleGetChainStatus).Methods("GET")
+	api.HandleFunc("/blocks", s.handleGetBlocks).Methods("GET")
+	api.HandleFunc("/blocks/{height}", s.handleGetBlock).Methods("GET")
+	api.HandleFunc("/light/headers/{height}", s.handleGetSignedHeader).Methods("GET")
 
 	// Order submission
 	api.HandleFunc("/orders", s.handleSubmitOrder).Methods("POST")
@@ -89,6 +115,9 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/health", s.handleHealth).Methods("GET")
 }
 
+// Handler returns the router without CORS (for embedding and tests).
+func (s *Server) Handler() http.Handler { return s.router }
+
 // Start starts the API server
 func (s *Server) Start(addr string) error {
 	// Start WebSocket hub
@@ -281,19 +310,161 @@ func (s *Server) handleGetOrders(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetChainStatus(w http.ResponseWriter, r *http.Request) {
-	// TODO: Access consensus state (need to pass State reference)
-	// For now, return placeholder
 	response := ChainStatus{
-		Height:       0,
-		View:         0,
-		AvgBlockTime: 100.0,
-		MempoolSize:  s.app.GetMempoolSize(),
-		Validators:   4,
+		MempoolSize: s.app.GetMempoolSize(),
+	}
+	if s.state != nil {
+		response.View = int64(s.state.View)
+		response.Validators = s.state.Q.N
+	}
+	if s.blocks != nil {
+		if head, ok := s.blocks.LatestCommitted(); ok {
+			response.Height = int64(head.Height)
+			response.AvgBlockTime = s.avgBlockTime(head)
+		}
+	}
+	if s.proposals != nil {
+		m := s.proposals()
+		response.Proposals = &ProposalStats{Accepted: m.Accepted, Rejected: m.RejectedTotal(), RejectedBy: m.Rejected}
 	}
 
 	respondJSON(w, response)
 }
 
+// avgBlockTime averages the block interval (ms) over the committed blocks
+// leading up to head.
+func (s *Server) avgBlockTime(head consensus.Block) float64 {
+	from := consensus.Height(0)
+	if head.Height > avgBlockTimeWindow {
+		from = head.Height - avgBlockTimeWindow
+	}
+	var first, last time.Time
+	n := 0
+	s.blocks.IterateCommitted(from, head.Height, func(b consensus.Block) bool {
+		if n == 0 {
+			first = b.Time
+		}
+		last = b.Time
+		n++
+		return true
+	})
+	if n < 2 {
+		return 0
+	}
+	return float64(last.Sub(first).Milliseconds()) / float64(n-1)
+}
+
+func (s *Server) handleGetBlock(w http.ResponseWriter, r *http.Request) {
+	if s.blocks == nil {
+		respondError(w, http.StatusServiceUnavailable, "chain data unavailable", "")
+		return
+	}
+	height, err := strconv.ParseUint(mux.Vars(r)["height"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid height", err.Error())
+		return
+	}
+	b, ok := s.blocks.GetBlockByHeight(consensus.Height(height))
+	if !ok {
+		respondError(w, http.StatusNotFound, "block not found", "")
+		return
+	}
+	respondJSON(w, blockInfo(b))
+}
+
+// handleGetSignedHeader returns the committed header at height with its QC
+// and commit proof, for light clients (see pkg/light).
+func (s *Server) handleGetSignedHeader(w http.ResponseWriter, r *http.Request) {
+	if s.blocks == nil {
+		respondError(w, http.StatusServiceUnavailable, "chain data unavailable", "")
+		return
+	}
+	height, err := strconv.ParseUint(mux.Vars(r)["height"], 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid height", err.Error())
+		return
+	}
+	sh, err := light.BuildSignedHeader(s.blocks, consensus.Height(height))
+	switch {
+	case errors.Is(err, light.ErrNotFound):
+		respondError(w, http.StatusNotFound, "block not found", "")
+		return
+	case err != nil:
+		respondError(w, http.StatusConflict, "commit proof not available", err.Error())
+		return
+	}
+	respondJSON(w, sh)
+}
+
+// handleGetBlocks returns committed blocks with from <= height <= to, oldest
+// first, at most maxBlocksPerRequest. Without parameters it returns the
+// latest blocks.
+func (s *Server) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
+	if s.blocks == nil {
+		respondError(w, http.StatusServiceUnavailable, "chain data unavailable", "")
+		return
+	}
+	head, ok := s.blocks.LatestCommitted()
+	if !ok {
+		respondJSON(w, []BlockInfo{})
+		return
+	}
+
+	to := head.Height
+	if v := r.URL.Query().Get("to"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid to", err.Error())
+			return
+		}
+		if consensus.Height(n) < to {
+			to = consensus.Height(n)
+		}
+	}
+	var from consensus.Height
+	if to >= maxBlocksPerRequest {
+		from = to - maxBlocksPerRequest + 1
+	}
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid from", err.Error())
+			return
+		}
+		from = consensus.Height(n)
+	}
+	if from > to {
+		respondError(w, http.StatusBadRequest, "invalid range", "from must not exceed to")
+		return
+	}
+
+	out := []BlockInfo{}
+	s.blocks.IterateCommitted(from, to, func(b consensus.Block) bool {
+		out = append(out, blockInfo(b))
+		return len(out) < maxBlocksPerRequest
+	})
+	respondJSON(w, out)
+}
+
+func blockInfo(b consensus.Block) BlockInfo {
+	txs := consensus.PayloadTxs(b.Payload)
+	info := BlockInfo{
+		Height:    int64(b.Height),
+		View:      int64(b.View),
+		Hash:      "0x" + consensus.HashOfBlock(b).String(),
+		Parent:    "0x" + b.Parent.String(),
+		AppHash:   "0x" + b.AppHash.String(),
+		Proposer:  string(b.Proposer),
+		Timestamp: b.Time.UnixMilli(),
+		TxCount:   len(txs),
+		TxHashes:  make([]string, len(txs)),
+	}
+	for i, tx := range txs {
+		info.TxHashes[i] = "0x" + consensus.TxHash(tx).String()
+	}
+	return info
+}
+
 func (s *Server) handleSubmitOrder(w http.ResponseWriter, r *http.Request) {
 	// Read signed transaction body
 	bodyBytes, err := io.ReadAll(r.Body)
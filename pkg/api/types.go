@@ -88,6 +88,28 @@ type ChainStatus struct {
 	AvgBlockTime  float64 `json:"avgBlockTime"`  // Average block time (ms)
 	MempoolSize   int     `json:"mempoolSize"`   // Pending transactions
 	Validators    int     `json:"validators"`    // Active validator count
+
+	Proposals *ProposalStats `json:"proposals,omitempty"` // This validator's proposal checks
+}
+
+// ProposalStats counts the proposals a validator checked before voting
+type ProposalStats struct {
+	Accepted   uint64            `json:"accepted"`
+	Rejected   uint64            `json:"rejected"`
+	RejectedBy map[string]uint64 `json:"rejectedBy"` // payload, evidence, valset, app
+}
+
+// BlockInfo represents a committed block
+type BlockInfo struct {
+	Height    int64    `json:"height"`
+	View      int64    `json:"view"`
+	Hash      string   `json:"hash"`      // 0x-prefixed block hash
+	Parent    string   `json:"parent"`    // 0x-prefixed parent hash
+	AppHash   string   `json:"appHash"`   // State root after executing this block
+	Proposer  string   `json:"proposer"`  // Validator NodeID
+	Timestamp int64    `json:"timestamp"` // Unix milliseconds
+	TxCount   int      `json:"txCount"`
+	TxHashes  []string `json:"txHashes"` // sha256 of each raw tx, in block order
 }
 
 // ==============================
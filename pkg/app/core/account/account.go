@@ -1,7 +1,10 @@
 package account
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -16,6 +19,10 @@ type Account struct {
 	USDCBalance      int64 // Total USDC deposited via bridge
 	LockedCollateral int64 // Collateral locked for open orders + positions
 
+	// Part of LockedCollateral held for resting orders, per symbol: the
+	// initial margin of their unfilled qty
+	OrderMargin map[string]int64
+
 	// Open positions (per symbol)
 	Positions map[string]*Position // symbol → position (e.g., "HYPL-USDC" → long 100 HYPL)
 
@@ -25,8 +32,15 @@ type Account struct {
 	TotalFeesEarned  int64 // Cumulative maker rebates earned
 	TotalVolume      int64 // Lifetime trading volume (in USDC cents)
 	TradeCount       int64 // Total number of trades executed
+
+	// Most recent rejected orders (Status OrderRejected, with the reason),
+	// oldest first, at most MaxRejectedOrders
+	Rejected []*Order
 }
 
+// MaxRejectedOrders is how many rejected orders an account keeps.
+const MaxRejectedOrders = 16
+
 // Position represents an open perpetual futures position
 type Position struct {
 	Symbol string // Market symbol (e.g., "HYPL-USDC")
@@ -51,9 +65,23 @@ type Position struct {
 // NewAccount creates a new account with zero balance
 func NewAccount(addr common.Address) *Account {
 	return &Account{
-		Address:   addr,
-		Positions: make(map[string]*Position),
+		Address:     addr,
+		Positions:   make(map[string]*Position),
+		OrderMargin: make(map[string]int64),
+	}
+}
+
+// clone returns a deep copy of a (positions included)
+func (a *Account) clone() *Account {
+	c := *a
+	c.Positions = make(map[string]*Position, len(a.Positions))
+	for sym, pos := range a.Positions {
+		p := *pos
+		c.Positions[sym] = &p
 	}
+	c.OrderMargin = maps.Clone(a.OrderMargin)
+	c.Rejected = slices.Clone(a.Rejected) // recorded orders don't change
+	return &c
 }
 
 // AvailableBalance returns balance available for new orders
@@ -183,6 +211,20 @@ func (p *Position) MarginRatio(price int64) int64 {
 	return (p.Margin * 10000) / notional
 }
 
+// ErrReduceOnly rejects a reduce-only order that would not reduce the position
+var ErrReduceOnly = errors.New("reduce-only order would not reduce the position")
+
+// Reducible returns how much of an order for sizeDelta (buy > 0, sell < 0)
+// reduces the position in symbol: 0 if there is none or the order would
+// increase it, at most the position size otherwise
+func (a *Account) Reducible(symbol string, sizeDelta int64) int64 {
+	pos := a.GetPosition(symbol)
+	if pos == nil || pos.Size == 0 || (pos.Size > 0) == (sizeDelta > 0) {
+		return 0
+	}
+	return min(absInt64(sizeDelta), absInt64(pos.Size))
+}
+
 // OrderStatus represents the lifecycle state of an order
 type OrderStatus int8
 
@@ -217,7 +259,7 @@ type Order struct {
 	Owner  common.Address // Account that owns this order
 	Symbol string         // Market symbol (e.g., "HYPL-USDC")
 	Side   string         // "buy" or "sell"
-	Type   string         // "GTC" or "IOC"
+	Type   string         // "GTC", "IOC", "ALO" or "MARKET"
 
 	// Order details
 	Price  int64 // Limit price (in ticks)
@@ -226,6 +268,7 @@ type Order struct {
 
 	// Status
 	Status OrderStatus
+	Reason string // why the order was rejected (OrderRejected)
 
 	// Margin
 	LockedMargin int64 // Collateral locked for this order
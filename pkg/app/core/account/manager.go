@@ -2,6 +2,7 @@ package account
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -11,10 +12,15 @@ import (
 // AccountManager manages all user accounts in a thread-safe manner
 // Handles deposits, withdrawals, margin locking/unlocking, and position updates
 // Uses in-memory cache + Pebble persistence for durability
+//
+// A fork (Fork) is a copy-on-write view of another manager, for executing
+// blocks that may be discarded: it has no store, reads fall through to its
+// parent, and an account is copied into the fork before it is changed.
 type AccountManager struct {
 	mu       sync.RWMutex
 	accounts map[common.Address]*Account // address -> account (in-memory cache)
-	store    *Store                      // Pebble persistence layer
+	store    *Store                      // Pebble persistence layer (nil for forks)
+	parent   *AccountManager             // forks only
 }
 
 // NewAccountManager creates an account manager with Pebble persistence
@@ -32,37 +38,27 @@ func NewAccountManager(dbPath string) (*AccountManager, error) {
 
 // Close closes the underlying Pebble database
 func (am *AccountManager) Close() error {
+	if am.store == nil {
+		return nil
+	}
 	return am.store.Close()
 }
 
+// save persists acc, if am has a store (forks are persisted on Merge)
+func (am *AccountManager) save(acc *Account) error {
+	if am.store == nil {
+		return nil
+	}
+	return am.store.SaveAccount(acc)
+}
+
 // GetAccount retrieves an account by address
 // Creates a new account with zero balance if it doesn't exist
 // Loads from Pebble if not in cache
 func (am *AccountManager) GetAccount(addr common.Address) *Account {
 	am.mu.Lock()
 	defer am.mu.Unlock()
-
-	// Check cache first
-	acc, exists := am.accounts[addr]
-	if exists {
-		return acc
-	}
-
-	// Try loading from Pebble
-	acc, err := am.store.LoadAccount(addr)
-	if err != nil {
-		// Log error but don't fail - create new account
-		fmt.Printf("[account] failed to load account %s: %v\n", addr.Hex(), err)
-	}
-
-	if acc == nil {
-		// Account doesn't exist - create new
-		acc = NewAccount(addr)
-	}
-
-	// Cache it
-	am.accounts[addr] = acc
-	return acc
+	return am.getAccountLocked(addr)
 }
 
 // GetAccountReadOnly retrieves an account without creating it
@@ -70,7 +66,8 @@ func (am *AccountManager) GetAccount(addr common.Address) *Account {
 func (am *AccountManager) GetAccountReadOnly(addr common.Address) *Account {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
-	return am.accounts[addr]
+	acc, _ := am.peekLocked(addr)
+	return acc
 }
 
 // Deposit adds USDC to an account (from bridge)
@@ -87,15 +84,22 @@ func (am *AccountManager) Deposit(addr common.Address, amount int64) error {
 	acc.USDCBalance += amount
 
 	// Persist to Pebble
-	return am.store.SaveAccount(acc)
+	return am.save(acc)
 }
 
 // getAccountLocked is an internal helper that gets account (assumes lock is held)
 func (am *AccountManager) getAccountLocked(addr common.Address) *Account {
-	acc, exists := am.accounts[addr]
-	if exists {
+	if acc, exists := am.lookupLocked(addr); exists {
 		return acc
 	}
+	if am.parent != nil {
+		if acc := am.parent.load(addr); acc != nil {
+			am.accounts[addr] = acc.clone()
+			return am.accounts[addr]
+		}
+		am.accounts[addr] = NewAccount(addr)
+		return am.accounts[addr]
+	}
 
 	// Try loading from Pebble
 	acc, err := am.store.LoadAccount(addr)
@@ -111,6 +115,92 @@ func (am *AccountManager) getAccountLocked(addr common.Address) *Account {
 	return acc
 }
 
+// peekLocked returns the cached account, a fork's own or its parent's
+// (assumes read lock is held)
+func (am *AccountManager) peekLocked(addr common.Address) (*Account, bool) {
+	if acc, exists := am.accounts[addr]; exists {
+		return acc, true
+	}
+	if am.parent == nil {
+		return nil, false
+	}
+	am.parent.mu.RLock()
+	defer am.parent.mu.RUnlock()
+	return am.parent.peekLocked(addr)
+}
+
+// lookupLocked returns the cached account for changing it: a fork copies
+// its parent's first (assumes lock is held)
+func (am *AccountManager) lookupLocked(addr common.Address) (*Account, bool) {
+	if acc, exists := am.accounts[addr]; exists {
+		return acc, true
+	}
+	if am.parent == nil {
+		return nil, false
+	}
+	am.parent.mu.RLock()
+	acc, exists := am.parent.peekLocked(addr)
+	am.parent.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+	am.accounts[addr] = acc.clone()
+	return am.accounts[addr], true
+}
+
+// load returns the account as cached or stored, nil if it doesn't exist,
+// without creating it
+func (am *AccountManager) load(addr common.Address) *Account {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if acc, exists := am.accounts[addr]; exists {
+		return acc
+	}
+	if am.parent != nil {
+		return am.parent.load(addr)
+	}
+	acc, err := am.store.LoadAccount(addr)
+	if err != nil {
+		fmt.Printf("[account] failed to load account %s: %v\n", addr.Hex(), err)
+	}
+	if acc != nil {
+		am.accounts[addr] = acc
+	}
+	return acc
+}
+
+// Fork returns a copy-on-write view of am. am must only change through
+// Merge while forks of it are in use.
+func (am *AccountManager) Fork() *AccountManager {
+	return &AccountManager{accounts: make(map[common.Address]*Account), parent: am}
+}
+
+// Merge applies the accounts fork changed (a fork of am, or of a fork
+// merged before) to am and persists them.
+func (am *AccountManager) Merge(fork *AccountManager) error {
+	fork.mu.RLock()
+	defer fork.mu.RUnlock()
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	for addr, acc := range fork.accounts {
+		am.accounts[addr] = acc.clone()
+		if am.store != nil {
+			if err := am.store.SaveAccount(am.accounts[addr]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rebase makes fork am read through parent, once its old parent was merged
+// into parent.
+func (am *AccountManager) Rebase(parent *AccountManager) {
+	am.mu.Lock()
+	am.parent = parent
+	am.mu.Unlock()
+}
+
 // Withdraw removes USDC from an account (to bridge)
 // Returns error if insufficient available balance
 func (am *AccountManager) Withdraw(addr common.Address, amount int64) error {
@@ -131,7 +221,7 @@ func (am *AccountManager) Withdraw(addr common.Address, amount int64) error {
 	acc.USDCBalance -= amount
 
 	// Persist to Pebble
-	return am.store.SaveAccount(acc)
+	return am.save(acc)
 }
 
 // LockCollateral locks collateral for an order or position
@@ -147,7 +237,7 @@ func (am *AccountManager) LockCollateral(addr common.Address, amount int64) erro
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -174,7 +264,7 @@ func (am *AccountManager) UnlockCollateral(addr common.Address, amount int64) er
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -187,13 +277,77 @@ func (am *AccountManager) UnlockCollateral(addr common.Address, amount int64) er
 	return nil
 }
 
+// LockOrderMargin locks collateral for an order on symbol, as held for
+// resting orders (Account.OrderMargin); SetOrderMargin settles it once the
+// order has matched
+func (am *AccountManager) LockOrderMargin(addr common.Address, symbol string, amount int64) error {
+	if amount < 0 {
+		return fmt.Errorf("lock amount cannot be negative: %d", amount)
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	acc, exists := am.lookupLocked(addr)
+	if !exists {
+		return fmt.Errorf("account not found: %s", addr.Hex())
+	}
+
+	available := acc.AvailableBalance()
+	if available < amount {
+		return fmt.Errorf("insufficient balance to lock: have %d, need %d", available, amount)
+	}
+
+	acc.LockedCollateral += amount
+	if acc.OrderMargin == nil {
+		acc.OrderMargin = make(map[string]int64)
+	}
+	acc.OrderMargin[symbol] += amount
+	return nil
+}
+
+// SetOrderMargin sets the collateral held for an account's resting orders
+// on symbol to target, locking or releasing the difference
+func (am *AccountManager) SetOrderMargin(addr common.Address, symbol string, target int64) error {
+	if target < 0 {
+		return fmt.Errorf("order margin cannot be negative: %d", target)
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	// Don't copy a parent's account that needs no change
+	if acc, exists := am.peekLocked(addr); !exists || acc.OrderMargin[symbol] == target {
+		if !exists && target != 0 {
+			return fmt.Errorf("account not found: %s", addr.Hex())
+		}
+		return nil
+	}
+	acc, _ := am.lookupLocked(addr)
+
+	delta := target - acc.OrderMargin[symbol]
+	if available := acc.AvailableBalance(); delta > available {
+		return fmt.Errorf("insufficient balance to lock: have %d, need %d", available, delta)
+	}
+	acc.LockedCollateral += delta
+	if acc.OrderMargin == nil {
+		acc.OrderMargin = make(map[string]int64)
+	}
+	if target == 0 {
+		delete(acc.OrderMargin, symbol)
+	} else {
+		acc.OrderMargin[symbol] = target
+	}
+	return nil
+}
+
 // GetAvailableBalance returns balance available for new orders
 // Thread-safe read
 func (am *AccountManager) GetAvailableBalance(addr common.Address) int64 {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.peekLocked(addr)
 	if !exists {
 		return 0
 	}
@@ -206,7 +360,7 @@ func (am *AccountManager) UpdatePosition(addr common.Address, symbol string, siz
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -310,7 +464,7 @@ func (am *AccountManager) ApplyFees(addr common.Address, feeDelta int64) error {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -331,7 +485,7 @@ func (am *AccountManager) RecordTrade(addr common.Address, volume int64) error {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -341,6 +495,20 @@ func (am *AccountManager) RecordTrade(addr common.Address, volume int64) error {
 	return nil
 }
 
+// RecordRejection reports a rejected order (Status OrderRejected, Reason
+// set) to its owner's account, which keeps the last MaxRejectedOrders.
+func (am *AccountManager) RecordRejection(o *Order) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	acc := am.getAccountLocked(o.Owner)
+	acc.Rejected = append(acc.Rejected, o)
+	if n := len(acc.Rejected) - MaxRejectedOrders; n > 0 {
+		acc.Rejected = slices.Delete(acc.Rejected, 0, n)
+	}
+	return am.save(acc)
+}
+
 // ListAccounts returns all registered accounts
 // Returns a snapshot copy to avoid holding the lock
 func (am *AccountManager) ListAccounts() []*Account {
@@ -366,7 +534,7 @@ func (am *AccountManager) ValidateAccount(addr common.Address) error {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.peekLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -385,7 +553,7 @@ func (am *AccountManager) CheckMarginRequirement(addr common.Address, mkt *marke
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.peekLocked(addr)
 	if !exists {
 		return fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -454,7 +622,7 @@ func (am *AccountManager) CheckLiquidation(addr common.Address, markets map[stri
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.peekLocked(addr)
 	if !exists {
 		return false, 0, 0, fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -514,7 +682,7 @@ func (am *AccountManager) Liquidate(addr common.Address, markets map[string]*mar
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	acc, exists := am.accounts[addr]
+	acc, exists := am.lookupLocked(addr)
 	if !exists {
 		return 0, 0, fmt.Errorf("account not found: %s", addr.Hex())
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
 	"github.com/uhyunpark/hyperlicked/pkg/app/core/mempool"
 	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/trigger"
 )
 
 // Re-export types from subpackages for backward compatibility
@@ -20,8 +21,28 @@ type (
 	Fill       = orderbook.Fill
 	PriceLevel = orderbook.PriceLevel
 	OrderBook  = orderbook.OrderBook
+	SelfTrade  = orderbook.SelfTrade
 )
 
+// ErrWouldCross rejects an ALO order that would match (OrderBook.Place).
+var ErrWouldCross = orderbook.ErrWouldCross
+
+// ErrWouldNotFill rejects a FOK order the book cannot fill in full.
+var ErrWouldNotFill = orderbook.ErrWouldNotFill
+
+// ErrExpired rejects an order whose deadline (Order.Expiry) has passed.
+var ErrExpired = orderbook.ErrExpired
+
+// ErrMarketPrice rejects a MARKET order with a price; ErrNoLiquidity one
+// with nothing to fill it (OrderBook.MarketPrice).
+var (
+	ErrMarketPrice = orderbook.ErrMarketPrice
+	ErrNoLiquidity = orderbook.ErrNoLiquidity
+)
+
+// ErrSelfTradeMode rejects an order with an unknown Order.STP.
+var ErrSelfTradeMode = orderbook.ErrSelfTradeMode
+
 const (
 	Buy  = orderbook.Buy
 	Sell = orderbook.Sell
@@ -36,6 +57,16 @@ type (
 	Account        = account.Account
 	Position       = account.Position
 	AccountManager = account.AccountManager
+	AccountOrder   = account.Order
+)
+
+// ErrReduceOnly rejects a reduce-only order that would not reduce the
+// position (Account.Reducible).
+var ErrReduceOnly = account.ErrReduceOnly
+
+const (
+	OrderRejected     = account.OrderRejected
+	MaxRejectedOrders = account.MaxRejectedOrders
 )
 
 func NewAccount(addr common.Address) *Account {
@@ -62,6 +93,8 @@ type (
 	Market         = market.Market
 	MarketParams   = market.MarketParams
 	MarketRegistry = market.MarketRegistry
+
+	SelfTradePrevention = market.SelfTradePrevention
 )
 
 func NewMarket(symbol, baseAsset, quoteAsset string, params market.MarketParams) (*Market, error) {
@@ -86,5 +119,15 @@ func NewMempool() *Mempool {
 	return mempool.NewMempool()
 }
 
+// From trigger package
+type (
+	TriggerOrder = trigger.Order
+	TriggerStore = trigger.Store
+)
+
+func NewTriggerStore() *TriggerStore {
+	return trigger.NewStore()
+}
+
 // From transaction package (new)
 // Import not needed yet - will add when transaction package is used
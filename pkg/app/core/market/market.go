@@ -1,6 +1,7 @@
 package market
 
 import (
+	"cmp"
 	"fmt"
 	"time"
 )
@@ -52,6 +53,38 @@ func (ms MarketStatus) String() string {
 	}
 }
 
+// SelfTradePrevention decides what happens when an order would match a
+// resting order of the same owner (Order.OwnerHex)
+type SelfTradePrevention uint8
+
+const (
+	STPMarketDefault      SelfTradePrevention = iota // Unset: an order uses its market's mode
+	STPNone                                          // Match as usual
+	STPCancelNewest                                  // Cancel the taker's remaining qty
+	STPCancelOldest                                  // Cancel the resting order, keep matching
+	STPCancelBoth                                    // Cancel both
+	STPDecrementAndCancel                            // Shrink both by the smaller qty
+)
+
+func (m SelfTradePrevention) String() string {
+	switch m {
+	case STPMarketDefault:
+		return "market-default"
+	case STPNone:
+		return "none"
+	case STPCancelNewest:
+		return "cancel-newest"
+	case STPCancelOldest:
+		return "cancel-oldest"
+	case STPCancelBoth:
+		return "cancel-both"
+	case STPDecrementAndCancel:
+		return "decrement-and-cancel"
+	default:
+		return "unknown"
+	}
+}
+
 // Market defines all parameters for a trading market (e.g., HYPL-USDC perpetual)
 type Market struct {
 	// Identity
@@ -92,6 +125,11 @@ type Market struct {
 	MakerFeeBps int64 // Maker fee in basis points (can be negative for rebate, e.g., -2 bps)
 	TakerFeeBps int64 // Taker fee in basis points (e.g., 5 bps = 0.05%)
 
+	// Self-trade prevention for orders that don't set their own; never
+	// STPMarketDefault (NewMarket makes that STPNone, so markets that
+	// don't choose a mode keep matching self-crosses)
+	SelfTradePrevention SelfTradePrevention
+
 	// Metadata
 	LaunchedAt int64 // Block height when market was opened
 }
@@ -117,6 +155,7 @@ func NewMarket(symbol, baseAsset, quoteAsset string, params MarketParams) (*Mark
 		MaxPosition:          params.MaxPosition,
 		MakerFeeBps:          params.MakerFeeBps,
 		TakerFeeBps:          params.TakerFeeBps,
+		SelfTradePrevention:  cmp.Or(params.SelfTradePrevention, STPNone),
 		LaunchedAt:           0, // Set when market opens
 	}
 
@@ -196,6 +235,10 @@ func (m *Market) Validate() error {
 		return fmt.Errorf("taker fee cannot be negative")
 	}
 
+	if m.SelfTradePrevention == STPMarketDefault || m.SelfTradePrevention > STPDecrementAndCancel {
+		return fmt.Errorf("invalid self-trade prevention mode %s (%d)", m.SelfTradePrevention, m.SelfTradePrevention)
+	}
+
 	return nil
 }
 
@@ -288,3 +331,15 @@ func (m *Market) ValidateOrder(price, qty int64) error {
 	}
 	return nil
 }
+
+// ValidateMarketOrder validates an order without a limit price (MARKET):
+// there is no price to check, nor notional
+func (m *Market) ValidateMarketOrder(qty int64) error {
+	if m.Status != Active {
+		return fmt.Errorf("market %s is not active (status: %s)", m.Symbol, m.Status)
+	}
+	if qty <= 0 {
+		return fmt.Errorf("quantity must be positive")
+	}
+	return m.ValidateOrderSize(qty)
+}
@@ -19,6 +19,7 @@ type MarketParams struct {
 	MaxPosition          int64
 	MakerFeeBps          int64
 	TakerFeeBps          int64
+	SelfTradePrevention  SelfTradePrevention
 }
 
 // DefaultHYPLUSDC returns default parameters for HYPL-USDC perpetual futures
@@ -63,6 +64,10 @@ var DefaultHYPLUSDC = MarketParams{
 	// Taker: 0.05% = 5 bps
 	MakerFeeBps: -2, // Rebate to makers
 	TakerFeeBps: 5,  // Fee from takers
+
+	// Self-trade prevention: an order never trades against its owner's
+	// resting orders; what is left of it is cancelled
+	SelfTradePrevention: STPCancelNewest,
 }
 
 // NewMarketWithDefaults creates a market using default HYPL-USDC parameters
@@ -90,5 +95,6 @@ func CustomPerpetual(tickSize, lotSize, leverage int64) MarketParams {
 		MaxPosition:          10000000,
 		MakerFeeBps:          -2,
 		TakerFeeBps:          5,
+		SelfTradePrevention:  STPCancelNewest,
 	}
 }
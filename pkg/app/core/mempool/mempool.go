@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"sync"
 )
@@ -25,6 +26,7 @@ type Tx struct {
 // All transactions must be signed JSON format (EIP-712):
 //   {"type": "order", ...}   -> TxOrderGTC (default for orders)
 //   {"type": "cancel", ...}  -> TxCancel
+//   {"type": "trigger", ...} -> TxOrderGTC (placing a stop / take-profit order)
 //
 // Invalid or malformed transactions default to TxOrderGTC for backward compatibility.
 func ClassifyRaw(b []byte) TxType {
@@ -47,7 +49,7 @@ func ClassifyRaw(b []byte) TxType {
 	switch txEnvelope.Type {
 	case "cancel":
 		return TxCancel
-	case "order":
+	case "order", "trigger":
 		// TODO: Check order.type field for IOC vs GTC distinction
 		// For now, all signed orders are classified as GTC
 		return TxOrderGTC
@@ -60,15 +62,25 @@ func ClassifyRaw(b []byte) TxType {
 // Mempool maintains three queues per HL ordering rule:
 // (1) Non-order, (2) Cancel, (3) Orders (GTC/IOC)
 // Within each bucket, FIFO by proposer admission order.
+// Pending txs are also indexed by hash (sha256 of the raw bytes, as
+// consensus.TxHash), so followers can rebuild proposed blocks from the txs
+// they already hold.
 type Mempool struct {
 	mu       sync.Mutex
 	nonOrder [][]byte
 	cancel   [][]byte
 	orders   [][]byte // both GTC/IOC kept together; parser may tag inside the bytes if needed
+	byHash   map[[32]byte]*pending
+}
+
+// pending is an indexed tx with the number of its queued copies.
+type pending struct {
+	tx     []byte
+	copies int
 }
 
 func NewMempool() *Mempool {
-	return &Mempool{}
+	return &Mempool{byHash: make(map[[32]byte]*pending)}
 }
 
 // PushRaw classifies and enqueues a tx.
@@ -76,6 +88,12 @@ func (m *Mempool) PushRaw(b []byte) {
 	cp := append([]byte(nil), b...)
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	h := sha256.Sum256(cp)
+	if p, ok := m.byHash[h]; ok {
+		p.copies++
+	} else {
+		m.byHash[h] = &pending{tx: cp, copies: 1}
+	}
 	switch ClassifyRaw(b) {
 	case TxNonOrder:
 		m.nonOrder = append(m.nonOrder, cp)
@@ -105,6 +123,7 @@ func (m *Mempool) SelectForProposal(maxBytes int64) [][]byte {
 			out = append(out, tx)
 			used += n
 			*q = (*q)[1:]
+			m.unindex(sha256.Sum256(tx))
 		}
 	}
 
@@ -116,6 +135,53 @@ func (m *Mempool) SelectForProposal(maxBytes int64) [][]byte {
 	return out
 }
 
+// Get returns the pending tx with hash h.
+func (m *Mempool) Get(h [32]byte) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.byHash[h]
+	if !ok {
+		return nil, false
+	}
+	return p.tx, true
+}
+
+// Remove drops every pending copy of the txs with the given hashes (txs a
+// block committed, whoever proposed it).
+func (m *Mempool) Remove(hashes [][32]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	drop := make(map[[32]byte]bool, len(hashes))
+	for _, h := range hashes {
+		if _, ok := m.byHash[h]; ok {
+			drop[h] = true
+		}
+	}
+	if len(drop) == 0 {
+		return
+	}
+	for _, q := range []*[][]byte{&m.nonOrder, &m.cancel, &m.orders} {
+		kept := (*q)[:0]
+		for _, tx := range *q {
+			if h := sha256.Sum256(tx); drop[h] {
+				m.unindex(h)
+				continue
+			}
+			kept = append(kept, tx)
+		}
+		*q = kept
+	}
+}
+
+// unindex forgets one pending copy of h. Caller holds mu.
+func (m *Mempool) unindex(h [32]byte) {
+	if p, ok := m.byHash[h]; ok {
+		if p.copies--; p.copies == 0 {
+			delete(m.byHash, h)
+		}
+	}
+}
+
 // Len returns total pending txs (for tests/metrics if needed).
 func (m *Mempool) Len() int {
 	m.mu.Lock()
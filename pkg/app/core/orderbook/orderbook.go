@@ -2,17 +2,42 @@ package orderbook
 
 import (
 	"container/heap"
+	"errors"
+	"fmt"
+	"math"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
 )
 
+// ErrWouldCross rejects an ALO (post-only) order that would take liquidity.
+var ErrWouldCross = errors.New("post-only order would cross the book")
+
+// ErrWouldNotFill rejects a FOK (fill-or-kill) order the book cannot fill in full.
+var ErrWouldNotFill = errors.New("fill-or-kill order cannot be filled in full")
+
+// ErrExpired rejects an order whose Expiry has passed.
+var ErrExpired = errors.New("order expired")
+
+var (
+	// ErrMarketPrice rejects a MARKET order that sets a price.
+	ErrMarketPrice = errors.New("market order must not carry a price")
+	// ErrNoLiquidity rejects a MARKET order with no resting orders to fill it.
+	ErrNoLiquidity = errors.New("no liquidity for market order")
+)
+
+// ErrSelfTradeMode rejects an order whose STP is not a known mode.
+var ErrSelfTradeMode = errors.New("unknown self-trade prevention mode")
+
 type Fill struct {
-	TakerID string
-	MakerID string
-	Price   int64
-	Qty     int64
+	TakerID    string
+	MakerID    string
+	Price      int64
+	Qty        int64
+	TakerSide  Side
+	MakerOwner string // the maker order's OwnerHex
 }
 
 type PriceLevel struct {
@@ -55,6 +80,44 @@ func NewOrderBook() *OrderBook {
 	}
 }
 
+// Clone returns a deep copy of ob (resting orders included), which can be
+// changed without affecting ob.
+func (ob *OrderBook) Clone() *OrderBook {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	bidHeap := append(MaxPriceHeap(nil), *ob.bidHeap...)
+	askHeap := append(MinPriceHeap(nil), *ob.askHeap...)
+	c := &OrderBook{
+		bidHeap:    &bidHeap,
+		askHeap:    &askHeap,
+		bids:       make(map[int64][]*Order, len(ob.bids)),
+		asks:       make(map[int64][]*Order, len(ob.asks)),
+		orderIndex: make(map[string]int64, len(ob.orderIndex)),
+		index:      make(map[string]struct{}, len(ob.index)),
+		lastPrice:  ob.lastPrice,
+	}
+	cloneLevels := func(dst, src map[int64][]*Order) {
+		for p, q := range src {
+			cq := make([]*Order, len(q))
+			for i, o := range q {
+				co := *o
+				cq[i] = &co
+			}
+			dst[p] = cq
+		}
+	}
+	cloneLevels(c.bids, ob.bids)
+	cloneLevels(c.asks, ob.asks)
+	for id, p := range ob.orderIndex {
+		c.orderIndex[id] = p
+	}
+	for id := range ob.index {
+		c.index[id] = struct{}{}
+	}
+	return c
+}
+
 func min(a, b int64) int64 {
 	if a < b {
 		return a
@@ -104,7 +167,10 @@ func (ob *OrderBook) addAsk(p int64, o *Order) {
 func (ob *OrderBook) Cancel(id string) bool {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	return ob.cancelLocked(id)
+}
 
+func (ob *OrderBook) cancelLocked(id string) bool {
 	// O(1) lookup via orderIndex
 	price, ok := ob.orderIndex[id]
 	if !ok {
@@ -154,6 +220,167 @@ func (ob *OrderBook) Cancel(id string) bool {
 	return false
 }
 
+// Resize sets the qty of resting order id, keeping its time priority; a qty
+// of 0 cancels it. Returns false if id is not resting.
+func (ob *OrderBook) Resize(id string, qty int64) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if qty <= 0 {
+		return ob.cancelLocked(id)
+	}
+	price, ok := ob.orderIndex[id]
+	if !ok {
+		return false
+	}
+	for _, level := range [][]*Order{ob.bids[price], ob.asks[price]} {
+		for _, o := range level {
+			if o.ID == id {
+				o.Qty = qty
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReduceOnlyOrders returns copies of owner's resting reduce-only orders,
+// bids then asks, each in matching priority (best price first, then FIFO).
+func (ob *OrderBook) ReduceOnlyOrders(owner string) []Order {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.restingLocked(func(o *Order) bool {
+		return o.ReduceOnly && strings.EqualFold(o.OwnerHex, owner)
+	})
+}
+
+// Get returns a copy of resting order id.
+func (ob *OrderBook) Get(id string) (Order, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	price, ok := ob.orderIndex[id]
+	if !ok {
+		return Order{}, false
+	}
+	for _, level := range [][]*Order{ob.bids[price], ob.asks[price]} {
+		for _, o := range level {
+			if o.ID == id {
+				return *o, true
+			}
+		}
+	}
+	return Order{}, false
+}
+
+// Resting returns copies of owner's resting orders, in the order of
+// ReduceOnlyOrders.
+func (ob *OrderBook) Resting(owner string) []Order {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.restingLocked(func(o *Order) bool {
+		return strings.EqualFold(o.OwnerHex, owner)
+	})
+}
+
+// Expired returns copies of the resting orders expired at block time now
+// (Order.Expiry), in the order of ReduceOnlyOrders. They stay on the book
+// until cancelled.
+func (ob *OrderBook) Expired(now int64) []Order {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.restingLocked(func(o *Order) bool {
+		return o.Expiry > 0 && o.Expiry <= now
+	})
+}
+
+// restingLocked returns copies of the resting orders matching keep, bids
+// then asks, each in matching priority.
+func (ob *OrderBook) restingLocked(keep func(*Order) bool) []Order {
+	var out []Order
+	collect := func(levels map[int64][]*Order, better func(a, b int64) bool) {
+		prices := make([]int64, 0, len(levels))
+		for p := range levels {
+			prices = append(prices, p)
+		}
+		sort.Slice(prices, func(i, j int) bool { return better(prices[i], prices[j]) })
+		for _, p := range prices {
+			for _, o := range levels[p] {
+				if keep(o) {
+					out = append(out, *o)
+				}
+			}
+		}
+	}
+	collect(ob.bids, func(a, b int64) bool { return a > b })
+	collect(ob.asks, func(a, b int64) bool { return a < b })
+	return out
+}
+
+// MarketPrice returns the worst price a MARKET order for qty on side would
+// fill at, walking the opposite levels best first; the last level's if they
+// hold less than qty. False if the opposite side is empty.
+func (ob *OrderBook) MarketPrice(side Side, qty int64) (int64, bool) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	levels, better := ob.asks, func(a, b int64) bool { return a < b }
+	if side == Sell {
+		levels, better = ob.bids, func(a, b int64) bool { return a > b }
+	}
+	prices := make([]int64, 0, len(levels))
+	for p, level := range levels {
+		if len(level) > 0 {
+			prices = append(prices, p)
+		}
+	}
+	if len(prices) == 0 {
+		return 0, false
+	}
+	sort.Slice(prices, func(i, j int) bool { return better(prices[i], prices[j]) })
+	for _, p := range prices {
+		for _, maker := range levels[p] {
+			if qty -= maker.Qty; qty <= 0 {
+				return p, true
+			}
+		}
+	}
+	return prices[len(prices)-1], true
+}
+
+// fillable reports whether matching o would fill it in full: the book
+// holds enough liquidity at prices o takes, and no self-trade prevention
+// cancels or shrinks o on the way.
+func (ob *OrderBook) fillable(o *Order, mkt *market.Market) bool {
+	levels, better := ob.asks, func(a, b int64) bool { return a < b }
+	if o.Side == Sell {
+		levels, better = ob.bids, func(a, b int64) bool { return a > b }
+	}
+	prices := make([]int64, 0, len(levels))
+	for p := range levels {
+		if o.takes(p) {
+			prices = append(prices, p)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return better(prices[i], prices[j]) })
+	var qty int64
+	for _, p := range prices {
+		for _, maker := range levels[p] {
+			switch selfTradeMode(o, maker, mkt) {
+			case market.STPNone:
+				if qty += maker.Qty; qty >= o.Qty {
+					return true
+				}
+			case market.STPCancelOldest:
+				// the maker goes, o keeps matching
+			default:
+				return false
+			}
+		}
+	}
+	return false
+}
+
 // removeFromBidHeap removes a price level from the bid heap (O(N) worst case, but rare)
 func (ob *OrderBook) removeFromBidHeap(price int64) {
 	for i := 0; i < ob.bidHeap.Len(); i++ {
@@ -174,24 +401,56 @@ func (ob *OrderBook) removeFromAskHeap(price int64) {
 	}
 }
 
-// Place matches IOC/GTC by price-time. Remaining qty rests only if GTC.
+// Place is PlaceWithSelfTrades without the prevented self-trades.
+func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
+	fills, _, err := ob.PlaceWithSelfTrades(o, mkt)
+	return fills, err
+}
+
+// PlaceWithSelfTrades matches IOC/GTC by price-time. Remaining qty rests only if GTC.
+// MARKET orders match at any price and never rest.
+// FOK orders fill in full or not at all (ErrWouldNotFill).
+// Matches between orders of the same owner are prevented as the order, else
+// the market, says (SelfTradePrevention); they are returned.
+// ALO orders never match: one that would is rejected (ErrWouldCross) or,
+// with Reprice, rests one tick inside the spread (see postOnly).
 // Validates order against market parameters before matching.
 // Returns error if order violates market rules (invalid tick/lot size, min notional, etc.)
-func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
+func (ob *OrderBook) PlaceWithSelfTrades(o *Order, mkt *market.Market) ([]Fill, []SelfTrade, error) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
+	if o.STP > market.STPDecrementAndCancel {
+		return nil, nil, ErrSelfTradeMode
+	}
+
 	// Validate order against market parameters
-	if err := mkt.ValidateOrder(o.Price, o.Qty); err != nil {
-		return nil, err
+	if o.Type == "MARKET" {
+		if o.Price != 0 {
+			return nil, nil, ErrMarketPrice
+		}
+		if err := mkt.ValidateMarketOrder(o.Qty); err != nil {
+			return nil, nil, err
+		}
+	} else if err := mkt.ValidateOrder(o.Price, o.Qty); err != nil {
+		return nil, nil, err
+	}
+	if o.Type == "ALO" {
+		if err := ob.postOnly(o, mkt); err != nil {
+			return nil, nil, err
+		}
+	}
+	if o.Type == "FOK" && !ob.fillable(o, mkt) {
+		return nil, nil, ErrWouldNotFill
 	}
 
 	var fills []Fill
+	var selfTrades []SelfTrade
 
 	if o.Side == Buy {
 		for o.Qty > 0 {
 			askP, ok := ob.bestAsk()
-			if !ok || askP > o.Price {
+			if !ok || !o.takes(askP) {
 				break
 			}
 			level := ob.asks[askP]
@@ -201,11 +460,15 @@ func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
 				continue
 			}
 			maker := level[0]
-			match := min(o.Qty, maker.Qty)
-			o.Qty -= match
-			maker.Qty -= match
-			fills = append(fills, Fill{TakerID: o.ID, MakerID: maker.ID, Price: askP, Qty: match})
-			ob.lastPrice = askP // Update last traded price
+			if mode := selfTradeMode(o, maker, mkt); mode != market.STPNone {
+				selfTrades = append(selfTrades, preventSelfTrade(o, maker, mode, askP))
+			} else {
+				match := min(o.Qty, maker.Qty)
+				o.Qty -= match
+				maker.Qty -= match
+				fills = append(fills, Fill{TakerID: o.ID, MakerID: maker.ID, Price: askP, Qty: match, TakerSide: o.Side, MakerOwner: maker.OwnerHex})
+				ob.lastPrice = askP // Update last traded price
+			}
 			if maker.Qty == 0 {
 				ob.asks[askP] = level[1:]
 				delete(ob.index, maker.ID)
@@ -218,14 +481,14 @@ func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
 				ob.asks[askP][0] = maker
 			}
 		}
-		if o.Qty > 0 && o.Type == "GTC" {
+		if o.Qty > 0 && o.rests() {
 			cp := *o
 			ob.addBid(o.Price, &cp)
 		}
 	} else { // Sell
 		for o.Qty > 0 {
 			bidP, ok := ob.bestBid()
-			if !ok || bidP < o.Price {
+			if !ok || !o.takes(bidP) {
 				break
 			}
 			level := ob.bids[bidP]
@@ -235,11 +498,15 @@ func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
 				continue
 			}
 			maker := level[0]
-			match := min(o.Qty, maker.Qty)
-			o.Qty -= match
-			maker.Qty -= match
-			fills = append(fills, Fill{TakerID: o.ID, MakerID: maker.ID, Price: bidP, Qty: match})
-			ob.lastPrice = bidP // Update last traded price
+			if mode := selfTradeMode(o, maker, mkt); mode != market.STPNone {
+				selfTrades = append(selfTrades, preventSelfTrade(o, maker, mode, bidP))
+			} else {
+				match := min(o.Qty, maker.Qty)
+				o.Qty -= match
+				maker.Qty -= match
+				fills = append(fills, Fill{TakerID: o.ID, MakerID: maker.ID, Price: bidP, Qty: match, TakerSide: o.Side, MakerOwner: maker.OwnerHex})
+				ob.lastPrice = bidP // Update last traded price
+			}
 			if maker.Qty == 0 {
 				ob.bids[bidP] = level[1:]
 				delete(ob.index, maker.ID)
@@ -252,12 +519,46 @@ func (ob *OrderBook) Place(o *Order, mkt *market.Market) ([]Fill, error) {
 				ob.bids[bidP][0] = maker
 			}
 		}
-		if o.Qty > 0 && o.Type == "GTC" {
+		if o.Qty > 0 && o.rests() {
 			cp := *o
 			ob.addAsk(o.Price, &cp)
 		}
 	}
-	return fills, nil
+	return fills, selfTrades, nil
+}
+
+// postOnly checks an ALO order against the opposite side before it rests:
+// if it would match, it is rejected or, with Reprice, moved one tick inside
+// the spread (one tick better than the best opposite price). The new price
+// is validated like any order's; if it fails, or there is no price one tick
+// inside, the order is rejected.
+func (ob *OrderBook) postOnly(o *Order, mkt *market.Market) error {
+	tick := mkt.TickSize
+	var price int64
+	if o.Side == Buy {
+		ask, ok := ob.bestAsk()
+		if !ok || o.Price < ask {
+			return nil
+		}
+		if !o.Reprice || ask-tick <= 0 {
+			return fmt.Errorf("%w: buy at %d, best ask %d", ErrWouldCross, o.Price, ask)
+		}
+		price = ask - tick
+	} else {
+		bid, ok := ob.bestBid()
+		if !ok || o.Price > bid {
+			return nil
+		}
+		if !o.Reprice || bid > math.MaxInt64-tick {
+			return fmt.Errorf("%w: sell at %d, best bid %d", ErrWouldCross, o.Price, bid)
+		}
+		price = bid + tick
+	}
+	if err := mkt.ValidateOrder(price, o.Qty); err != nil {
+		return fmt.Errorf("%w: repriced to %d: %v", ErrWouldCross, price, err)
+	}
+	o.Price = price
+	return nil
 }
 
 // GetBidLevels returns all bid price levels sorted high to low (best bid first).
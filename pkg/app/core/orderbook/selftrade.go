@@ -0,0 +1,50 @@
+package orderbook
+
+import (
+	"strings"
+
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+)
+
+// SelfTrade is a match between two orders of the same owner that Place
+// prevented.
+type SelfTrade struct {
+	TakerID string
+	MakerID string
+	Owner   string
+	Mode    market.SelfTradePrevention
+	Price   int64 // the maker's price
+	Qty     int64 // what would have matched
+}
+
+// selfTradeMode returns how to prevent taker o from matching maker: the
+// order's mode unless it is STPMarketDefault, else the market's; STPNone if
+// they have different (or unknown) owners.
+func selfTradeMode(o, maker *Order, mkt *market.Market) market.SelfTradePrevention {
+	if o.OwnerHex == "" || !strings.EqualFold(o.OwnerHex, maker.OwnerHex) {
+		return market.STPNone
+	}
+	if o.STP != market.STPMarketDefault {
+		return o.STP
+	}
+	return mkt.SelfTradePrevention
+}
+
+// preventSelfTrade applies mode to taker o and maker instead of matching
+// them: a qty of 0 cancels an order (the maker is then removed from the
+// book by the caller).
+func preventSelfTrade(o, maker *Order, mode market.SelfTradePrevention, price int64) SelfTrade {
+	st := SelfTrade{TakerID: o.ID, MakerID: maker.ID, Owner: o.OwnerHex, Mode: mode, Price: price, Qty: min(o.Qty, maker.Qty)}
+	switch mode {
+	case market.STPCancelOldest:
+		maker.Qty = 0
+	case market.STPCancelBoth:
+		o.Qty, maker.Qty = 0, 0
+	case market.STPDecrementAndCancel:
+		o.Qty -= st.Qty
+		maker.Qty -= st.Qty
+	default: // STPCancelNewest
+		o.Qty = 0
+	}
+	return st
+}
@@ -1,5 +1,7 @@
 package orderbook
 
+import "github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+
 type Side int8
 
 const (
@@ -11,8 +13,36 @@ type Order struct {
 	ID       string
 	Symbol   string
 	Side     Side
-	Price    int64  // integer ticks
+	Price    int64  // integer ticks; MARKET: ignored (the app margins it at MarketPrice)
 	Qty      int64  // integer lots
-	Type     string // "GTC" or "IOC"
+	Type     string // "GTC", "IOC", "ALO" (post-only), "FOK" or "MARKET"
 	OwnerHex string // optional owner address (0x...)
+	// Reprice makes an ALO order that would match rest one tick inside the
+	// spread instead of being rejected
+	Reprice bool
+	// ReduceOnly orders may only shrink the owner's position; the app sizes
+	// them (ReduceOnlyOrders, Resize), the book matches them as usual
+	ReduceOnly bool
+	// Expiry is the block time (Unix seconds) from which a resting order is
+	// swept (Expired); 0 never expires
+	Expiry int64
+	// STP is how to prevent the order matching its owner's resting orders;
+	// STPMarketDefault (unset) uses the market's mode
+	// (market.Market.SelfTradePrevention), STPNone lets them match
+	STP market.SelfTradePrevention
+}
+
+// rests reports whether the unfilled qty of o stays on the book.
+func (o *Order) rests() bool { return o.Type == "GTC" || o.Type == "ALO" }
+
+// takes reports whether o matches a maker at price p.
+func (o *Order) takes(p int64) bool {
+	switch {
+	case o.Type == "MARKET":
+		return true
+	case o.Side == Buy:
+		return p <= o.Price
+	default:
+		return p >= o.Price
+	}
 }
@@ -15,6 +15,7 @@ type TxType string
 const (
 	TxTypeOrder      TxType = "order"       // Place order (signed)
 	TxTypeCancel     TxType = "cancel"      // Cancel order (signed)
+	TxTypeTrigger    TxType = "trigger"     // Place stop / take-profit order (signed)
 	TxTypeLegacy     TxType = "legacy"      // Old string format (backward compat)
 	TxTypeDelegation TxType = "delegation"  // Agent key delegation
 )
@@ -25,6 +26,7 @@ type SignedTransaction struct {
 	Type      TxType          `json:"type"`               // Transaction type
 	Order     *OrderPayload   `json:"order,omitempty"`    // Order data (if type=order)
 	Cancel    *CancelPayload  `json:"cancel,omitempty"`   // Cancel data (if type=cancel)
+	Trigger   *TriggerPayload `json:"trigger,omitempty"`  // Trigger order data (if type=trigger)
 	Signature string          `json:"signature"`          // Hex-encoded signature (0x...)
 
 	// For agent key orders
@@ -36,13 +38,31 @@ type SignedTransaction struct {
 type OrderPayload struct {
 	Symbol   string `json:"symbol"`    // "BTC-USDT"
 	Side     uint8  `json:"side"`      // 1=Buy, 2=Sell
-	Type     uint8  `json:"type"`      // 1=GTC, 2=IOC, 3=ALO
-	Price    string `json:"price"`     // BigInt as string
-	Qty      string `json:"qty"`       // BigInt as string
+	Type     uint8  `json:"type"`      // 1=GTC, 2=IOC, 3=ALO, 4=MARKET, 5=CLOSE (whole position), 6=FOK
+	Price    string `json:"price"`     // BigInt as string ("0" for MARKET, CLOSE)
+	Qty      string `json:"qty"`       // BigInt as string (CLOSE: ignored)
 	Nonce    string `json:"nonce"`     // BigInt as string
-	Deadline string `json:"deadline"`  // Unix timestamp (0 = no expiry)
+	Deadline string `json:"deadline"`  // Unix timestamp (0 = no expiry); resting orders expire at it (GTT)
 	Leverage uint8  `json:"leverage"`  // 1-50x
 	Owner    string `json:"owner"`     // Ethereum address (0x...)
+
+	ReduceOnly bool  `json:"reduceOnly,omitempty"` // Only reduce the owner's position
+	STP        uint8 `json:"stp,omitempty"`        // Self-trade prevention mode (market.SelfTradePrevention), 0 = market's
+}
+
+// TriggerPayload contains stop / take-profit order data for EIP-712 signing
+type TriggerPayload struct {
+	Symbol       string `json:"symbol"`        // "BTC-USDT"
+	Side         uint8  `json:"side"`          // 1=Buy, 2=Sell
+	Kind         uint8  `json:"kind"`          // 1=stop-market, 2=stop-limit, 3=take-profit-market, 4=take-profit-limit
+	TriggerPrice string `json:"trigger_price"` // BigInt as string
+	PriceRef     uint8  `json:"price_ref"`     // 1=mark, 2=last
+	Price        string `json:"price"`         // BigInt as string (limit kinds, else "0")
+	Qty          string `json:"qty"`           // BigInt as string
+	Nonce        string `json:"nonce"`         // BigInt as string
+	Deadline     string `json:"deadline"`      // Unix timestamp (0 = no expiry); pending orders expire at it
+	Leverage     uint8  `json:"leverage"`      // 1-50x
+	Owner        string `json:"owner"`         // Ethereum address (0x...)
 }
 
 // CancelPayload contains order cancellation data
@@ -85,6 +105,9 @@ func (o *OrderPayload) ToEIP712Order() (*crypto.OrderEIP712, error) {
 		Deadline: deadline,
 		Leverage: o.Leverage,
 		Owner:    common.HexToAddress(o.Owner),
+
+		ReduceOnly: o.ReduceOnly,
+		STP:        o.STP,
 	}, nil
 }
 
@@ -100,6 +123,68 @@ func FromEIP712Order(order *crypto.OrderEIP712) *OrderPayload {
 		Deadline: order.Deadline.String(),
 		Leverage: order.Leverage,
 		Owner:    order.Owner.Hex(),
+
+		ReduceOnly: order.ReduceOnly,
+		STP:        order.STP,
+	}
+}
+
+// ToEIP712TriggerOrder converts TriggerPayload to crypto.TriggerOrderEIP712 for signing/verification
+func (t *TriggerPayload) ToEIP712TriggerOrder() (*crypto.TriggerOrderEIP712, error) {
+	triggerPrice, ok := new(big.Int).SetString(t.TriggerPrice, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid trigger price: %s", t.TriggerPrice)
+	}
+
+	price, ok := new(big.Int).SetString(t.Price, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid price: %s", t.Price)
+	}
+
+	qty, ok := new(big.Int).SetString(t.Qty, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid qty: %s", t.Qty)
+	}
+
+	nonce, ok := new(big.Int).SetString(t.Nonce, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid nonce: %s", t.Nonce)
+	}
+
+	deadline, ok := new(big.Int).SetString(t.Deadline, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid deadline: %s", t.Deadline)
+	}
+
+	return &crypto.TriggerOrderEIP712{
+		Symbol:       t.Symbol,
+		Side:         t.Side,
+		Kind:         t.Kind,
+		TriggerPrice: triggerPrice,
+		PriceRef:     t.PriceRef,
+		Price:        price,
+		Qty:          qty,
+		Nonce:        nonce,
+		Deadline:     deadline,
+		Leverage:     t.Leverage,
+		Owner:        common.HexToAddress(t.Owner),
+	}, nil
+}
+
+// FromEIP712TriggerOrder converts crypto.TriggerOrderEIP712 to TriggerPayload
+func FromEIP712TriggerOrder(order *crypto.TriggerOrderEIP712) *TriggerPayload {
+	return &TriggerPayload{
+		Symbol:       order.Symbol,
+		Side:         order.Side,
+		Kind:         order.Kind,
+		TriggerPrice: order.TriggerPrice.String(),
+		PriceRef:     order.PriceRef,
+		Price:        order.Price.String(),
+		Qty:          order.Qty.String(),
+		Nonce:        order.Nonce.String(),
+		Deadline:     order.Deadline.String(),
+		Leverage:     order.Leverage,
+		Owner:        order.Owner.Hex(),
 	}
 }
 
@@ -142,6 +227,20 @@ func (tx *SignedTransaction) Validate() error {
 			return fmt.Errorf("missing order owner")
 		}
 
+	case TxTypeTrigger:
+		if tx.Trigger == nil {
+			return fmt.Errorf("trigger type requires trigger payload")
+		}
+		if tx.Trigger.Symbol == "" {
+			return fmt.Errorf("missing trigger symbol")
+		}
+		if tx.Trigger.Side == 0 {
+			return fmt.Errorf("invalid trigger side")
+		}
+		if tx.Trigger.Owner == "" {
+			return fmt.Errorf("missing trigger owner")
+		}
+
 	case TxTypeCancel:
 		if tx.Cancel == nil {
 			return fmt.Errorf("cancel type requires cancel payload")
@@ -215,6 +314,25 @@ func ParseTransaction(data []byte) (*SignedTransaction, error) {
 //     "signature": "0x1234567890abcdef..."
 //   }
 
+// Trigger order (stop-loss at mark price 48000, then market sell):
+//   {
+//     "type": "trigger",
+//     "trigger": {
+//       "symbol": "BTC-USDT",
+//       "side": 2,
+//       "kind": 1,
+//       "trigger_price": "48000",
+//       "price_ref": 1,
+//       "price": "0",
+//       "qty": "100",
+//       "nonce": "43",
+//       "deadline": "0",
+//       "leverage": 10,
+//       "owner": "0x742d35Cc6634C0532925a3b844Bc9e7595f0bEb0"
+//     },
+//     "signature": "0x..."
+//   }
+
 // Agent mode (pre-authorized):
 //   {
 //     "type": "order",
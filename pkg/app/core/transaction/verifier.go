@@ -60,6 +60,39 @@ func (v *Verifier) VerifyOrderTransaction(tx *SignedTransaction) (common.Address
 	return order.Owner, true, nil
 }
 
+// VerifyTriggerTransaction verifies a signed trigger (stop / take-profit) order transaction
+// Returns (owner address, valid, error)
+func (v *Verifier) VerifyTriggerTransaction(tx *SignedTransaction) (common.Address, bool, error) {
+	if tx.Type != TxTypeTrigger {
+		return common.Address{}, false, fmt.Errorf("not a trigger transaction")
+	}
+
+	if tx.Trigger == nil {
+		return common.Address{}, false, fmt.Errorf("missing trigger payload")
+	}
+
+	order, err := tx.Trigger.ToEIP712TriggerOrder()
+	if err != nil {
+		return common.Address{}, false, fmt.Errorf("invalid trigger format: %w", err)
+	}
+
+	sigBytes, err := decodeSignature(tx.Signature)
+	if err != nil {
+		return common.Address{}, false, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	valid, err := v.eip712Signer.VerifyTriggerOrderSignature(order, sigBytes)
+	if err != nil {
+		return common.Address{}, false, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	if !valid {
+		return common.Address{}, false, fmt.Errorf("signature invalid")
+	}
+
+	return order.Owner, true, nil
+}
+
 // VerifyAgentOrderTransaction verifies an order signed by an agent key
 // Requires delegation to be provided
 func (v *Verifier) VerifyAgentOrderTransaction(
@@ -177,6 +210,16 @@ func (v *Verifier) RecoverSigner(tx *SignedTransaction) (common.Address, error)
 		}
 		return owner, nil
 
+	case TxTypeTrigger:
+		owner, valid, err := v.VerifyTriggerTransaction(tx)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if !valid {
+			return common.Address{}, fmt.Errorf("invalid signature")
+		}
+		return owner, nil
+
 	case TxTypeCancel:
 		owner, valid, err := v.VerifyCancelTransaction(tx)
 		if err != nil {
@@ -0,0 +1,183 @@
+// Package trigger holds stop and take-profit orders off the book until the
+// mark or last price reaches their trigger price; they then become market
+// or limit orders.
+package trigger
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+)
+
+// Kind is what a trigger order does and becomes.
+type Kind uint8
+
+const (
+	StopMarket       Kind = 1 // stop-loss, then a MARKET order
+	StopLimit        Kind = 2 // stop-loss, then a GTC order at Price
+	TakeProfitMarket Kind = 3
+	TakeProfitLimit  Kind = 4
+)
+
+// PriceRef is the price a trigger order watches.
+type PriceRef uint8
+
+const (
+	MarkPrice PriceRef = 1
+	LastPrice PriceRef = 2
+)
+
+var ErrDuplicate = errors.New("trigger order already exists")
+
+type Order struct {
+	ID           string
+	Owner        common.Address
+	Symbol       string
+	Side         orderbook.Side
+	Kind         Kind
+	Ref          PriceRef
+	TriggerPrice int64 // integer ticks
+	Price        int64 // limit price of the *Limit kinds (integer ticks); 0 for *Market
+	Qty          int64 // integer lots
+	Expiry       int64 // block time (Unix seconds) it lapses at; 0: never
+
+	seq uint64 // arrival order: triggered orders fire oldest first
+}
+
+// IsStop reports whether o is a stop (vs take-profit) order.
+func (o *Order) IsStop() bool { return o.Kind == StopMarket || o.Kind == StopLimit }
+
+// IsMarket reports whether o becomes a MARKET order (vs a GTC limit).
+func (o *Order) IsMarket() bool { return o.Kind == StopMarket || o.Kind == TakeProfitMarket }
+
+// Triggered reports whether o fires at mark and last price (0: unknown).
+// A stop fires when the price moves against the position it protects (a buy
+// stop at or above its trigger, a sell stop at or below), a take-profit
+// when it moves in its favour.
+func (o *Order) Triggered(mark, last int64) bool {
+	p := mark
+	if o.Ref == LastPrice {
+		p = last
+	}
+	if p <= 0 {
+		return false
+	}
+	above := o.Side == orderbook.Buy
+	if !o.IsStop() {
+		above = !above
+	}
+	if above {
+		return p >= o.TriggerPrice
+	}
+	return p <= o.TriggerPrice
+}
+
+// Validate checks the fields a signed trigger order sets.
+func (o *Order) Validate() error {
+	switch {
+	case o.Kind < StopMarket || o.Kind > TakeProfitLimit:
+		return errors.New("unknown trigger kind")
+	case o.Ref != MarkPrice && o.Ref != LastPrice:
+		return errors.New("unknown trigger price reference")
+	case o.Side != orderbook.Buy && o.Side != orderbook.Sell:
+		return errors.New("invalid side")
+	case o.TriggerPrice <= 0 || o.Qty <= 0:
+		return errors.New("trigger price and quantity must be positive")
+	case !o.IsMarket() && o.Price <= 0:
+		return errors.New("limit trigger order needs a price")
+	case o.IsMarket() && o.Price != 0:
+		return errors.New("market trigger order must not carry a price")
+	}
+	return nil
+}
+
+// Store holds the pending trigger orders. It is not safe for concurrent
+// use: like the books, each block's execution has its own (Clone).
+type Store struct {
+	orders map[string]*Order
+	next   uint64
+}
+
+func NewStore() *Store {
+	return &Store{orders: make(map[string]*Order)}
+}
+
+// Clone returns a copy of s that can be changed without affecting s.
+func (s *Store) Clone() *Store {
+	c := &Store{orders: make(map[string]*Order, len(s.orders)), next: s.next}
+	for id, o := range s.orders {
+		co := *o
+		c.orders[id] = &co
+	}
+	return c
+}
+
+// Add stores o until it triggers or is cancelled.
+func (s *Store) Add(o *Order) error {
+	if _, ok := s.orders[o.ID]; ok {
+		return ErrDuplicate
+	}
+	s.next++
+	co := *o
+	co.seq = s.next
+	s.orders[o.ID] = &co
+	return nil
+}
+
+func (s *Store) Get(id string) (*Order, bool) {
+	o, ok := s.orders[id]
+	return o, ok
+}
+
+// Cancel removes order id if owner placed it.
+func (s *Store) Cancel(id string, owner common.Address) bool {
+	o, ok := s.orders[id]
+	if !ok || o.Owner != owner {
+		return false
+	}
+	delete(s.orders, id)
+	return true
+}
+
+// Remove removes order id.
+func (s *Store) Remove(id string) { delete(s.orders, id) }
+
+func (s *Store) Len() int { return len(s.orders) }
+
+// Pending returns the orders on symbol ("": all), oldest first.
+func (s *Store) Pending(symbol string) []*Order {
+	var out []*Order
+	for _, o := range s.orders {
+		if symbol == "" || o.Symbol == symbol {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out
+}
+
+// Expired returns the orders lapsed at block time now, oldest first. They
+// stay in the store until removed.
+func (s *Store) Expired(now int64) []*Order {
+	var out []*Order
+	for _, o := range s.Pending("") {
+		if o.Expiry > 0 && o.Expiry <= now {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Due returns the orders on symbol that fire at mark and last price, oldest
+// first. They stay in the store until removed.
+func (s *Store) Due(symbol string, mark, last int64) []*Order {
+	var due []*Order
+	for _, o := range s.Pending(symbol) {
+		if o.Triggered(mark, last) {
+			due = append(due, o)
+		}
+	}
+	return due
+}
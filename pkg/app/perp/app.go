@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"log"
+	"maps"
 	"sort"
 	"strconv"
 	"strings"
@@ -27,28 +28,39 @@ type StoredDelegation struct {
 }
 
 type App struct {
-	mempool        *core.Mempool
-	registry       *core.MarketRegistry
-	books          map[string]*core.OrderBook
-	accountManager *core.AccountManager
-	txVerifier     *TxVerifier // Signature verifier for signed transactions
+	mempool    *core.Mempool
+	registry   *core.MarketRegistry
+	txVerifier *TxVerifier // Signature verifier for signed transactions
 
 	// Agent key delegations: delegationID -> delegation
 	delegations   map[string]*StoredDelegation
 	delegationsMu sync.RWMutex
 
+	// Block state: canon is the committed state, overlays the executed
+	// blocks not committed yet (see state.go). mu guards both.
+	mu        sync.Mutex
+	canon     state
+	committed consensus.Hash // last committed block (zero: none yet)
+	overlays  map[consensus.Hash]*overlay
+
 	// Callbacks for external integrations (WebSocket, etc.)
 	OnTrade TradeBroadcaster
 }
 
 func NewApp() *App {
 	app := &App{
-		mempool:        core.NewMempool(),
-		registry:       core.NewMarketRegistry(),
-		books:          make(map[string]*core.OrderBook),
-		accountManager: core.NewAccountManager(),
-		txVerifier:     NewTxVerifier(), // Initialize transaction verifier
-		delegations:    make(map[string]*StoredDelegation),
+		mempool:    core.NewMempool(),
+		registry:   core.NewMarketRegistry(),
+		txVerifier: NewTxVerifier(), // Initialize transaction verifier
+		canon: state{
+			books:          make(map[string]*core.OrderBook),
+			accountManager: core.NewAccountManager(),
+			triggers:       core.NewTriggerStore(),
+			jailed:         make(map[consensus.NodeID]int64),
+			punished:       make(map[consensus.Hash]bool),
+		},
+		overlays:    make(map[consensus.Hash]*overlay),
+		delegations: make(map[string]*StoredDelegation),
 	}
 
 	// Register single market: BTC-USDT perpetual
@@ -59,54 +71,108 @@ func NewApp() *App {
 	if err := app.registry.RegisterMarket(market); err != nil {
 		log.Fatalf("[app] failed to register BTC-USDT market: %v", err)
 	}
-	app.books["BTC-USDT"] = core.NewOrderBook()
+	app.canon.books["BTC-USDT"] = core.NewOrderBook()
 
 	log.Printf("[app] initialized with market: BTC-USDT")
 
 	return app
 }
 
+// Close closes the account store.
+func (a *App) Close() error { return a.canon.accountManager.Close() }
+
 func (a *App) PushTx(b []byte) { a.mempool.PushRaw(b) }
 
+// GetTx returns the pending tx with hash h (p2p.TxPool), for rebuilding
+// proposed blocks from their tx hashes.
+func (a *App) GetTx(h consensus.Hash) ([]byte, bool) { return a.mempool.Get(h) }
+
 func (a *App) PrepareProposal(req abci.RequestPrepareProposal) abci.ResponsePrepareProposal {
-	txs := a.mempool.SelectForProposal(req.MaxTxBytes)
+	selected := a.mempool.SelectForProposal(req.MaxTxBytes)
+	// Drop what followers would reject the whole block for
+	txs := selected[:0]
+	for _, tx := range selected {
+		if err := a.txVerifier.CheckTx(tx); err != nil {
+			log.Printf("[app] dropping invalid tx %s: %v", formatHash(consensus.TxHash(tx)), err)
+			continue
+		}
+		txs = append(txs, tx)
+	}
 	return abci.ResponsePrepareProposal{Txs: txs}
 }
-func (a *App) ProcessProposal(_ abci.RequestProcessProposal) abci.ResponseProcessProposal {
+
+// ProcessProposal rejects a proposed block holding a tx that fails the
+// stateless checks (TxVerifier.CheckTx).
+func (a *App) ProcessProposal(req abci.RequestProcessProposal) abci.ResponseProcessProposal {
+	for i, tx := range req.Txs {
+		if err := a.txVerifier.CheckTx(tx); err != nil {
+			return abci.ResponseProcessProposal{Reason: fmt.Sprintf("tx %d (%s): %v", i, formatHash(consensus.TxHash(tx)), err)}
+		}
+	}
 	return abci.ResponseProcessProposal{Accept: true}
 }
-func (a *App) FinalizeBlock(req abci.RequestFinalizeBlock) abci.ResponseFinalizeBlock {
-	// Track fills for broadcasting
-	var allFills []fillWithMetadata
-	totalFills := 0
+
+// Execute applies the block to an overlay of its parent's state; fills are
+// broadcast, and the txs leave the mempool for good, once it commits.
+func (a *App) Execute(req abci.RequestExecute) (abci.ResponseExecute, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if o, ok := a.overlays[req.Hash]; ok {
+		return o.resp, nil
+	}
+	up, ok := a.overlays[req.Parent]
+	if !ok && a.committed != (consensus.Hash{}) && req.Parent != a.committed {
+		return abci.ResponseExecute{}, fmt.Errorf("execute h=%d: parent %s not executed", req.Height, req.Parent)
+	}
+	x := &execution{App: a, overlay: a.fork(up, req)}
+	x.expireOrders()
+	updates := x.applyMisbehavior(req.Height, req.Misbehavior)
 
 	for _, tx := range req.Txs {
 		// Use new signature-verified transaction processor
-		fills := a.applyTxV2WithFills(tx, a.txVerifier)
-		totalFills += len(fills)
-		allFills = append(allFills, fills...)
+		x.fills = append(x.fills, x.applyTxV2WithFills(tx, a.txVerifier)...)
 	}
+	x.fills = append(x.fills, x.fireTriggers()...)
 
-	// Broadcast trades to WebSocket clients (if callback registered)
-	if a.OnTrade != nil {
-		for _, f := range allFills {
-			a.OnTrade(f.Symbol, f.Price, f.Qty, f.Side, req.Timestamp)
-		}
-	}
+	// Txs in a block leave the mempool; they come back if it is orphaned
+	a.mempool.Remove(txHashes(req.Txs))
 
 	// Compute state hash after executing all transactions (includes height, timestamp, orderbook state)
-	appHash := a.computeStateHash(req.Height, req.Timestamp)
+	appHash := x.computeStateHash(req.Height, req.Timestamp)
 
 	// Log block execution summary
-	if len(req.Txs) > 0 || totalFills > 0 {
-		log.Printf("[app] FinalizeBlock h=%d txs=%d fills=%d apphash=%s",
-			req.Height, len(req.Txs), totalFills, formatHash(appHash))
+	if len(req.Txs) > 0 || len(x.fills) > 0 {
+		log.Printf("[app] Execute h=%d txs=%d fills=%d apphash=%s",
+			req.Height, len(req.Txs), len(x.fills), formatHash(appHash))
 	}
 
-	return abci.ResponseFinalizeBlock{
-		Events:  []string{"commit"},
-		AppHash: appHash,
+	x.resp = abci.ResponseExecute{
+		Events:           append([]string{"execute"}, x.events...),
+		AppHash:          appHash,
+		ValidatorUpdates: updates,
 	}
+	a.overlays[req.Hash] = x.overlay
+	return x.resp, nil
+}
+
+// applyMisbehavior jails the validators named by the block's evidence and
+// returns their removal from the validator set (power 0). An offence is
+// applied once, however many blocks carry it.
+func (a *execution) applyMisbehavior(height int64, evidence []consensus.Evidence) []consensus.ValidatorUpdate {
+	var updates []consensus.ValidatorUpdate
+	for _, ev := range evidence {
+		if a.punished[ev.Key()] {
+			continue
+		}
+		a.punished[ev.Key()] = true
+		if _, ok := a.jailed[ev.Validator]; !ok {
+			a.jailed[ev.Validator] = height
+			updates = append(updates, consensus.ValidatorUpdate{ID: ev.Validator, Power: 0})
+		}
+		log.Printf("[app] misbehavior %s by %s in view %d: jailed since h=%d",
+			ev.Type, ev.Validator, ev.View, a.jailed[ev.Validator])
+	}
+	return updates
 }
 
 // fillWithMetadata wraps a Fill with its symbol and side for broadcasting
@@ -117,16 +183,19 @@ type fillWithMetadata struct {
 	Side   string // "buy" or "sell" (taker side)
 }
 
+// getBook returns the committed book of sym.
 func (a *App) getBook(sym string) *core.OrderBook {
-	if ob, ok := a.books[sym]; ok {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if ob, ok := a.canon.books[sym]; ok {
 		return ob
 	}
 	ob := core.NewOrderBook()
-	a.books[sym] = ob
+	a.canon.books[sym] = ob
 	return ob
 }
 
-func (a *App) applyTx(s string) int {
+func (a *execution) applyTx(s string) int {
 	if strings.HasPrefix(s, "N:") {
 		return 0
 	}
@@ -143,8 +212,11 @@ func (a *App) applyTx(s string) int {
 			sym, oid = parts[0], parts[1]
 		}
 
+		o, _ := a.getBook(sym).Get(oid)
 		if ok := a.getBook(sym).Cancel(oid); !ok {
 			log.Printf("[app] cancel miss: %s/%s", sym, oid)
+		} else if common.IsHexAddress(o.OwnerHex) {
+			a.syncOrderMargin(common.HexToAddress(o.OwnerHex), sym)
 		}
 
 		return 0
@@ -163,10 +235,24 @@ func (a *App) applyTx(s string) int {
 		qtyStr := strings.TrimPrefix(parts[5], "qty=")
 		idStr := strings.TrimPrefix(parts[6], "id=")
 
+		// Optional: owner=<address>, reprice (ALO: see core.Order.Reprice),
+		// expiry=<unix seconds> (see core.Order.Expiry), stp=<mode number>
+		// (see core.Order.STP)
 		var owner string
-
-		if len(parts) >= 8 && strings.HasPrefix(parts[7], "owner=") {
-			owner = strings.TrimPrefix(parts[7], "owner=")
+		var reprice bool
+		var expiry int64
+		var stp core.SelfTradePrevention
+		for _, opt := range parts[7:] {
+			if strings.HasPrefix(opt, "owner=") {
+				owner = strings.TrimPrefix(opt, "owner=")
+			} else if opt == "reprice" {
+				reprice = true
+			} else if strings.HasPrefix(opt, "expiry=") {
+				expiry, _ = strconv.ParseInt(strings.TrimPrefix(opt, "expiry="), 10, 64)
+			} else if strings.HasPrefix(opt, "stp=") {
+				mode, _ := strconv.ParseUint(strings.TrimPrefix(opt, "stp="), 10, 8)
+				stp = core.SelfTradePrevention(mode)
+			}
 		}
 		price, err1 := strconv.ParseInt(priceStr, 10, 64)
 		qty, err2 := strconv.ParseInt(qtyStr, 10, 64)
@@ -183,7 +269,11 @@ func (a *App) applyTx(s string) int {
 		} else {
 			side = core.Sell
 		}
-		o := &core.Order{ID: idStr, Symbol: sym, Side: side, Price: price, Qty: qty, Type: typ, OwnerHex: owner}
+		o := &core.Order{ID: idStr, Symbol: sym, Side: side, Price: price, Qty: qty, Type: typ, OwnerHex: owner, Reprice: reprice, Expiry: expiry, STP: stp}
+		if expiry > 0 && expiry <= a.timestamp {
+			log.Printf("[app] order rejected: %s %v", idStr, core.ErrExpired)
+			return 0
+		}
 
 		// Get market for validation
 		market, err := a.registry.GetMarket(sym)
@@ -213,24 +303,25 @@ func (a *App) applyTx(s string) int {
 				return 0
 			}
 
-			// Lock margin for order
+			// Lock margin for order; after matching only what rests keeps it
 			requiredMargin := market.RequiredInitialMargin(price, qty)
-			if err := a.accountManager.LockCollateral(ownerAddr, requiredMargin); err != nil {
+			if err := a.accountManager.LockOrderMargin(ownerAddr, sym, requiredMargin); err != nil {
 				log.Printf("[app] failed to lock margin: %v (required=%d)", err, requiredMargin)
 				return 0
 			}
-
-			// TODO: If order is GTC and not fully filled, keep margin locked
-			// For now: unlock immediately after matching (simplified)
-			defer a.accountManager.UnlockCollateral(ownerAddr, requiredMargin)
+			defer a.syncOrderMargin(ownerAddr, sym)
 		}
 
 		// Place order with market validation
-		fills, err := a.getBook(sym).Place(o, market)
+		fills, selfTrades, err := a.getBook(sym).PlaceWithSelfTrades(o, market)
 		if err != nil {
 			log.Printf("[app] order rejected: %v", err)
+			if owner != "" {
+				a.rejectOrder(ownerAddr, o, err)
+			}
 			return 0
 		}
+		a.reportSelfTrades(sym, selfTrades)
 
 		// Process all fills (update positions, apply fees)
 		for _, f := range fills {
@@ -245,14 +336,36 @@ func (a *App) applyTx(s string) int {
 	return 0
 }
 
+// rejectOrder reports the rejection of o, and why, to its owner's account.
+func (a *execution) rejectOrder(owner common.Address, o *core.Order, reason error) {
+	side := "buy"
+	if o.Side == core.Sell {
+		side = "sell"
+	}
+	at := a.timestamp * 1000 // block time, Unix ms
+	err := a.accountManager.RecordRejection(&core.AccountOrder{
+		ID: o.ID, Owner: owner, Symbol: o.Symbol, Side: side, Type: o.Type,
+		Price: o.Price, Qty: o.Qty, Status: core.OrderRejected, Reason: reason.Error(),
+		CreatedAt: at, UpdatedAt: at,
+	})
+	if err != nil {
+		log.Printf("[app] record rejected order %s: %v", o.ID, err)
+	}
+}
+
 // processFill updates positions and applies fees for a trade fill
-func (a *App) processFill(fill core.Fill, market *core.Market) {
+func (a *execution) processFill(fill core.Fill, market *core.Market) {
 	// TODO: Support fills without owner addresses (for backward compat with test txs)
 	// For now, skip fills without owner info
 
 	// Note: In production, we need to track which order ID belongs to which address
 	// For prototype: we'll extract addresses from order IDs if they start with "0x"
 
+	// The filled qty no longer rests: release the maker's margin for it
+	if common.IsHexAddress(fill.MakerOwner) {
+		defer a.syncOrderMargin(common.HexToAddress(fill.MakerOwner), market.Symbol)
+	}
+
 	// Extract taker and maker addresses from order IDs
 	// Format: order IDs should be "0xADDRESS-orderId" or just use raw address
 	takerAddr, takerOk := a.parseOwnerFromOrderID(fill.TakerID)
@@ -283,14 +396,14 @@ func (a *App) processFill(fill core.Fill, market *core.Market) {
 		}
 	}
 
-	// 2. Update positions
-	// Taker increases position (buy = +ve, sell = -ve)
-	// Determine taker's position delta from fill
-	// Note: We need to know taker's side - for now infer from orderbook context
-	// TODO: Add Side field to Fill struct
-
-	// For prototype: assume taker is always the one taking liquidity
-	// We'll need better tracking in production
+	// 2. Update positions: the taker moves by the fill in its side, the
+	// maker the other way
+	takerDelta := fill.Qty
+	if fill.TakerSide == core.Sell {
+		takerDelta = -fill.Qty
+	}
+	a.updatePosition(takerAddr, market, takerDelta, fill.Price)
+	a.updatePosition(makerAddr, market, -takerDelta, fill.Price)
 
 	// 3. Record trade statistics
 	if err := a.accountManager.RecordTrade(takerAddr, notional); err != nil {
@@ -301,6 +414,118 @@ func (a *App) processFill(fill core.Fill, market *core.Market) {
 	}
 }
 
+// syncOrderMargin sets the collateral held for owner's resting orders on
+// symbol to the initial margin of their unfilled qty. Reduce-only orders
+// hold none.
+func (a *execution) syncOrderMargin(owner common.Address, symbol string) {
+	market, err := a.registry.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	var target int64
+	if ob, ok := a.allBooks()[symbol]; ok {
+		for _, o := range ob.Resting(owner.Hex()) {
+			if !o.ReduceOnly {
+				target += market.RequiredInitialMargin(o.Price, o.Qty)
+			}
+		}
+	}
+	if err := a.accountManager.SetOrderMargin(owner, symbol, target); err != nil {
+		log.Printf("[app] order margin %s/%s: %v", owner.Hex(), symbol, err)
+	}
+}
+
+// updatePosition moves addr's position by sizeDelta at price. Its margin
+// becomes the market's initial margin for the new size; resting reduce-only
+// orders are trimmed to it.
+func (a *execution) updatePosition(addr common.Address, market *core.Market, sizeDelta, price int64) {
+	var oldSize, oldMargin int64
+	if acc := a.accountManager.GetAccountReadOnly(addr); acc != nil {
+		if pos := acc.GetPosition(market.Symbol); pos != nil {
+			oldSize, oldMargin = pos.Size, pos.Margin
+		}
+	}
+	newSize := oldSize + sizeDelta
+	marginDelta := market.RequiredInitialMargin(price, max(newSize, -newSize)) - oldMargin
+	if (oldSize > 0 && newSize < 0) || (oldSize < 0 && newSize > 0) {
+		marginDelta += oldMargin // a flip replaces the margin
+	}
+	if err := a.accountManager.UpdatePosition(addr, market.Symbol, sizeDelta, price, marginDelta); err != nil {
+		log.Printf("[app] failed to update position: %v", err)
+		return
+	}
+	a.trimReduceOnly(addr, market.Symbol)
+}
+
+// trimReduceOnly shrinks or cancels owner's resting reduce-only orders on
+// symbol so that, filled in matching priority, they take its position to
+// zero at most.
+func (a *execution) trimReduceOnly(owner common.Address, symbol string) {
+	ob, ok := a.allBooks()[symbol]
+	if !ok {
+		return
+	}
+	orders := ob.ReduceOnlyOrders(owner.Hex())
+	if len(orders) == 0 {
+		return
+	}
+	var size int64
+	if acc := a.accountManager.GetAccountReadOnly(owner); acc != nil {
+		if pos := acc.GetPosition(symbol); pos != nil {
+			size = pos.Size
+		}
+	}
+	left := max(size, -size)
+	for _, o := range orders {
+		var keep int64
+		if (o.Side == core.Buy) == (size < 0) { // reduces the position
+			keep = min(o.Qty, left)
+			left -= keep
+		}
+		if keep < o.Qty {
+			a.getBook(symbol).Resize(o.ID, keep)
+			log.Printf("[app] reduce-only order %s/%s resized %d -> %d (position %d)", symbol, o.ID, o.Qty, keep, size)
+		}
+	}
+}
+
+// reportSelfTrades logs the matches of symbol that self-trade prevention
+// stopped and emits an event for each.
+func (a *execution) reportSelfTrades(symbol string, selfTrades []core.SelfTrade) {
+	for _, st := range selfTrades {
+		ev := fmt.Sprintf("self_trade symbol=%s owner=%s taker=%s maker=%s mode=%s price=%d qty=%d",
+			symbol, st.Owner, st.TakerID, st.MakerID, st.Mode, st.Price, st.Qty)
+		a.events = append(a.events, ev)
+		log.Printf("[app] %s", ev)
+	}
+}
+
+// expireOrders sweeps the resting and trigger orders whose expiry the block
+// time has reached, before the block's txs: books in symbol order, each in
+// matching priority, then trigger orders oldest first. The margin held for
+// an expired order is released.
+func (a *execution) expireOrders() {
+	books := a.allBooks()
+	symbols := make([]string, 0, len(books))
+	for sym := range books {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+	for _, sym := range symbols {
+		for _, o := range books[sym].Expired(a.timestamp) {
+			a.getBook(sym).Cancel(o.ID)
+			if common.IsHexAddress(o.OwnerHex) {
+				a.syncOrderMargin(common.HexToAddress(o.OwnerHex), sym)
+			}
+			log.Printf("[app] order expired: %s/%s qty=%d expiry=%d", sym, o.ID, o.Qty, o.Expiry)
+		}
+	}
+	for _, t := range a.triggers().Expired(a.timestamp) {
+		a.mutTriggers().Remove(t.ID)
+		log.Printf("[app] trigger order expired: %s expiry=%d", t.ID, t.Expiry)
+	}
+}
+
 // parseOwnerFromOrderID extracts address from order ID
 // Supports formats: "0xADDRESS", "0xADDRESS-suffix", or plain orderID (returns false)
 func (a *App) parseOwnerFromOrderID(orderID string) (common.Address, bool) {
@@ -320,6 +545,15 @@ func (a *App) parseOwnerFromOrderID(orderID string) (common.Address, bool) {
 	return common.HexToAddress(addrStr), true
 }
 
+// txHashes returns the consensus tx hash of every tx.
+func txHashes(txs [][]byte) [][32]byte {
+	out := make([][32]byte, len(txs))
+	for i, tx := range txs {
+		out[i] = consensus.TxHash(tx)
+	}
+	return out
+}
+
 // formatHash returns a short hex representation of hash for logging
 func formatHash(h consensus.Hash) string {
 	// Show first 8 bytes for readability (0xabcd1234...)
@@ -336,6 +570,9 @@ func formatHash(h consensus.Hash) string {
 //     - Symbol name
 //     - Bid levels (price → qty, sorted high to low)
 //     - Ask levels (price → qty, sorted low to high)
+//  4. Jailed validators (sorted by ID): ID, jail height
+//  5. Pending trigger orders (oldest first): ID, symbol, owner, side, kind,
+//     price reference, trigger price, limit price, qty, expiry
 //
 // Extension points (update this hash when adding features):
 //   - [ ] Account balances (address → balance map, sorted by address)
@@ -349,7 +586,7 @@ func formatHash(h consensus.Hash) string {
 //   - Incremental updates (don't rehash everything)
 //   - State proofs (Merkle inclusion/exclusion proofs)
 //   - Fast sync (snapshot + proof validation)
-func (a *App) computeStateHash(height, timestamp int64) [32]byte {
+func (a *execution) computeStateHash(height, timestamp int64) [32]byte {
 	h := sha256.New()
 
 	// 1. Include height (ensures hash changes every block even if state unchanged)
@@ -363,15 +600,16 @@ func (a *App) computeStateHash(height, timestamp int64) [32]byte {
 
 	// 3. Hash orderbook state
 	// Get sorted symbol names for deterministic ordering
-	symbols := make([]string, 0, len(a.books))
-	for sym := range a.books {
+	books := a.allBooks()
+	symbols := make([]string, 0, len(books))
+	for sym := range books {
 		symbols = append(symbols, sym)
 	}
 	sort.Strings(symbols)
 
 	// Hash each orderbook in sorted order
 	for _, sym := range symbols {
-		book := a.books[sym]
+		book := books[sym]
 
 		// Write symbol name
 		h.Write([]byte(sym))
@@ -397,6 +635,33 @@ func (a *App) computeStateHash(height, timestamp int64) [32]byte {
 		}
 	}
 
+	// 4. Hash jailed validators
+	jailed := make([]string, 0, len(a.jailed))
+	for id := range a.jailed {
+		jailed = append(jailed, string(id))
+	}
+	sort.Strings(jailed)
+	for _, id := range jailed {
+		h.Write([]byte(id))
+		binary.BigEndian.PutUint64(buf[:], uint64(a.jailed[consensus.NodeID(id)]))
+		h.Write(buf[:])
+	}
+
+	// 5. Hash pending trigger orders, every field (strings length-prefixed)
+	for _, t := range a.triggers().Pending("") {
+		for _, s := range []string{t.ID, t.Symbol} {
+			binary.BigEndian.PutUint64(buf[:], uint64(len(s)))
+			h.Write(buf[:])
+			h.Write([]byte(s))
+		}
+		h.Write(t.Owner.Bytes())
+		h.Write([]byte{byte(t.Side), byte(t.Kind), byte(t.Ref)})
+		for _, v := range []int64{t.TriggerPrice, t.Price, t.Qty, t.Expiry} {
+			binary.BigEndian.PutUint64(buf[:], uint64(v))
+			h.Write(buf[:])
+		}
+	}
+
 	return sha256.Sum256(h.Sum(nil))
 }
 
@@ -421,7 +686,15 @@ func (a *App) ListMarkets() []*core.Market {
 
 // GetAccount returns account details for an address (creates if not exists)
 func (a *App) GetAccount(addr common.Address) *core.Account {
-	return a.accountManager.GetAccount(addr)
+	return a.canon.accountManager.GetAccount(addr)
+}
+
+// JailedValidators returns the validators jailed for misbehaviour and the
+// height each was jailed at.
+func (a *App) JailedValidators() map[consensus.NodeID]int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return maps.Clone(a.canon.jailed)
 }
 
 // GetMempoolSize returns current mempool transaction count
@@ -24,15 +24,60 @@ func NewTxVerifier() *TxVerifier {
 	}
 }
 
+// CheckTx runs the stateless checks of a transaction: it decodes, its
+// amounts are positive and its signature verifies. Blocks with a tx failing
+// them are rejected (App.ProcessProposal). Agent-signed orders need their
+// delegation, which is state: they are verified when the block executes,
+// like nonces and margin.
+func (v *TxVerifier) CheckTx(txBytes []byte) error {
+	tx, err := transaction.ParseTransaction(txBytes)
+	if err != nil {
+		return err
+	}
+	switch tx.Type {
+	case transaction.TxTypeLegacy:
+		return nil // unsigned (backward compatibility)
+	case transaction.TxTypeOrder:
+		price, okP := new(big.Int).SetString(tx.Order.Price, 10)
+		qty, okQ := new(big.Int).SetString(tx.Order.Qty, 10)
+		orderType := crypto.Uint8ToOrderType(tx.Order.Type)
+		closing := orderType == "CLOSE" // sized by the position
+		if !okP || !okQ || price.Sign() < 0 || qty.Sign() < 0 || (qty.Sign() == 0 && !closing) {
+			return fmt.Errorf("invalid price or quantity")
+		}
+		// MARKET and CLOSE orders fill at the book's prices; others need one
+		if (orderType == "MARKET" || closing) != (price.Sign() == 0) {
+			return fmt.Errorf("invalid price for %s order", orderType)
+		}
+		if tx.AgentMode {
+			return nil
+		}
+		_, _, err = v.verifier.VerifyOrderTransaction(tx)
+		return err
+	case transaction.TxTypeTrigger:
+		trigger, okT := new(big.Int).SetString(tx.Trigger.TriggerPrice, 10)
+		qty, okQ := new(big.Int).SetString(tx.Trigger.Qty, 10)
+		if !okT || !okQ || trigger.Sign() <= 0 || qty.Sign() <= 0 {
+			return fmt.Errorf("invalid trigger price or quantity")
+		}
+		_, _, err = v.verifier.VerifyTriggerTransaction(tx)
+		return err
+	case transaction.TxTypeCancel:
+		_, _, err = v.verifier.VerifyCancelTransaction(tx)
+		return err
+	}
+	return fmt.Errorf("unsupported transaction type: %s", tx.Type)
+}
+
 // applyTxV2 processes a transaction with signature verification
 // Deprecated: Use applyTxV2WithFills for better observability
-func (a *App) applyTxV2(txBytes []byte, verifier *TxVerifier) int {
+func (a *execution) applyTxV2(txBytes []byte, verifier *TxVerifier) int {
 	fills := a.applyTxV2WithFills(txBytes, verifier)
 	return len(fills)
 }
 
 // applyTxV2WithFills processes a transaction and returns fills with metadata for broadcasting
-func (a *App) applyTxV2WithFills(txBytes []byte, verifier *TxVerifier) []fillWithMetadata {
+func (a *execution) applyTxV2WithFills(txBytes []byte, verifier *TxVerifier) []fillWithMetadata {
 	// Try parsing as signed transaction first
 	tx, err := transaction.ParseTransaction(txBytes)
 	if err != nil {
@@ -53,6 +98,10 @@ func (a *App) applyTxV2WithFills(txBytes []byte, verifier *TxVerifier) []fillWit
 	case transaction.TxTypeOrder:
 		return a.applySignedOrderWithFills(tx, verifier)
 
+	case transaction.TxTypeTrigger:
+		a.applySignedTrigger(tx, verifier)
+		return nil // Fills come when it triggers (fireTriggers)
+
 	case transaction.TxTypeCancel:
 		a.applySignedCancel(tx, verifier)
 		return nil // Cancels don't produce fills
@@ -65,13 +114,13 @@ func (a *App) applyTxV2WithFills(txBytes []byte, verifier *TxVerifier) []fillWit
 
 // applySignedOrder processes a signed order transaction (returns fill count)
 // Deprecated: Use applySignedOrderWithFills for better observability
-func (a *App) applySignedOrder(tx *transaction.SignedTransaction, verifier *TxVerifier) int {
+func (a *execution) applySignedOrder(tx *transaction.SignedTransaction, verifier *TxVerifier) int {
 	fills := a.applySignedOrderWithFills(tx, verifier)
 	return len(fills)
 }
 
 // applySignedOrderWithFills processes a signed order and returns fills with metadata
-func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verifier *TxVerifier) []fillWithMetadata {
+func (a *execution) applySignedOrderWithFills(tx *transaction.SignedTransaction, verifier *TxVerifier) []fillWithMetadata {
 	var owner common.Address
 	var valid bool
 	var err error
@@ -141,7 +190,9 @@ func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verif
 	price, _ := new(big.Int).SetString(tx.Order.Price, 10)
 	qty, _ := new(big.Int).SetString(tx.Order.Qty, 10)
 
-	if price.Int64() <= 0 || qty.Int64() <= 0 {
+	orderType := crypto.Uint8ToOrderType(tx.Order.Type)
+	atMarket := orderType == "MARKET" || orderType == "CLOSE" // priced by the book
+	if price.Int64() < 0 || qty.Int64() < 0 || (qty.Int64() == 0 && orderType != "CLOSE") || atMarket != (price.Int64() == 0) {
 		log.Printf("[app] invalid price or quantity")
 		return nil
 	}
@@ -154,7 +205,6 @@ func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verif
 		side = core.Sell
 	}
 
-	orderType := crypto.Uint8ToOrderType(tx.Order.Type)
 	orderID := fmt.Sprintf("%s-ord-%s", owner.Hex(), tx.Order.Nonce)
 
 	order := &core.Order{
@@ -165,6 +215,35 @@ func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verif
 		Qty:      qty.Int64(),
 		Type:     orderType,
 		OwnerHex: owner.Hex(),
+
+		ReduceOnly: tx.Order.ReduceOnly,
+		STP:        core.SelfTradePrevention(tx.Order.STP),
+	}
+	if orderType == "CLOSE" {
+		// A reduce-only market order for the whole position, on the side
+		// that closes it
+		order.Type, order.ReduceOnly, order.Qty = "MARKET", true, 0
+		if acc := a.accountManager.GetAccountReadOnly(owner); acc != nil {
+			if pos := acc.GetPosition(order.Symbol); pos != nil && pos.Size != 0 {
+				order.Side, order.Qty = core.Buy, -pos.Size
+				if pos.Size > 0 {
+					order.Side, order.Qty = core.Sell, pos.Size
+				}
+			}
+		}
+	}
+
+	// Good-til-time: the deadline is the resting order's expiry
+	deadline, ok := new(big.Int).SetString(tx.Order.Deadline, 10)
+	if !ok || deadline.Sign() < 0 {
+		log.Printf("[app] invalid deadline: %s", tx.Order.Deadline)
+		return nil
+	}
+	order.Expiry = deadline.Int64()
+	if order.Expiry > 0 && order.Expiry <= a.timestamp {
+		log.Printf("[app] order rejected: %s %v (deadline=%d, block time=%d)", orderID, core.ErrExpired, order.Expiry, a.timestamp)
+		a.rejectOrder(owner, order, core.ErrExpired)
+		return nil
 	}
 
 	// Get market for validation
@@ -174,56 +253,91 @@ func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verif
 		return nil
 	}
 
-	// Calculate position delta
-	sizeDelta := qty.Int64()
-	if side == core.Sell {
-		sizeDelta = -qty.Int64()
-	}
+	return a.placeOrder(owner, order, market)
+}
 
-	// PRE-TRADE MARGIN CHECK
-	if err := a.accountManager.CheckMarginRequirement(owner, market, price.Int64(), sizeDelta); err != nil {
-		log.Printf("[app] margin check failed: %v", err)
-		return nil
+// placeOrder checks and locks owner's margin for order, places it and
+// processes its fills. A rejection is recorded on the account (rejectOrder).
+// A reduce-only order is clamped to the position instead: it needs no
+// margin, and what rests of it shrinks with the position (trimReduceOnly).
+func (a *execution) placeOrder(owner common.Address, order *core.Order, market *core.Market) []fillWithMetadata {
+	// Calculate position delta (Place consumes order.Qty)
+	qty := order.Qty
+	sizeDelta := qty
+	if order.Side == core.Sell {
+		sizeDelta = -qty
 	}
 
-	// Lock margin for order
-	requiredMargin := market.RequiredInitialMargin(price.Int64(), qty.Int64())
-	if err := a.accountManager.LockCollateral(owner, requiredMargin); err != nil {
-		log.Printf("[app] failed to lock margin: %v (required=%d)", err, requiredMargin)
-		return nil
-	}
+	if order.ReduceOnly {
+		if acc := a.accountManager.GetAccountReadOnly(owner); acc != nil {
+			qty = acc.Reducible(order.Symbol, sizeDelta)
+		} else {
+			qty = 0
+		}
+		if qty == 0 {
+			log.Printf("[app] order rejected: %v", core.ErrReduceOnly)
+			a.rejectOrder(owner, order, core.ErrReduceOnly)
+			return nil
+		}
+		order.Qty = qty
+		defer a.trimReduceOnly(owner, order.Symbol)
+	} else {
+		// A MARKET order is margined at the worst price it would fill at
+		price := order.Price
+		if order.Type == "MARKET" {
+			var ok bool
+			if price, ok = a.getBook(order.Symbol).MarketPrice(order.Side, qty); !ok {
+				log.Printf("[app] order rejected: %v", core.ErrNoLiquidity)
+				a.rejectOrder(owner, order, core.ErrNoLiquidity)
+				return nil
+			}
+		}
 
-	// TODO: If order is GTC and not fully filled, keep margin locked
-	// For now: unlock immediately after matching
-	defer a.accountManager.UnlockCollateral(owner, requiredMargin)
+		// PRE-TRADE MARGIN CHECK
+		if err := a.accountManager.CheckMarginRequirement(owner, market, price, sizeDelta); err != nil {
+			log.Printf("[app] margin check failed: %v", err)
+			a.rejectOrder(owner, order, err)
+			return nil
+		}
+
+		// Lock margin for order; after matching only what rests keeps it
+		requiredMargin := market.RequiredInitialMargin(price, qty)
+		if err := a.accountManager.LockOrderMargin(owner, order.Symbol, requiredMargin); err != nil {
+			log.Printf("[app] failed to lock margin: %v (required=%d)", err, requiredMargin)
+			a.rejectOrder(owner, order, err)
+			return nil
+		}
+		defer a.syncOrderMargin(owner, order.Symbol)
+	}
 
 	// Place order with market validation
-	fills, err := a.getBook(tx.Order.Symbol).Place(order, market)
+	fills, selfTrades, err := a.getBook(order.Symbol).PlaceWithSelfTrades(order, market)
 	if err != nil {
 		log.Printf("[app] order rejected: %v", err)
+		a.rejectOrder(owner, order, err)
 		return nil
 	}
+	a.reportSelfTrades(order.Symbol, selfTrades)
 
 	// Process all fills
 	for _, fill := range fills {
 		a.processFill(fill, market)
-		log.Printf("[fill] %s taker=%s maker=%s px=%d qty=%d", tx.Order.Symbol, fill.TakerID, fill.MakerID, fill.Price, fill.Qty)
+		log.Printf("[fill] %s taker=%s maker=%s px=%d qty=%d", order.Symbol, fill.TakerID, fill.MakerID, fill.Price, fill.Qty)
 	}
 
-	log.Printf("[app] signed order accepted: %s side=%s price=%s qty=%s owner=%s",
-		tx.Order.Symbol, crypto.Uint8ToSide(tx.Order.Side), tx.Order.Price, tx.Order.Qty, owner.Hex())
+	// Taker side determines trade side (buyer or seller initiated)
+	tradeSide := "buy"
+	if order.Side == core.Sell {
+		tradeSide = "sell"
+	}
+	log.Printf("[app] order accepted: %s %s side=%s price=%d qty=%d owner=%s",
+		order.Symbol, order.Type, tradeSide, order.Price, qty, owner.Hex())
 
 	// Convert fills to metadata format for broadcasting
 	var result []fillWithMetadata
 	for _, fill := range fills {
-		// Taker side determines trade side (buyer or seller initiated)
-		tradeSide := "buy"
-		if side == core.Sell {
-			tradeSide = "sell"
-		}
-
 		result = append(result, fillWithMetadata{
-			Symbol: tx.Order.Symbol,
+			Symbol: order.Symbol,
 			Price:  fill.Price,
 			Qty:    fill.Qty,
 			Side:   tradeSide,
@@ -234,7 +348,7 @@ func (a *App) applySignedOrderWithFills(tx *transaction.SignedTransaction, verif
 }
 
 // applySignedCancel processes a signed cancel transaction
-func (a *App) applySignedCancel(tx *transaction.SignedTransaction, verifier *TxVerifier) int {
+func (a *execution) applySignedCancel(tx *transaction.SignedTransaction, verifier *TxVerifier) int {
 	// Verify signature
 	owner, valid, err := verifier.verifier.VerifyCancelTransaction(tx)
 	if err != nil {
@@ -263,10 +377,15 @@ func (a *App) applySignedCancel(tx *transaction.SignedTransaction, verifier *TxV
 	// Update nonce
 	acc.Nonce = cancelNonce.Uint64()
 
-	// Cancel the order
-	if ok := a.getBook(tx.Cancel.Symbol).Cancel(tx.Cancel.OrderID); !ok {
+	// Cancel the order, or the trigger order
+	if a.cancelTrigger(tx.Cancel.OrderID, owner) {
+		log.Printf("[app] trigger order cancelled: %s/%s by %s", tx.Cancel.Symbol, tx.Cancel.OrderID, owner.Hex())
+	} else if o, _ := a.getBook(tx.Cancel.Symbol).Get(tx.Cancel.OrderID); !a.getBook(tx.Cancel.Symbol).Cancel(tx.Cancel.OrderID) {
 		log.Printf("[app] cancel miss: %s/%s", tx.Cancel.Symbol, tx.Cancel.OrderID)
 	} else {
+		if common.IsHexAddress(o.OwnerHex) {
+			a.syncOrderMargin(common.HexToAddress(o.OwnerHex), tx.Cancel.Symbol)
+		}
 		log.Printf("[app] order cancelled: %s/%s by %s", tx.Cancel.Symbol, tx.Cancel.OrderID, owner.Hex())
 	}
 
@@ -0,0 +1,84 @@
+package perp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+)
+
+// BookQuery is the answer to an "orderbook/<symbol>" query.
+type BookQuery struct {
+	Bids []core.PriceLevel
+	Asks []core.PriceLevel
+}
+
+// Query answers queries on the committed state, JSON encoded:
+//
+//	account/<address>  the account (core.Account)
+//	orderbook/<symbol> its price levels (BookQuery)
+//	triggers/<address> its pending trigger orders, oldest first
+//	jailed             the jailed validators and their jail heights
+func (a *App) Query(req abci.RequestQuery) (abci.ResponseQuery, error) {
+	kind, arg, _ := strings.Cut(req.Path, "/")
+	var v any
+	switch kind {
+	case "account":
+		if !common.IsHexAddress(arg) {
+			return abci.ResponseQuery{}, fmt.Errorf("query %s: bad address", req.Path)
+		}
+		acc := a.canon.accountManager.GetAccountReadOnly(common.HexToAddress(arg))
+		if acc == nil {
+			return abci.ResponseQuery{}, fmt.Errorf("query %s: no such account", req.Path)
+		}
+		v = acc
+	case "orderbook":
+		if _, err := a.registry.GetMarket(arg); err != nil {
+			return abci.ResponseQuery{}, fmt.Errorf("query %s: %w", req.Path, err)
+		}
+		ob := a.getBook(arg)
+		v = BookQuery{Bids: ob.GetBidLevels(), Asks: ob.GetAskLevels()}
+	case "triggers":
+		if !common.IsHexAddress(arg) {
+			return abci.ResponseQuery{}, fmt.Errorf("query %s: bad address", req.Path)
+		}
+		owner := common.HexToAddress(arg)
+		owned := []*core.TriggerOrder{}
+		a.mu.Lock()
+		for _, t := range a.canon.triggers.Pending("") {
+			if t.Owner == owner {
+				owned = append(owned, t)
+			}
+		}
+		a.mu.Unlock()
+		v = owned
+	case "jailed":
+		v = a.JailedValidators()
+	default:
+		return abci.ResponseQuery{}, fmt.Errorf("query %s: unknown path", req.Path)
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return abci.ResponseQuery{}, err
+	}
+	return abci.ResponseQuery{Value: value}, nil
+}
+
+// CheckTx admits a tx to the mempool if it passes the stateless checks
+// (TxVerifier.CheckTx), as ProcessProposal would.
+func (a *App) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	if err := a.txVerifier.CheckTx(req.Tx); err != nil {
+		return abci.ResponseCheckTx{Reason: err.Error()}
+	}
+	a.PushTx(req.Tx)
+	return abci.ResponseCheckTx{Accept: true}
+}
+
+var (
+	_ abci.Application = (*App)(nil)
+	_ abci.Querier     = (*App)(nil)
+	_ abci.TxChecker   = (*App)(nil)
+)
@@ -0,0 +1,240 @@
+package perp
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// Speculative execution
+//
+// Blocks are executed when validators vote on them, before they are final,
+// and some are orphaned. Execute therefore never touches the committed
+// state (canon): each block gets an overlay, a copy-on-write fork of its
+// parent's state (the parent's overlay, or canon) keyed by block hash.
+// Books and the trigger orders are cloned the first time a block changes
+// them, accounts when they change (AccountManager.Fork); the jail maps are
+// small and copied whole.
+// Commit merges a block's overlay chain into canon and discards the
+// overlays that do not descend from it; their txs go back to the mempool.
+
+// state is what blocks change.
+type state struct {
+	books          map[string]*core.OrderBook // an overlay's: the ones it touched
+	accountManager *core.AccountManager
+	triggers       *core.TriggerStore // an overlay's: nil until it changes them
+
+	// Validators punished for misbehaviour: validator -> jail height.
+	// punished holds the Evidence.Key of every offence already applied.
+	jailed   map[consensus.NodeID]int64
+	punished map[consensus.Hash]bool
+}
+
+// overlay is the state after an executed, not yet committed block.
+type overlay struct {
+	state
+	up        *overlay // the parent's overlay, nil if the parent is canon
+	parent    consensus.Hash
+	timestamp int64
+	txs       [][]byte
+	fills     []fillWithMetadata // broadcast on commit
+	events    []string           // e.g. prevented self-trades
+	resp      abci.ResponseExecute
+}
+
+// execution applies a block's txs to its overlay.
+type execution struct {
+	*App
+	*overlay
+}
+
+// fork returns the overlay for the block of req, on top of up (nil: canon).
+// Caller holds a.mu.
+func (a *App) fork(up *overlay, req abci.RequestExecute) *overlay {
+	base := &a.canon
+	if up != nil {
+		base = &up.state
+	}
+	return &overlay{
+		state: state{
+			books:          make(map[string]*core.OrderBook),
+			accountManager: base.accountManager.Fork(),
+			jailed:         maps.Clone(base.jailed),
+			punished:       maps.Clone(base.punished),
+		},
+		up:        up,
+		parent:    req.Parent,
+		timestamp: req.Timestamp,
+		txs:       req.Txs,
+	}
+}
+
+// getBook returns the overlay's book of sym, cloning the parent's first.
+func (a *execution) getBook(sym string) *core.OrderBook {
+	if ob, ok := a.books[sym]; ok {
+		return ob
+	}
+	ob := core.NewOrderBook()
+	for u := a.up; ; u = u.up {
+		if u == nil {
+			if canon, ok := a.canon.books[sym]; ok {
+				ob = canon.Clone()
+			}
+			break
+		}
+		if parent, ok := u.books[sym]; ok {
+			ob = parent.Clone()
+			break
+		}
+	}
+	a.books[sym] = ob
+	return ob
+}
+
+// triggers returns the trigger orders as of the overlay, for reading.
+func (a *execution) triggers() *core.TriggerStore {
+	for u := a.overlay; u != nil; u = u.up {
+		if u.triggers != nil {
+			return u.triggers
+		}
+	}
+	return a.canon.triggers
+}
+
+// mutTriggers returns the overlay's trigger orders, cloning the parent's
+// first.
+func (a *execution) mutTriggers() *core.TriggerStore {
+	if a.overlay.triggers == nil {
+		a.overlay.triggers = a.triggers().Clone()
+	}
+	return a.overlay.triggers
+}
+
+// allBooks returns every book as of the overlay, without cloning.
+func (a *execution) allBooks() map[string]*core.OrderBook {
+	var chain []*overlay
+	for u := a.overlay; u != nil; u = u.up {
+		chain = append(chain, u)
+	}
+	books := maps.Clone(a.canon.books)
+	for i := len(chain) - 1; i >= 0; i-- {
+		maps.Copy(books, chain[i].books)
+	}
+	return books
+}
+
+// Commit makes the state after block req.Hash canonical, with its executed
+// ancestors: their overlays are merged into canon, oldest first, and their
+// fills broadcast.
+func (a *App) Commit(req abci.RequestCommit) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	o, ok := a.overlays[req.Hash]
+	if !ok {
+		if req.Hash == a.committed {
+			return nil
+		}
+		return fmt.Errorf("commit: block %s not executed", req.Hash)
+	}
+	var chain []*overlay
+	for u := o; u != nil; u = u.up {
+		chain = append(chain, u)
+	}
+	var committed [][]byte
+	for i := len(chain) - 1; i >= 0; i-- {
+		u := chain[i]
+		maps.Copy(a.canon.books, u.books)
+		if u.triggers != nil {
+			a.canon.triggers = u.triggers
+		}
+		if err := a.canon.accountManager.Merge(u.accountManager); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		committed = append(committed, u.txs...)
+		if a.OnTrade != nil {
+			for _, f := range u.fills {
+				a.OnTrade(f.Symbol, f.Price, f.Qty, f.Side, u.timestamp)
+			}
+		}
+	}
+	a.canon.jailed, a.canon.punished = o.jailed, o.punished
+
+	// Children of the committed block (x.up == o) now build on canon: their
+	// up link is cut and their account forks rebase onto canon's accounts.
+	// Deeper descendants are left alone; their up chain now ends at such a
+	// child, so they read canon through it. Every other overlay is dropped:
+	// the committed block and its ancestors (merged above) and the blocks
+	// off the committed branch, whose txs return to the mempool unless
+	// committed (dropLocked)
+	var orphans []consensus.Hash
+	for h, x := range a.overlays {
+		switch {
+		case h == req.Hash || !descends(x, o):
+			orphans = append(orphans, h)
+		case x.up == o:
+			x.up = nil
+			x.accountManager.Rebase(a.canon.accountManager)
+		}
+	}
+	for _, u := range chain {
+		u.txs = nil // committed, not orphaned
+	}
+	a.committed = req.Hash
+	a.dropLocked(orphans, committed)
+	return nil
+}
+
+// Rollback discards the execution of block req.Hash and its descendants.
+func (a *App) Rollback(req abci.RequestRollback) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	o, ok := a.overlays[req.Hash]
+	if !ok {
+		return
+	}
+	var dropped []consensus.Hash
+	for h, x := range a.overlays {
+		if x == o || descends(x, o) {
+			dropped = append(dropped, h)
+		}
+	}
+	a.dropLocked(dropped, nil)
+}
+
+// descends reports whether x builds on anc.
+func descends(x, anc *overlay) bool {
+	for u := x.up; u != nil; u = u.up {
+		if u == anc {
+			return true
+		}
+	}
+	return false
+}
+
+// dropLocked discards the overlays hs, returning their txs to the mempool
+// unless committed or in a remaining overlay. Caller holds a.mu.
+func (a *App) dropLocked(hs []consensus.Hash, committed [][]byte) {
+	var txs [][]byte
+	for _, h := range hs {
+		txs = append(txs, a.overlays[h].txs...)
+		delete(a.overlays, h)
+	}
+	included := make(map[consensus.Hash]bool)
+	for _, tx := range committed {
+		included[consensus.TxHash(tx)] = true
+	}
+	for _, o := range a.overlays {
+		for _, tx := range o.txs {
+			included[consensus.TxHash(tx)] = true
+		}
+	}
+	for _, tx := range txs {
+		if h := consensus.TxHash(tx); !included[h] {
+			included[h] = true
+			a.mempool.PushRaw(tx)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package perp
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/transaction"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/trigger"
+)
+
+// Trigger orders
+//
+// Stop and take-profit orders wait off the book, in the trigger store
+// (core.TriggerStore), until the mark or last price of their market reaches
+// their trigger price. After a block's txs, and so after its fills,
+// fireTriggers converts the due ones into MARKET or GTC orders against the
+// book, oldest first. Their fills move the price, which may trigger more:
+// it repeats until none is due. There is no oracle yet: the mark price is
+// the book's mid price, or the last price when the book is one-sided.
+
+// applySignedTrigger stores a signed trigger order.
+func (a *execution) applySignedTrigger(tx *transaction.SignedTransaction, verifier *TxVerifier) {
+	owner, valid, err := verifier.verifier.VerifyTriggerTransaction(tx)
+	if err != nil || !valid {
+		log.Printf("[app] trigger signature verification failed: %v", err)
+		return
+	}
+
+	// Check nonce (replay protection), shared with orders
+	acc := a.accountManager.GetAccount(owner)
+	nonce, ok := new(big.Int).SetString(tx.Trigger.Nonce, 10)
+	if !ok || nonce.Uint64() <= acc.Nonce {
+		log.Printf("[app] trigger nonce invalid or too low: %s (account nonce=%d)", tx.Trigger.Nonce, acc.Nonce)
+		return
+	}
+	acc.Nonce = nonce.Uint64()
+
+	p, _ := tx.Trigger.ToEIP712TriggerOrder() // verified above
+	side := core.Buy
+	if p.Side != 1 {
+		side = core.Sell
+	}
+	t := &core.TriggerOrder{
+		ID:           fmt.Sprintf("%s-trg-%s", owner.Hex(), tx.Trigger.Nonce),
+		Owner:        owner,
+		Symbol:       p.Symbol,
+		Side:         side,
+		Kind:         trigger.Kind(p.Kind),
+		Ref:          trigger.PriceRef(p.PriceRef),
+		TriggerPrice: p.TriggerPrice.Int64(),
+		Price:        p.Price.Int64(),
+		Qty:          p.Qty.Int64(),
+		Expiry:       p.Deadline.Int64(),
+	}
+	if t.Expiry > 0 && t.Expiry <= a.timestamp {
+		log.Printf("[app] trigger order %s rejected: %v", t.ID, core.ErrExpired)
+		return
+	}
+	if err := t.Validate(); err != nil {
+		log.Printf("[app] invalid trigger order %s: %v", t.ID, err)
+		return
+	}
+	market, err := a.registry.GetMarket(t.Symbol)
+	if err != nil {
+		log.Printf("[app] market not found for %s: %v", t.Symbol, err)
+		return
+	}
+	if t.IsMarket() {
+		err = market.ValidateMarketOrder(t.Qty)
+	} else {
+		err = market.ValidateOrder(t.Price, t.Qty)
+	}
+	if err != nil {
+		log.Printf("[app] trigger order %s rejected: %v", t.ID, err)
+		return
+	}
+	if err := a.mutTriggers().Add(t); err != nil {
+		log.Printf("[app] trigger order %s rejected: %v", t.ID, err)
+		return
+	}
+	log.Printf("[app] trigger order stored: %s kind=%d trigger=%d ref=%d owner=%s",
+		t.ID, t.Kind, t.TriggerPrice, t.Ref, owner.Hex())
+}
+
+// cancelTrigger removes trigger order id if owner placed it.
+func (a *execution) cancelTrigger(id string, owner common.Address) bool {
+	if t, ok := a.triggers().Get(id); !ok || t.Owner != owner {
+		return false
+	}
+	return a.mutTriggers().Cancel(id, owner)
+}
+
+// fireTriggers places the trigger orders due (see above) and returns their
+// fills.
+func (a *execution) fireTriggers() []fillWithMetadata {
+	var fills []fillWithMetadata
+	for {
+		pending := a.triggers().Pending("")
+		if len(pending) == 0 {
+			return fills
+		}
+		var symbols []string
+		for _, t := range pending {
+			if !slices.Contains(symbols, t.Symbol) {
+				symbols = append(symbols, t.Symbol)
+			}
+		}
+		slices.Sort(symbols)
+
+		books := a.allBooks()
+		var due []*core.TriggerOrder
+		for _, sym := range symbols {
+			if ob, ok := books[sym]; ok {
+				due = append(due, a.triggers().Due(sym, markPrice(ob), ob.GetLastPrice())...)
+			}
+		}
+		if len(due) == 0 {
+			return fills
+		}
+		for _, t := range due {
+			fills = append(fills, a.fireTrigger(t)...)
+		}
+	}
+}
+
+// fireTrigger removes t from the store and places its order.
+func (a *execution) fireTrigger(t *core.TriggerOrder) []fillWithMetadata {
+	a.mutTriggers().Remove(t.ID)
+	market, err := a.registry.GetMarket(t.Symbol)
+	if err != nil {
+		log.Printf("[app] trigger %s: market not found: %v", t.ID, err)
+		return nil
+	}
+	o := &core.Order{ID: t.ID, Symbol: t.Symbol, Side: t.Side, Price: t.Price, Qty: t.Qty, Type: "GTC", OwnerHex: t.Owner.Hex(), Expiry: t.Expiry}
+	if t.IsMarket() {
+		o.Type = "MARKET" // margined at the book's prices (placeOrder)
+	}
+	log.Printf("[app] trigger order fired: %s kind=%d trigger=%d", t.ID, t.Kind, t.TriggerPrice)
+	return a.placeOrder(t.Owner, o, market)
+}
+
+// markPrice returns ob's mark price: its mid price, else its last price.
+func markPrice(ob *core.OrderBook) int64 {
+	if mid := ob.GetMidPrice(); mid > 0 {
+		return mid
+	}
+	return ob.GetLastPrice()
+}
@@ -0,0 +1,130 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BFT time
+//
+// A leader does not pick its block's time: every vote carries its voter's
+// clock (Vote.Time, signed), the QC keeps them (Certificate.Times), and a
+// block's time is the stake-weighted median of the times in its parent's QC
+// (the proposal's HighCert). Faulty validators hold less than half the
+// power of any QC's signers, so the median lies between honest clocks.
+// Voters stamp at least voteTimeStep past the block they vote for, which
+// makes block times strictly increasing.
+//
+// Followers reject (RejectTime) a block whose time is not that median, not
+// after its parent's, or more than Engine.MaxClockDrift ahead of their
+// clock. Only a time without votes behind it (the first block, whose parent
+// is genesis) must also be within MaxClockDrift of the past: a median may
+// lag after an outage.
+
+var ErrBlockTime = errors.New("header: wrong block time")
+
+// voteTimeStep is how far past its block a vote's time is at least.
+const voteTimeStep = time.Millisecond
+
+// MedianTime returns the stake-weighted median of c's vote times, weighted
+// by the signers' power in vs; ok is false if c has no vote times.
+func (c Certificate) MedianTime(vs *ValidatorSet) (t time.Time, ok bool, err error) {
+	if len(c.Times) == 0 {
+		return time.Time{}, false, nil
+	}
+	signers, err := CertSigners(vs.IDs(), c.Signers)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(signers) != len(c.Times) {
+		return time.Time{}, false, fmt.Errorf("%w: %d times, %d signers", ErrCertBadTimes, len(c.Times), len(signers))
+	}
+	type weighted struct {
+		t     int64
+		power uint64
+	}
+	ws := make([]weighted, len(signers))
+	var total uint64
+	for i, id := range signers {
+		ws[i] = weighted{c.Times[i], vs.VotingPower([]NodeID{id})}
+		total += ws[i].power
+	}
+	sort.Slice(ws, func(i, j int) bool { return ws[i].t < ws[j].t })
+	var acc uint64
+	for _, w := range ws {
+		if acc += w.power; acc*2 > total {
+			return time.Unix(0, w.t), true, nil
+		}
+	}
+	return time.Unix(0, ws[len(ws)-1].t), true, nil
+}
+
+// voteTime is the time a vote for b carries: our clock, but past b's time.
+func (e *Engine) voteTime(b Block) time.Time {
+	now := e.PM.Clock.Now()
+	if floor := b.Time.Add(voteTimeStep); now.Before(floor) {
+		return floor
+	}
+	return now
+}
+
+// bftTime returns the time of a block extending parent under high (parent's
+// QC); ok is false if the QC has no vote times.
+func (e *Engine) bftTime(high Certificate, parent Block) (time.Time, bool, error) {
+	if len(high.Times) == 0 {
+		return time.Time{}, false, nil
+	}
+	vs, err := e.ValidatorSetOf(parent)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return high.MedianTime(vs)
+}
+
+// checkBlockTime checks the time of b, proposed under high (see above).
+func (e *Engine) checkBlockTime(b, parent Block, high Certificate) error {
+	if !b.Time.After(parent.Time) {
+		return fmt.Errorf("%w: %s not after the parent's %s", ErrBlockTime, b.Time, parent.Time)
+	}
+	now := e.PM.Clock.Now()
+	median, ok, err := e.bftTime(high, parent)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlockTime, err)
+	}
+	switch {
+	case ok && !b.Time.Equal(median):
+		return fmt.Errorf("%w: %s, the QC's median is %s", ErrBlockTime, b.Time, median)
+	case !ok && e.MaxClockDrift > 0 && b.Time.Before(now.Add(-e.MaxClockDrift)):
+		return fmt.Errorf("%w: %s more than %s behind our clock", ErrBlockTime, b.Time, e.MaxClockDrift)
+	case e.MaxClockDrift > 0 && b.Time.After(now.Add(e.MaxClockDrift)):
+		return fmt.Errorf("%w: %s more than %s ahead of our clock", ErrBlockTime, b.Time, e.MaxClockDrift)
+	}
+	return nil
+}
+
+// certTimes lists the vote times of a QC's signers in bitmap order (the
+// order of validators); nil if no vote has one.
+func certTimes(validators []NodeID, times map[NodeID]int64) []int64 {
+	var out []int64
+	have := false
+	for _, id := range validators {
+		if t, ok := times[id]; ok {
+			out = append(out, t)
+			have = have || t != 0
+		}
+	}
+	if !have {
+		return nil
+	}
+	return out
+}
+
+// unixNanos is t in Unix nanoseconds, 0 for the zero time.
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
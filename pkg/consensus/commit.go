@@ -0,0 +1,188 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Commit rule (two-chain HotStuff with lock, as in HotStuff-2 / Jolteon)
+//
+//	Lock:   a replica that votes for a proposal locks on its justify QC
+//	        (Propose.HighCert) and afterwards only votes for proposals whose
+//	        justify is at least as high as the lock (Safety.CanVote).
+//	Commit: block B1 certified by C1 in view v and its child B2 certified by
+//	        C2 in view v+1 form a DoubleCert (C1, C2); it commits B1 and every
+//	        not yet committed ancestor of B1.
+//
+// Why: the 2f+1 voters of C2 voted for B2, whose justify is C1, so they are
+// locked on C1 (view v). A QC in a later view needs f+1 of them, who only vote
+// for proposals justified by a QC of view >= v; the only QCs of views v and
+// v+1 are C1 and C2, both on B1's chain, so every later certified block
+// extends B1.
+//
+// Views must be contiguous. With C2.View > C1.View+1 a view in between may
+// have certified a conflicting block that some replicas are locked on, and
+// B1 is not committed by that pair; it commits later, as an ancestor.
+//
+// A DoubleCert can be assembled from parts that arrive in any order (two
+// proposals, two QCs), so the check runs whenever a block or QC is learned.
+// Leaders attach their highest DoubleCert to proposals (Propose.HighDouble),
+// which lets replicas that missed a QC commit without it.
+
+var (
+	ErrCommitConflict  = errors.New("commit: block does not extend the committed head")
+	ErrDoubleCertViews = errors.New("double cert: views not consecutive")
+	ErrDoubleCertChain = errors.New("double cert: C2 block does not extend C1")
+)
+
+// checkDoubleCert validates the structure of dc against the certified child
+// block b2. Signatures are checked separately (verifyCert).
+func checkDoubleCert(dc DoubleCert, b2 Block) error {
+	if dc.C2.View != dc.C1.View+1 {
+		return fmt.Errorf("%w: %d, %d", ErrDoubleCertViews, dc.C1.View, dc.C2.View)
+	}
+	if HashOfBlock(b2) != dc.C2.H || b2.Parent != dc.C1.H {
+		return ErrDoubleCertChain
+	}
+	return nil
+}
+
+// checkCommitAt looks for a DoubleCert whose C2 is the QC of view v and
+// commits it. Call it for v and v+1 when a block or QC of view v arrives.
+func (e *Engine) checkCommitAt(ctx context.Context, v View) {
+	if e.Store == nil || v < 2 {
+		return // DoubleCerts start at (C1, C2) = (view 1, view 2)
+	}
+	c2, ok := e.Store.GetCert(v)
+	if !ok {
+		return
+	}
+	c1, ok := e.Store.GetCert(v - 1)
+	if !ok {
+		return
+	}
+	b2, ok := e.Store.GetBlock(c2.H)
+	if !ok {
+		return
+	}
+	dc := DoubleCert{C1: c1, C2: c2}
+	if checkDoubleCert(dc, b2) != nil {
+		return // fork or view gap: no commit from this pair
+	}
+	e.onDoubleCert(ctx, dc, b2)
+}
+
+// onDoubleCert records a valid DoubleCert and commits block(C1).
+func (e *Engine) onDoubleCert(ctx context.Context, dc DoubleCert, b2 Block) {
+	b1, ok := e.Store.GetBlock(dc.C1.H)
+	if !ok {
+		e.requestSync(ctx, "double_cert_unknown_block")
+		return
+	}
+	e.Safety.OnDoubleCert(dc, b1, b2)
+	if err := e.commit(ctx, b1, dc.C1); err != nil && e.Logger != nil {
+		e.Logger.Errorw("commit_failed", "view", b1.View, "height", b1.Height, "err", err)
+	}
+	e.commitApp()
+}
+
+// commitPending retries the highest known DoubleCert when its commit stopped
+// at a missing ancestor; call it after learning a block.
+func (e *Engine) commitPending(ctx context.Context) {
+	dc := e.Safety.HighestDouble()
+	if e.Store == nil || dc == nil || dc.C1.Height <= e.State.Height {
+		return
+	}
+	if b1, ok := e.Store.GetBlock(dc.C1.H); ok {
+		_ = e.commit(ctx, b1, dc.C1) // still incomplete: sync was requested
+		e.commitApp()
+	}
+}
+
+// consumeDoubleCert handles the DoubleCert carried by a proposal.
+func (e *Engine) consumeDoubleCert(ctx context.Context, dc DoubleCert) {
+	if e.Store == nil || dc.C1.View == 0 || dc.C1.Height <= e.State.Height {
+		return // nothing new to commit
+	}
+	if e.verifyCert(dc.C1) != nil || e.verifyCert(dc.C2) != nil {
+		return
+	}
+	e.Store.SaveCert(dc.C1)
+	e.Store.SaveCert(dc.C2)
+	b2, ok := e.Store.GetBlock(dc.C2.H)
+	if !ok {
+		e.requestSync(ctx, "double_cert_unknown_block")
+		return
+	}
+	if err := checkDoubleCert(dc, b2); err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("double_cert_invalid", "c1_view", dc.C1.View, "c2_view", dc.C2.View, "err", err)
+		}
+		return
+	}
+	e.onDoubleCert(ctx, dc, b2)
+}
+
+// commit makes b (certified by cert) the committed head, together with its
+// not yet committed ancestors. b must extend the current committed head: a
+// DoubleCert for a conflicting block means more than f faults, and is
+// reported instead of applied. The app state follows with commitApp, as far
+// as the app has executed the committed chain.
+func (e *Engine) commit(ctx context.Context, b Block, cert Certificate) error {
+	e.commitMu.Lock()
+	defer e.commitMu.Unlock()
+	if b.Height <= e.State.Height {
+		return nil // already committed
+	}
+
+	head := HashOfBlock(e.State.Genesis)
+	if h, ok := e.Store.GetCommitted(); ok {
+		head = h
+	}
+	newly := []Block{b}
+	for cur := b.Parent; cur != head; {
+		a, ok := e.Store.GetBlock(cur)
+		if !ok {
+			e.requestSync(ctx, "commit_missing_ancestor")
+			return fmt.Errorf("ancestor %s of height %d missing", cur, b.Height)
+		}
+		if a.Height <= e.State.Height {
+			return fmt.Errorf("%w: height %d view %d", ErrCommitConflict, b.Height, b.View)
+		}
+		newly = append(newly, a)
+		cur = a.Parent
+	}
+
+	// AppHash from the QC (already agreed upon by 2f+1 validators)
+	if b.AppHash == (Hash{}) {
+		b.AppHash = cert.AppHash
+	}
+	h := HashOfBlock(b)
+	e.Store.SaveBlock(b) // re-save with AppHash
+	e.Store.SetCommitted(h)
+	e.State.Height = b.Height
+	_ = e.walWrite(Record{Type: RecordCommit, Commit: &CommitInfo{
+		Height: b.Height, View: b.View, H: h, AppHash: b.AppHash,
+	}})
+	for _, nb := range newly {
+		// Evidence in a committed block has been acted upon by the app
+		evs, _ := PayloadEvidence(nb.Payload)
+		e.evidence.markCommitted(evs)
+	}
+	e.commitValidatorSets(newly)
+
+	if e.Logger != nil {
+		e.Logger.Infow("commit",
+			"height", b.Height,
+			"committed_view", b.View,
+			"txs", len(PayloadTxs(b.Payload)),
+			"apphash", fmt.Sprintf("0x%x", b.AppHash[:])) // Full hash
+	}
+
+	// Trigger API broadcast callback (for WebSocket updates)
+	if e.OnBlockCommit != nil {
+		e.OnBlockCommit(b.Height)
+	}
+	return nil
+}
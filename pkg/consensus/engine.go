@@ -1,8 +1,12 @@
 package consensus
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/uhyunpark/hyperlicked/pkg/crypto"
@@ -20,8 +24,18 @@ type Engine struct {
 	Signer  interface{} // can be *crypto.BLSSigner (proto) or dummy
 	ID      NodeID
 
-	EnableBLS bool                         // when true, use crypto.BLS to sign/aggregate
-	PubKeys   map[NodeID]*crypto.BLSPubKey // validator pubkeys (same message aggregation)
+	EnableBLS  bool                         // when true, use crypto.BLS to sign/aggregate/verify
+	PubKeys    map[NodeID]*crypto.BLSPubKey // validator pubkeys (same message aggregation)
+	Validators []NodeID                     // validator directory order (the genesis set, unless GenesisSet is given)
+	ChainID    string                       // bound into every signed vote/timeout (see VoteSignBytes)
+
+	// Validator sets (see epoch.go). GenesisSet nil = equal power for
+	// Validators; EpochLength 0 = the genesis set forever.
+	GenesisSet  *ValidatorSet
+	EpochLength Height
+	valsets     *valSetRegistry
+	valsetsOnce sync.Once
+	genesis     *ValidatorSet
 
 	Logger         *zap.SugaredLogger
 	VerboseLogging bool // if false, only log commits and errors
@@ -30,24 +44,62 @@ type Engine struct {
 	Store BlockStore
 	WAL   WAL
 
-	// MinBlockTime throttles block production (prevents excessive empty blocks)
+	// MinBlockTime throttles empty blocks: a leader holding the previous QC
+	// proposes as soon as it has transactions, but waits up to MinBlockTime
+	// since its last block before proposing an empty one.
 	// Set to 0 to disable throttling (production), or 100-200ms for devnet
-	MinBlockTime time.Duration
+	MinBlockTime  time.Duration
 	lastBlockTime time.Time
 
+	// MaxClockDrift bounds how far from our clock a proposed block's
+	// time may be (see bfttime.go); 0 = unchecked
+	MaxClockDrift time.Duration
+
+	// commitMu serialises commits (vote path, DoubleCerts, sync)
+	commitMu sync.Mutex
+
 	// OnBlockCommit is called after a block is committed (for API broadcasts)
 	OnBlockCommit func(height Height)
+
+	// View-change state: timeouts received per view and the latest TC formed
+	timeouts *timeoutCollector
+	tcMu     sync.Mutex
+	lastTC   *TimeoutCert
+
+	// verified caches QCs whose aggregate signature already checked out
+	// (the HighCert in a proposal is usually the QC we just verified)
+	certMu   sync.Mutex
+	verified map[View]Certificate
+
+	// sync tracks executed blocks and fetches missed ones from peers
+	sync *blockSync
+
+	// evidence holds detected misbehaviour until a block commits it
+	evidence *evidencePool
+
+	// proposals counts proposal validation outcomes (see proposal.go)
+	proposals proposalCounter
 }
 
 func NewEngine(state *State, safety *Safety, pm *Pacemaker, app AppHook, net Network, elec LeaderElector, signer interface{}) *Engine {
 	e := &Engine{
 		State: state, Safety: safety, PM: pm,
 		App: app, Net: net, Elector: elec, Signer: signer,
-		ID: state.SelfID,
+		ID:       state.SelfID,
+		timeouts: newTimeoutCollector(),
+		verified: make(map[View]Certificate),
+		sync:     newBlockSync(state.Genesis),
+		evidence: newEvidencePool(),
+		valsets:  newValSetRegistry(),
 	}
 	net.SetHandlers(Handlers{
-		OnPropose: e.onPropose,
-		OnPrepare: e.onPrepare,
+		OnPropose:   e.onPropose,
+		OnPrepare:   e.onPrepare,
+		OnTimeout:   e.onTimeout,
+		VerifyVote:  e.verifyVote,
+		ServeBlocks: e.serveBlocks,
+		ServeCert:   e.serveCert,
+		OnEvidence:  e.onEvidence,
 	})
 	return e
 }
@@ -71,29 +123,35 @@ func (e *Engine) Run(ctx context.Context) error {
 		}
 
 		if leader == e.ID {
-			// Throttle block production if MinBlockTime is set
-			if e.MinBlockTime > 0 {
-				elapsed := time.Since(e.lastBlockTime)
-				if elapsed < e.MinBlockTime {
-					time.Sleep(e.MinBlockTime - elapsed)
-				}
-			}
-
-			// I am leader: actively propose
+			// I am leader: actively propose (right away when holding the
+			// previous QC; empty blocks are throttled by MinBlockTime)
 			if err := e.leaderRound(ctx, v); err != nil {
-				return err
+				if !errors.Is(err, ErrViewTimeout) {
+					return err
+				}
+				// No QC in time: join the view-change like everybody else
+				if err := e.localTimeout(ctx, v); err != nil {
+					return err
+				}
+				continue
 			}
 
 			// Update last block time
-			e.lastBlockTime = time.Now()
+			e.lastBlockTime = e.PM.Clock.Now()
 
 			// Leader advances view after getting QC
 			e.State.View = v
 		} else {
 			// I am follower: wait for propose/prepare (reactive)
-			// View will advance in onPrepare when we receive prepare message
+			// View will advance in onPrepare when we receive prepare message,
+			// or via a TC if the leader fails to produce a QC in time
 			if err := e.PM.WaitForViewAdvance(ctx, v); err != nil {
-				return err
+				if !errors.Is(err, ErrViewTimeout) {
+					return err
+				}
+				if err := e.localTimeout(ctx, v); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -106,16 +164,27 @@ func (e *Engine) RunN(ctx context.Context, rounds int) error {
 		leader := e.Elector.LeaderOf(v)
 
 		if leader != e.ID {
-			// Non-leader: wait for view to advance via onPrepare
+			// Non-leader: wait for view to advance via onPrepare (or a TC)
 			if err := e.PM.WaitForViewAdvance(ctx, v); err != nil {
-				return err
+				if !errors.Is(err, ErrViewTimeout) {
+					return err
+				}
+				if err := e.localTimeout(ctx, v); err != nil {
+					return err
+				}
 			}
 			continue
 		}
 
 		// Leader: propose
 		if err := e.leaderRound(ctx, v); err != nil {
-			return err
+			if !errors.Is(err, ErrViewTimeout) {
+				return err
+			}
+			if err := e.localTimeout(ctx, v); err != nil {
+				return err
+			}
+			continue
 		}
 		e.State.View = v
 	}
@@ -125,8 +194,71 @@ func (e *Engine) RunN(ctx context.Context, rounds int) error {
 // CRITICAL: Followers must EXECUTE block before voting to compute AppHash
 // This ensures the vote commits to both transactions (H) and resulting state (AppHash)
 func (e *Engine) onPropose(ctx context.Context, p Propose) {
+	if p.Block.Proposer != e.Elector.LeaderOf(p.Block.View) {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("propose_skip_wrong_leader", "view", p.Block.View, "proposer", p.Block.Proposer)
+		}
+		return
+	}
+	if !e.verifyFrom(p.Block.Proposer, ProposalSignBytes(e.ChainID, p.Block), p.Sig) {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("propose_skip_bad_sig", "view", p.Block.View, "proposer", p.Block.Proposer)
+		}
+		return
+	}
+	// A second, different signed block for this view is equivocation
+	e.observeProposal(ctx, p)
+	if p.TC != nil {
+		// A proposal following a failed view carries the TC; it lets nodes that
+		// missed the timeouts catch up to the new view
+		if p.TC.View+1 != p.Block.View || !e.verifyTimeoutCert(*p.TC) {
+			if e.Logger != nil && e.VerboseLogging {
+				e.Logger.Debugw("propose_skip_bad_tc", "view", p.Block.View, "tc_view", p.TC.View)
+			}
+			return
+		}
+		e.onTimeoutCert(p.TC)
+	}
+	if err := e.verifyCert(p.HighCert); err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("propose_skip_bad_highcert", "view", p.Block.View, "cert_view", p.HighCert.View, "err", err)
+		}
+		if errors.Is(err, ErrCertUnknownValSet) {
+			e.requestSync(ctx, "unknown_validator_set") // an epoch or more behind
+		}
+		return
+	}
+	if err := checkProposalLink(p); err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("propose_skip_bad_link", "view", p.Block.View, "height", p.Block.Height, "err", err)
+		}
+		return
+	}
 	if e.Store != nil {
 		e.Store.SaveBlock(p.Block)
+		if p.HighCert.View > 0 {
+			e.Store.SaveCert(p.HighCert)
+		}
+	}
+	_ = e.walWrite(Record{Type: RecordProposal, Proposal: &p})
+
+	// The justify is a QC like any other; the proposal may also complete a
+	// DoubleCert whose other parts arrived earlier
+	parent, _ := e.Safety.BlockByHash(p.HighCert.H)
+	e.Safety.OnPrepare(p.HighCert, parent)
+	if p.HighDouble != nil {
+		e.consumeDoubleCert(ctx, *p.HighDouble)
+	}
+	for _, v := range []View{p.HighCert.View, p.HighCert.View + 1, p.Block.View, p.Block.View + 1} {
+		e.checkCommitAt(ctx, v)
+	}
+	e.commitPending(ctx)
+
+	if !e.Safety.CanVoteInView(p.Block.View) {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("vote_skip_already_voted", "view", p.Block.View)
+		}
+		return
 	}
 	if !e.Safety.CanVote(p) {
 		if e.Logger != nil && e.VerboseLogging {
@@ -135,24 +267,104 @@ func (e *Engine) onPropose(ctx context.Context, p Propose) {
 		return
 	}
 
+	// The payload must decode (see payload.go) before anything reads it
+	if _, err := DecodePayload(p.Block.Payload); err != nil {
+		e.proposals.reject(RejectPayload)
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_bad_payload", "view", p.Block.View, "proposer", p.Block.Proposer, "err", err)
+		}
+		return
+	}
+
+	// A leader must not get anybody punished without proof
+	if err := e.checkPayloadEvidence(p.Block.Payload); err != nil {
+		e.proposals.reject(RejectEvidence)
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_bad_evidence", "view", p.Block.View, "proposer", p.Block.Proposer, "err", err)
+		}
+		return
+	}
+
 	// Execute block to compute AppHash BEFORE voting
 	// This is the key change: validators must agree on state before voting
-	appHash := e.App.OnCommit(p.Block)
+	e.sync.execMu.Lock()
+	if e.sync.isExecuted(HashOfBlock(p.Block)) {
+		e.sync.execMu.Unlock()
+		return // already applied by catch-up; its QC exists
+	}
+	if !e.sync.isExecuted(p.Block.Parent) {
+		e.sync.execMu.Unlock()
+		// Our state does not include the parent: voting now would sign a wrong AppHash
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("vote_skip_parent_not_executed", "view", p.Block.View, "parent", p.Block.Parent.String())
+		}
+		e.requestSync(ctx, "parent_not_executed")
+		return
+	}
+	// The header must name the epoch's validator set (and, at an epoch
+	// start, the next one) as our own execution determines it
+	pb, _ := e.blockByHash(p.Block.Parent)
+	if err := e.checkHeaderValidators(p.Block, pb); err != nil {
+		e.sync.execMu.Unlock()
+		e.proposals.reject(RejectValSet)
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_bad_valset", "view", p.Block.View, "height", p.Block.Height, "err", err)
+		}
+		return
+	}
+	// The block time is the QC's, not the leader's (see bfttime.go)
+	if err := e.checkBlockTime(p.Block, pb, p.HighCert); err != nil {
+		e.sync.execMu.Unlock()
+		e.proposals.reject(RejectTime)
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_bad_time", "view", p.Block.View, "height", p.Block.Height, "proposer", p.Block.Proposer, "err", err)
+		}
+		return
+	}
+	// The app checks what only it understands: tx signatures and encoding,
+	// size limits, the timestamp
+	if err := e.processProposal(pb, p.Block); err != nil {
+		e.sync.execMu.Unlock()
+		e.proposals.reject(RejectApp)
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_rejected", "view", p.Block.View, "height", p.Block.Height, "proposer", p.Block.Proposer, "err", err)
+		}
+		return
+	}
+	e.proposals.accept()
+	appHash, err := e.execute(p.Block)
+	if err != nil {
+		e.sync.execMu.Unlock()
+		if e.Logger != nil {
+			e.Logger.Warnw("vote_skip_execute_failed", "view", p.Block.View, "height", p.Block.Height, "err", err)
+		}
+		return
+	}
+	e.sync.markExecuted(p.Block)
+	e.commitAppLocked() // a DoubleCert may have committed it already
+	e.sync.execMu.Unlock()
 
 	v := Vote{
 		View:     p.Block.View,
+		Height:   p.Block.Height,
 		H:        HashOfBlock(p.Block),
 		AppHash:  appHash, // ← NEW: Include state commitment in vote
+		Time:     e.voteTime(p.Block),
 		SigShare: nil,
 		From:     e.ID,
 	}
 
-	if e.EnableBLS {
-		if s, ok := e.Signer.(*crypto.BLSSigner); ok {
-			v.SigShare = s.Sign(v.H[:])
+	v.SigShare = e.sign(v.SignBytes(e.ChainID))
+	e.Safety.UpdateLock(p.HighCert, parent) // lock on the justify of what we vote for
+	if err := e.Safety.MarkVoted(p.Block.View); err != nil {
+		// Not durably recorded: voting now could lead to a double vote after a restart
+		if e.Logger != nil {
+			e.Logger.Errorw("vote_skip_persist_failed", "view", p.Block.View, "err", err)
 		}
-	} else {
-		v.SigShare = []byte("s")
+		return
+	}
+	if err := e.walWrite(Record{Type: RecordVote, Vote: &v}); err != nil {
+		return
 	}
 	to := e.Elector.LeaderOf(p.Block.View)
 	_ = e.Net.SendVote(ctx, to, v)
@@ -161,138 +373,113 @@ func (e *Engine) onPropose(ctx context.Context, p Propose) {
 	}
 }
 
+// checkProposalLink checks that the block extends its justify: parent is the
+// certified block, height is one above it, the view is later and the header
+// records the justify's signers.
+func checkProposalLink(p Propose) error {
+	switch {
+	case p.Block.Parent != p.HighCert.H:
+		return errors.New("parent is not the HighCert block")
+	case p.Block.Height != p.HighCert.Height+1:
+		return fmt.Errorf("height %d, HighCert height %d", p.Block.Height, p.HighCert.Height)
+	case p.Block.View <= p.HighCert.View:
+		return fmt.Errorf("view %d not above HighCert view %d", p.Block.View, p.HighCert.View)
+	case !bytes.Equal(p.Block.ParentSigners, p.HighCert.Signers):
+		return errors.New("ParentSigners differ from the HighCert signers")
+	}
+	return nil
+}
+
 // follower/leader 공통: Prepare 수신 → HighestQC 갱신 + (더블‑체인 충족 시) 커밋
 func (e *Engine) onPrepare(ctx context.Context, cert Certificate, blk Block) {
+	// Never accept a QC that a quorum of its epoch's validators did not sign
+	if err := e.verifyCertFor(cert, blk); err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("prepare_reject_bad_cert", "view", cert.View, "err", err)
+		}
+		if errors.Is(err, ErrCertUnknownValSet) {
+			e.requestSync(ctx, "unknown_validator_set")
+		}
+		return
+	}
+	if blk.Proposer != "" && HashOfBlock(blk) != cert.H {
+		blk = Block{} // not the certified block
+	}
+	if blk.Proposer == "" && e.Store != nil {
+		// Prepares carry no block; as next leader we extend it, and need its
+		// header (and validator set) in Safety
+		blk, _ = e.Store.GetBlock(cert.H)
+	}
 	if e.Store != nil {
 		e.Store.SaveCert(cert)
-		if blk.Height > 0 || blk.Proposer != "" {
+		if blk.Proposer != "" {
 			e.Store.SaveBlock(blk)
 		}
 	}
+	_ = e.walWrite(Record{Type: RecordCert, Cert: &cert})
 	e.Safety.OnPrepare(cert, blk)
 
 	// Signal view advancement to waiting followers (reactive mode)
+	e.PM.OnQC()
 	e.PM.SignalViewAdvance(cert.View)
 
-	// 더블‑체인 커밋: C_{v-1}, C_v 있고, B_v.Parent == C_{v-1}.H 이면 B_{v-1} 커밋
-	if cert.View == 0 {
-		return
-	}
-	if e.Store == nil {
-		return
-	} // 저장소 없으면 스킵(프로토)
-	prevCert, ok := e.Store.GetCert(cert.View - 1)
-	if !ok {
-		return
-	}
-
-	childBlk := blk
-	if childBlk.Height == 0 && childBlk.Proposer == "" {
-		if b, ok2 := e.Store.GetBlock(cert.H); ok2 {
-			childBlk = b
-		}
-	}
-	if childBlk.Proposer == "" {
-		return
-	} // 현재 블록 모르면 커밋 판단 보류
-
-	if childBlk.Parent != prevCert.H {
-		if e.Logger != nil && e.VerboseLogging {
-			e.Logger.Debugw("commit_skip_chain_mismatch",
-				"view", cert.View,
-				"child_parent", childBlk.Parent.String(),
-				"prev_cert_h", prevCert.H.String())
+	// A QC for a block we never saw: we are behind
+	if e.Store != nil && cert.View > 0 && blk.Proposer == "" {
+		if _, ok := e.Store.GetBlock(cert.H); !ok {
+			e.requestSync(ctx, "unknown_certified_block")
 		}
-		return
-	}
-
-	prevBlk, ok := e.Store.GetBlock(prevCert.H)
-	if !ok {
-		return
 	}
 
-	// Use AppHash from previous certificate (already agreed upon by 2f+1 validators)
-	// Block was executed during voting, AppHash is now part of the certificate
-	appHash := prevCert.AppHash
-
-	// Update block with AppHash if not already set
-	if prevBlk.AppHash == (Hash{}) {
-		prevBlk.AppHash = appHash
-	}
-
-	e.Safety.UpdateLock(prevCert, prevBlk)
-	e.State.Height++
-
-	if e.Store != nil {
-		e.Store.SaveBlock(prevBlk) // Re-save block with AppHash
-		e.Store.SetCommitted(HashOfBlock(prevBlk))
-	}
-	if e.WAL != nil {
-		e.WAL.Append(fmt.Sprintf("commit height=%d view=%d apphash=0x%x", e.State.Height, prevBlk.View, appHash[:]))
-	}
-
-	if e.Logger != nil {
-		txCount := len(prevBlk.Payload)
-		if txCount > 0 {
-			// Count actual transactions (payload split by 0x00 delimiter)
-			txCount = 0
-			for _, b := range prevBlk.Payload {
-				if b == 0x00 {
-					txCount++
-				}
-			}
-		}
-		e.Logger.Infow("commit",
-			"height", e.State.Height,
-			"committed_view", prevBlk.View,
-			"txs", txCount,
-			"apphash", fmt.Sprintf("0x%x", appHash[:])) // Full hash
-	}
-
-	// Trigger API broadcast callback (for WebSocket updates)
-	if e.OnBlockCommit != nil {
-		e.OnBlockCommit(e.State.Height)
+	// The QC may be C2 (with the QC of cert.View-1) or C1 (with the QC of
+	// cert.View+1) of a DoubleCert; see commit.go
+	e.checkCommitAt(ctx, cert.View)
+	e.checkCommitAt(ctx, cert.View+1)
+	if blk.Proposer != "" {
+		e.commitPending(ctx)
 	}
 }
 
 func (e *Engine) leaderRound(ctx context.Context, v View) error {
-	ldr := &Leader{ID: e.ID, Net: e.Net, Safety: e.Safety, App: e.App}
-	block, prop, err := ldr.Propose(ctx, v, e.State.Height)
+	ldr := &Leader{
+		ID: e.ID, Net: e.Net, Safety: e.Safety, App: e.App, TC: e.timeoutCertFor(v - 1),
+		MinEmptyInterval: e.MinBlockTime, LastBlock: e.lastBlockTime, Clock: e.PM.Clock,
+		Evidence: e.evidenceFor, Sign: e.sign, ChainID: e.ChainID,
+		Validators: e.leaderValidators, BlockTime: e.bftTime,
+	}
+	block, prop, err := ldr.Propose(ctx, v)
+	if errors.Is(err, ErrCertUnknownValSet) || errors.Is(err, ErrHeaderValSet) {
+		// Cannot build a valid header yet: give the view up and catch up
+		e.requestSync(ctx, "unknown_validator_set")
+		return fmt.Errorf("propose: %w (%v)", ErrViewTimeout, err)
+	}
 	if err != nil {
 		return fmt.Errorf("propose: %w", err)
 	}
+	vs, err := e.ValidatorSetOf(block)
+	if err != nil {
+		return fmt.Errorf("propose: %w (%v)", ErrViewTimeout, err)
+	}
 	if e.Logger != nil && e.VerboseLogging {
 		e.Logger.Infow("propose_broadcasted", "height", block.Height, "view", v, "parent", prop.HighCert.H.String())
 	}
 	if e.Store != nil {
 		e.Store.SaveBlock(block)
 	}
-	if e.WAL != nil {
-		e.WAL.Append(fmt.Sprintf("propose v=%d h=%d", v, block.Height))
-	}
+	_ = e.walWrite(Record{Type: RecordProposal, Proposal: &prop})
 
 	// Leader receives its own propose and votes (handled by onPropose via broadcast)
 	// No execution here - leader executes in onPropose like all other validators
 
-	need := 2*e.State.Q.T + 1
-	votes, err := e.Net.CollectVotes(ctx, v, HashOfBlock(block), need)
+	vctx, cancel := e.PM.WithViewDeadline(ctx)
+	defer cancel()
+	votes, divergent, err := e.collectAgreeingVotes(vctx, v, HashOfBlock(block), vs)
 	if err != nil {
-		return fmt.Errorf("collect votes: %w", err)
-	}
-
-	// CRITICAL: Verify all votes have the SAME AppHash
-	// This detects Byzantine validators with divergent state
-	if len(votes) == 0 {
-		return fmt.Errorf("no votes collected")
-	}
-
-	agreedAppHash := votes[0].AppHash
-	for i, vote := range votes {
-		if vote.AppHash != agreedAppHash {
-			return fmt.Errorf("AppHash mismatch: vote[0] from %s has 0x%x, but vote[%d] from %s has 0x%x - Byzantine fault detected",
-				votes[0].From, agreedAppHash[:8], i, vote.From, vote.AppHash[:8])
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+		return fmt.Errorf("collect votes: %w (%v)", ErrViewTimeout, err)
 	}
+	agreedAppHash := votes[0].AppHash
 
 	if e.Logger != nil && e.VerboseLogging {
 		e.Logger.Debugw("apphash_verified", "view", v, "apphash", fmt.Sprintf("0x%x", agreedAppHash[:8]), "votes", len(votes))
@@ -300,8 +487,16 @@ func (e *Engine) leaderRound(ctx context.Context, v View) error {
 
 	var sigAgg []byte
 
+	signers := make([]NodeID, 0, len(votes))
+	times := make(map[NodeID]int64, len(votes))
+	for _, vt := range votes {
+		signers = append(signers, vt.From)
+		times[vt.From] = unixNanos(vt.Time)
+	}
+
 	if e.EnableBLS {
-		// aggregate shares (same message = block hash)
+		// aggregate shares (VoteSignBytes of the agreed block/AppHash, each
+		// with its vote's time)
 		var shares [][]byte
 		for _, vt := range votes {
 			if len(vt.SigShare) > 0 {
@@ -316,17 +511,330 @@ func (e *Engine) leaderRound(ctx context.Context, v View) error {
 	// Certificate now commits to BOTH consensus hash AND application state
 	cert := Certificate{
 		View:    v,
+		Height:  block.Height,
 		H:       HashOfBlock(block),
 		AppHash: agreedAppHash, // ← NEW: Include agreed state in certificate
 		Sig:     sigAgg,
+		Signers: SignerBitmap(vs.IDs(), signers),
+		Times:   certTimes(vs.IDs(), times),
 	}
 	if e.Store != nil {
 		e.Store.SaveCert(cert)
 	}
+	_ = e.walWrite(Record{Type: RecordCert, Cert: &cert})
 	if err := e.Net.BroadcastPrepare(ctx, cert); err != nil {
 		return fmt.Errorf("broadcast prepare: %w", err)
 	}
 	e.Safety.OnPrepare(cert, block) // 로컬도 관찰 처리
+	e.checkCommitAt(ctx, v)
+
+	// The QC proves the divergent voters computed a state 2f+1 others did not
+	for _, dv := range divergent {
+		e.reportEvidence(ctx, NewConflictingAppHash(dv, cert))
+	}
+	return nil
+}
+
+// leaderValidators gives our proposal its validator set fields (see
+// headerValidators). At an epoch start the next set depends on the execution
+// of the previous epoch, so the parent must have been executed.
+func (e *Engine) leaderValidators(parent Block, height Height) (Hash, *ValidatorSet, error) {
+	if e.EpochLength > 0 && height == e.epochStart(e.epochOf(height)) {
+		e.sync.execMu.Lock()
+		ok := e.sync.isExecuted(HashOfBlock(parent))
+		e.sync.execMu.Unlock()
+		if !ok {
+			return Hash{}, nil, fmt.Errorf("%w: parent at height %d not executed", ErrCertUnknownValSet, parent.Height)
+		}
+	}
+	return e.headerValidators(parent, height)
+}
+
+// collectAgreeingVotes waits until votes for block h with a quorum of vs's
+// power agree on the AppHash. Votes with another AppHash (a validator with
+// divergent state, or a Byzantine one) are returned separately instead of
+// failing the round; the quorum only becomes impossible once more than
+// MaxFaultyPower disagrees.
+func (e *Engine) collectAgreeingVotes(ctx context.Context, v View, h Hash, vs *ValidatorSet) ([]Vote, []Vote, error) {
+	need := vs.QuorumPower()
+	for want := vs.MinQuorumSize(); want <= vs.Size(); want++ {
+		votes, err := e.Net.CollectVotes(ctx, v, h, want)
+		if err != nil {
+			return nil, nil, err
+		}
+		groups := make(map[Hash][]Vote)
+		power := make(map[Hash]uint64)
+		for _, vt := range votes {
+			if p := vs.Power(vt.From); p > 0 {
+				groups[vt.AppHash] = append(groups[vt.AppHash], vt)
+				power[vt.AppHash] += p
+			}
+		}
+		for app, agree := range groups {
+			if power[app] < need {
+				continue
+			}
+			var divergent []Vote
+			for _, vt := range votes {
+				if vt.AppHash != app {
+					divergent = append(divergent, vt)
+				}
+			}
+			if len(divergent) > 0 && e.Logger != nil {
+				e.Logger.Warnw("apphash_divergent_votes", "view", v,
+					"apphash", fmt.Sprintf("0x%x", app[:8]), "agree", len(agree), "divergent", len(divergent))
+			}
+			return agree, divergent, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no votes with power %d agree on the AppHash", need)
+}
+
+// localTimeout gives up on view v: broadcast a signed Timeout carrying our
+// HighCert, then wait (with exponential backoff, re-broadcasting on every
+// expiry) until a TC or QC moves us past v.
+func (e *Engine) localTimeout(ctx context.Context, v View) error {
+	for {
+		e.PM.OnViewFailed()
+		if err := e.sendTimeout(ctx, v); err != nil {
+			return err
+		}
+
+		err := e.PM.WaitForViewAdvance(ctx, v)
+		if !errors.Is(err, ErrViewTimeout) {
+			return err
+		}
+	}
+}
+
+// sendTimeout signs, logs and broadcasts our Timeout for view v.
+func (e *Engine) sendTimeout(ctx context.Context, v View) error {
+	if err := e.Safety.MarkVoted(v); err != nil { // no votes in a view we have abandoned
+		return err
+	}
+
+	high := e.Safety.HighestCert()
+	t := Timeout{View: v, HighCert: high, From: e.ID}
+	t.Sig = e.sign(t.SignBytes(e.ChainID))
+
+	if e.Logger != nil {
+		e.Logger.Warnw("view_timeout",
+			"view", v,
+			"leader", e.Elector.LeaderOf(v),
+			"high_cert_view", high.View,
+			"failed_views", e.PM.FailedViews())
+	}
+
+	if err := e.walWrite(Record{Type: RecordTimeout, Timeout: &t}); err != nil {
+		return err
+	}
+	e.onTimeout(ctx, t) // count our own timeout
+	if err := e.Net.BroadcastTimeout(ctx, t); err != nil && e.Logger != nil {
+		e.Logger.Warnw("broadcast_timeout_failed", "view", v, "err", err)
+	}
+	return nil
+}
+
+// onTimeout: collect timeouts; 2f+1 for the same view form a TC
+func (e *Engine) onTimeout(ctx context.Context, t Timeout) {
+	if t.View <= e.State.View {
+		return // view already concluded locally
+	}
+	if !e.verifyTimeout(t) {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("timeout_bad_sig", "view", t.View, "from", t.From)
+		}
+		return
+	}
+	if err := e.verifyCert(t.HighCert); err != nil {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("timeout_bad_highcert", "view", t.View, "from", t.From, "err", err)
+		}
+		return
+	}
+	vs := e.ActiveValidators()
+	if !vs.Has(t.From) {
+		return
+	}
+	if tc, ok := e.timeouts.add(t, vs); ok {
+		e.onTimeoutCert(tc)
+		return
+	}
+	// More than f's power of timeouts includes an honest one: join that
+	// view's timeout. Without this, validators stuck in different views (e.g.
+	// after a partition) keep timing out their own views and never reach a
+	// quorum in any of them.
+	if e.timeouts.power(t.View, vs) > vs.MaxFaultyPower() && e.Safety.CanVoteInView(t.View) {
+		if e.Logger != nil && e.VerboseLogging {
+			e.Logger.Debugw("timeout_join", "view", t.View)
+		}
+		_ = e.sendTimeout(ctx, t.View)
+	}
+}
+
+// onTimeoutCert: view tc.View failed; remember the TC for the next leader
+// and let the pacemaker move on to tc.View+1.
+func (e *Engine) onTimeoutCert(tc *TimeoutCert) {
+	e.tcMu.Lock()
+	if e.lastTC != nil && e.lastTC.View >= tc.View {
+		e.tcMu.Unlock()
+		return
+	}
+	e.lastTC = tc
+	e.tcMu.Unlock()
+
+	e.timeouts.prune(tc.View)
+	if e.Logger != nil {
+		e.Logger.Infow("timeout_cert",
+			"view", tc.View,
+			"high_cert_view", tc.HighCert().View,
+			"signers", len(tc.Timeouts))
+	}
+	e.PM.SignalViewAdvance(tc.View)
+}
+
+// timeoutCertFor returns the TC for view v, if one was formed.
+func (e *Engine) timeoutCertFor(v View) *TimeoutCert {
+	e.tcMu.Lock()
+	defer e.tcMu.Unlock()
+	if e.lastTC != nil && e.lastTC.View == v {
+		return e.lastTC
+	}
+	return nil
+}
+
+// verifyTimeout checks t's signature over its View and HighCert
+// (VerifyTimeout).
+func (e *Engine) verifyTimeout(t Timeout) bool {
+	return !e.EnableBLS || VerifyTimeout(t, e.ChainID, e.PubKeys) == nil
+}
+
+// verifyTimeoutCert checks that tc holds distinct, validly signed timeouts
+// for tc.View from a quorum of the active set's power, each over the
+// HighCert it carries.
+func (e *Engine) verifyTimeoutCert(tc TimeoutCert) bool {
+	seen := make(map[NodeID]bool, len(tc.Timeouts))
+	signers := make([]NodeID, 0, len(tc.Timeouts))
+	for _, t := range tc.Timeouts {
+		if t.View != tc.View || seen[t.From] {
+			return false
+		}
+		if !e.verifyTimeout(t) {
+			return false
+		}
+		if e.verifyCert(t.HighCert) != nil {
+			return false
+		}
+		seen[t.From] = true
+		signers = append(signers, t.From)
+	}
+	return e.ActiveValidators().HasQuorum(signers)
+}
 
+// walWrite appends r to the WAL, if one is configured. Failures are logged
+// and returned; callers about to send a vote or timeout must then not send it.
+func (e *Engine) walWrite(r Record) error {
+	if e.WAL == nil {
+		return nil
+	}
+	if err := e.WAL.Write(r); err != nil {
+		if e.Logger != nil {
+			e.Logger.Errorw("wal_write_failed", "type", r.Type.String(), "err", err)
+		}
+		return err
+	}
 	return nil
 }
+
+// sign signs msg with the node's BLS key (or a placeholder without BLS).
+func (e *Engine) sign(msg []byte) []byte {
+	if e.EnableBLS {
+		if s, ok := e.Signer.(*crypto.BLSSigner); ok {
+			return s.Sign(msg)
+		}
+	}
+	return []byte("s")
+}
+
+// verifyFrom checks sig over msg against the validator key of id.
+// Always true when BLS is disabled.
+func (e *Engine) verifyFrom(id NodeID, msg, sig []byte) bool {
+	if !e.EnableBLS {
+		return true
+	}
+	pk, ok := e.PubKeys[id]
+	if !ok {
+		return false
+	}
+	return crypto.Verify(pk, sig, msg)
+}
+
+// verifyCert checks a QC before it is accepted: validators in c.Signers
+// holding a quorum of the power of its block's validator set must have
+// produced the aggregate c.Sig. The genesis certificate (view 0) is implicit
+// and carries no signature.
+func (e *Engine) verifyCert(c Certificate) error { return e.verifyCertFor(c, Block{}) }
+
+// verifyCertFor is verifyCert for a QC that arrived with blk, which names the
+// validator set if it is the certified block (blk may be empty).
+func (e *Engine) verifyCertFor(c Certificate, blk Block) error {
+	if c.View == 0 {
+		if c.H != HashOfBlock(e.State.Genesis) {
+			return ErrCertBadGenesis
+		}
+		return nil
+	}
+	if !e.EnableBLS {
+		return nil
+	}
+
+	e.certMu.Lock()
+	prev, ok := e.verified[c.View]
+	e.certMu.Unlock()
+	if ok && prev.H == c.H && prev.AppHash == c.AppHash && prev.Height == c.Height &&
+		bytes.Equal(prev.Sig, c.Sig) && bytes.Equal(prev.Signers, c.Signers) && slices.Equal(prev.Times, c.Times) {
+		return nil
+	}
+
+	sets, err := e.certValidatorSets(c, blk)
+	if err != nil {
+		return err
+	}
+	// Without the block (a node behind) any known set of the epoch will do
+	for i, vs := range sets {
+		if err = VerifyCertificate(c, c.SignBytes(e.ChainID), vs, e.PubKeys); err == nil {
+			break
+		}
+		if i == len(sets)-1 {
+			return err
+		}
+	}
+
+	e.certMu.Lock()
+	e.verified[c.View] = c
+	for v := range e.verified {
+		if v+verifiedCertWindow < c.View {
+			delete(e.verified, v)
+		}
+	}
+	e.certMu.Unlock()
+	return nil
+}
+
+// verifiedCertWindow bounds the verified-QC cache (in views).
+const verifiedCertWindow = 16
+
+// verifyVote checks a vote share against the sender's key before the network
+// lets it count toward CollectVotes; votes from outside the validator set of
+// the block's epoch are dropped.
+// A verified vote is also checked against the sender's earlier votes.
+func (e *Engine) verifyVote(v Vote) bool {
+	if !e.isMember(v.From, v.Height) {
+		return false
+	}
+	if e.EnableBLS && !e.verifyFrom(v.From, v.SignBytes(e.ChainID), v.SigShare) {
+		return false
+	}
+	e.observeVote(v)
+	return true
+}
@@ -0,0 +1,430 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Validator sets and epochs
+//
+// Heights are grouped into epochs of EpochLength blocks: epoch k holds the
+// heights k*L+1 .. (k+1)*L (genesis belongs to epoch 0). Every block of an
+// epoch is certified by that epoch's set, which its header names
+// (Block.ValSetHash); QCs are checked against that set, by voting power.
+//
+// The app changes the set with validator updates returned from Execute.
+// Updates emitted while executing epoch k-1 take effect in epoch k+1: the
+// first block of epoch k, whose parent ends epoch k-1, carries the resulting
+// set (Block.NextValSet). Followers recompute it from their own execution and
+// do not vote for a header that disagrees, so a certified header names the
+// right next set, and whoever trusts epoch k's set can verify epoch k+1's.
+// The delay fixes a set a whole epoch before anybody votes in it.
+//
+// EpochLength 0 disables reconfiguration: the genesis set certifies every
+// block and updates are ignored.
+
+var (
+	ErrCertUnknownValSet = errors.New("certificate: validator set of its epoch unknown")
+	ErrHeaderValSet      = errors.New("header: wrong validator set")
+)
+
+// ValidatorUpdater is implemented by AppHooks whose app can change the
+// validator set (abci.Bridge). LastValidatorUpdates returns the updates
+// emitted by the block most recently passed to Execute.
+type ValidatorUpdater interface {
+	LastValidatorUpdates() []ValidatorUpdate
+}
+
+// ValidatorSetElector rotates round-robin over a validator set that may
+// change every epoch; Set is usually Engine.ActiveValidators.
+type ValidatorSetElector struct{ Set func() *ValidatorSet }
+
+func (s ValidatorSetElector) LeaderOf(v View) NodeID {
+	return RoundRobinElector{IDs: s.Set().IDs()}.LeaderOf(v)
+}
+
+// valSetWindow is how many epochs of sets and updates are kept.
+const valSetWindow = 4
+
+// blockUpdates are the validator updates an executed block emitted.
+type blockUpdates struct {
+	height  Height
+	updates []ValidatorUpdate
+}
+
+// valSetRegistry holds the validator sets taken from certified (or locally
+// validated) headers, and the updates of executed blocks.
+type valSetRegistry struct {
+	mu      sync.Mutex
+	byHash  map[Hash]*ValidatorSet
+	byEpoch map[uint64][]*ValidatorSet // candidates per epoch; committed one first
+	updates map[Hash]blockUpdates      // executed blocks with non-empty updates
+}
+
+func newValSetRegistry() *valSetRegistry {
+	return &valSetRegistry{
+		byHash:  make(map[Hash]*ValidatorSet),
+		byEpoch: make(map[uint64][]*ValidatorSet),
+		updates: make(map[Hash]blockUpdates),
+	}
+}
+
+// add registers vs as a candidate for its epoch (committed: as the one).
+func (r *valSetRegistry) add(vs *ValidatorSet, committed bool) {
+	h := vs.Hash()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byHash[h]; ok && !committed {
+		return
+	}
+	r.byHash[h] = vs
+	switch {
+	case committed:
+		for _, other := range r.byEpoch[vs.Epoch] {
+			if oh := other.Hash(); oh != h {
+				delete(r.byHash, oh) // a fork that lost
+			}
+		}
+		r.byEpoch[vs.Epoch] = []*ValidatorSet{vs}
+	default:
+		r.byEpoch[vs.Epoch] = append(r.byEpoch[vs.Epoch], vs)
+	}
+	for epoch, sets := range r.byEpoch {
+		if epoch+valSetWindow < vs.Epoch {
+			for _, old := range sets {
+				delete(r.byHash, old.Hash())
+			}
+			delete(r.byEpoch, epoch)
+		}
+	}
+}
+
+func (r *valSetRegistry) get(h Hash) (*ValidatorSet, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	vs, ok := r.byHash[h]
+	return vs, ok
+}
+
+// forEpoch returns the known candidates for epoch, committed one first.
+func (r *valSetRegistry) forEpoch(epoch uint64) []*ValidatorSet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*ValidatorSet(nil), r.byEpoch[epoch]...)
+}
+
+// latest returns the first candidate of the highest known epoch <= epoch.
+func (r *valSetRegistry) latest(epoch uint64) *ValidatorSet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var best *ValidatorSet
+	for k, sets := range r.byEpoch {
+		if k <= epoch && (best == nil || k > best.Epoch) {
+			best = sets[0]
+		}
+	}
+	return best
+}
+
+// record stores the updates emitted by executed block h, pruning those older
+// than minHeight.
+func (r *valSetRegistry) record(h Hash, height Height, updates []ValidatorUpdate, minHeight Height) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates[h] = blockUpdates{height: height, updates: updates}
+	for bh, bu := range r.updates {
+		if bu.height < minHeight {
+			delete(r.updates, bh)
+		}
+	}
+}
+
+func (r *valSetRegistry) updatesOf(h Hash) []ValidatorUpdate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.updates[h].updates
+}
+
+// EpochOf returns the epoch of height h for epochs of length blocks.
+func EpochOf(h, length Height) uint64 {
+	if length == 0 || h == 0 {
+		return 0
+	}
+	return uint64((h - 1) / length)
+}
+
+// EpochStart returns the first height of epoch k; its block names the set of
+// epoch k+1 (Block.NextValSet) when length > 0.
+func EpochStart(k uint64, length Height) Height {
+	return Height(k)*length + 1
+}
+
+func (e *Engine) epochOf(h Height) uint64 { return EpochOf(h, e.EpochLength) }
+
+func (e *Engine) epochStart(k uint64) Height { return EpochStart(k, e.EpochLength) }
+
+// genesisValidators returns the set of epoch 0: GenesisSet, or else equal
+// power for Validators (the round-robin IDs, or just this node, without
+// them). The registry is set up on first use, after the caller configured
+// the engine.
+func (e *Engine) genesisValidators() *ValidatorSet {
+	e.valsetsOnce.Do(func() {
+		vs := e.GenesisSet
+		if vs == nil {
+			ids := e.Validators
+			if rr, ok := e.Elector.(RoundRobinElector); ok && len(ids) == 0 {
+				ids = rr.IDs
+			}
+			if len(ids) == 0 {
+				ids = []NodeID{e.ID}
+			}
+			var err error
+			if vs, err = EqualPowerSet(0, ids); err != nil {
+				panic(fmt.Sprintf("genesis validator set: %v", err))
+			}
+		}
+		e.genesis = vs
+		e.valsets.add(vs, true)
+	})
+	return e.genesis
+}
+
+// ActiveValidators returns the set of the epoch of the next height to
+// commit. It derives from committed blocks only, so all nodes that committed
+// the same chain agree on it (leader election and timeouts use it).
+func (e *Engine) ActiveValidators() *ValidatorSet {
+	gen := e.genesisValidators()
+	k := e.epochOf(e.State.Height + 1)
+	if k == 0 {
+		return gen
+	}
+	if vs := e.valsets.latest(k); vs != nil {
+		return vs
+	}
+	return gen
+}
+
+// ValidatorSetOf returns the validator set that certifies b.
+func (e *Engine) ValidatorSetOf(b Block) (*ValidatorSet, error) {
+	if HashOfBlock(b) == HashOfBlock(e.State.Genesis) {
+		return e.genesisValidators(), nil
+	}
+	if vs, ok := e.valsets.get(b.ValSetHash); ok && vs.Epoch == e.epochOf(b.Height) {
+		return vs, nil
+	}
+	parent, ok := e.blockByHash(b.Parent)
+	if !ok {
+		return nil, fmt.Errorf("%w: parent of height %d unknown", ErrCertUnknownValSet, b.Height)
+	}
+	vs, err := e.childValidatorSet(parent, b.Height)
+	if err != nil {
+		return nil, err
+	}
+	if vs.Hash() != b.ValSetHash {
+		return nil, fmt.Errorf("%w: height %d names %s, epoch %d has %s", ErrHeaderValSet, b.Height, b.ValSetHash, vs.Epoch, vs.Hash())
+	}
+	return vs, nil
+}
+
+// childValidatorSet returns the set of a block at height extending parent:
+// the parent's set within an epoch, else the NextValSet of the first block
+// of the previous epoch on parent's branch. parent must be certified.
+func (e *Engine) childValidatorSet(parent Block, height Height) (*ValidatorSet, error) {
+	k := e.epochOf(height)
+	if k == 0 {
+		return e.genesisValidators(), nil
+	}
+	if e.epochOf(parent.Height) == k {
+		if vs, ok := e.valsets.get(parent.ValSetHash); ok && vs.Epoch == k {
+			return vs, nil
+		}
+	}
+	start := e.epochStart(k - 1)
+	b := parent
+	for b.Height > start {
+		var ok bool
+		if b, ok = e.blockByHash(b.Parent); !ok {
+			return nil, fmt.Errorf("%w: ancestor at height %d missing", ErrCertUnknownValSet, start)
+		}
+	}
+	if b.Height != start || b.NextValSet == nil || b.NextValSet.Epoch != k {
+		return nil, fmt.Errorf("%w: block at height %d does not name epoch %d's set", ErrHeaderValSet, b.Height, k)
+	}
+	e.valsets.add(b.NextValSet, false)
+	return b.NextValSet, nil
+}
+
+// nextValidatorSet computes the set of epoch cur.Epoch+1 for the first block
+// of epoch cur.Epoch extending parent: cur with the updates of the previous
+// epoch's blocks (parent and its ancestors down to that epoch's start), in
+// height order. An update leaving no validator is ignored.
+func (e *Engine) nextValidatorSet(cur *ValidatorSet, parent Block) *ValidatorSet {
+	var updates []ValidatorUpdate
+	if cur.Epoch > 0 {
+		start := e.epochStart(cur.Epoch - 1)
+		var chain []Hash
+		for b, ok := parent, true; ok && b.Height >= start; b, ok = e.blockByHash(b.Parent) {
+			chain = append(chain, HashOfBlock(b))
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			updates = append(updates, e.valsets.updatesOf(chain[i])...)
+		}
+	}
+	next, err := cur.Apply(cur.Epoch+1, updates)
+	if err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("validator_updates_ignored", "epoch", cur.Epoch+1, "updates", len(updates), "err", err)
+		}
+		next = &ValidatorSet{Epoch: cur.Epoch + 1, Validators: cur.Validators}
+	}
+	return next
+}
+
+// headerValidators returns the ValSetHash and NextValSet of a block at
+// height extending parent.
+func (e *Engine) headerValidators(parent Block, height Height) (Hash, *ValidatorSet, error) {
+	cur, err := e.childValidatorSet(parent, height)
+	if err != nil {
+		return Hash{}, nil, err
+	}
+	var next *ValidatorSet
+	if e.EpochLength > 0 && height == e.epochStart(cur.Epoch) {
+		next = e.nextValidatorSet(cur, parent)
+	}
+	return cur.Hash(), next, nil
+}
+
+// checkHeaderValidators checks a proposed block's validator sets against
+// our own computation. parent must be executed, so its epoch's updates are
+// known.
+func (e *Engine) checkHeaderValidators(b, parent Block) error {
+	want, next, err := e.headerValidators(parent, b.Height)
+	if err != nil {
+		return err
+	}
+	if b.ValSetHash != want {
+		return fmt.Errorf("%w: ValSetHash %s, want %s", ErrHeaderValSet, b.ValSetHash, want)
+	}
+	switch {
+	case next == nil && b.NextValSet != nil:
+		return fmt.Errorf("%w: NextValSet outside an epoch start", ErrHeaderValSet)
+	case next != nil && (b.NextValSet == nil || b.NextValSet.Hash() != next.Hash()):
+		return fmt.Errorf("%w: NextValSet for epoch %d differs from the validator updates", ErrHeaderValSet, next.Epoch)
+	}
+	return nil
+}
+
+// certValidatorSets returns the sets a QC may be signed by: the set named by
+// its block when we know the block, else the known sets of its epoch.
+func (e *Engine) certValidatorSets(c Certificate, blk Block) ([]*ValidatorSet, error) {
+	if blk.Proposer == "" || HashOfBlock(blk) != c.H {
+		blk, _ = e.blockByHash(c.H)
+	}
+	if blk.Proposer != "" {
+		if vs, err := e.ValidatorSetOf(blk); err == nil {
+			return []*ValidatorSet{vs}, nil
+		}
+	}
+	k := e.epochOf(c.Height)
+	if k == 0 {
+		return []*ValidatorSet{e.genesisValidators()}, nil
+	}
+	if sets := e.valsets.forEpoch(k); len(sets) > 0 {
+		return sets, nil
+	}
+	return nil, fmt.Errorf("%w: epoch %d (height %d)", ErrCertUnknownValSet, k, c.Height)
+}
+
+// isMember reports whether id belongs to a known set of height h's epoch
+// (true while none is known: the QC check decides then).
+func (e *Engine) isMember(id NodeID, h Height) bool {
+	e.genesisValidators()
+	sets := e.valsets.forEpoch(e.epochOf(h))
+	for _, vs := range sets {
+		if vs.Has(id) {
+			return true
+		}
+	}
+	return len(sets) == 0
+}
+
+// execute applies b to the app (speculatively, see AppHook) and records
+// what it changes in the validator set. Caller holds execMu; b must have
+// been validated (voted on, or certified).
+func (e *Engine) execute(b Block) (Hash, error) {
+	appHash, err := e.App.Execute(b)
+	if err != nil {
+		return Hash{}, err
+	}
+	if b.NextValSet != nil {
+		e.valsets.add(b.NextValSet, false)
+	}
+	if vu, ok := e.App.(ValidatorUpdater); ok && e.EpochLength > 0 {
+		if updates := vu.LastValidatorUpdates(); len(updates) > 0 {
+			e.recordValidatorUpdates(HashOfBlock(b), b.Height, updates)
+			_ = e.walWrite(Record{Type: RecordValidatorUpdates, Updates: &BlockValidatorUpdates{
+				H: HashOfBlock(b), Height: b.Height, Updates: updates,
+			}})
+			if e.Logger != nil {
+				e.Logger.Infow("validator_updates", "height", b.Height, "updates", len(updates),
+					"effective_epoch", e.epochOf(b.Height)+2)
+			}
+		}
+	}
+	return appHash, nil
+}
+
+// recordValidatorUpdates keeps updates for the next epoch start, dropping
+// those of epochs already applied.
+func (e *Engine) recordValidatorUpdates(h Hash, height Height, updates []ValidatorUpdate) {
+	var min Height
+	if k := e.epochOf(height); k >= valSetWindow {
+		min = e.epochStart(k - valSetWindow)
+	}
+	e.valsets.record(h, height, updates, min)
+}
+
+// commitValidatorSets makes the sets named by newly committed blocks the
+// sets of their epochs.
+func (e *Engine) commitValidatorSets(newly []Block) {
+	for _, b := range newly {
+		if b.NextValSet != nil {
+			e.valsets.add(b.NextValSet, true)
+			if e.Logger != nil {
+				e.Logger.Infow("validator_set_committed", "epoch", b.NextValSet.Epoch,
+					"validators", b.NextValSet.Size(), "power", b.NextValSet.TotalPower(), "height", b.Height)
+			}
+		}
+	}
+}
+
+// loadValidatorSets registers the sets of the current and next epoch from
+// the committed chain (after a restart).
+func (e *Engine) loadValidatorSets() {
+	e.genesisValidators()
+	if e.Store == nil || e.EpochLength == 0 {
+		return
+	}
+	// Epoch k's set is named by the first block of epoch k-1, the next
+	// epoch's by the first block of epoch k (if committed already)
+	k := e.epochOf(e.State.Height + 1)
+	for epoch := k; epoch <= k+1; epoch++ {
+		if epoch == 0 {
+			continue
+		}
+		if b, ok := e.Store.GetBlockByHeight(e.epochStart(epoch - 1)); ok && b.NextValSet != nil {
+			e.valsets.add(b.NextValSet, true)
+		}
+	}
+}
+
+// blockByHash looks h up in the store, then in Safety's block cache.
+func (e *Engine) blockByHash(h Hash) (Block, bool) {
+	if e.Store != nil {
+		if b, ok := e.Store.GetBlock(h); ok {
+			return b, true
+		}
+	}
+	return e.Safety.BlockByHash(h)
+}
@@ -0,0 +1,387 @@
+package consensus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// EvidenceType identifies a kind of provable validator misbehaviour.
+type EvidenceType uint8
+
+const (
+	// EvidenceDuplicateVote: two different votes signed by one validator in a view
+	EvidenceDuplicateVote EvidenceType = iota + 1
+	// EvidenceDuplicateProposal: two different blocks signed by the leader of a view
+	EvidenceDuplicateProposal
+	// EvidenceConflictingAppHash: a vote whose AppHash differs from the one
+	// 2f+1 validators certified for the same block
+	EvidenceConflictingAppHash
+)
+
+func (t EvidenceType) String() string {
+	switch t {
+	case EvidenceDuplicateVote:
+		return "duplicate_vote"
+	case EvidenceDuplicateProposal:
+		return "duplicate_proposal"
+	case EvidenceConflictingAppHash:
+		return "conflicting_apphash"
+	}
+	return fmt.Sprintf("evidence(%d)", uint8(t))
+}
+
+// SignedProposal is a block together with its proposer's signature
+// (over ProposalSignBytes).
+type SignedProposal struct {
+	Block Block
+	Sig   []byte
+}
+
+// Evidence is a self-contained proof that Validator misbehaved in View.
+// Anyone holding the validator keys can check it (Engine.VerifyEvidence).
+type Evidence struct {
+	Type      EvidenceType
+	Validator NodeID
+	View      View
+
+	Votes     [2]Vote           // DuplicateVote: both votes; ConflictingAppHash: Votes[0]
+	Proposals [2]SignedProposal // DuplicateProposal
+	Cert      Certificate       // ConflictingAppHash: the QC for the same block
+}
+
+func NewDuplicateVote(a, b Vote) Evidence {
+	return Evidence{Type: EvidenceDuplicateVote, Validator: a.From, View: a.View, Votes: [2]Vote{a, b}}
+}
+
+func NewDuplicateProposal(a, b SignedProposal) Evidence {
+	return Evidence{Type: EvidenceDuplicateProposal, Validator: a.Block.Proposer, View: a.Block.View,
+		Proposals: [2]SignedProposal{a, b}}
+}
+
+func NewConflictingAppHash(v Vote, qc Certificate) Evidence {
+	return Evidence{Type: EvidenceConflictingAppHash, Validator: v.From, View: v.View, Votes: [2]Vote{v}, Cert: qc}
+}
+
+// Key identifies the offence: one piece of evidence per type, validator and view.
+func (ev Evidence) Key() Hash {
+	buf := []byte{byte(ev.Type)}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(ev.View))
+	buf = append(buf, ev.Validator...)
+	return sha256.Sum256(buf)
+}
+
+var ErrInvalidEvidence = errors.New("invalid evidence")
+
+// checkStructure validates everything but signatures and the QC.
+func (ev Evidence) checkStructure() error {
+	bad := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidEvidence, ev.Type, fmt.Sprintf(format, args...))
+	}
+	switch ev.Type {
+	case EvidenceDuplicateVote:
+		a, b := ev.Votes[0], ev.Votes[1]
+		if a.From != ev.Validator || b.From != ev.Validator || a.View != ev.View || b.View != ev.View {
+			return bad("votes not from %s in view %d", ev.Validator, ev.View)
+		}
+		if a.sameAs(b) {
+			return bad("votes are for the same block")
+		}
+	case EvidenceDuplicateProposal:
+		a, b := ev.Proposals[0].Block, ev.Proposals[1].Block
+		if a.Proposer != ev.Validator || b.Proposer != ev.Validator || a.View != ev.View || b.View != ev.View {
+			return bad("blocks not proposed by %s in view %d", ev.Validator, ev.View)
+		}
+		if HashOfBlock(a) == HashOfBlock(b) {
+			return bad("blocks are identical")
+		}
+	case EvidenceConflictingAppHash:
+		v := ev.Votes[0]
+		if v.From != ev.Validator || v.View != ev.View || ev.Cert.View != ev.View {
+			return bad("vote not from %s in view %d", ev.Validator, ev.View)
+		}
+		if v.H != ev.Cert.H || v.Height != ev.Cert.Height {
+			return bad("vote and QC are for different blocks")
+		}
+		if v.AppHash == ev.Cert.AppHash {
+			return bad("AppHash agrees with the QC")
+		}
+	default:
+		return bad("unknown type")
+	}
+	return nil
+}
+
+// VerifyEvidence checks ev: its structure, that Validator is in the
+// validator set (and led the view, for proposals), and every signature.
+func (e *Engine) VerifyEvidence(ev Evidence) error {
+	if err := ev.checkStructure(); err != nil {
+		return err
+	}
+	if len(e.Validators) > 0 && !containsID(e.Validators, ev.Validator) {
+		return fmt.Errorf("%w: %s is not a validator", ErrInvalidEvidence, ev.Validator)
+	}
+	switch ev.Type {
+	case EvidenceDuplicateVote:
+		for _, v := range ev.Votes {
+			if !e.verifyFrom(v.From, v.SignBytes(e.ChainID), v.SigShare) {
+				return fmt.Errorf("%w: bad vote signature", ErrInvalidEvidence)
+			}
+		}
+	case EvidenceDuplicateProposal:
+		if e.Elector.LeaderOf(ev.View) != ev.Validator {
+			return fmt.Errorf("%w: %s is not the leader of view %d", ErrInvalidEvidence, ev.Validator, ev.View)
+		}
+		for _, p := range ev.Proposals {
+			if !e.verifyFrom(ev.Validator, ProposalSignBytes(e.ChainID, p.Block), p.Sig) {
+				return fmt.Errorf("%w: bad proposal signature", ErrInvalidEvidence)
+			}
+		}
+	case EvidenceConflictingAppHash:
+		v := ev.Votes[0]
+		if !e.verifyFrom(v.From, v.SignBytes(e.ChainID), v.SigShare) {
+			return fmt.Errorf("%w: bad vote signature", ErrInvalidEvidence)
+		}
+		if err := e.verifyCert(ev.Cert); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+		}
+	}
+	return nil
+}
+
+func containsID(ids []NodeID, id NodeID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// EvidenceNetwork is implemented by networks that can gossip evidence.
+// Received evidence is passed to Handlers.OnEvidence.
+type EvidenceNetwork interface {
+	BroadcastEvidence(ctx context.Context, ev Evidence) error
+}
+
+const (
+	// maxEvidencePerBlock bounds the evidence a leader puts in one block
+	maxEvidencePerBlock = 16
+	// evidenceWindow is how many views of votes/proposals are kept to detect
+	// duplicates
+	evidenceWindow = 64
+)
+
+// evidencePool holds verified evidence until a committed block includes it,
+// and the recent votes and proposals that duplicates are detected against.
+type evidencePool struct {
+	mu        sync.Mutex
+	pending   map[Hash]Evidence
+	order     []Hash // pending, oldest first
+	committed map[Hash]bool
+
+	votes     map[View]map[NodeID]Vote
+	proposals map[View]SignedProposal
+}
+
+func newEvidencePool() *evidencePool {
+	return &evidencePool{
+		pending:   make(map[Hash]Evidence),
+		committed: make(map[Hash]bool),
+		votes:     make(map[View]map[NodeID]Vote),
+		proposals: make(map[View]SignedProposal),
+	}
+}
+
+// add records ev; false if it is already pending or committed.
+func (p *evidencePool) add(ev Evidence) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k := ev.Key()
+	if _, ok := p.pending[k]; ok || p.committed[k] {
+		return false
+	}
+	p.pending[k] = ev
+	p.order = append(p.order, k)
+	return true
+}
+
+// list returns up to max (0 = all) pending evidence, oldest first.
+func (p *evidencePool) list(max int) []Evidence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Evidence
+	for _, k := range p.order {
+		if max > 0 && len(out) == max {
+			break
+		}
+		out = append(out, p.pending[k])
+	}
+	return out
+}
+
+// markCommitted drops evs from the pending set for good.
+func (p *evidencePool) markCommitted(evs []Evidence) {
+	if len(evs) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ev := range evs {
+		k := ev.Key()
+		p.committed[k] = true
+		delete(p.pending, k)
+	}
+	kept := p.order[:0]
+	for _, k := range p.order {
+		if _, ok := p.pending[k]; ok {
+			kept = append(kept, k)
+		}
+	}
+	p.order = kept
+}
+
+// observeVote remembers v and returns the earlier vote of the same sender in
+// the same view for another block, if any.
+func (p *evidencePool) observeVote(v Vote) (Vote, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := p.votes[v.View]
+	if m == nil {
+		m = make(map[NodeID]Vote)
+		p.votes[v.View] = m
+		for view := range p.votes {
+			if view+evidenceWindow < v.View {
+				delete(p.votes, view)
+			}
+		}
+	}
+	prev, ok := m[v.From]
+	if !ok {
+		m[v.From] = v
+		return Vote{}, false
+	}
+	return prev, !prev.sameAs(v)
+}
+
+// sameAs reports whether v and o vote for the same block and state; their
+// times may differ.
+func (v Vote) sameAs(o Vote) bool {
+	return v.View == o.View && v.Height == o.Height && v.H == o.H && v.AppHash == o.AppHash
+}
+
+// observeProposal remembers sp and returns the earlier, different proposal
+// for the same view, if any.
+func (p *evidencePool) observeProposal(sp SignedProposal) (SignedProposal, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v := sp.Block.View
+	prev, ok := p.proposals[v]
+	if !ok {
+		p.proposals[v] = sp
+		for view := range p.proposals {
+			if view+evidenceWindow < v {
+				delete(p.proposals, view)
+			}
+		}
+		return SignedProposal{}, false
+	}
+	return prev, HashOfBlock(prev.Block) != HashOfBlock(sp.Block)
+}
+
+// PendingEvidence returns the verified evidence not yet committed.
+func (e *Engine) PendingEvidence() []Evidence {
+	return e.evidence.list(0)
+}
+
+// reportEvidence adds ev to the pool and gossips it the first time.
+func (e *Engine) reportEvidence(ctx context.Context, ev Evidence) {
+	if !e.evidence.add(ev) {
+		return
+	}
+	if e.Logger != nil {
+		e.Logger.Warnw("evidence", "type", ev.Type.String(), "validator", ev.Validator, "view", ev.View)
+	}
+	if en, ok := e.Net.(EvidenceNetwork); ok {
+		if err := en.BroadcastEvidence(ctx, ev); err != nil && e.Logger != nil {
+			e.Logger.Warnw("broadcast_evidence_failed", "type", ev.Type.String(), "err", err)
+		}
+	}
+}
+
+// onEvidence handles gossiped evidence.
+func (e *Engine) onEvidence(_ context.Context, ev Evidence) {
+	if err := e.VerifyEvidence(ev); err != nil {
+		if e.Logger != nil {
+			e.Logger.Warnw("evidence_rejected", "type", ev.Type.String(), "validator", ev.Validator, "err", err)
+		}
+		return
+	}
+	if e.evidence.add(ev) && e.Logger != nil {
+		e.Logger.Infow("evidence_received", "type", ev.Type.String(), "validator", ev.Validator, "view", ev.View)
+	}
+}
+
+// observeVote checks a verified vote against earlier votes of its sender.
+func (e *Engine) observeVote(v Vote) {
+	if prev, dup := e.evidence.observeVote(v); dup {
+		e.reportEvidence(context.Background(), NewDuplicateVote(prev, v))
+	}
+}
+
+// observeProposal checks a verified proposal against earlier ones in its view.
+func (e *Engine) observeProposal(ctx context.Context, p Propose) {
+	sp := SignedProposal{Block: p.Block, Sig: p.Sig}
+	if prev, dup := e.evidence.observeProposal(sp); dup {
+		e.reportEvidence(ctx, NewDuplicateProposal(prev, sp))
+	}
+}
+
+// evidenceFor returns the pending evidence for a block extending parent,
+// leaving out what parent's uncommitted ancestors already carry.
+func (e *Engine) evidenceFor(parent Block) []Evidence {
+	pending := e.evidence.list(0)
+	if len(pending) == 0 {
+		return nil
+	}
+	head := HashOfBlock(e.State.Genesis)
+	if e.Store != nil {
+		if h, ok := e.Store.GetCommitted(); ok {
+			head = h
+		}
+	}
+	included := make(map[Hash]bool)
+	b, ok := parent, true
+	for i := 0; ok && i < maxServeWalk && b.Height > 0 && HashOfBlock(b) != head; i++ {
+		evs, _ := PayloadEvidence(b.Payload)
+		for _, ev := range evs {
+			included[ev.Key()] = true
+		}
+		b, ok = e.Safety.BlockByHash(b.Parent)
+	}
+	var out []Evidence
+	for _, ev := range pending {
+		if !included[ev.Key()] && len(out) < maxEvidencePerBlock {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// checkPayloadEvidence verifies the evidence a proposed block carries: a
+// leader must not get a validator punished without proof.
+func (e *Engine) checkPayloadEvidence(payload []byte) error {
+	evs, err := PayloadEvidence(payload)
+	if err != nil {
+		return err
+	}
+	for _, ev := range evs {
+		if err := e.VerifyEvidence(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package consensus
 import (
 	"context"
 	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/util"
 )
 
 type LeaderElector interface{ LeaderOf(v View) NodeID }
@@ -25,19 +27,108 @@ type Leader struct {
 	Net    Network
 	Safety *Safety
 	App    AppHook
+	TC     *TimeoutCert // TC for view-1, if the previous view timed out
+
+	// MinEmptyInterval: on the fast path, an empty block is only proposed
+	// once this long has passed since LastBlock (0 = no throttle)
+	MinEmptyInterval time.Duration
+	LastBlock        time.Time
+
+	// Clock stamps blocks and paces the empty-block throttle (nil = real time)
+	Clock util.Clock
+
+	// Evidence returns the evidence to include in a block extending parent
+	// (see AppHook.PreparePayload); nil = none
+	Evidence func(parent Block) []Evidence
+	// Sign signs the proposal (ProposalSignBytes for ChainID); nil = unsigned
+	Sign    func(msg []byte) []byte
+	ChainID string
+	// Validators returns the header's ValSetHash and NextValSet for a block
+	// at height extending parent (see epoch.go); nil = none
+	Validators func(parent Block, height Height) (Hash, *ValidatorSet, error)
+	// BlockTime returns the BFT time of a block extending parent under its
+	// QC high (see bfttime.go); nil, or ok false, stamps Clock's time
+	BlockTime func(high Certificate, parent Block) (t time.Time, ok bool, err error)
 }
 
-func (l *Leader) Propose(ctx context.Context, view View, height Height) (Block, Propose, error) {
+func (l *Leader) clock() util.Clock {
+	if l.Clock == nil {
+		return util.RealClock{}
+	}
+	return l.Clock
+}
+
+// emptyPollInterval is how often a throttled leader re-checks the mempool.
+const emptyPollInterval = 10 * time.Millisecond
+
+// Propose builds and broadcasts the block of view: it extends the highest
+// known QC (or the TC's highest, after a failed view) at height QC.Height+1.
+func (l *Leader) Propose(ctx context.Context, view View) (Block, Propose, error) {
 	high := l.Safety.HighestCert()
+	if l.TC != nil {
+		// Extend the highest cert any member of the timeout quorum has seen
+		if tcHigh := l.TC.HighCert(); tcHigh.View > high.View {
+			high = tcHigh
+		}
+	}
 	parent, ok := l.Safety.BlockByHash(high.H)
 	if !ok {
 		parent = l.Safety.state.Genesis
 	}
-	payload := l.App.PreparePayload(parent, height+1)
+	height := high.Height + 1
+	var valSet Hash
+	var nextValSet *ValidatorSet
+	if l.Validators != nil {
+		var err error
+		if valSet, nextValSet, err = l.Validators(parent, height); err != nil {
+			return Block{}, Propose{}, err
+		}
+	}
+	var evidence []Evidence
+	if l.Evidence != nil {
+		evidence = l.Evidence(parent)
+	}
+	payload := l.App.PreparePayload(parent, height, evidence)
+	if l.TC == nil && l.Safety.FastPathReady(view) {
+		// Holding the previous QC: propose as soon as there is something to
+		// include, but don't spin out empty blocks faster than MinEmptyInterval
+		for len(payload) == 0 {
+			wait := l.LastBlock.Add(l.MinEmptyInterval).Sub(l.clock().Now())
+			if wait <= 0 {
+				break
+			}
+			if wait > emptyPollInterval {
+				wait = emptyPollInterval
+			}
+			select {
+			case <-ctx.Done():
+				return Block{}, Propose{}, ctx.Err()
+			case <-l.clock().After(wait):
+			}
+			payload = l.App.PreparePayload(parent, height, evidence)
+		}
+	}
+	now := l.clock().Now()
+	if l.BlockTime != nil {
+		t, ok, err := l.BlockTime(high, parent)
+		if err != nil {
+			return Block{}, Propose{}, err
+		}
+		if ok {
+			now = t
+		}
+	}
+	if !now.After(parent.Time) {
+		now = parent.Time.Add(voteTimeStep) // followers reject blocks not after their parent
+	}
 	b := Block{
-		Height: height + 1, View: view, Parent: high.H,
-		Payload: payload, Proposer: l.ID, Time: time.Now(),
+		Height: height, View: view, Parent: high.H,
+		Payload: payload, Proposer: l.ID, Time: now,
+		ValSetHash: valSet, NextValSet: nextValSet, ParentSigners: high.Signers,
+	}
+	prop := Propose{Block: b, HighCert: high, HighDouble: l.Safety.HighestDouble(), TC: l.TC}
+	if l.Sign != nil {
+		prop.Sig = l.Sign(ProposalSignBytes(l.ChainID, b))
 	}
-	prop := Propose{Block: b, HighCert: high, HighDouble: l.Safety.HighestDouble()}
 	return b, prop, l.Net.BroadcastPropose(ctx, prop)
 }
@@ -3,5 +3,7 @@ package consensus
 type Propose struct {
 	Block      Block
 	HighCert   Certificate
-	HighDouble *DoubleCert // optional fast path
+	HighDouble *DoubleCert  // optional fast path
+	TC         *TimeoutCert // set when the previous view ended in a timeout
+	Sig        []byte       // proposer's signature over ProposalSignBytes(Block)
 }
@@ -2,11 +2,19 @@ package consensus
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/uhyunpark/hyperlicked/pkg/util"
 )
 
+// ErrViewTimeout is returned when a view ends without a QC or TC.
+var ErrViewTimeout = errors.New("view timeout")
+
+// maxBackoffExp caps the exponential backoff at (Ppc+Delta) << maxBackoffExp.
+const maxBackoffExp = 6
+
 type PacemakerTimers struct {
 	Ppc   time.Duration
 	Delta time.Duration
@@ -19,6 +27,11 @@ type Pacemaker struct {
 
 	// Channels for reactive view advancement (follower mode)
 	viewAdvanceCh chan View
+
+	// failedViews counts consecutive local timeouts since the last QC.
+	// Each failure doubles the view timeout (capped by maxBackoffExp).
+	mu          sync.Mutex
+	failedViews int
 }
 
 func NewPacemaker(timers PacemakerTimers, clock util.Clock, state *State) *Pacemaker {
@@ -30,20 +43,66 @@ func NewPacemaker(timers PacemakerTimers, clock util.Clock, state *State) *Pacem
 	}
 }
 
-// WaitForViewAdvance: Follower waits for prepare message to advance view
-// Returns when prepare for this view is received, or timeout
+// ViewTimeout returns how long to wait for progress in the current view:
+// (Ppc+Δ) doubled for every consecutive failed view.
+func (p *Pacemaker) ViewTimeout() time.Duration {
+	p.mu.Lock()
+	exp := p.failedViews
+	p.mu.Unlock()
+	if exp > maxBackoffExp {
+		exp = maxBackoffExp
+	}
+	return (p.Timers.Ppc + p.Timers.Delta) << exp
+}
+
+// OnViewFailed records a local timeout (grows the backoff).
+func (p *Pacemaker) OnViewFailed() {
+	p.mu.Lock()
+	p.failedViews++
+	p.mu.Unlock()
+}
+
+// OnQC records progress (a QC was observed) and resets the backoff.
+func (p *Pacemaker) OnQC() {
+	p.mu.Lock()
+	p.failedViews = 0
+	p.mu.Unlock()
+}
+
+// FailedViews returns the number of consecutive failed views.
+func (p *Pacemaker) FailedViews() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failedViews
+}
+
+// WithViewDeadline returns a context cancelled once the current view timeout
+// elapses on the pacemaker clock. Used by the leader while collecting votes.
+func (p *Pacemaker) WithViewDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	vctx, cancel := context.WithCancel(ctx)
+	deadline := p.Clock.After(p.ViewTimeout())
+	go func() {
+		select {
+		case <-deadline:
+			cancel()
+		case <-vctx.Done():
+		}
+	}()
+	return vctx, cancel
+}
+
+// WaitForViewAdvance: Follower waits for a QC or TC to advance the view.
+// Returns nil once a certificate for targetView (or later) is signalled, or
+// ErrViewTimeout if the (backed-off) view timer fires first.
 func (p *Pacemaker) WaitForViewAdvance(ctx context.Context, targetView View) error {
-	timeout := p.Timers.Ppc + p.Timers.Delta
-	deadline := p.Clock.After(timeout)
+	deadline := p.Clock.After(p.ViewTimeout())
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-deadline:
-			// Timeout: view-change (not implemented yet, for now just advance)
-			p.State.View = targetView
-			return nil
+			return ErrViewTimeout
 		case v := <-p.viewAdvanceCh:
 			if v >= targetView {
 				p.State.View = v
@@ -53,7 +112,7 @@ func (p *Pacemaker) WaitForViewAdvance(ctx context.Context, targetView View) err
 	}
 }
 
-// SignalViewAdvance: Called by onPrepare to signal view advancement
+// SignalViewAdvance: Called by onPrepare (QC) or onTimeoutCert (TC) to signal view advancement
 func (p *Pacemaker) SignalViewAdvance(v View) {
 	select {
 	case p.viewAdvanceCh <- v:
@@ -65,22 +124,68 @@ func (p *Pacemaker) SignalViewAdvance(v View) {
 type Handlers struct {
 	OnPropose func(ctx context.Context, p Propose)
 	OnPrepare func(ctx context.Context, cert Certificate, blk Block)
+	OnTimeout func(ctx context.Context, t Timeout)
+
+	// VerifyVote is consulted by the network before a received vote is stored
+	// for CollectVotes; votes failing it never count toward a quorum.
+	VerifyVote func(v Vote) bool
+
+	// ServeBlocks and ServeCert answer catch-up requests from peers (see SyncNetwork).
+	ServeBlocks func(from, to Height) BlockRange
+	ServeCert   func(v View) (Certificate, bool)
+
+	// OnEvidence receives evidence gossiped by peers (see EvidenceNetwork).
+	OnEvidence func(ctx context.Context, ev Evidence)
 }
 
 type Network interface {
 	// outbound
 	BroadcastPropose(ctx context.Context, p Propose) error
 	BroadcastPrepare(ctx context.Context, cert Certificate) error
+	BroadcastTimeout(ctx context.Context, t Timeout) error
 	SendVote(ctx context.Context, to NodeID, v Vote) error
 
-	// leader-side collections
+	// leader-side collections (distinct senders, verified via Handlers.VerifyVote)
 	CollectVotes(ctx context.Context, view View, h Hash, need int) ([]Vote, error)
 
 	// inbound handler registration
 	SetHandlers(h Handlers)
 }
 
+// SyncNetwork is implemented by networks that can fetch missed blocks from
+// peers for catch-up. Requests are answered by the peers' Handlers.ServeBlocks
+// and Handlers.ServeCert.
+type SyncNetwork interface {
+	// GetBlocksByHeight returns a peer's certified chain blocks with
+	// from <= Height <= to, oldest first, plus that peer's committed head.
+	GetBlocksByHeight(ctx context.Context, from, to Height) (BlockRange, error)
+	// GetCertByView returns the QC formed in view v, from any peer that has it.
+	GetCertByView(ctx context.Context, v View) (Certificate, error)
+}
+
+// BlockRange is a response to GetBlocksByHeight.
+type BlockRange struct {
+	Blocks    []Block // oldest first, each extending the previous one
+	Committed Hash    // responder's committed head
+}
+
+// AppHook is the application as the engine sees it. Blocks are executed
+// before they are voted on, when they may still be orphaned, so execution
+// is speculative: Execute applies a block to a fork of its parent's state
+// (an overlay), and only Commit makes that state canonical.
 type AppHook interface {
-	PreparePayload(parent Block, next Height) []byte
-	OnCommit(committed Block) Hash // Returns AppHash after executing block
+	// PreparePayload builds the next block's payload; it must include the
+	// given evidence (see EvidenceTx) so the app can punish the offenders.
+	PreparePayload(parent Block, next Height, evidence []Evidence) []byte
+	// Execute applies b on top of the state after its parent (the
+	// committed state, or a block executed before) and returns the AppHash.
+	// Nothing is canonical until Commit. It fails if the parent's state is
+	// gone (never executed, or orphaned by a commit).
+	Execute(b Block) (Hash, error)
+	// Commit makes the state after executed block b canonical, together
+	// with its executed ancestors, and discards the executions that do not
+	// extend b.
+	Commit(b Block) error
+	// Rollback discards the execution of block h and its descendants.
+	Rollback(h Hash)
 }
@@ -0,0 +1,242 @@
+package consensus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Block payload encoding (version 1): a list of entries, each an arbitrary
+// byte string (empty and NUL-containing transactions included):
+//
+//	version (1 byte, PayloadVersion) || uvarint(count) ||
+//	count × (uvarint(len) || entry)
+//
+// The empty payload (no bytes) is the empty list. Encodings are canonical:
+// DecodePayload rejects anything EncodePayload would not have produced
+// (unknown version, overlong varints, trailing bytes), so a list has one
+// payload. Evidence travels as entries prefixed with evidenceTxPrefix and
+// is not a transaction.
+//
+// Headers commit to the payload by PayloadHash, the Merkle root of the
+// entries' hashes (TxHash), so a node that knows the header and the entry
+// hashes can check them before it has the entries (p2p proposals carry
+// only the hashes; see p2p.ProposalWire).
+
+var (
+	ErrPayloadMismatch  = errors.New("payload: does not match the header's PayloadHash")
+	ErrMalformedPayload = errors.New("payload: malformed encoding")
+)
+
+// PayloadVersion is the first byte of a non-empty payload.
+const PayloadVersion byte = 1
+
+const evidenceTxPrefix = "evidence:"
+
+// PayloadTxs splits a block payload into its transactions (evidence entries
+// excluded).
+func PayloadTxs(p []byte) [][]byte {
+	var out [][]byte
+	for _, e := range payloadEntries(p) {
+		if !IsEvidenceTx(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// IsEvidenceTx reports whether a payload entry carries evidence.
+func IsEvidenceTx(tx []byte) bool { return bytes.HasPrefix(tx, []byte(evidenceTxPrefix)) }
+
+// EvidenceTx encodes ev as a payload entry.
+func EvidenceTx(ev Evidence) []byte {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ev); err != nil {
+		panic(fmt.Sprintf("encode evidence: %v", err)) // plain data, cannot fail
+	}
+	out := make([]byte, len(evidenceTxPrefix)+hex.EncodedLen(buf.Len()))
+	copy(out, evidenceTxPrefix)
+	hex.Encode(out[len(evidenceTxPrefix):], buf.Bytes())
+	return out
+}
+
+// ParseEvidenceTx decodes an entry produced by EvidenceTx.
+func ParseEvidenceTx(tx []byte) (Evidence, error) {
+	var ev Evidence
+	if !IsEvidenceTx(tx) {
+		return ev, fmt.Errorf("%w: not an evidence entry", ErrInvalidEvidence)
+	}
+	raw := make([]byte, hex.DecodedLen(len(tx)-len(evidenceTxPrefix)))
+	if _, err := hex.Decode(raw, tx[len(evidenceTxPrefix):]); err != nil {
+		return ev, fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+	}
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&ev); err != nil {
+		return ev, fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+	}
+	return ev, nil
+}
+
+// PayloadEvidence returns the evidence a block payload carries.
+func PayloadEvidence(p []byte) ([]Evidence, error) {
+	entries, err := DecodePayload(p)
+	if err != nil {
+		return nil, err
+	}
+	var out []Evidence
+	for _, e := range entries {
+		if !IsEvidenceTx(e) {
+			continue
+		}
+		ev, err := ParseEvidenceTx(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// PayloadEntries splits a payload into its entries (transactions and
+// evidence), in order. A malformed payload has none; validate proposals
+// with DecodePayload.
+func PayloadEntries(p []byte) [][]byte { return payloadEntries(p) }
+
+// EncodePayload encodes entries as a payload (nil for none).
+func EncodePayload(entries [][]byte) []byte {
+	if len(entries) == 0 {
+		return nil
+	}
+	n := 1 + binary.MaxVarintLen64
+	for _, e := range entries {
+		n += binary.MaxVarintLen64 + len(e)
+	}
+	out := make([]byte, 0, n)
+	out = append(out, PayloadVersion)
+	out = binary.AppendUvarint(out, uint64(len(entries)))
+	for _, e := range entries {
+		out = binary.AppendUvarint(out, uint64(len(e)))
+		out = append(out, e...)
+	}
+	return out
+}
+
+// DecodePayload decodes a payload into its entries. It fails with
+// ErrMalformedPayload unless p is the canonical encoding of a list.
+func DecodePayload(p []byte) ([][]byte, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	if p[0] != PayloadVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrMalformedPayload, p[0])
+	}
+	rest := p[1:]
+	count, n := binary.Uvarint(rest)
+	// every entry takes at least its length byte
+	if n <= 0 || count == 0 || count > uint64(len(rest)-n) {
+		return nil, fmt.Errorf("%w: bad entry count", ErrMalformedPayload)
+	}
+	rest = rest[n:]
+	out := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		l, n := binary.Uvarint(rest)
+		if n <= 0 || l > uint64(len(rest)-n) {
+			return nil, fmt.Errorf("%w: entry %d truncated", ErrMalformedPayload, i)
+		}
+		out = append(out, append([]byte{}, rest[n:n+int(l)]...))
+		rest = rest[n+int(l):]
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformedPayload, len(rest))
+	}
+	if !bytes.Equal(EncodePayload(out), p) {
+		return nil, fmt.Errorf("%w: non-canonical varint", ErrMalformedPayload)
+	}
+	return out, nil
+}
+
+// PayloadHash returns the Merkle root of p's entry hashes (zero for an
+// empty payload). A malformed payload hashes to sha256(0xff || p) instead,
+// so it cannot share a block hash with a well-formed one.
+func PayloadHash(p []byte) Hash {
+	entries, err := DecodePayload(p)
+	if err != nil {
+		return sha256.Sum256(append([]byte{0xff}, p...))
+	}
+	leaves := make([]Hash, len(entries))
+	for i, e := range entries {
+		leaves[i] = TxHash(e)
+	}
+	return MerkleRoot(leaves)
+}
+
+// MerkleRoot returns the root of a binary Merkle tree over leaves: inner
+// nodes are sha256(0x01 || left || right), the left subtree holding the
+// largest power of two below the leaf count, and the root binds the count
+// (sha256(u64 count || tree root)). Zero for no leaves.
+func MerkleRoot(leaves []Hash) Hash {
+	if len(leaves) == 0 {
+		return Hash{}
+	}
+	top := merkleNode(leaves)
+	var buf [8 + len(top)]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(len(leaves)))
+	copy(buf[8:], top[:])
+	return sha256.Sum256(buf[:])
+}
+
+func merkleNode(leaves []Hash) Hash {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	l, r := merkleNode(leaves[:k]), merkleNode(leaves[k:])
+	var buf [1 + 2*len(l)]byte
+	buf[0] = 0x01
+	copy(buf[1:], l[:])
+	copy(buf[1+len(l):], r[:])
+	return sha256.Sum256(buf[:])
+}
+
+// WithPayload rebuilds the block of header h from its payload entries. It
+// fails with ErrPayloadMismatch unless they are exactly the entries h
+// commits to.
+func (h BlockHeader) WithPayload(entries [][]byte) (Block, error) {
+	hashes := make([]Hash, len(entries))
+	for i, e := range entries {
+		hashes[i] = TxHash(e)
+	}
+	if MerkleRoot(hashes) != h.PayloadHash {
+		return Block{}, ErrPayloadMismatch
+	}
+	payload := EncodePayload(entries)
+	return Block{
+		Height:        h.Height,
+		View:          h.View,
+		Parent:        h.Parent,
+		Payload:       payload,
+		Proposer:      h.Proposer,
+		Time:          h.Time,
+		ValSetHash:    h.ValSetHash,
+		NextValSet:    h.NextValSet,
+		ParentSigners: h.ParentSigners,
+	}, nil
+}
+
+// payloadEntries decodes a payload, with no entries if it is malformed.
+func payloadEntries(p []byte) [][]byte {
+	entries, err := DecodePayload(p)
+	if err != nil {
+		return nil
+	}
+	return entries
+}
+
+// TxHash identifies a transaction: sha256 of its raw bytes.
+func TxHash(tx []byte) Hash { return sha256.Sum256(tx) }
@@ -0,0 +1,90 @@
+package consensus
+
+import (
+	"errors"
+	"sync"
+)
+
+// Proposal validation
+//
+// Before executing a proposed block, a follower runs it through the checks
+// below; a block that fails any of them gets no vote. The consensus checks
+// (payload encoding, evidence, validator set) come first, then the app's
+// ProcessProposal (signatures, size limits, tx decoding, timestamps).
+// ProposalMetrics counts the outcomes, rejections by check.
+
+var ErrProposalRejected = errors.New("proposal: rejected by the app")
+
+// ProposalProcessor is implemented by AppHooks whose app validates proposed
+// blocks before they are executed (abci.Bridge). ProcessProposal returns an
+// error wrapping ErrProposalRejected to withhold the vote; it must not
+// change state and must decide the same way on every honest validator.
+type ProposalProcessor interface {
+	ProcessProposal(parent, b Block) error
+}
+
+// Proposal rejection reasons (ProposalMetrics.Rejected keys)
+const (
+	RejectPayload  = "payload"  // payload does not decode
+	RejectEvidence = "evidence" // evidence that does not verify
+	RejectValSet   = "valset"   // wrong ValSetHash / NextValSet
+	RejectTime     = "time"     // block time not the BFT time (bfttime.go)
+	RejectApp      = "app"      // ProposalProcessor said no
+)
+
+// ProposalMetrics counts the proposals a validator checked before voting.
+type ProposalMetrics struct {
+	Accepted uint64
+	Rejected map[string]uint64 // by reason (Reject*)
+}
+
+// RejectedTotal sums the rejections of every reason.
+func (m ProposalMetrics) RejectedTotal() uint64 {
+	var n uint64
+	for _, c := range m.Rejected {
+		n += c
+	}
+	return n
+}
+
+type proposalCounter struct {
+	mu       sync.Mutex
+	accepted uint64
+	rejected map[string]uint64
+}
+
+func (c *proposalCounter) accept() {
+	c.mu.Lock()
+	c.accepted++
+	c.mu.Unlock()
+}
+
+func (c *proposalCounter) reject(reason string) {
+	c.mu.Lock()
+	if c.rejected == nil {
+		c.rejected = make(map[string]uint64)
+	}
+	c.rejected[reason]++
+	c.mu.Unlock()
+}
+
+// ProposalMetrics returns how many proposals this validator accepted (went
+// on to execute) and rejected so far.
+func (e *Engine) ProposalMetrics() ProposalMetrics {
+	e.proposals.mu.Lock()
+	defer e.proposals.mu.Unlock()
+	m := ProposalMetrics{Accepted: e.proposals.accepted, Rejected: make(map[string]uint64, len(e.proposals.rejected))}
+	for r, n := range e.proposals.rejected {
+		m.Rejected[r] = n
+	}
+	return m
+}
+
+// processProposal asks the app, if it validates proposals, about b.
+func (e *Engine) processProposal(parent, b Block) error {
+	pp, ok := e.App.(ProposalProcessor)
+	if !ok {
+		return nil
+	}
+	return pp.ProcessProposal(parent, b)
+}
@@ -0,0 +1,86 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+var (
+	ErrCertNoQuorum   = errors.New("certificate: not enough signers")
+	ErrCertBadSigner  = errors.New("certificate: unknown signer")
+	ErrCertBadSig     = errors.New("certificate: aggregate signature invalid")
+	ErrCertBadGenesis = errors.New("certificate: malformed genesis certificate")
+	ErrCertBadTimes   = errors.New("certificate: vote times do not match the signers")
+)
+
+// SignerBitmap encodes signers as a bitmap over the ordered validator list:
+// bit i (LSB-first within each byte) is set iff validators[i] signed.
+// Signers that are not in the list are ignored.
+func SignerBitmap(validators []NodeID, signers []NodeID) []byte {
+	bm := make([]byte, (len(validators)+7)/8)
+	for _, s := range signers {
+		for i, id := range validators {
+			if id == s {
+				bm[i/8] |= 1 << (uint(i) % 8)
+				break
+			}
+		}
+	}
+	return bm
+}
+
+// CertSigners decodes a signer bitmap against the ordered validator list.
+// Returns ErrCertBadSigner if a bit beyond the list is set.
+func CertSigners(validators []NodeID, bitmap []byte) ([]NodeID, error) {
+	var out []NodeID
+	for i := 0; i < len(bitmap)*8; i++ {
+		if bitmap[i/8]&(1<<(uint(i)%8)) == 0 {
+			continue
+		}
+		if i >= len(validators) {
+			return nil, fmt.Errorf("%w: bit %d", ErrCertBadSigner, i)
+		}
+		out = append(out, validators[i])
+	}
+	return out, nil
+}
+
+// VerifyCertificate checks that c.Sig is a valid BLS aggregate over msg by
+// validators named in c.Signers (a bitmap over vs) holding a quorum of vs's
+// voting power; with c.Times, each signer signed msg and its vote time.
+func VerifyCertificate(c Certificate, msg []byte, vs *ValidatorSet, pubKeys map[NodeID]*crypto.BLSPubKey) error {
+	signers, err := CertSigners(vs.IDs(), c.Signers)
+	if err != nil {
+		return err
+	}
+	if have, need := vs.VotingPower(signers), vs.QuorumPower(); have < need {
+		return fmt.Errorf("%w: power %d, need %d", ErrCertNoQuorum, have, need)
+	}
+	if len(c.Times) > 0 && len(c.Times) != len(signers) {
+		return fmt.Errorf("%w: %d times, %d signers", ErrCertBadTimes, len(c.Times), len(signers))
+	}
+	pks := make([]*crypto.BLSPubKey, 0, len(signers))
+	for _, id := range signers {
+		pk, ok := pubKeys[id]
+		if !ok {
+			return fmt.Errorf("%w: no key for %s", ErrCertBadSigner, id)
+		}
+		pks = append(pks, pk)
+	}
+	if len(c.Times) == 0 {
+		if !crypto.VerifyAggregateSameMsg(pks, msg, c.Sig) {
+			return ErrCertBadSig
+		}
+		return nil
+	}
+	msgs := make([][]byte, len(c.Times))
+	for i, t := range c.Times {
+		msgs[i] = VoteTimeSignBytes(msg, t)
+	}
+	if !crypto.VerifyAggregate(pks, msgs, c.Sig) {
+		return ErrCertBadSig
+	}
+	return nil
+}
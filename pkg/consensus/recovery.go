@@ -0,0 +1,164 @@
+package consensus
+
+import "fmt"
+
+// Recover rebuilds State and Safety after a restart, from the persisted safety
+// state (Safety.Store), the WAL and the block store. Call it once, before Run.
+//
+// The node resumes after the highest view it voted/timed out in or saw a QC
+// for, so it can never vote twice in a view it voted in before the crash.
+// Application state is not touched: the app restores its own state.
+func (e *Engine) Recover() error {
+	if e.Safety.Store != nil {
+		ss, ok, err := e.Safety.Store.LoadSafety()
+		if err != nil {
+			return fmt.Errorf("recover: %w", err)
+		}
+		if ok {
+			e.Safety.Restore(ss)
+		}
+	}
+
+	if e.WAL != nil {
+		if err := e.replayWAL(); err != nil {
+			return fmt.Errorf("recover: %w", err)
+		}
+	}
+
+	if e.Store != nil {
+		if h, ok := e.Store.GetCommitted(); ok {
+			b, ok := e.Store.GetBlock(h)
+			if !ok {
+				return fmt.Errorf("recover: committed block %s missing from store", h)
+			}
+			if b.Height > e.State.Height {
+				e.State.Height = b.Height
+			}
+			// Committed blocks are locked on, as in replayWAL; the safety
+			// state was persisted at the last vote and may predate the commit
+			if cert, ok := e.Store.GetCert(b.View); ok && cert.H == h {
+				e.Safety.UpdateLock(cert, b)
+			}
+			// The app restores its own state up to the committed head
+			e.sync.execMu.Lock()
+			e.sync.markExecuted(b)
+			e.sync.setAppCommitted(b)
+			e.sync.execMu.Unlock()
+		}
+		e.loadValidatorSets()
+		e.reexecuteUncommitted()
+		// As leader we extend HighCert; make its block known to Safety again
+		high := e.Safety.HighestCert()
+		if b, ok := e.Store.GetBlock(high.H); ok {
+			e.Safety.OnPrepare(high, b)
+		}
+	}
+
+	view := e.Safety.LastVoted()
+	if hv := e.Safety.HighestCert().View; hv > view {
+		view = hv
+	}
+	if view > e.State.View {
+		e.State.View = view
+	}
+
+	if e.Logger != nil {
+		e.Logger.Infow("consensus_recovered",
+			"height", e.State.Height,
+			"view", e.State.View,
+			"last_voted", e.Safety.LastVoted(),
+			"high_cert_view", e.Safety.HighestCert().View)
+	}
+	return nil
+}
+
+// replayWAL re-applies every WAL record in order, reproducing the blocks,
+// certificates, lock, last voted view and height the node had when it stopped.
+func (e *Engine) replayWAL() error {
+	var n int
+	certs := make(map[Hash]Certificate)   // QC per block hash, for the lock
+	justify := make(map[Hash]Certificate) // proposal HighCert per block hash
+	err := e.WAL.Replay(func(r Record) {
+		n++
+		switch r.Type {
+		case RecordProposal:
+			e.Safety.AddBlock(r.Proposal.Block)
+			justify[HashOfBlock(r.Proposal.Block)] = r.Proposal.HighCert
+			if e.Store != nil {
+				e.Store.SaveBlock(r.Proposal.Block)
+			}
+		case RecordVote:
+			e.Safety.Restore(SafetyState{LastVoted: r.Vote.View})
+			// Same lock update as the live vote path (onPropose)
+			if hc, ok := justify[r.Vote.H]; ok {
+				parent, _ := e.Safety.BlockByHash(hc.H)
+				e.Safety.UpdateLock(hc, parent)
+			}
+		case RecordTimeout:
+			e.Safety.Restore(SafetyState{LastVoted: r.Timeout.View})
+		case RecordCert:
+			certs[r.Cert.H] = *r.Cert
+			blk, _ := e.Safety.BlockByHash(r.Cert.H)
+			if e.Store != nil {
+				e.Store.SaveCert(*r.Cert)
+			}
+			e.Safety.OnPrepare(*r.Cert, blk)
+		case RecordCommit:
+			c := r.Commit
+			blk, ok := e.Safety.BlockByHash(c.H)
+			if !ok {
+				return
+			}
+			if blk.AppHash == (Hash{}) {
+				blk.AppHash = c.AppHash
+			}
+			// Committed blocks are locked on (as by the DoubleCert that committed them)
+			if cert, ok := certs[c.H]; ok {
+				e.Safety.UpdateLock(cert, blk)
+			}
+			if e.Store != nil {
+				e.Store.SaveBlock(blk)
+				e.Store.SetCommitted(c.H)
+			}
+			e.State.Height = c.Height
+		case RecordValidatorUpdates:
+			u := r.Updates
+			e.recordValidatorUpdates(u.H, u.Height, u.Updates)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("replay wal: %w", err)
+	}
+	if e.Logger != nil {
+		e.Logger.Infow("wal_replayed", "records", n, "height", e.State.Height)
+	}
+	return nil
+}
+
+// reexecuteUncommitted applies the certified blocks between the executed
+// committed head and HighCert, so the next proposal (which extends HighCert)
+// finds its parent executed. Blocks not linked to an executed block are left
+// to block sync.
+func (e *Engine) reexecuteUncommitted() {
+	var pending []Block
+	h := e.Safety.HighestCert().H
+	e.sync.execMu.Lock()
+	defer e.sync.execMu.Unlock()
+	for !e.sync.isExecuted(h) {
+		b, ok := e.Store.GetBlock(h)
+		if !ok {
+			return
+		}
+		pending = append(pending, b)
+		h = b.Parent
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		if _, err := e.execute(pending[i]); err != nil {
+			if e.Logger != nil {
+				e.Logger.Warnw("reexecute_failed", "height", pending[i].Height, "err", err)
+			}
+			return
+		}
+		e.sync.markExecuted(pending[i])
+	}
+}
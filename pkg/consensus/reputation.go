@@ -0,0 +1,212 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// Reputation-based leader election (after Diem's LeaderReputation)
+//
+// Round-robin keeps handing views to a crashed validator: with N validators
+// one view in N times out. ReputationElector instead draws the leader of
+// view v at random, weighted by voting power, and penalises validators that
+// have neither proposed nor voted recently (Block.ParentSigners records the
+// voters of every committed block's parent).
+//
+// Everything it uses comes from the committed chain, so validators that
+// committed the same blocks elect the same leaders:
+//
+//	anchor: the highest committed block whose view is at most v-Lag
+//	window: the Window committed blocks ending at the anchor (fewer early on)
+//	set:    the validator set of the height after the anchor
+//	active: proposed a block of the window or signed the QC of one (or
+//	        cannot be judged, see below)
+//	weight: Power*InactivePenalty if active, Power if not
+//	leader: sha256(anchor hash || v) picks a point in the summed weights
+//
+// Lag leaves room for commits to reach every validator before a view that
+// depends on them starts (a block commits about two views after its own).
+// A validator is only judged if it was already a member when the window
+// began and if its power share makes at least two proposals in the window
+// likely; a new or tiny validator is not penalised for lack of turns. A penalised validator that is back online votes again
+// and is active once a window block records its vote.
+//
+// Until the chain has an anchor (the first Lag views) Fallback elects.
+
+const (
+	DefaultReputationLag   = View(10)
+	DefaultInactivePenalty = 100
+	// MaxInactivePenalty keeps the weights of a set within a uint64
+	MaxInactivePenalty = math.MaxUint64 / MaxTotalPower
+
+	reputationWindowPerValidator = 10 // default window: blocks per validator
+	reputationMinExpected        = 2  // proposals a judged validator should have made
+	reputationCacheSize          = 8
+)
+
+// ReputationElector is a LeaderElector for Engine; create it with
+// NewReputationElector. Zero fields take their defaults.
+type ReputationElector struct {
+	// Window is the number of committed blocks looked at (0 = 10 per validator)
+	Window int
+	// Lag is the view distance between the anchor and the elected view
+	Lag View
+	// InactivePenalty is how many times less likely an inactive validator is
+	// elected than an active one of the same power (at most MaxInactivePenalty)
+	InactivePenalty uint64
+	// Fallback elects while there is no anchor
+	Fallback LeaderElector
+
+	e *Engine
+
+	mu      sync.Mutex
+	weights map[Hash][]Validator // per anchor: candidates with their weight
+}
+
+// NewReputationElector returns a reputation elector for e. It reads e.Store
+// (the committed chain) when electing, so the store may be set afterwards;
+// Fallback rotates round-robin over the active validators.
+func NewReputationElector(e *Engine) *ReputationElector {
+	return &ReputationElector{
+		Fallback: ValidatorSetElector{Set: e.ActiveValidators},
+		e:        e,
+		weights:  make(map[Hash][]Validator),
+	}
+}
+
+func (r *ReputationElector) LeaderOf(v View) NodeID {
+	anchor, ok := r.anchor(v)
+	if !ok {
+		return r.Fallback.LeaderOf(v)
+	}
+	h := HashOfBlock(anchor)
+	cands := r.candidates(h, anchor)
+	if len(cands) == 0 {
+		return r.Fallback.LeaderOf(v)
+	}
+	var total uint64
+	for _, c := range cands {
+		total += c.Power
+	}
+	seed := sha256.New()
+	seed.Write(h[:])
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+	seed.Write(buf[:])
+	point := binary.BigEndian.Uint64(seed.Sum(nil)[:8]) % total
+	for _, c := range cands {
+		if point < c.Power {
+			return c.ID
+		}
+		point -= c.Power
+	}
+	return cands[len(cands)-1].ID // unreachable
+}
+
+// Weights returns the election weight of every candidate for view v, or nil
+// before the first anchor.
+func (r *ReputationElector) Weights(v View) []Validator {
+	anchor, ok := r.anchor(v)
+	if !ok {
+		return nil
+	}
+	return append([]Validator(nil), r.candidates(HashOfBlock(anchor), anchor)...)
+}
+
+func (r *ReputationElector) lag() View {
+	if r.Lag == 0 {
+		return DefaultReputationLag
+	}
+	return r.Lag
+}
+
+func (r *ReputationElector) penalty() uint64 {
+	switch {
+	case r.InactivePenalty == 0:
+		return DefaultInactivePenalty
+	case r.InactivePenalty > MaxInactivePenalty:
+		return MaxInactivePenalty
+	}
+	return r.InactivePenalty
+}
+
+// anchor returns the highest committed block with View <= v-Lag.
+func (r *ReputationElector) anchor(v View) (Block, bool) {
+	store := r.e.Store
+	if store == nil || v <= r.lag() {
+		return Block{}, false
+	}
+	b, ok := store.LatestCommitted()
+	for ok && b.View+r.lag() > v {
+		if b.Height <= 1 {
+			return Block{}, false
+		}
+		b, ok = store.GetBlockByHeight(b.Height - 1)
+	}
+	return b, ok
+}
+
+// candidates returns (cached per anchor) the validators of the height after
+// anchor with their election weight.
+func (r *ReputationElector) candidates(h Hash, anchor Block) []Validator {
+	r.mu.Lock()
+	cands, ok := r.weights[h]
+	r.mu.Unlock()
+	if ok {
+		return cands
+	}
+
+	vs, err := r.e.childValidatorSet(anchor, anchor.Height+1)
+	if err != nil {
+		return nil
+	}
+	window := r.Window
+	if window <= 0 {
+		window = reputationWindowPerValidator * vs.Size()
+	}
+	cands = make([]Validator, vs.Size())
+	copy(cands, vs.Validators)
+
+	if Height(window) > anchor.Height {
+		window = int(anchor.Height)
+	}
+
+	from := anchor.Height - Height(window) + 1
+	active := make(map[NodeID]bool)
+	var before *ValidatorSet // the set when the window began
+	var prev Block
+	r.e.Store.IterateCommitted(from, anchor.Height, func(b Block) bool {
+		active[b.Proposer] = true
+		if b.Height == from {
+			before, _ = r.e.ValidatorSetOf(b)
+		} else if pvs, err := r.e.ValidatorSetOf(prev); err == nil {
+			// b records who voted for prev (certified by prev's set)
+			voters, _ := CertSigners(pvs.IDs(), b.ParentSigners)
+			for _, id := range voters {
+				active[id] = true
+			}
+		}
+		prev = b
+		return true
+	})
+	total := vs.TotalPower()
+	for i, c := range cands {
+		// Expected proposals in the window: Power*window/total
+		hi, lo := bits.Mul64(c.Power, uint64(window))
+		judged := before != nil && before.Has(c.ID) && (hi > 0 || lo >= reputationMinExpected*total)
+		if !judged || active[c.ID] {
+			cands[i].Power *= r.penalty()
+		}
+	}
+
+	r.mu.Lock()
+	if len(r.weights) >= reputationCacheSize {
+		r.weights = make(map[Hash][]Validator)
+	}
+	r.weights[h] = cands
+	r.mu.Unlock()
+	return cands
+}
@@ -1,11 +1,26 @@
 package consensus
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 type Safety struct {
 	state  *State
 	blocks map[Hash]Block
 	mu     sync.RWMutex // Protects blocks map from concurrent access
+
+	// lastVoted is the highest view in which this node voted or timed out.
+	// Never send a second vote (or a vote after a timeout) in the same view.
+	lastVoted View
+
+	// highDouble is the highest DoubleCert observed (by C2.View); leaders
+	// attach it to proposals so replicas that missed a QC can commit.
+	highDouble *DoubleCert
+
+	// Store, if set, receives a durable snapshot in MarkVoted before any vote
+	// or timeout leaves the node (nil = in-memory only, lost on restart).
+	Store SafetyStore
 }
 
 func NewSafety(s *State) *Safety {
@@ -16,6 +31,8 @@ func NewSafety(s *State) *Safety {
 }
 
 func (s *Safety) HighestCert() Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.state.HighCert != nil {
 		return *s.state.HighCert
 	}
@@ -23,7 +40,29 @@ func (s *Safety) HighestCert() Certificate {
 	return Certificate{View: 0, H: HashOfBlock(s.state.Genesis), AppHash: Hash{}, Sig: nil}
 }
 
-func (s *Safety) HighestDouble() *DoubleCert { return nil }
+// HighestDouble returns the highest DoubleCert observed, or nil.
+func (s *Safety) HighestDouble() *DoubleCert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.highDouble == nil {
+		return nil
+	}
+	dc := *s.highDouble
+	return &dc
+}
+
+// OnDoubleCert records a verified DoubleCert: both QCs are observed and the
+// lock rises to C1 (every voter of C2 is locked on it already).
+func (s *Safety) OnDoubleCert(dc DoubleCert, b1, b2 Block) {
+	s.OnPrepare(dc.C1, b1)
+	s.OnPrepare(dc.C2, b2)
+	s.UpdateLock(dc.C1, b1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.highDouble == nil || dc.C2.View > s.highDouble.C2.View {
+		s.highDouble = &dc
+	}
+}
 
 // OnPrepare: 새 Prepare QC 관찰 시 HighestQC 갱신, 블록(있으면) 보관
 func (s *Safety) OnPrepare(cert Certificate, b Block) {
@@ -39,22 +78,120 @@ func (s *Safety) OnPrepare(cert Certificate, b Block) {
 	}
 }
 
+// UpdateLock raises the lock to cert (certifying b). The lock never moves
+// back, and HighCert is only raised (a lock is always a QC we have seen).
 func (s *Safety) UpdateLock(cert Certificate, b Block) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.state.HighCert = &cert
-	s.blocks[HashOfBlock(b)] = b
-	s.state.Locked = &Locked{Block: b, Cert: cert}
+	if b.Height > 0 || b.Proposer != "" {
+		s.blocks[HashOfBlock(b)] = b
+	}
+	if s.state.HighCert == nil || cert.View > s.state.HighCert.View {
+		c := cert
+		s.state.HighCert = &c
+	}
+	if s.state.Locked == nil || cert.View > s.state.Locked.Cert.View {
+		s.state.Locked = &Locked{Block: b, Cert: cert}
+	}
 }
 
+// LockedView returns the view of the locked QC (0 if unlocked).
+func (s *Safety) LockedView() View {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.state.Locked == nil {
+		return 0
+	}
+	return s.state.Locked.Cert.View
+}
+
+// CanVote applies the locking rule: the proposal's justify (HighCert) must be
+// at least as high as the lock, and after a TC at least as high as the
+// highest QC reported in the TC.
 func (s *Safety) CanVote(p Propose) bool {
+	// After a TC the proposal must extend the highest cert reported in it
+	if p.TC != nil && p.HighCert.View < p.TC.HighCert().View {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.state.Locked == nil {
 		return true
 	}
 	return p.HighCert.View >= s.state.Locked.Cert.View
 }
 
+// CanVoteInView reports whether this node may still vote in view v.
+func (s *Safety) CanVoteInView(v View) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return v > s.lastVoted
+}
+
+// MarkVoted records that a vote or timeout is about to be sent for view v and
+// persists the safety state. The caller must not send anything if it fails.
+//
+// Lock/HighCert changes between votes are not persisted on their own: until
+// the next vote nothing depends on them, and MarkVoted snapshots them then.
+func (s *Safety) MarkVoted(v View) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v > s.lastVoted {
+		s.lastVoted = v
+	}
+	if s.Store == nil {
+		return nil
+	}
+	if err := s.Store.SaveSafety(s.snapshotLocked()); err != nil {
+		return fmt.Errorf("persist safety state: %w", err)
+	}
+	return nil
+}
+
+// Snapshot returns the current safety state.
+func (s *Safety) Snapshot() SafetyState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshotLocked()
+}
+
+func (s *Safety) snapshotLocked() SafetyState {
+	return SafetyState{LastVoted: s.lastVoted, Locked: s.state.Locked, HighCert: s.state.HighCert}
+}
+
+// Restore reinstates a persisted safety state (startup recovery).
+func (s *Safety) Restore(ss SafetyState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ss.LastVoted > s.lastVoted {
+		s.lastVoted = ss.LastVoted
+	}
+	if ss.Locked != nil {
+		s.state.Locked = ss.Locked
+		if ss.Locked.Block.Proposer != "" {
+			s.blocks[HashOfBlock(ss.Locked.Block)] = ss.Locked.Block
+		}
+	}
+	if ss.HighCert != nil {
+		s.state.HighCert = ss.HighCert
+	}
+}
+
+// LastVoted returns the highest view this node voted or timed out in.
+func (s *Safety) LastVoted() View {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastVoted
+}
+
+// AddBlock makes b known for BlockByHash (e.g. a proposal replayed from the WAL).
+func (s *Safety) AddBlock(b Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[HashOfBlock(b)] = b
+}
+
 func (s *Safety) BlockByHash(h Hash) (Block, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -64,7 +201,10 @@ func (s *Safety) BlockByHash(h Hash) (Block, bool) {
 }
 
 // FastPath: 직전 뷰 QC를 봤다면 다음 뷰에서 대기 생략 가능
+// (the leader of v holds the QC of v-1 and may propose immediately)
 func (s *Safety) FastPathReady(v View) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.state.HighCert == nil {
 		return false
 	}
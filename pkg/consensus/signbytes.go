@@ -0,0 +1,92 @@
+package consensus
+
+import "encoding/binary"
+
+// Domain tags separate the signed message spaces so a signature produced for
+// one message type can never be replayed as another.
+const (
+	voteDomain     = "hyperlicked/vote/v1"
+	timeoutDomain  = "hyperlicked/timeout/v1"
+	proposalDomain = "hyperlicked/proposal/v1"
+)
+
+// VoteSignBytes is the canonical message signed by a vote (and therefore the
+// message a QC's aggregate signature verifies against):
+//
+//	domain || u16 len(chainID) || chainID || u64 view || u64 height || blockHash || appHash
+//
+// Binding AppHash means a verified QC alone proves 2f+1 validators computed
+// that state root, which is what light clients rely on. A vote carrying a
+// timestamp signs these bytes followed by u64 unixNanos (VoteTimeSignBytes).
+func VoteSignBytes(chainID string, v View, h Height, blockHash, appHash Hash) []byte {
+	buf := make([]byte, 0, len(voteDomain)+2+len(chainID)+16+64)
+	buf = appendDomain(buf, voteDomain, chainID)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(v))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(h))
+	buf = append(buf, blockHash[:]...)
+	buf = append(buf, appHash[:]...)
+	return buf
+}
+
+// VoteTimeSignBytes appends a vote's timestamp to its VoteSignBytes msg
+// (0 = the vote has none: msg alone).
+func VoteTimeSignBytes(msg []byte, unixNanos int64) []byte {
+	if unixNanos == 0 {
+		return msg
+	}
+	return binary.BigEndian.AppendUint64(append([]byte(nil), msg...), uint64(unixNanos))
+}
+
+// SignBytes returns the message this vote's SigShare signs.
+func (v Vote) SignBytes(chainID string) []byte {
+	return VoteTimeSignBytes(VoteSignBytes(chainID, v.View, v.Height, v.H, v.AppHash), unixNanos(v.Time))
+}
+
+// SignBytes returns the message the certificate's aggregate signature signs,
+// before each signer's timestamp (see Certificate.Times).
+func (c Certificate) SignBytes(chainID string) []byte {
+	return VoteSignBytes(chainID, c.View, c.Height, c.H, c.AppHash)
+}
+
+// TimeoutSignBytes is the message signed by a timeout sender:
+//
+//	domain || u16 len(chainID) || chainID || u64 view || u64 high.View || u64 high.Height || high.H || high.AppHash
+//
+// Binding the whole HighCert, not just its view, keeps a relayer from
+// swapping in another certificate of the same view: a TC's HighCert is what
+// the next leader must extend.
+func TimeoutSignBytes(chainID string, v View, high Certificate) []byte {
+	buf := make([]byte, 0, len(timeoutDomain)+2+len(chainID)+24+64)
+	buf = appendDomain(buf, timeoutDomain, chainID)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(v))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(high.View))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(high.Height))
+	buf = append(buf, high.H[:]...)
+	buf = append(buf, high.AppHash[:]...)
+	return buf
+}
+
+// SignBytes returns the message this timeout's Sig signs.
+func (t Timeout) SignBytes(chainID string) []byte {
+	return TimeoutSignBytes(chainID, t.View, t.HighCert)
+}
+
+// ProposalSignBytes is the message a leader signs for its block:
+//
+//	domain || u16 len(chainID) || chainID || u64 view || u64 height || blockHash
+//
+// Two of these for one view with different block hashes prove equivocation.
+func ProposalSignBytes(chainID string, b Block) []byte {
+	buf := make([]byte, 0, len(proposalDomain)+2+len(chainID)+16+32)
+	buf = appendDomain(buf, proposalDomain, chainID)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(b.View))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(b.Height))
+	h := HashOfBlock(b)
+	return append(buf, h[:]...)
+}
+
+func appendDomain(buf []byte, domain, chainID string) []byte {
+	buf = append(buf, domain...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(chainID)))
+	return append(buf, chainID...)
+}
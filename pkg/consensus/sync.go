@@ -0,0 +1,287 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	maxSyncBatch    = 64   // blocks per GetBlocksByHeight request
+	maxServeWalk    = 4096 // parent links walked when serving a range
+	executedHistory = 4096 // executed-block entries kept before pruning
+)
+
+var ErrSyncAppHash = errors.New("sync: AppHash differs from certificate")
+
+// blockSync is the catch-up manager. A node that is asked to vote on a block
+// whose parent it never executed (it was offline, or missed the proposal) has
+// a gap: instead of voting on the wrong state it fetches the certified chain
+// from peers, checks every block against its QC, and executes the blocks in
+// order through the AppHook until it reaches the peers' HighCert.
+type blockSync struct {
+	mu      sync.Mutex
+	running bool
+
+	// execMu serialises block execution between the vote path and sync.
+	// executed holds the blocks whose effects are in the app state.
+	execMu   sync.Mutex
+	executed map[Hash]Height
+
+	// appCommitted is the last block passed to AppHook.Commit (guarded by
+	// execMu): the app's canonical state is the state after it.
+	appCommitted       Hash
+	appCommittedHeight Height
+}
+
+func newBlockSync(genesis Block) *blockSync {
+	h := HashOfBlock(genesis)
+	return &blockSync{executed: map[Hash]Height{h: 0}, appCommitted: h}
+}
+
+// isExecuted reports whether h has been applied to the app. Caller holds execMu.
+func (s *blockSync) isExecuted(h Hash) bool {
+	_, ok := s.executed[h]
+	return ok
+}
+
+// markExecuted records b as applied. Caller holds execMu.
+func (s *blockSync) markExecuted(b Block) {
+	s.executed[HashOfBlock(b)] = b.Height
+	if len(s.executed) <= executedHistory {
+		return
+	}
+	for h, ht := range s.executed {
+		if ht+executedHistory/2 < b.Height {
+			delete(s.executed, h)
+		}
+	}
+}
+
+// setAppCommitted records b as the app's canonical state and forgets the
+// executions below it (or beside it), which the app discarded. Caller holds
+// execMu.
+func (s *blockSync) setAppCommitted(b Block) {
+	h := HashOfBlock(b)
+	s.appCommitted, s.appCommittedHeight = h, b.Height
+	for eh, ht := range s.executed {
+		if ht < b.Height || (ht == b.Height && eh != h) {
+			delete(s.executed, eh)
+		}
+	}
+}
+
+// commitApp commits the app state as far as the committed chain has been
+// executed (see commitAppLocked).
+func (e *Engine) commitApp() {
+	e.sync.execMu.Lock()
+	defer e.sync.execMu.Unlock()
+	e.commitAppLocked()
+}
+
+// commitAppLocked passes the highest committed block the app has executed
+// to AppHook.Commit. A block can commit before it is executed here (its
+// DoubleCert arrived first, or it is being synced); it is app-committed once
+// executed. Caller holds execMu.
+func (e *Engine) commitAppLocked() {
+	if e.Store == nil {
+		return
+	}
+	head, ok := e.Store.GetCommitted()
+	if !ok {
+		return
+	}
+	for cur := head; cur != e.sync.appCommitted; {
+		b, ok := e.Store.GetBlock(cur)
+		if !ok || b.Height <= e.sync.appCommittedHeight {
+			return
+		}
+		if e.sync.isExecuted(cur) {
+			if err := e.App.Commit(b); err != nil {
+				if e.Logger != nil {
+					e.Logger.Errorw("app_commit_failed", "height", b.Height, "err", err)
+				}
+				return
+			}
+			e.sync.setAppCommitted(b)
+			return
+		}
+		cur = b.Parent
+	}
+}
+
+// Syncing reports whether a catch-up is in progress.
+func (e *Engine) Syncing() bool {
+	e.sync.mu.Lock()
+	defer e.sync.mu.Unlock()
+	return e.sync.running
+}
+
+// requestSync starts a catch-up in the background unless one is running or
+// the network cannot serve blocks.
+func (e *Engine) requestSync(ctx context.Context, reason string) {
+	sn, ok := e.Net.(SyncNetwork)
+	if !ok {
+		return
+	}
+	e.sync.mu.Lock()
+	if e.sync.running {
+		e.sync.mu.Unlock()
+		return
+	}
+	e.sync.running = true
+	e.sync.mu.Unlock()
+
+	if e.Logger != nil {
+		e.Logger.Infow("sync_start", "reason", reason, "height", e.State.Height)
+	}
+	go func() {
+		applied, err := e.runSync(ctx, sn)
+		e.sync.mu.Lock()
+		e.sync.running = false
+		e.sync.mu.Unlock()
+		if e.Logger != nil {
+			if err != nil {
+				e.Logger.Warnw("sync_failed", "applied", applied, "height", e.State.Height, "err", err)
+			} else {
+				e.Logger.Infow("sync_done", "applied", applied, "height", e.State.Height,
+					"high_cert_view", e.Safety.HighestCert().View)
+			}
+		}
+	}()
+}
+
+// runSync fetches and executes blocks until a round brings nothing new.
+func (e *Engine) runSync(ctx context.Context, sn SyncNetwork) (int, error) {
+	total := 0
+	from := e.executedTipHeight()
+	for {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		rng, err := sn.GetBlocksByHeight(ctx, from, from+maxSyncBatch)
+		if err != nil {
+			return total, fmt.Errorf("get blocks %d..%d: %w", from, from+maxSyncBatch, err)
+		}
+		n, err := e.applySyncRange(ctx, sn, rng)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+		from = e.executedTipHeight()
+	}
+
+	high := e.Safety.HighestCert()
+	if total > 0 {
+		e.PM.OnQC()
+		e.PM.SignalViewAdvance(high.View)
+	}
+	return total, nil
+}
+
+// applySyncRange executes every block of rng that extends an executed block,
+// after checking it against its QC. Returns the number of blocks applied.
+func (e *Engine) applySyncRange(ctx context.Context, sn SyncNetwork, rng BlockRange) (int, error) {
+	e.sync.execMu.Lock()
+	defer e.sync.execMu.Unlock()
+
+	applied := 0
+	for _, b := range rng.Blocks {
+		h := HashOfBlock(b)
+		if e.sync.isExecuted(h) || !e.sync.isExecuted(b.Parent) {
+			continue
+		}
+		cert, err := sn.GetCertByView(ctx, b.View)
+		if err != nil {
+			return applied, fmt.Errorf("get cert for view %d: %w", b.View, err)
+		}
+		if cert.H != h {
+			return applied, fmt.Errorf("cert for view %d certifies %s, not %s", b.View, cert.H, h)
+		}
+		if err := e.verifyCertFor(cert, b); err != nil {
+			return applied, fmt.Errorf("cert for view %d: %w", b.View, err)
+		}
+
+		appHash, err := e.execute(b)
+		if err != nil {
+			return applied, fmt.Errorf("execute view %d: %w", b.View, err)
+		}
+		if appHash != cert.AppHash {
+			e.App.Rollback(h)
+			return applied, fmt.Errorf("%w: view %d got 0x%x, cert 0x%x", ErrSyncAppHash, b.View, appHash[:8], cert.AppHash[:8])
+		}
+		e.sync.markExecuted(b)
+		b.AppHash = appHash
+		if e.Store != nil {
+			e.Store.SaveBlock(b)
+			e.Store.SaveCert(cert)
+		}
+		_ = e.walWrite(Record{Type: RecordCert, Cert: &cert})
+
+		e.Safety.OnPrepare(cert, b)
+		if h == rng.Committed && e.Store != nil {
+			// Committed by the peers' DoubleCert: lock and commit likewise
+			e.Safety.UpdateLock(cert, b)
+			if err := e.commit(ctx, b, cert); err != nil {
+				return applied, err
+			}
+		}
+		e.commitAppLocked()
+		applied++
+	}
+	return applied, nil
+}
+
+// executedTipHeight is the height to resume fetching from: the highest
+// executed block's height (inclusive: a fork may hold another block there).
+func (e *Engine) executedTipHeight() Height {
+	e.sync.execMu.Lock()
+	defer e.sync.execMu.Unlock()
+	var tip Height
+	for _, ht := range e.sync.executed {
+		if ht > tip {
+			tip = ht
+		}
+	}
+	return tip
+}
+
+// serveBlocks answers GetBlocksByHeight: the blocks of our certified chain
+// (ending at HighCert) with from <= Height <= to, oldest first.
+func (e *Engine) serveBlocks(from, to Height) BlockRange {
+	var out BlockRange
+	if e.Store == nil {
+		return out
+	}
+	out.Committed, _ = e.Store.GetCommitted()
+
+	genesis := HashOfBlock(e.State.Genesis)
+	var rev []Block
+	h := e.Safety.HighestCert().H
+	for i := 0; i < maxServeWalk && h != genesis; i++ {
+		b, ok := e.Store.GetBlock(h)
+		if !ok || b.Height < from {
+			break
+		}
+		if b.Height <= to {
+			rev = append(rev, b)
+		}
+		h = b.Parent
+	}
+	for i := len(rev) - 1; i >= 0 && len(out.Blocks) < maxSyncBatch; i-- {
+		out.Blocks = append(out.Blocks, rev[i])
+	}
+	return out
+}
+
+// serveCert answers GetCertByView.
+func (e *Engine) serveCert(v View) (Certificate, bool) {
+	if e.Store == nil {
+		return Certificate{}, false
+	}
+	return e.Store.GetCert(v)
+}
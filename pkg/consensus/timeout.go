@@ -0,0 +1,135 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// ErrTimeoutBadSig rejects a timeout whose Sig does not cover its View and
+// HighCert.
+var ErrTimeoutBadSig = errors.New("timeout: signature invalid")
+
+// Timeout is broadcast by a validator that gave up waiting for a QC in View.
+// It carries the sender's HighCert so the next leader can extend the highest
+// certified block known to any member of the timeout quorum.
+type Timeout struct {
+	View     View
+	HighCert Certificate
+	From     NodeID
+	Sig      []byte // signature over TimeoutSignBytes(chainID, View, HighCert)
+}
+
+// VerifyTimeout checks that t.Sig is From's signature over t's View and
+// HighCert (Timeout.SignBytes). It does not verify the HighCert itself.
+func VerifyTimeout(t Timeout, chainID string, pubKeys map[NodeID]*crypto.BLSPubKey) error {
+	pk, ok := pubKeys[t.From]
+	if !ok {
+		return fmt.Errorf("%w: no key for %s", ErrCertBadSigner, t.From)
+	}
+	if !crypto.Verify(pk, t.Sig, t.SignBytes(chainID)) {
+		return ErrTimeoutBadSig
+	}
+	return nil
+}
+
+// TimeoutCert (TC) proves that a quorum of the validators' power abandoned View.
+// The individual timeouts are kept (rather than one aggregate) because every
+// signer commits to a different HighCert view.
+type TimeoutCert struct {
+	View     View
+	Timeouts []Timeout
+}
+
+// HighCert returns the highest certificate carried by any timeout in the TC.
+// A leader proposing after a TC must extend (at least) this certificate.
+func (tc TimeoutCert) HighCert() Certificate {
+	var high Certificate
+	for i, t := range tc.Timeouts {
+		if i == 0 || t.HighCert.View > high.View {
+			high = t.HighCert
+		}
+	}
+	return high
+}
+
+// timeoutCollector aggregates timeouts per view until a TC can be formed.
+type timeoutCollector struct {
+	mu     sync.Mutex
+	byView map[View]map[NodeID]Timeout
+	formed map[View]bool
+}
+
+func newTimeoutCollector() *timeoutCollector {
+	return &timeoutCollector{
+		byView: make(map[View]map[NodeID]Timeout),
+		formed: make(map[View]bool),
+	}
+}
+
+// add records t and returns a TC the first time the senders that timed out
+// in t.View hold a quorum of vs's power.
+func (c *timeoutCollector) add(t Timeout, vs *ValidatorSet) (*TimeoutCert, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.formed[t.View] {
+		return nil, false
+	}
+	m := c.byView[t.View]
+	if m == nil {
+		m = make(map[NodeID]Timeout)
+		c.byView[t.View] = m
+	}
+	if _, dup := m[t.From]; dup {
+		return nil, false
+	}
+	m[t.From] = t
+	if powerOf(m, vs) < vs.QuorumPower() {
+		return nil, false
+	}
+
+	tc := &TimeoutCert{View: t.View}
+	for _, to := range m {
+		tc.Timeouts = append(tc.Timeouts, to)
+	}
+	sort.Slice(tc.Timeouts, func(i, j int) bool { return tc.Timeouts[i].From < tc.Timeouts[j].From })
+	c.formed[t.View] = true
+	delete(c.byView, t.View)
+	return tc, true
+}
+
+// power returns the voting power (in vs) of the senders that timed out in v
+// so far (0 once a TC for v has formed).
+func (c *timeoutCollector) power(v View, vs *ValidatorSet) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return powerOf(c.byView[v], vs)
+}
+
+func powerOf(m map[NodeID]Timeout, vs *ValidatorSet) uint64 {
+	var sum uint64
+	for id := range m {
+		sum += vs.Power(id)
+	}
+	return sum
+}
+
+// prune drops state for views strictly below v.
+func (c *timeoutCollector) prune(v View) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for view := range c.byView {
+		if view < v {
+			delete(c.byView, view)
+		}
+	}
+	for view := range c.formed {
+		if view < v {
+			delete(c.formed, view)
+		}
+	}
+}
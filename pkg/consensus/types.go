@@ -4,7 +4,10 @@ package consensus
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,12 +15,35 @@ type NodeID string
 type View uint64
 type Height uint64
 
-type Quorum struct{ N, T int } // N=3t+1, T=t
+// Quorum is the count-based summary of an equal-power set (N=3t+1, T=t),
+// kept for status reporting. Consensus thresholds are measured in voting
+// power (ValidatorSet.QuorumPower).
+type Quorum struct{ N, T int }
 
 type Hash [32]byte
 
 func (h Hash) String() string { return fmt.Sprintf("%x", h[:]) }
 
+// MarshalJSON encodes h as a 0x-prefixed hex string (API and light client
+// payloads). Binary encodings (gob) are unaffected.
+func (h Hash) MarshalJSON() ([]byte, error) { return []byte(`"0x` + h.String() + `"`), nil }
+
+func (h *Hash) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return fmt.Errorf("hash: %w", err)
+	}
+	if len(b) != len(h) {
+		return fmt.Errorf("hash: %d bytes, want %d", len(b), len(h))
+	}
+	copy(h[:], b)
+	return nil
+}
+
 type Block struct {
 	Height   Height
 	View     View
@@ -26,21 +52,73 @@ type Block struct {
 	Payload  []byte
 	Proposer NodeID
 	Time     time.Time
+
+	// ValSetHash is the hash of the validator set of this block's epoch (the
+	// set whose quorum certifies it). Zero only in the genesis block.
+	ValSetHash Hash
+	// NextValSet is set in the first block of every epoch: the validator set
+	// of the following epoch (see epoch.go). Nil in all other blocks.
+	NextValSet *ValidatorSet
+	// ParentSigners is the signer bitmap of the QC for Parent (the proposal's
+	// HighCert), over the parent's validator set: committed headers record
+	// who voted, which leader election uses (see reputation.go).
+	ParentSigners []byte
+}
+
+// BlockHeader is a block without its payload, which it commits to by
+// PayloadHash. The block hash is the hash of the header (HashOfHeader), so
+// headers can be relayed, certified and verified (pkg/light) without the
+// transactions.
+type BlockHeader struct {
+	Height        Height
+	View          View
+	Parent        Hash
+	PayloadHash   Hash // Merkle root of the payload entries (see PayloadHash)
+	Proposer      NodeID
+	Time          time.Time
+	ValSetHash    Hash
+	NextValSet    *ValidatorSet
+	ParentSigners []byte
+}
+
+// Header returns b's header.
+func (b Block) Header() BlockHeader {
+	return BlockHeader{
+		Height:        b.Height,
+		View:          b.View,
+		Parent:        b.Parent,
+		PayloadHash:   PayloadHash(b.Payload),
+		Proposer:      b.Proposer,
+		Time:          b.Time,
+		ValSetHash:    b.ValSetHash,
+		NextValSet:    b.NextValSet,
+		ParentSigners: b.ParentSigners,
+	}
 }
 
 type Certificate struct {
 	View    View
-	H       Hash // Consensus hash (transactions)
-	AppHash Hash // Application state hash (state after execution)
-	Sig     []byte
+	Height  Height // Height of the certified block
+	H       Hash   // Consensus hash (transactions)
+	AppHash Hash   // Application state hash (state after execution)
+	Sig     []byte // BLS aggregate of the signers' vote shares
+	Signers []byte // bitmap over the ordered validator set (see SignerBitmap)
+	// Times are the signers' vote timestamps (Unix nanoseconds, in Signers
+	// order, 0 for a vote without one); empty if no vote had one. Each vote
+	// signed its own, so Sig verifies against them (see bfttime.go).
+	Times []int64
 }
 
+// DoubleCert is two QCs for a parent and child block in consecutive views
+// (C2.View == C1.View+1, block(C2).Parent == C1.H). It commits block(C1).
 type DoubleCert struct{ C1, C2 Certificate }
 
 type Vote struct {
 	View     View
-	H        Hash // Consensus hash (transactions)
-	AppHash  Hash // Application state hash (state after execution)
+	Height   Height
+	H        Hash      // Consensus hash (transactions)
+	AppHash  Hash      // Application state hash (state after execution)
+	Time     time.Time // voter's clock, signed (see bfttime.go); zero = none
 	SigShare []byte
 	From     NodeID
 }
@@ -50,11 +128,13 @@ type Locked struct {
 	Cert  Certificate
 }
 
-// HashOfBlock computes the consensus hash of a block.
+// HashOfBlock computes the consensus hash of a block: the hash of its header.
 // Following Tendermint/HotStuff architecture, this hash commits to CONSENSUS data only:
 //   - Block structure (height, view, parent)
-//   - Transaction payload
+//   - Transaction payload, by its Merkle root (PayloadHash)
 //   - Proposer and timestamp
+//   - Validator set of the epoch (and the next one, in an epoch's first block)
+//   - Voters of the parent (ParentSigners)
 //
 // IMPORTANT: AppHash is NOT included in this hash. Why?
 //  1. Blocks are proposed BEFORE execution (AppHash unknown at proposal time)
@@ -71,7 +151,10 @@ type Locked struct {
 //   - Tendermint: BlockHash (header) vs AppHash (state root)
 //   - Cosmos SDK: Block commitment vs IAVL state root
 //   - HotStuff family: QC over block vs application state
-func HashOfBlock(b Block) Hash {
+func HashOfBlock(b Block) Hash { return HashOfHeader(b.Header()) }
+
+// HashOfHeader computes the block hash from a header (see HashOfBlock).
+func HashOfHeader(b BlockHeader) Hash {
 	h := sha256.New()
 
 	// Height (8 bytes)
@@ -90,8 +173,8 @@ func HashOfBlock(b Block) Hash {
 	// NOTE: AppHash is NOT included in consensus hash
 	// It's set after execution and verified separately
 
-	// Payload (variable length)
-	h.Write(b.Payload)
+	// Payload, by its Merkle root
+	h.Write(b.PayloadHash[:])
 
 	// Proposer (variable length string)
 	h.Write([]byte(b.Proposer))
@@ -102,6 +185,22 @@ func HashOfBlock(b Block) Hash {
 	binary.BigEndian.PutUint64(timeBuf[:], uint64(b.Time.UnixNano()))
 	h.Write(timeBuf[:])
 
+	// Validator sets: the header commits to who certifies it and, at an
+	// epoch start, to who certifies the next epoch
+	h.Write(b.ValSetHash[:])
+	if b.NextValSet != nil {
+		next := b.NextValSet.Hash()
+		h.Write(next[:])
+	}
+
+	// Parent's voters (length-prefixed; absent before this field existed)
+	if len(b.ParentSigners) > 0 {
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(b.ParentSigners)))
+		h.Write(lenBuf[:])
+		h.Write(b.ParentSigners)
+	}
+
 	return sha256.Sum256(h.Sum(nil))
 }
 
@@ -112,10 +211,49 @@ type BlockStore interface {
 	GetBlock(h Hash) (Block, bool)
 	SaveCert(c Certificate)
 	GetCert(v View) (Certificate, bool)
+	// SetCommitted moves the committed head to h and indexes every block it
+	// commits (h and its not yet committed ancestors, which must be saved) by
+	// height, and their transactions by hash.
 	SetCommitted(h Hash)
 	GetCommitted() (Hash, bool)
+
+	// Committed-chain queries (answered from the index built by SetCommitted)
+	LatestCommitted() (Block, bool)
+	GetBlockByHeight(ht Height) (Block, bool)
+	// IterateCommitted calls fn for the committed blocks with
+	// from <= Height <= to in ascending order, until fn returns false.
+	IterateCommitted(from, to Height, fn func(Block) bool)
+	GetTx(h Hash) (TxLocation, bool)
+}
+
+// TxLocation is where a committed transaction was included.
+type TxLocation struct {
+	Height Height
+	Block  Hash
+	Index  int // position in the block payload
+	Tx     []byte
 }
 
+// WAL is the consensus write-ahead log (see Record).
 type WAL interface {
-	Append(line string)
+	// Write appends r; it is durable when Write returns.
+	Write(r Record) error
+	// Replay calls fn for every record, in write order.
+	Replay(fn func(Record)) error
+}
+
+// SafetyState is the part of the voting state a validator must never forget:
+// losing it across a restart would allow a second vote in the same view or a
+// vote against the lock.
+type SafetyState struct {
+	LastVoted View
+	Locked    *Locked
+	HighCert  *Certificate
+}
+
+// SafetyStore persists SafetyState. SaveSafety must be durable (fsynced)
+// when it returns: the engine sends a vote only after it succeeds.
+type SafetyStore interface {
+	SaveSafety(s SafetyState) error
+	LoadSafety() (SafetyState, bool, error)
 }
@@ -0,0 +1,194 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxTotalPower bounds the voting power of a set so that quorum arithmetic
+// (2*total) can never overflow.
+const MaxTotalPower = uint64(1) << 60
+
+var (
+	ErrValSetEmpty     = errors.New("validator set: empty")
+	ErrValSetDuplicate = errors.New("validator set: duplicate validator")
+	ErrValSetPower     = errors.New("validator set: invalid voting power")
+)
+
+// Validator is a member of a validator set with its voting power (stake).
+type Validator struct {
+	ID    NodeID
+	Power uint64
+}
+
+// ValidatorUpdate changes one validator's power in a future epoch.
+// Power 0 removes the validator; an unknown ID with Power > 0 adds it.
+// The app emits updates from Execute (see abci.ResponseExecute);
+// a new validator's BLS key and address come from the validator directory.
+type ValidatorUpdate struct {
+	ID    NodeID
+	Power uint64
+}
+
+// ValidatorSet is the active validator set of one epoch. The order is fixed:
+// index i is bit i of Certificate.Signers. Quorums are measured in voting
+// power: a QC or TC needs more than 2/3 of the total power.
+type ValidatorSet struct {
+	Epoch      uint64
+	Validators []Validator
+}
+
+// NewValidatorSet checks vals (non-empty, unique IDs, non-zero power, total
+// at most MaxTotalPower) and returns the set for epoch, keeping vals' order.
+func NewValidatorSet(epoch uint64, vals []Validator) (*ValidatorSet, error) {
+	if len(vals) == 0 {
+		return nil, ErrValSetEmpty
+	}
+	seen := make(map[NodeID]bool, len(vals))
+	var total uint64
+	for _, v := range vals {
+		if v.ID == "" || seen[v.ID] {
+			return nil, fmt.Errorf("%w: %q", ErrValSetDuplicate, v.ID)
+		}
+		seen[v.ID] = true
+		if v.Power == 0 || v.Power > MaxTotalPower-total {
+			return nil, fmt.Errorf("%w: %s has %d", ErrValSetPower, v.ID, v.Power)
+		}
+		total += v.Power
+	}
+	return &ValidatorSet{Epoch: epoch, Validators: append([]Validator(nil), vals...)}, nil
+}
+
+// EqualPowerSet gives every id power 1: the quorum is the classic 2f+1 of
+// N=3f+1 (for N not of that form, still more than 2/3 of N).
+func EqualPowerSet(epoch uint64, ids []NodeID) (*ValidatorSet, error) {
+	vals := make([]Validator, len(ids))
+	for i, id := range ids {
+		vals[i] = Validator{ID: id, Power: 1}
+	}
+	return NewValidatorSet(epoch, vals)
+}
+
+// Size returns the number of validators.
+func (vs *ValidatorSet) Size() int { return len(vs.Validators) }
+
+// IDs returns the validator IDs in set order (the Certificate.Signers order).
+func (vs *ValidatorSet) IDs() []NodeID {
+	out := make([]NodeID, len(vs.Validators))
+	for i, v := range vs.Validators {
+		out[i] = v.ID
+	}
+	return out
+}
+
+// Power returns id's voting power (0 if id is not in the set).
+func (vs *ValidatorSet) Power(id NodeID) uint64 {
+	for _, v := range vs.Validators {
+		if v.ID == id {
+			return v.Power
+		}
+	}
+	return 0
+}
+
+// Has reports whether id is in the set.
+func (vs *ValidatorSet) Has(id NodeID) bool { return vs.Power(id) > 0 }
+
+// TotalPower is the sum of all voting power.
+func (vs *ValidatorSet) TotalPower() uint64 {
+	var total uint64
+	for _, v := range vs.Validators {
+		total += v.Power
+	}
+	return total
+}
+
+// QuorumPower is the smallest power strictly above 2/3 of the total (2f+1
+// for N=3f+1 equal validators).
+func (vs *ValidatorSet) QuorumPower() uint64 { return vs.TotalPower()*2/3 + 1 }
+
+// MaxFaultyPower is the most power that can be faulty while the remaining
+// power still makes a quorum (f for N=3f+1 equal validators). Any group with
+// more power contains an honest validator.
+func (vs *ValidatorSet) MaxFaultyPower() uint64 { return vs.TotalPower() - vs.QuorumPower() }
+
+// VotingPower sums the power of the distinct members among ids; unknown IDs
+// count for nothing.
+func (vs *ValidatorSet) VotingPower(ids []NodeID) uint64 {
+	seen := make(map[NodeID]bool, len(ids))
+	var sum uint64
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		sum += vs.Power(id)
+	}
+	return sum
+}
+
+// HasQuorum reports whether ids hold more than 2/3 of the power.
+func (vs *ValidatorSet) HasQuorum(ids []NodeID) bool { return vs.VotingPower(ids) >= vs.QuorumPower() }
+
+// MinQuorumSize is the fewest validators that can make a quorum (the
+// largest ones); a leader needs at least this many votes.
+func (vs *ValidatorSet) MinQuorumSize() int {
+	powers := make([]uint64, len(vs.Validators))
+	for i, v := range vs.Validators {
+		powers[i] = v.Power
+	}
+	sort.Slice(powers, func(i, j int) bool { return powers[i] > powers[j] })
+	need := vs.QuorumPower()
+	var sum uint64
+	for i, p := range powers {
+		if sum += p; sum >= need {
+			return i + 1
+		}
+	}
+	return len(powers)
+}
+
+// Hash commits to the epoch and the ordered (ID, power) list. Block headers
+// carry it (Block.ValSetHash), so a QC is checked against the set its block
+// names.
+func (vs *ValidatorSet) Hash() Hash {
+	h := sha256.New()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], vs.Epoch)
+	h.Write(buf[:])
+	for _, v := range vs.Validators {
+		binary.BigEndian.PutUint16(buf[:2], uint16(len(v.ID)))
+		h.Write(buf[:2])
+		h.Write([]byte(v.ID))
+		binary.BigEndian.PutUint64(buf[:], v.Power)
+		h.Write(buf[:])
+	}
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// Apply returns the set for epoch after updates, applied in order: existing
+// validators keep their position, removed ones drop out and new ones are
+// appended in the order they were first added.
+func (vs *ValidatorSet) Apply(epoch uint64, updates []ValidatorUpdate) (*ValidatorSet, error) {
+	vals := append([]Validator(nil), vs.Validators...)
+	for _, u := range updates {
+		i := 0
+		for i < len(vals) && vals[i].ID != u.ID {
+			i++
+		}
+		switch {
+		case i < len(vals) && u.Power == 0:
+			vals = append(vals[:i], vals[i+1:]...)
+		case i < len(vals):
+			vals[i].Power = u.Power
+		case u.Power > 0:
+			vals = append(vals, Validator{ID: u.ID, Power: u.Power})
+		}
+	}
+	return NewValidatorSet(epoch, vals)
+}
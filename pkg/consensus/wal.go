@@ -0,0 +1,59 @@
+package consensus
+
+// RecordType tags a WAL record.
+type RecordType uint8
+
+const (
+	RecordProposal         RecordType = iota + 1 // proposal received (or sent, as leader)
+	RecordVote                                   // vote sent
+	RecordTimeout                                // timeout sent
+	RecordCert                                   // QC observed (or formed, as leader)
+	RecordCommit                                 // block committed
+	RecordValidatorUpdates                       // validator updates emitted by an executed block
+)
+
+func (t RecordType) String() string {
+	switch t {
+	case RecordProposal:
+		return "proposal"
+	case RecordVote:
+		return "vote"
+	case RecordTimeout:
+		return "timeout"
+	case RecordCert:
+		return "cert"
+	case RecordCommit:
+		return "commit"
+	case RecordValidatorUpdates:
+		return "validator_updates"
+	}
+	return "unknown"
+}
+
+// Record is one typed WAL entry. Exactly the field matching Type is set.
+type Record struct {
+	Type     RecordType
+	Proposal *Propose
+	Vote     *Vote
+	Timeout  *Timeout
+	Cert     *Certificate
+	Commit   *CommitInfo
+	Updates  *BlockValidatorUpdates
+}
+
+// CommitInfo describes a committed block.
+type CommitInfo struct {
+	Height  Height // height of the committed block (State.Height after the commit)
+	View    View
+	H       Hash
+	AppHash Hash
+}
+
+// BlockValidatorUpdates are the validator updates the app emitted executing
+// block H. They decide the set of a later epoch (see epoch.go), so they must
+// survive a restart.
+type BlockValidatorUpdates struct {
+	H       Hash
+	Height  Height
+	Updates []ValidatorUpdate
+}
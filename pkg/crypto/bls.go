@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"crypto/rand"
+
 	bls "github.com/cloudflare/circl/sign/bls"
 )
 
@@ -21,8 +23,43 @@ func NewBLSSignerFromSeed(seed []byte) *BLSSigner {
 	return &BLSSigner{sk: sk, pk: pk}
 }
 
+// GenerateBLSSigner creates a signer with a random key (a validator's
+// keystore, see ParseBLSSigner).
+func GenerateBLSSigner() (*BLSSigner, error) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	sk, err := bls.KeyGen[scheme](seed, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BLSSigner{sk: sk, pk: sk.PublicKey()}, nil
+}
+
+// ParseBLSSigner decodes a secret key produced by BLSSigner.MarshalBinary.
+func ParseBLSSigner(b []byte) (*BLSSigner, error) {
+	sk := new(bls.PrivateKey[scheme])
+	if err := sk.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return &BLSSigner{sk: sk, pk: sk.PublicKey()}, nil
+}
+
+// MarshalBinary encodes the secret key. Keep it secret.
+func (s *BLSSigner) MarshalBinary() ([]byte, error) { return s.sk.MarshalBinary() }
+
 func (s *BLSSigner) Pubkey() *BLSPubKey { return s.pk }
 
+// ParseBLSPubKey decodes a public key produced by BLSPubKey.MarshalBinary.
+func ParseBLSPubKey(b []byte) (*BLSPubKey, error) {
+	pk := new(BLSPubKey)
+	if err := pk.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
 func (s *BLSSigner) Sign(msg []byte) []byte {
 	return bls.Sign(s.sk, msg)
 }
@@ -47,6 +84,26 @@ func Aggregate(sigBytesList [][]byte) []byte {
 	return agg
 }
 
+// VerifyAggregate verifies an aggregate of signatures by pks[i] over
+// msgs[i] (e.g. a QC whose votes carry their own timestamps).
+func VerifyAggregate(pks []*BLSPubKey, msgs [][]byte, aggSig []byte) bool {
+	if len(pks) == 0 || len(pks) != len(msgs) || len(aggSig) == 0 {
+		return false
+	}
+	return bls.VerifyAggregate(pks, msgs, bls.Signature(aggSig))
+}
+
+// VerifyAggregateSameMsg verifies an aggregate of signatures by pks over the
+// same msg (e.g. a QC: every validator signs the same vote bytes).
+// NOTE: same-message aggregation assumes validator keys are registered with a
+// proof of possession (genesis/config), otherwise rogue-key attacks apply.
 func VerifyAggregateSameMsg(pks []*BLSPubKey, msg []byte, aggSig []byte) bool {
-	return bls.VerifyAggregate(pks, [][]byte{msg}, bls.Signature(aggSig))
+	if len(pks) == 0 || len(aggSig) == 0 {
+		return false
+	}
+	msgs := make([][]byte, len(pks))
+	for i := range msgs {
+		msgs[i] = msg
+	}
+	return bls.VerifyAggregate(pks, msgs, bls.Signature(aggSig))
 }
@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testBLSSigners(n int) []*BLSSigner {
+	out := make([]*BLSSigner, n)
+	for i := range out {
+		out[i] = NewBLSSignerFromSeed(bytes.Repeat([]byte{byte(i + 1)}, 32))
+	}
+	return out
+}
+
+func TestBLSAggregateSameMsg(t *testing.T) {
+	signers := testBLSSigners(4)
+	msg := []byte("vote bytes")
+
+	var shares [][]byte
+	var pks []*BLSPubKey
+	for _, s := range signers[:3] {
+		shares = append(shares, s.Sign(msg))
+		pks = append(pks, s.Pubkey())
+	}
+	agg := Aggregate(shares)
+	if agg == nil {
+		t.Fatal("aggregate failed")
+	}
+
+	if !VerifyAggregateSameMsg(pks, msg, agg) {
+		t.Fatal("valid aggregate rejected")
+	}
+	if VerifyAggregateSameMsg(pks, []byte("other msg"), agg) {
+		t.Error("aggregate verified against wrong message")
+	}
+
+	// Claiming a signer that did not contribute must fail
+	wrongPks := []*BLSPubKey{pks[0], pks[1], signers[3].Pubkey()}
+	if VerifyAggregateSameMsg(wrongPks, msg, agg) {
+		t.Error("aggregate verified against wrong key set")
+	}
+	if VerifyAggregateSameMsg(nil, msg, agg) {
+		t.Error("aggregate verified with no keys")
+	}
+}
+
+func TestBLSVerifyShare(t *testing.T) {
+	signers := testBLSSigners(2)
+	msg := []byte("vote bytes")
+	sig := signers[0].Sign(msg)
+
+	if !Verify(signers[0].Pubkey(), sig, msg) {
+		t.Fatal("valid share rejected")
+	}
+	if Verify(signers[1].Pubkey(), sig, msg) {
+		t.Error("share verified under another validator's key")
+	}
+}
+
+func TestBLSSignerRoundTrip(t *testing.T) {
+	signer, err := GenerateBLSSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := signer.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ParseBLSSigner(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Pubkey().Equal(signer.Pubkey()) {
+		t.Fatal("loaded key has another public key")
+	}
+	msg := []byte("vote bytes")
+	if !Verify(signer.Pubkey(), loaded.Sign(msg), msg) {
+		t.Error("loaded key's share rejected")
+	}
+	if _, err := ParseBLSSigner(sk[:len(sk)-1]); err == nil {
+		t.Error("truncated key parsed")
+	}
+}
@@ -23,15 +23,34 @@ type EIP712Domain struct {
 // OrderEIP712 represents an order for EIP-712 signing
 // This is the typed data structure users sign in their wallets
 type OrderEIP712 struct {
-	Symbol   string         // Market symbol (e.g., "BTC-USDT")
-	Side     uint8          // 1 = Buy, 2 = Sell (uint8 for EIP-712 compatibility)
-	Type     uint8          // 1 = GTC, 2 = IOC, 3 = ALO
-	Price    *big.Int       // Limit price in ticks
-	Qty      *big.Int       // Quantity in lots
-	Nonce    *big.Int       // Nonce for replay protection
-	Deadline *big.Int       // Expiration timestamp (Unix seconds), 0 = no expiry
-	Leverage uint8          // Leverage multiplier (1-50)
-	Owner    common.Address // Order owner address
+	Symbol     string         // Market symbol (e.g., "BTC-USDT")
+	Side       uint8          // 1 = Buy, 2 = Sell (uint8 for EIP-712 compatibility)
+	Type       uint8          // 1 = GTC, 2 = IOC, 3 = ALO, 4 = MARKET, 5 = CLOSE (market, whole position), 6 = FOK
+	Price      *big.Int       // Limit price in ticks (MARKET, CLOSE: must be 0)
+	Qty        *big.Int       // Quantity in lots (CLOSE: ignored)
+	Nonce      *big.Int       // Nonce for replay protection
+	Deadline   *big.Int       // Expiration timestamp (Unix seconds), 0 = no expiry
+	Leverage   uint8          // Leverage multiplier (1-50)
+	ReduceOnly bool           // Only reduce the position: clamped to it, never flips it
+	STP        uint8          // Self-trade prevention: 0 = market's mode, 1 = none, 2 = cancel newest, 3 = cancel oldest, 4 = cancel both, 5 = decrement and cancel
+	Owner      common.Address // Order owner address
+}
+
+// TriggerOrderEIP712 represents a stop or take-profit order for EIP-712
+// signing. It rests off the book until the mark or last price reaches
+// TriggerPrice, then becomes a market order or a GTC order at Price.
+type TriggerOrderEIP712 struct {
+	Symbol       string         // Market symbol (e.g., "BTC-USDT")
+	Side         uint8          // 1 = Buy, 2 = Sell
+	Kind         uint8          // 1 = stop-market, 2 = stop-limit, 3 = take-profit-market, 4 = take-profit-limit
+	TriggerPrice *big.Int       // Trigger price in ticks
+	PriceRef     uint8          // Price watched: 1 = mark, 2 = last
+	Price        *big.Int       // Limit price in ticks (limit kinds), 0 otherwise
+	Qty          *big.Int       // Quantity in lots
+	Nonce        *big.Int       // Nonce for replay protection
+	Deadline     *big.Int       // Expiration timestamp (Unix seconds), 0 = no expiry
+	Leverage     uint8          // Leverage multiplier (1-50)
+	Owner        common.Address // Order owner address
 }
 
 // CancelEIP712 represents a cancel order request for EIP-712 signing
@@ -83,6 +102,8 @@ func (e *EIP712Signer) HashOrder(order *OrderEIP712) ([]byte, error) {
 				{Name: "nonce", Type: "uint256"},
 				{Name: "deadline", Type: "uint256"},
 				{Name: "leverage", Type: "uint8"},
+				{Name: "reduceOnly", Type: "bool"},
+				{Name: "stp", Type: "uint8"},
 				{Name: "owner", Type: "address"},
 			},
 		},
@@ -94,15 +115,17 @@ func (e *EIP712Signer) HashOrder(order *OrderEIP712) ([]byte, error) {
 			VerifyingContract: e.domain.VerifyingContract.Hex(),
 		},
 		Message: apitypes.TypedDataMessage{
-			"symbol":   order.Symbol,
-			"side":     fmt.Sprintf("%d", order.Side),
-			"type":     fmt.Sprintf("%d", order.Type),
-			"price":    order.Price.String(),
-			"qty":      order.Qty.String(),
-			"nonce":    order.Nonce.String(),
-			"deadline": order.Deadline.String(),
-			"leverage": fmt.Sprintf("%d", order.Leverage),
-			"owner":    order.Owner.Hex(),
+			"symbol":     order.Symbol,
+			"side":       fmt.Sprintf("%d", order.Side),
+			"type":       fmt.Sprintf("%d", order.Type),
+			"price":      order.Price.String(),
+			"qty":        order.Qty.String(),
+			"nonce":      order.Nonce.String(),
+			"deadline":   order.Deadline.String(),
+			"leverage":   fmt.Sprintf("%d", order.Leverage),
+			"reduceOnly": order.ReduceOnly,
+			"stp":        fmt.Sprintf("%d", order.STP),
+			"owner":      order.Owner.Hex(),
 		},
 	}
 
@@ -168,6 +191,100 @@ func (e *EIP712Signer) RecoverOrderSigner(order *OrderEIP712, signature []byte)
 	return RecoverAddress(hash, signature)
 }
 
+// HashTriggerOrder hashes a trigger order according to EIP-712 spec
+// Returns the digest that should be signed
+func (e *EIP712Signer) HashTriggerOrder(order *TriggerOrderEIP712) ([]byte, error) {
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": []apitypes.Type{
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"TriggerOrder": []apitypes.Type{
+				{Name: "symbol", Type: "string"},
+				{Name: "side", Type: "uint8"},
+				{Name: "kind", Type: "uint8"},
+				{Name: "triggerPrice", Type: "uint256"},
+				{Name: "priceRef", Type: "uint8"},
+				{Name: "price", Type: "uint256"},
+				{Name: "qty", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+				{Name: "leverage", Type: "uint8"},
+				{Name: "owner", Type: "address"},
+			},
+		},
+		PrimaryType: "TriggerOrder",
+		Domain: apitypes.TypedDataDomain{
+			Name:              e.domain.Name,
+			Version:           e.domain.Version,
+			ChainId:           (*math.HexOrDecimal256)(e.domain.ChainID),
+			VerifyingContract: e.domain.VerifyingContract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"symbol":       order.Symbol,
+			"side":         fmt.Sprintf("%d", order.Side),
+			"kind":         fmt.Sprintf("%d", order.Kind),
+			"triggerPrice": order.TriggerPrice.String(),
+			"priceRef":     fmt.Sprintf("%d", order.PriceRef),
+			"price":        order.Price.String(),
+			"qty":          order.Qty.String(),
+			"nonce":        order.Nonce.String(),
+			"deadline":     order.Deadline.String(),
+			"leverage":     fmt.Sprintf("%d", order.Leverage),
+			"owner":        order.Owner.Hex(),
+		},
+	}
+
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash message: %w", err)
+	}
+
+	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
+	digest := crypto.Keccak256Hash(rawData)
+
+	return digest.Bytes(), nil
+}
+
+// SignTriggerOrder signs a trigger order and returns the signature
+func (e *EIP712Signer) SignTriggerOrder(signer *Signer, order *TriggerOrderEIP712) ([]byte, error) {
+	hash, err := e.HashTriggerOrder(order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash trigger order: %w", err)
+	}
+
+	signature, err := signer.Sign(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign trigger order: %w", err)
+	}
+
+	return signature, nil
+}
+
+// VerifyTriggerOrderSignature verifies that a trigger order signature is valid
+// Returns true if signature matches the order and claimed owner
+func (e *EIP712Signer) VerifyTriggerOrderSignature(order *TriggerOrderEIP712, signature []byte) (bool, error) {
+	hash, err := e.HashTriggerOrder(order)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash trigger order: %w", err)
+	}
+
+	recoveredAddr, err := RecoverAddress(hash, signature)
+	if err != nil {
+		return false, fmt.Errorf("failed to recover address: %w", err)
+	}
+
+	return recoveredAddr == order.Owner, nil
+}
+
 // OrderToJSON converts an order to JSON for frontend/wallet signing
 // MetaMask and other wallets use this format for eth_signTypedData_v4
 func (e *EIP712Signer) OrderToJSON(order *OrderEIP712) (string, error) {
@@ -188,6 +305,8 @@ func (e *EIP712Signer) OrderToJSON(order *OrderEIP712) (string, error) {
 				{"name": "nonce", "type": "uint256"},
 				{"name": "deadline", "type": "uint256"},
 				{"name": "leverage", "type": "uint8"},
+				{"name": "reduceOnly", "type": "bool"},
+				{"name": "stp", "type": "uint8"},
 				{"name": "owner", "type": "address"},
 			},
 		},
@@ -199,15 +318,17 @@ func (e *EIP712Signer) OrderToJSON(order *OrderEIP712) (string, error) {
 			"verifyingContract": e.domain.VerifyingContract.Hex(),
 		},
 		"message": map[string]interface{}{
-			"symbol":   order.Symbol,
-			"side":     order.Side,
-			"type":     order.Type,
-			"price":    order.Price.String(),
-			"qty":      order.Qty.String(),
-			"nonce":    order.Nonce.String(),
-			"deadline": order.Deadline.String(),
-			"leverage": order.Leverage,
-			"owner":    order.Owner.Hex(),
+			"symbol":     order.Symbol,
+			"side":       order.Side,
+			"type":       order.Type,
+			"price":      order.Price.String(),
+			"qty":        order.Qty.String(),
+			"nonce":      order.Nonce.String(),
+			"deadline":   order.Deadline.String(),
+			"leverage":   order.Leverage,
+			"reduceOnly": order.ReduceOnly,
+			"stp":        order.STP,
+			"owner":      order.Owner.Hex(),
 		},
 	}
 
@@ -252,6 +373,12 @@ func OrderTypeToUint8(orderType string) uint8 {
 		return 2
 	case "ALO", "alo":
 		return 3
+	case "MARKET", "market":
+		return 4
+	case "CLOSE", "close":
+		return 5
+	case "FOK", "fok":
+		return 6
 	default:
 		return 0
 	}
@@ -266,6 +393,12 @@ func Uint8ToOrderType(orderType uint8) string {
 		return "IOC"
 	case 3:
 		return "ALO"
+	case 4:
+		return "MARKET"
+	case 5:
+		return "CLOSE"
+	case 6:
+		return "FOK"
 	default:
 		return "unknown"
 	}
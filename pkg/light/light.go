@@ -0,0 +1,226 @@
+// Package light verifies committed headers without running a validator.
+//
+// A light client starts from a trusted validator set (the genesis set, or a
+// header it trusts) and checks a SignedHeader against it: the QC of the
+// header, which carries the header's AppHash, and a DoubleCert (C1, C2)
+// proving the header committed under the two-chain rule (see
+// consensus/commit.go). Both need a quorum of the validator set that
+// certifies their blocks.
+//
+// Validator sets change every epoch (see consensus/epoch.go). The first
+// block of epoch k names the set of epoch k+1 (Block.NextValSet) and is
+// certified by epoch k's set, so a client that trusts epoch k learns epoch
+// k+1 by verifying that block: it hands off from set to set one epoch at a
+// time. HandoffHeights lists the headers to verify on the way to a target.
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+var (
+	ErrNotNewer       = errors.New("light: header not above the trusted height")
+	ErrUnknownValSet  = errors.New("light: validator set of the header's epoch not trusted yet")
+	ErrInvalidHeader  = errors.New("light: invalid header")
+	ErrInvalidCert    = errors.New("light: invalid certificate")
+	ErrInvalidCommit  = errors.New("light: invalid commit proof")
+	ErrInvalidTrusted = errors.New("light: invalid trusted state")
+)
+
+// SignedHeader is a committed header with what proves it:
+//
+//	Cert:   the QC for Header (its AppHash is the state after Header)
+//	Chain:  Header's descendants up to block(Commit.C2), in height order
+//	Commit: C1 certifies Chain[len-2] (Header if Chain has one block),
+//	        C2 certifies Chain[len-1], in consecutive views
+//
+// Committing block(C1) commits Header, its ancestor. Headers carry no
+// payload; a header's transactions can be checked against its PayloadHash.
+type SignedHeader struct {
+	Header consensus.BlockHeader
+	Cert   consensus.Certificate
+	Chain  []consensus.BlockHeader
+	Commit consensus.DoubleCert
+}
+
+// Trusted is what a light client trusts: the last verified header, the
+// state root after it, and the validator sets of its epoch and the next
+// (NextValSet nil until known).
+type Trusted struct {
+	ChainID     string
+	EpochLength consensus.Height // 0 = the genesis set certifies every block
+	Header      consensus.BlockHeader
+	AppHash     consensus.Hash
+	ValSet      *consensus.ValidatorSet
+	NextValSet  *consensus.ValidatorSet
+	PubKeys     map[consensus.NodeID]*crypto.BLSPubKey
+}
+
+// Genesis returns the trusted state of a new chain: the genesis block and
+// validator set. pubKeys must hold the BLS key of every validator that will
+// sign (later sets included; see validator directory).
+func Genesis(chainID string, epochLength consensus.Height, vs *consensus.ValidatorSet, pubKeys map[consensus.NodeID]*crypto.BLSPubKey) Trusted {
+	return Trusted{
+		ChainID:     chainID,
+		EpochLength: epochLength,
+		Header:      consensus.GenesisBlock().Header(),
+		ValSet:      vs,
+		PubKeys:     pubKeys,
+	}
+}
+
+// epoch returns the epoch of height h.
+func (t Trusted) epoch(h consensus.Height) uint64 { return consensus.EpochOf(h, t.EpochLength) }
+
+// HandoffHeights returns the heights of the headers to verify, in order,
+// before a header at target: the first block of every epoch up to target's
+// that names a set not trusted yet. Passing through the start of target's
+// epoch leaves the next set trusted as well, for the next handoff.
+func HandoffHeights(t Trusted, target consensus.Height) []consensus.Height {
+	if t.EpochLength == 0 || t.ValSet == nil {
+		return nil
+	}
+	known := t.ValSet.Epoch
+	if t.NextValSet != nil {
+		known = t.NextValSet.Epoch
+	}
+	var out []consensus.Height
+	for k := known; k <= t.epoch(target); k++ {
+		if h := consensus.EpochStart(k, t.EpochLength); h > t.Header.Height && h < target {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// VerifyHeader checks untrusted against trusted and returns the new trusted
+// state (untrusted's header and AppHash). It fails with ErrUnknownValSet if
+// the header's epoch is more than one past the trusted epoch; verify the
+// HandoffHeights headers first.
+func VerifyHeader(trusted Trusted, untrusted SignedHeader) (Trusted, error) {
+	if trusted.ValSet == nil {
+		return Trusted{}, fmt.Errorf("%w: no validator set", ErrInvalidTrusted)
+	}
+	h := untrusted.Header
+	if h.Height <= trusted.Header.Height {
+		return Trusted{}, fmt.Errorf("%w: height %d, trusted %d", ErrNotNewer, h.Height, trusted.Header.Height)
+	}
+
+	sets := map[uint64]*consensus.ValidatorSet{trusted.ValSet.Epoch: trusted.ValSet}
+	if trusted.NextValSet != nil {
+		sets[trusted.NextValSet.Epoch] = trusted.NextValSet
+	}
+
+	// Header, then its descendants: linked, each naming its epoch's set
+	all := append([]consensus.BlockHeader{h}, untrusted.Chain...)
+	if len(untrusted.Chain) == 0 {
+		return Trusted{}, fmt.Errorf("%w: empty chain", ErrInvalidCommit)
+	}
+	for i, b := range all {
+		if i > 0 {
+			prev := all[i-1]
+			if b.Parent != consensus.HashOfHeader(prev) || b.Height != prev.Height+1 || b.View <= prev.View {
+				return Trusted{}, fmt.Errorf("%w: height %d does not extend height %d", ErrInvalidHeader, b.Height, prev.Height)
+			}
+		}
+		if err := learnValSet(trusted, sets, b); err != nil {
+			return Trusted{}, err
+		}
+	}
+
+	// The header's own QC (its AppHash), then the DoubleCert
+	cert := untrusted.Cert
+	if cert.H != consensus.HashOfHeader(h) || cert.Height != h.Height || cert.View != h.View {
+		return Trusted{}, fmt.Errorf("%w: does not certify height %d", ErrInvalidCert, h.Height)
+	}
+	if err := verifyCert(trusted, sets, cert, h); err != nil {
+		return Trusted{}, err
+	}
+	dc := untrusted.Commit
+	b1, b2 := all[len(all)-2], all[len(all)-1]
+	switch {
+	case dc.C2.View != dc.C1.View+1:
+		return Trusted{}, fmt.Errorf("%w: views %d, %d not consecutive", ErrInvalidCommit, dc.C1.View, dc.C2.View)
+	case dc.C1.H != consensus.HashOfHeader(b1) || dc.C1.Height != b1.Height || dc.C1.View != b1.View:
+		return Trusted{}, fmt.Errorf("%w: C1 does not certify height %d", ErrInvalidCommit, b1.Height)
+	case dc.C2.H != consensus.HashOfHeader(b2) || dc.C2.Height != b2.Height || dc.C2.View != b2.View:
+		return Trusted{}, fmt.Errorf("%w: C2 does not certify height %d", ErrInvalidCommit, b2.Height)
+	}
+	if err := verifyCert(trusted, sets, dc.C1, b1); err != nil {
+		return Trusted{}, fmt.Errorf("%w: C1: %w", ErrInvalidCommit, err)
+	}
+	if err := verifyCert(trusted, sets, dc.C2, b2); err != nil {
+		return Trusted{}, fmt.Errorf("%w: C2: %w", ErrInvalidCommit, err)
+	}
+
+	k := trusted.epoch(h.Height)
+	next := trusted
+	next.Header = h
+	next.AppHash = cert.AppHash
+	next.ValSet = sets[k]
+	next.NextValSet = nil
+	if trusted.EpochLength > 0 {
+		next.NextValSet = sets[k+1]
+	}
+	return next, nil
+}
+
+// VerifyChain verifies headers in order, each against the state the
+// previous one left, and returns the last state.
+func VerifyChain(trusted Trusted, headers []SignedHeader) (Trusted, error) {
+	for _, sh := range headers {
+		var err error
+		if trusted, err = VerifyHeader(trusted, sh); err != nil {
+			return Trusted{}, fmt.Errorf("height %d: %w", sh.Header.Height, err)
+		}
+	}
+	return trusted, nil
+}
+
+// learnValSet checks that b names the set of its epoch and records the set
+// an epoch's first block names for the next epoch. A block whose epoch set
+// is unknown is left for verifyCert: only certified blocks need one.
+func learnValSet(t Trusted, sets map[uint64]*consensus.ValidatorSet, b consensus.BlockHeader) error {
+	k := t.epoch(b.Height)
+	if vs, ok := sets[k]; ok && b.ValSetHash != vs.Hash() {
+		return fmt.Errorf("%w: height %d names set %s, epoch %d has %s", ErrInvalidHeader, b.Height, b.ValSetHash, k, vs.Hash())
+	}
+	isStart := t.EpochLength > 0 && b.Height == consensus.EpochStart(k, t.EpochLength)
+	switch {
+	case !isStart && b.NextValSet != nil:
+		return fmt.Errorf("%w: NextValSet at height %d, not an epoch start", ErrInvalidHeader, b.Height)
+	case isStart && b.NextValSet == nil:
+		return fmt.Errorf("%w: epoch start %d without NextValSet", ErrInvalidHeader, b.Height)
+	case isStart:
+		if b.NextValSet.Epoch != k+1 {
+			return fmt.Errorf("%w: height %d names the set of epoch %d, want %d", ErrInvalidHeader, b.Height, b.NextValSet.Epoch, k+1)
+		}
+		if _, err := consensus.NewValidatorSet(b.NextValSet.Epoch, b.NextValSet.Validators); err != nil {
+			return fmt.Errorf("%w: height %d: %v", ErrInvalidHeader, b.Height, err)
+		}
+		if known, ok := sets[k+1]; ok && known.Hash() != b.NextValSet.Hash() {
+			return fmt.Errorf("%w: height %d names a different set for epoch %d", ErrInvalidHeader, b.Height, k+1)
+		}
+		if _, ok := sets[k]; ok {
+			sets[k+1] = b.NextValSet // only a block we can verify hands off
+		}
+	}
+	return nil
+}
+
+// verifyCert checks c, which certifies b, against the set of b's epoch.
+func verifyCert(t Trusted, sets map[uint64]*consensus.ValidatorSet, c consensus.Certificate, b consensus.BlockHeader) error {
+	k := t.epoch(b.Height)
+	vs, ok := sets[k]
+	if !ok {
+		return fmt.Errorf("%w: epoch %d (height %d)", ErrUnknownValSet, k, b.Height)
+	}
+	if err := consensus.VerifyCertificate(c, c.SignBytes(t.ChainID), vs, t.PubKeys); err != nil {
+		return fmt.Errorf("%w: height %d: %v", ErrInvalidCert, b.Height, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+var (
+	ErrNotFound = errors.New("light: no committed block at that height")
+	ErrNoProof  = errors.New("light: commit proof not available yet")
+)
+
+// maxProofChain bounds how many descendants BuildSignedHeader walks to find
+// a DoubleCert (a block usually commits with its child).
+const maxProofChain = 64
+
+// BuildSignedHeader assembles the SignedHeader of committed height h from
+// a validator's block store. It returns ErrNoProof if the store lacks a
+// QC, or the block that commits h, yet (the proof of the latest committed
+// block can lag its commit on nodes that synced it).
+func BuildSignedHeader(store consensus.BlockStore, h consensus.Height) (SignedHeader, error) {
+	b, ok := store.GetBlockByHeight(h)
+	if !ok || h == 0 {
+		return SignedHeader{}, fmt.Errorf("%w: %d", ErrNotFound, h)
+	}
+	cert, ok := certOf(store, b)
+	if !ok {
+		return SignedHeader{}, fmt.Errorf("%w: no QC for height %d", ErrNoProof, h)
+	}
+	sh := SignedHeader{Header: b.Header(), Cert: cert}
+
+	// Walk up the committed chain to a block whose child is certified in the
+	// next view: that pair is the DoubleCert
+	cur, c1 := b, cert
+	for i := 0; i < maxProofChain; i++ {
+		if c2, ok := store.GetCert(cur.View + 1); ok {
+			if b2, ok := store.GetBlock(c2.H); ok && b2.Parent == c1.H {
+				sh.Chain = append(sh.Chain, b2.Header())
+				sh.Commit = consensus.DoubleCert{C1: c1, C2: c2}
+				return sh, nil
+			}
+		}
+		next, ok := store.GetBlockByHeight(cur.Height + 1)
+		if !ok {
+			break
+		}
+		if c1, ok = certOf(store, next); !ok {
+			break
+		}
+		sh.Chain = append(sh.Chain, next.Header())
+		cur = next
+	}
+	return SignedHeader{}, fmt.Errorf("%w: no DoubleCert above height %d", ErrNoProof, h)
+}
+
+// certOf returns the stored QC of b (QCs are stored by view).
+func certOf(store consensus.BlockStore, b consensus.Block) (consensus.Certificate, bool) {
+	c, ok := store.GetCert(b.View)
+	if !ok || c.H != consensus.HashOfBlock(b) {
+		return consensus.Certificate{}, false
+	}
+	return c, true
+}
@@ -0,0 +1,155 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+var (
+	ErrUnknownValidator = errors.New("validator not in directory")
+	ErrDuplicateEntry   = errors.New("duplicate validator directory entry")
+)
+
+// ValidatorInfo is one validator's network identity.
+type ValidatorInfo struct {
+	ID     consensus.NodeID
+	PeerID peer.ID        // libp2p identity (authenticated by the transport handshake)
+	Addrs  []ma.Multiaddr // where to dial it; may be empty if it dials us
+	BLSKey *crypto.BLSPubKey
+	Power  uint64 // genesis voting power (0 = 1)
+}
+
+// Directory maps consensus NodeIDs to libp2p peers and BLS keys. It is the
+// only source of truth for "who is validator X": votes are sent to X's peer
+// ID and a vote claiming From=X is accepted only from that peer.
+type Directory struct {
+	order  []consensus.NodeID
+	byID   map[consensus.NodeID]ValidatorInfo
+	byPeer map[peer.ID]consensus.NodeID
+}
+
+// NewDirectory builds a directory; IDs and peer IDs must be unique.
+func NewDirectory(vals []ValidatorInfo) (*Directory, error) {
+	d := &Directory{
+		byID:   make(map[consensus.NodeID]ValidatorInfo, len(vals)),
+		byPeer: make(map[peer.ID]consensus.NodeID, len(vals)),
+	}
+	for _, v := range vals {
+		if v.ID == "" || v.PeerID == "" {
+			return nil, fmt.Errorf("validator directory: entry %q needs id and peer id", v.ID)
+		}
+		if _, dup := d.byID[v.ID]; dup {
+			return nil, fmt.Errorf("%w: id %s", ErrDuplicateEntry, v.ID)
+		}
+		if _, dup := d.byPeer[v.PeerID]; dup {
+			return nil, fmt.Errorf("%w: peer %s", ErrDuplicateEntry, v.PeerID)
+		}
+		d.order = append(d.order, v.ID)
+		d.byID[v.ID] = v
+		d.byPeer[v.PeerID] = v.ID
+	}
+	return d, nil
+}
+
+// Lookup returns the identity of validator id.
+func (d *Directory) Lookup(id consensus.NodeID) (ValidatorInfo, bool) {
+	v, ok := d.byID[id]
+	return v, ok
+}
+
+// NodeOf returns the validator behind an authenticated peer.
+func (d *Directory) NodeOf(p peer.ID) (consensus.NodeID, bool) {
+	id, ok := d.byPeer[p]
+	return id, ok
+}
+
+// IDs returns validator IDs in directory order (the Certificate.Signers order).
+func (d *Directory) IDs() []consensus.NodeID {
+	return append([]consensus.NodeID(nil), d.order...)
+}
+
+// PubKeys returns the BLS key of every validator that has one.
+func (d *Directory) PubKeys() map[consensus.NodeID]*crypto.BLSPubKey {
+	out := make(map[consensus.NodeID]*crypto.BLSPubKey, len(d.byID))
+	for id, v := range d.byID {
+		if v.BLSKey != nil {
+			out[id] = v.BLSKey
+		}
+	}
+	return out
+}
+
+// ValidatorSet returns the genesis validator set: every validator in
+// directory order, with its Power.
+func (d *Directory) ValidatorSet() (*consensus.ValidatorSet, error) {
+	vals := make([]consensus.Validator, 0, len(d.order))
+	for _, id := range d.order {
+		power := d.byID[id].Power
+		if power == 0 {
+			power = 1
+		}
+		vals = append(vals, consensus.Validator{ID: id, Power: power})
+	}
+	return consensus.NewValidatorSet(0, vals)
+}
+
+// directoryFile is the JSON validator set (genesis or config):
+//
+//	{"validators": [{"id": "val1", "peer_id": "12D3Koo...",
+//	  "addrs": ["/ip4/10.0.0.1/tcp/4001"], "bls_pubkey": "<hex>", "power": 10}]}
+type directoryFile struct {
+	Validators []struct {
+		ID        string   `json:"id"`
+		PeerID    string   `json:"peer_id"`
+		Addrs     []string `json:"addrs"`
+		BLSPubKey string   `json:"bls_pubkey"`
+		Power     uint64   `json:"power"`
+	} `json:"validators"`
+}
+
+// LoadDirectory reads a validator directory from a JSON file.
+func LoadDirectory(path string) (*Directory, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f directoryFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("validator directory %s: %w", path, err)
+	}
+	vals := make([]ValidatorInfo, 0, len(f.Validators))
+	for _, e := range f.Validators {
+		pid, err := peer.Decode(e.PeerID)
+		if err != nil {
+			return nil, fmt.Errorf("validator %s: peer id: %w", e.ID, err)
+		}
+		v := ValidatorInfo{ID: consensus.NodeID(e.ID), PeerID: pid, Power: e.Power}
+		for _, a := range e.Addrs {
+			m, err := ma.NewMultiaddr(a)
+			if err != nil {
+				return nil, fmt.Errorf("validator %s: addr %q: %w", e.ID, a, err)
+			}
+			v.Addrs = append(v.Addrs, m)
+		}
+		if e.BLSPubKey != "" {
+			kb, err := hex.DecodeString(e.BLSPubKey)
+			if err != nil {
+				return nil, fmt.Errorf("validator %s: bls key: %w", e.ID, err)
+			}
+			if v.BLSKey, err = crypto.ParseBLSPubKey(kb); err != nil {
+				return nil, fmt.Errorf("validator %s: bls key: %w", e.ID, err)
+			}
+		}
+		vals = append(vals, v)
+	}
+	return NewDirectory(vals)
+}
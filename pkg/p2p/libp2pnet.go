@@ -3,15 +3,18 @@ package p2p
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"sync"
 	"time"
 
 	libp2p "github.com/libp2p/go-libp2p"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	ma "github.com/multiformats/go-multiaddr"
 	"go.uber.org/zap"
@@ -20,20 +23,34 @@ import (
 )
 
 const (
-	topicPropose = "hs2-propose"
-	topicPrepare = "hs2-prepare"
-	protocolVote = protocol.ID("/hs2/vote/1.0.0")
+	topicPropose  = "hs2-propose"
+	topicPrepare  = "hs2-prepare"
+	topicTimeout  = "hs2-timeout"
+	topicEvidence = "hs2-evidence"
+	protocolVote  = protocol.ID("/hs2/vote/1.0.0")
+	protocolSync  = protocol.ID("/hs2/sync/1.0.0")
+
+	syncRequestTimeout = 2 * time.Second
+	dialTimeout        = 5 * time.Second
 )
 
+var ErrNoDirectory = errors.New("no validator directory configured")
+
 type Libp2pNet struct {
 	h    host.Host
 	ps   *pubsub.PubSub
 	log  *zap.SugaredLogger
 	self consensus.NodeID
 	q    consensus.Quorum
+	dir  *Directory
+
+	// authenticated: connected peers whose (handshake-verified) peer ID is a
+	// validator in dir
+	muAuth        sync.RWMutex
+	authenticated map[peer.ID]consensus.NodeID
 
-	tPropose, tPrepare     *pubsub.Topic
-	subPropose, subPrepare *pubsub.Subscription
+	tPropose, tPrepare, tTimeout, tEvidence         *pubsub.Topic
+	subPropose, subPrepare, subTimeout, subEvidence *pubsub.Subscription
 
 	muVotes sync.Mutex
 	votes   map[consensus.View]map[consensus.Hash][]consensus.Vote // Leader collects votes here
@@ -42,6 +59,10 @@ type Libp2pNet struct {
 	// When a vote arrives, we signal voteArrivedCh to wake up CollectVotes immediately
 	voteArrivedCh chan struct{}
 
+	// header-first proposals: entries of recent proposals, pending txs
+	txs  *txCache
+	pool TxPool
+
 	muPrep  sync.Mutex
 	prepByV map[consensus.View]struct {
 		c consensus.Certificate
@@ -58,6 +79,16 @@ type Libp2pConfig struct {
 	SelfID     consensus.NodeID
 	Quorum     consensus.Quorum
 	Logger     *zap.SugaredLogger
+
+	// Identity is the libp2p key; its peer ID must match SelfID's directory
+	// entry. Nil generates a random key (only useful without a Directory).
+	Identity p2pcrypto.PrivKey
+	// Directory maps validators to peers. Votes are sent and accepted only
+	// through it; without one, only self-votes work.
+	Directory *Directory
+	// TxPool supplies pending txs to rebuild proposals from their tx hashes;
+	// nil fetches every tx from peers.
+	TxPool TxPool
 }
 
 func NewLibp2pNet(ctx context.Context, cfg Libp2pConfig) (*Libp2pNet, error) {
@@ -69,10 +100,19 @@ func NewLibp2pNet(ctx context.Context, cfg Libp2pConfig) (*Libp2pNet, error) {
 		}
 		opts = append(opts, libp2p.ListenAddrs(maddr))
 	}
+	if cfg.Identity != nil {
+		opts = append(opts, libp2p.Identity(cfg.Identity))
+	}
 	h, err := libp2p.New(opts...)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Directory != nil {
+		if self, ok := cfg.Directory.Lookup(cfg.SelfID); ok && self.PeerID != h.ID() {
+			h.Close()
+			return nil, fmt.Errorf("identity %s does not match directory entry %s for %s", h.ID(), self.PeerID, cfg.SelfID)
+		}
+	}
 	ps, err := pubsub.NewGossipSub(ctx, h)
 	if err != nil {
 		return nil, err
@@ -80,15 +120,24 @@ func NewLibp2pNet(ctx context.Context, cfg Libp2pConfig) (*Libp2pNet, error) {
 
 	net := &Libp2pNet{
 		h: h, ps: ps, log: cfg.Logger,
-		self: cfg.SelfID, q: cfg.Quorum,
+		self: cfg.SelfID, q: cfg.Quorum, dir: cfg.Directory,
+		authenticated: make(map[peer.ID]consensus.NodeID),
 		votes:         make(map[consensus.View]map[consensus.Hash][]consensus.Vote),
 		voteArrivedCh: make(chan struct{}, 100), // Buffered to avoid blocking vote handlers
+		txs:           newTxCache(),
+		pool:          cfg.TxPool,
 		prepByV: make(map[consensus.View]struct {
 			c consensus.Certificate
 			b consensus.Block
 		}),
 	}
 
+	h.Network().Notify(&network.NotifyBundle{
+		ConnectedF:    func(_ network.Network, c network.Conn) { net.onConnected(c) },
+		DisconnectedF: func(nw network.Network, c network.Conn) { net.onDisconnected(nw, c) },
+	})
+	net.dialValidators(ctx)
+
 	for _, bs := range cfg.Bootstrap {
 		if err := connectMultiaddr(ctx, h, bs); err != nil && cfg.Logger != nil {
 			cfg.Logger.Warnw("bootstrap_connect_failed", "addr", bs, "err", err)
@@ -101,9 +150,13 @@ func NewLibp2pNet(ctx context.Context, cfg Libp2pConfig) (*Libp2pNet, error) {
 
 	// Set up stream handler for receiving votes (unicast)
 	h.SetStreamHandler(protocolVote, net.handleVoteStream)
+	// Catch-up requests (GetBlocksByHeight / GetCertByView)
+	h.SetStreamHandler(protocolSync, net.handleSyncStream)
 
 	go net.handlePropose(ctx)
 	go net.handlePrepare(ctx)
+	go net.handleTimeout(ctx)
+	go net.handleEvidence(ctx)
 
 	if cfg.Logger != nil {
 		cfg.Logger.Infow("libp2p_ready", "peer", h.ID().String(), "listen", cfg.ListenAddr)
@@ -111,6 +164,70 @@ func NewLibp2pNet(ctx context.Context, cfg Libp2pConfig) (*Libp2pNet, error) {
 	return net, nil
 }
 
+// dialValidators adds every other validator's addresses to the peerstore and
+// connects to the ones that have any. Failures are logged: the peer may dial
+// us later, and SendVote redials on demand.
+func (n *Libp2pNet) dialValidators(ctx context.Context) {
+	if n.dir == nil {
+		return
+	}
+	for _, id := range n.dir.IDs() {
+		v, _ := n.dir.Lookup(id)
+		if id == n.self || len(v.Addrs) == 0 {
+			continue
+		}
+		n.h.Peerstore().AddAddrs(v.PeerID, v.Addrs, peerstore.PermanentAddrTTL)
+		dctx, cancel := context.WithTimeout(ctx, dialTimeout)
+		err := n.h.Connect(dctx, peer.AddrInfo{ID: v.PeerID, Addrs: v.Addrs})
+		cancel()
+		if err != nil && n.log != nil {
+			n.log.Warnw("validator_dial_failed", "validator", id, "peer", v.PeerID.String(), "err", err)
+		}
+	}
+}
+
+// onConnected authenticates a new connection. The security handshake has
+// already proven the remote owns its peer ID; here the peer ID is matched
+// against the directory.
+func (n *Libp2pNet) onConnected(c network.Conn) {
+	p := c.RemotePeer()
+	if n.dir == nil {
+		return
+	}
+	id, ok := n.dir.NodeOf(p)
+	if !ok {
+		if n.log != nil {
+			n.log.Debugw("peer_not_validator", "peer", p.String())
+		}
+		return
+	}
+	n.muAuth.Lock()
+	_, known := n.authenticated[p]
+	n.authenticated[p] = id
+	n.muAuth.Unlock()
+	if !known && n.log != nil {
+		n.log.Infow("validator_connected", "validator", id, "peer", p.String())
+	}
+}
+
+func (n *Libp2pNet) onDisconnected(nw network.Network, c network.Conn) {
+	p := c.RemotePeer()
+	if nw.Connectedness(p) == network.Connected {
+		return // another connection to p is still open
+	}
+	n.muAuth.Lock()
+	delete(n.authenticated, p)
+	n.muAuth.Unlock()
+}
+
+// ValidatorOf returns the validator behind a connected, authenticated peer.
+func (n *Libp2pNet) ValidatorOf(p peer.ID) (consensus.NodeID, bool) {
+	n.muAuth.RLock()
+	defer n.muAuth.RUnlock()
+	id, ok := n.authenticated[p]
+	return id, ok
+}
+
 func connectMultiaddr(ctx context.Context, h host.Host, addr string) error {
 	m, err := ma.NewMultiaddr(addr)
 	if err != nil {
@@ -131,6 +248,12 @@ func (n *Libp2pNet) joinTopics(ctx context.Context) error {
 	if n.tPrepare, err = n.ps.Join(topicPrepare); err != nil {
 		return err
 	}
+	if n.tTimeout, err = n.ps.Join(topicTimeout); err != nil {
+		return err
+	}
+	if n.tEvidence, err = n.ps.Join(topicEvidence); err != nil {
+		return err
+	}
 
 	if n.subPropose, err = n.tPropose.Subscribe(); err != nil {
 		return err
@@ -138,6 +261,12 @@ func (n *Libp2pNet) joinTopics(ctx context.Context) error {
 	if n.subPrepare, err = n.tPrepare.Subscribe(); err != nil {
 		return err
 	}
+	if n.subTimeout, err = n.tTimeout.Subscribe(); err != nil {
+		return err
+	}
+	if n.subEvidence, err = n.tEvidence.Subscribe(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -148,9 +277,19 @@ func (n *Libp2pNet) SetHandlers(h consensus.Handlers) { n.muH.Lock(); n.handlers
 func (n *Libp2pNet) Host() host.Host { return n.h }
 
 func (n *Libp2pNet) BroadcastPropose(ctx context.Context, p consensus.Propose) error {
-	bb, _ := gobEncode(p.Block)
-	hh, _ := gobEncode(p.HighCert)
-	data, err := gobEncode(ProposalWire{Block: bb, HighCert: hh})
+	w, err := n.proposalWire(p)
+	if err != nil {
+		return err
+	}
+	w.HighCert, _ = gobEncode(p.HighCert)
+	if p.TC != nil {
+		w.TC, _ = gobEncode(*p.TC)
+	}
+	if p.HighDouble != nil {
+		w.HighDouble, _ = gobEncode(*p.HighDouble)
+	}
+	w.Sig = p.Sig
+	data, err := gobEncode(w)
 	if err != nil {
 		return err
 	}
@@ -175,45 +314,54 @@ func (n *Libp2pNet) BroadcastPrepare(ctx context.Context, cert consensus.Certifi
 	return n.tPrepare.Publish(context.Background(), data)
 }
 
+func (n *Libp2pNet) BroadcastTimeout(ctx context.Context, t consensus.Timeout) error {
+	tb, err := gobEncode(t)
+	if err != nil {
+		return err
+	}
+	data, err := gobEncode(TimeoutWire{Timeout: tb})
+	if err != nil {
+		return err
+	}
+	return n.tTimeout.Publish(ctx, data)
+}
+
+func (n *Libp2pNet) BroadcastEvidence(ctx context.Context, ev consensus.Evidence) error {
+	eb, err := gobEncode(ev)
+	if err != nil {
+		return err
+	}
+	data, err := gobEncode(EvidenceWire{Evidence: eb})
+	if err != nil {
+		return err
+	}
+	return n.tEvidence.Publish(ctx, data)
+}
+
 func (n *Libp2pNet) SendVote(ctx context.Context, to consensus.NodeID, v consensus.Vote) error {
 	// HotStuff: votes are sent directly to leader (unicast), not broadcast
 
 	// Case 1: Sending to self (single-node or leader voting for own propose)
 	if to == n.self {
-		n.muVotes.Lock()
-		if n.votes[v.View] == nil {
-			n.votes[v.View] = make(map[consensus.Hash][]consensus.Vote)
-		}
-		n.votes[v.View][v.H] = append(n.votes[v.View][v.H], v)
-		n.muVotes.Unlock()
-
-		// Signal vote arrival (wake up CollectVotes immediately)
-		select {
-		case n.voteArrivedCh <- struct{}{}:
-		default:
-			// Channel full, skip signal (collector will check periodically anyway)
-		}
+		n.addVote(v)
 		return nil
 	}
 
-	// Case 2: Multi-node - send to leader via libp2p stream
-	// Find leader's peer ID (for now, assume NodeID maps to first connected peer)
-	// TODO: Proper peer ID mapping from NodeID
-	peers := n.h.Network().Peers()
-	if len(peers) == 0 {
-		return errors.New("no peers connected")
+	// Case 2: Multi-node - send to the leader's peer from the directory
+	if n.dir == nil {
+		return ErrNoDirectory
 	}
-
-	// Send to first peer (simplified - in production, map NodeID -> PeerID)
-	var targetPeer peer.ID
-	for _, p := range peers {
-		targetPeer = p
-		break
+	target, ok := n.dir.Lookup(to)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownValidator, to)
+	}
+	if n.h.Network().Connectedness(target.PeerID) != network.Connected && len(target.Addrs) > 0 {
+		n.h.Peerstore().AddAddrs(target.PeerID, target.Addrs, peerstore.PermanentAddrTTL)
 	}
 
-	stream, err := n.h.NewStream(ctx, targetPeer, protocolVote)
+	stream, err := n.h.NewStream(ctx, target.PeerID, protocolVote)
 	if err != nil {
-		return err
+		return fmt.Errorf("send vote to %s: %w", to, err)
 	}
 	defer stream.Close()
 
@@ -227,6 +375,86 @@ func (n *Libp2pNet) SendVote(ctx context.Context, to consensus.NodeID, v consens
 	return err
 }
 
+// GetBlocksByHeight asks every connected peer and keeps the longest answer.
+func (n *Libp2pNet) GetBlocksByHeight(ctx context.Context, from, to consensus.Height) (consensus.BlockRange, error) {
+	req := SyncRequestWire{Kind: syncGetBlocks, From: uint64(from), To: uint64(to)}
+	var best consensus.BlockRange
+	var lastErr error
+	answered := false
+	for _, p := range n.h.Network().Peers() {
+		resp, err := n.syncRequest(ctx, p, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var r consensus.BlockRange
+		if err := gobDecode(resp.Range, &r); err != nil {
+			lastErr = err
+			continue
+		}
+		answered = true
+		if len(r.Blocks) > len(best.Blocks) {
+			best = r
+		}
+	}
+	if !answered {
+		if lastErr == nil {
+			lastErr = errors.New("no peers connected")
+		}
+		return best, lastErr
+	}
+	return best, nil
+}
+
+// GetCertByView returns the QC for v from the first peer that has it.
+func (n *Libp2pNet) GetCertByView(ctx context.Context, v consensus.View) (consensus.Certificate, error) {
+	req := SyncRequestWire{Kind: syncGetCert, View: uint64(v)}
+	for _, p := range n.h.Network().Peers() {
+		resp, err := n.syncRequest(ctx, p, req)
+		if err != nil || len(resp.Cert) == 0 {
+			continue
+		}
+		var c consensus.Certificate
+		if err := gobDecode(resp.Cert, &c); err == nil {
+			return c, nil
+		}
+	}
+	return consensus.Certificate{}, fmt.Errorf("no peer has the certificate for view %d", v)
+}
+
+// syncRequest sends one request to p and reads its response.
+func (n *Libp2pNet) syncRequest(ctx context.Context, p peer.ID, req SyncRequestWire) (SyncResponseWire, error) {
+	var resp SyncResponseWire
+	ctx, cancel := context.WithTimeout(ctx, syncRequestTimeout)
+	defer cancel()
+
+	stream, err := n.h.NewStream(ctx, p, protocolSync)
+	if err != nil {
+		return resp, err
+	}
+	defer stream.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(dl)
+	}
+
+	data, err := gobEncode(req)
+	if err != nil {
+		return resp, err
+	}
+	if _, err := stream.Write(data); err != nil {
+		return resp, err
+	}
+	if err := stream.CloseWrite(); err != nil {
+		return resp, err
+	}
+	raw, err := io.ReadAll(stream)
+	if err != nil {
+		return resp, err
+	}
+	err = gobDecode(raw, &resp)
+	return resp, err
+}
+
 // CollectVotes: Channel-based reactive collection (eliminates polling)
 // Performance improvement: instant wake-up when threshold reached (was 0-50ms random delay)
 func (n *Libp2pNet) CollectVotes(ctx context.Context, view consensus.View, h consensus.Hash, need int) ([]consensus.Vote, error) {
@@ -286,20 +514,39 @@ func (n *Libp2pNet) handlePropose(ctx context.Context) {
 		if err := gobDecode(msg.Data, &w); err != nil {
 			continue
 		}
-		var blk consensus.Block
+		var hdr consensus.BlockHeader
 		var hc consensus.Certificate
-		if err := gobDecode(w.Block, &blk); err != nil {
+		if err := gobDecode(w.Header, &hdr); err != nil {
 			continue
 		}
 		if err := gobDecode(w.HighCert, &hc); err != nil {
 			continue
 		}
+		var tc *consensus.TimeoutCert
+		if len(w.TC) > 0 {
+			tc = new(consensus.TimeoutCert)
+			if err := gobDecode(w.TC, tc); err != nil {
+				continue
+			}
+		}
+		var dc *consensus.DoubleCert
+		if len(w.HighDouble) > 0 {
+			dc = new(consensus.DoubleCert)
+			if err := gobDecode(w.HighDouble, dc); err != nil {
+				continue
+			}
+		}
+
+		blk, ok := n.rebuildBlock(ctx, hdr, w.TxHashes, msg.ReceivedFrom)
+		if !ok {
+			continue
+		}
 
 		n.muH.RLock()
 		h := n.handlers
 		n.muH.RUnlock()
 		if h.OnPropose != nil {
-			h.OnPropose(ctx, consensus.Propose{Block: blk, HighCert: hc})
+			h.OnPropose(ctx, consensus.Propose{Block: blk, HighCert: hc, HighDouble: dc, TC: tc, Sig: w.Sig})
 		}
 	}
 }
@@ -338,6 +585,57 @@ func (n *Libp2pNet) handlePrepare(ctx context.Context) {
 	}
 }
 
+func (n *Libp2pNet) handleTimeout(ctx context.Context) {
+	for {
+		msg, err := n.subTimeout.Next(ctx)
+		if err != nil {
+			return
+		}
+		var w TimeoutWire
+		if err := gobDecode(msg.Data, &w); err != nil {
+			continue
+		}
+		var t consensus.Timeout
+		if err := gobDecode(w.Timeout, &t); err != nil {
+			continue
+		}
+
+		n.muH.RLock()
+		h := n.handlers
+		n.muH.RUnlock()
+		if h.OnTimeout != nil {
+			h.OnTimeout(ctx, t)
+		}
+	}
+}
+
+func (n *Libp2pNet) handleEvidence(ctx context.Context) {
+	for {
+		msg, err := n.subEvidence.Next(ctx)
+		if err != nil {
+			return
+		}
+		if msg.ReceivedFrom == n.h.ID() {
+			continue // our own report
+		}
+		var w EvidenceWire
+		if err := gobDecode(msg.Data, &w); err != nil {
+			continue
+		}
+		var ev consensus.Evidence
+		if err := gobDecode(w.Evidence, &ev); err != nil {
+			continue
+		}
+
+		n.muH.RLock()
+		h := n.handlers
+		n.muH.RUnlock()
+		if h.OnEvidence != nil {
+			h.OnEvidence(ctx, ev)
+		}
+	}
+}
+
 // handleVoteStream: Receive votes via libp2p stream (unicast from followers to leader)
 func (n *Libp2pNet) handleVoteStream(s network.Stream) {
 	defer s.Close()
@@ -353,11 +651,95 @@ func (n *Libp2pNet) handleVoteStream(s network.Stream) {
 		return
 	}
 
+	// The sender must be the validator it claims to be
+	remote := s.Conn().RemotePeer()
+	from, ok := n.ValidatorOf(remote)
+	if !ok && n.dir != nil {
+		from, ok = n.dir.NodeOf(remote) // stream raced the connect notification
+	}
+	if !ok || from != v.From {
+		if n.log != nil {
+			n.log.Warnw("vote_rejected_sender_mismatch", "view", v.View, "from", v.From,
+				"peer", remote.String(), "peer_validator", from)
+		}
+		return
+	}
+
 	// Store vote (leader collects votes)
+	n.addVote(v)
+}
+
+// handleSyncStream answers a catch-up request from the local store.
+func (n *Libp2pNet) handleSyncStream(s network.Stream) {
+	defer s.Close()
+	_ = s.SetDeadline(time.Now().Add(syncRequestTimeout))
+
+	raw, err := io.ReadAll(s)
+	if err != nil {
+		return
+	}
+	var req SyncRequestWire
+	if err := gobDecode(raw, &req); err != nil {
+		return
+	}
+
+	n.muH.RLock()
+	h := n.handlers
+	n.muH.RUnlock()
+
+	var resp SyncResponseWire
+	switch req.Kind {
+	case syncGetBlocks:
+		var r consensus.BlockRange
+		if h.ServeBlocks != nil {
+			r = h.ServeBlocks(consensus.Height(req.From), consensus.Height(req.To))
+		}
+		if resp.Range, err = gobEncode(r); err != nil {
+			return
+		}
+	case syncGetCert:
+		if h.ServeCert != nil {
+			if c, ok := h.ServeCert(consensus.View(req.View)); ok {
+				resp.Cert, _ = gobEncode(c)
+			}
+		}
+	case syncGetTxs:
+		resp.Txs = n.serveTxs(req.TxHashes)
+	default:
+		return
+	}
+
+	data, err := gobEncode(resp)
+	if err != nil {
+		return
+	}
+	_, _ = s.Write(data)
+}
+
+// addVote stores a verified vote for CollectVotes, one per sender per view.
+func (n *Libp2pNet) addVote(v consensus.Vote) {
+	n.muH.RLock()
+	verify := n.handlers.VerifyVote
+	n.muH.RUnlock()
+	if verify != nil && !verify(v) {
+		if n.log != nil {
+			n.log.Warnw("vote_rejected_bad_sig", "view", v.View, "from", v.From)
+		}
+		return
+	}
+
 	n.muVotes.Lock()
 	if n.votes[v.View] == nil {
 		n.votes[v.View] = make(map[consensus.Hash][]consensus.Vote)
 	}
+	for _, votes := range n.votes[v.View] {
+		for _, got := range votes {
+			if got.From == v.From {
+				n.muVotes.Unlock()
+				return // duplicate (or equivocating) vote from the same sender
+			}
+		}
+	}
 	n.votes[v.View][v.H] = append(n.votes[v.View][v.H], v)
 	n.muVotes.Unlock()
 
@@ -368,3 +750,7 @@ func (n *Libp2pNet) handleVoteStream(s network.Stream) {
 		// Channel full, skip signal (collector will eventually timeout and check)
 	}
 }
+
+var _ consensus.Network = (*Libp2pNet)(nil)
+var _ consensus.SyncNetwork = (*Libp2pNet)(nil)
+var _ consensus.EvidenceNetwork = (*Libp2pNet)(nil)
@@ -0,0 +1,217 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// Header-first proposals
+//
+// Gossiping whole blocks sends every transaction through GossipSub again,
+// although most validators already hold most of them. A ProposalWire
+// carries the block header and its payload entry hashes instead; the
+// receiver checks the hashes against Header.PayloadHash, takes the entries
+// it holds (recent proposals, then its TxPool) and fetches only the
+// missing ones, from the proposer first (syncGetTxs). The rebuilt block
+// hashes to the same block hash, so the engine sees no difference.
+
+// TxPool looks up pending transactions by hash (the app's mempool).
+type TxPool interface {
+	GetTx(h consensus.Hash) ([]byte, bool)
+}
+
+const (
+	// proposalCacheBlocks is how many recent proposals' entries are kept to
+	// rebuild and serve proposals
+	proposalCacheBlocks = 64
+	// maxTxsPerRequest caps the entries asked for (and served) per request
+	maxTxsPerRequest = 4096
+)
+
+// txCache holds the payload entries of recent proposals by hash.
+type txCache struct {
+	mu     sync.Mutex
+	byHash map[consensus.Hash][]byte
+	refs   map[consensus.Hash]int
+	blocks [][]consensus.Hash // oldest first
+}
+
+func newTxCache() *txCache {
+	return &txCache{byHash: make(map[consensus.Hash][]byte), refs: make(map[consensus.Hash]int)}
+}
+
+// add keeps entries (of one proposal), evicting the oldest proposal's.
+func (c *txCache) add(entries [][]byte) {
+	if len(entries) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hashes := make([]consensus.Hash, len(entries))
+	for i, e := range entries {
+		h := consensus.TxHash(e)
+		hashes[i] = h
+		c.byHash[h] = e
+		c.refs[h]++
+	}
+	c.blocks = append(c.blocks, hashes)
+	for len(c.blocks) > proposalCacheBlocks {
+		for _, h := range c.blocks[0] {
+			if c.refs[h]--; c.refs[h] == 0 {
+				delete(c.refs, h)
+				delete(c.byHash, h)
+			}
+		}
+		c.blocks = c.blocks[1:]
+	}
+}
+
+func (c *txCache) get(h consensus.Hash) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.byHash[h]
+	return e, ok
+}
+
+// lookupTx returns the entry with hash h from recent proposals or the pool.
+func (n *Libp2pNet) lookupTx(h consensus.Hash) ([]byte, bool) {
+	if e, ok := n.txs.get(h); ok {
+		return e, true
+	}
+	if n.pool != nil {
+		return n.pool.GetTx(h)
+	}
+	return nil, false
+}
+
+// proposalWire encodes p header-first and remembers its entries, which
+// peers will ask us for.
+func (n *Libp2pNet) proposalWire(p consensus.Propose) (ProposalWire, error) {
+	entries := consensus.PayloadEntries(p.Block.Payload)
+	n.txs.add(entries)
+	hashes := make([]consensus.Hash, len(entries))
+	for i, e := range entries {
+		hashes[i] = consensus.TxHash(e)
+	}
+	hb, err := gobEncode(p.Block.Header())
+	if err != nil {
+		return ProposalWire{}, err
+	}
+	return ProposalWire{Header: hb, TxHashes: hashes}, nil
+}
+
+// rebuildBlock turns a header and its entry hashes back into the block,
+// fetching missing entries from the proposer, then from relay and the
+// other peers. ok is false if the hashes do not match the header or an
+// entry could not be found.
+func (n *Libp2pNet) rebuildBlock(ctx context.Context, hdr consensus.BlockHeader, hashes []consensus.Hash, relay peer.ID) (consensus.Block, bool) {
+	if consensus.MerkleRoot(hashes) != hdr.PayloadHash {
+		if n.log != nil {
+			n.log.Warnw("proposal_payload_hash_mismatch", "view", hdr.View, "proposer", hdr.Proposer)
+		}
+		return consensus.Block{}, false
+	}
+	entries := make([][]byte, len(hashes))
+	var missing []int
+	cached := 0
+	for i, h := range hashes {
+		if e, ok := n.txs.get(h); ok {
+			entries[i] = e
+			cached++
+		} else if e, ok := n.lookupTx(h); ok {
+			entries[i] = e
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	fetched := len(missing)
+	if fetched > 0 {
+		for _, p := range n.txSources(hdr.Proposer, relay) {
+			if missing = n.fetchTxs(ctx, p, hashes, entries, missing); len(missing) == 0 {
+				break
+			}
+		}
+	}
+	if len(missing) > 0 {
+		if n.log != nil {
+			n.log.Warnw("proposal_txs_unavailable", "view", hdr.View, "proposer", hdr.Proposer,
+				"txs", len(hashes), "missing", len(missing))
+		}
+		return consensus.Block{}, false
+	}
+	blk, err := hdr.WithPayload(entries)
+	if err != nil {
+		return consensus.Block{}, false
+	}
+	if cached < len(entries) {
+		n.txs.add(entries) // not our own proposal coming back: serve it too
+	}
+	if fetched > 0 && n.log != nil {
+		n.log.Debugw("proposal_txs_fetched", "view", hdr.View, "txs", len(hashes), "fetched", fetched)
+	}
+	return blk, true
+}
+
+// txSources lists the peers to ask for missing entries: the proposer, the
+// peer that relayed the proposal, then everybody else.
+func (n *Libp2pNet) txSources(proposer consensus.NodeID, relay peer.ID) []peer.ID {
+	var out []peer.ID
+	seen := map[peer.ID]bool{n.h.ID(): true}
+	add := func(p peer.ID) {
+		if p != "" && !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	if n.dir != nil {
+		if v, ok := n.dir.Lookup(proposer); ok {
+			add(v.PeerID)
+		}
+	}
+	add(relay)
+	for _, p := range n.h.Network().Peers() {
+		add(p)
+	}
+	return out
+}
+
+// fetchTxs asks p for the entries at the missing indexes of hashes, fills
+// them into entries and returns the indexes still missing. Entries that do
+// not hash to what was asked are ignored.
+func (n *Libp2pNet) fetchTxs(ctx context.Context, p peer.ID, hashes []consensus.Hash, entries [][]byte, missing []int) []int {
+	var still []int
+	for len(missing) > 0 {
+		batch := missing[:min(len(missing), maxTxsPerRequest)]
+		missing = missing[len(batch):]
+		req := SyncRequestWire{Kind: syncGetTxs, TxHashes: make([]consensus.Hash, len(batch))}
+		for i, idx := range batch {
+			req.TxHashes[i] = hashes[idx]
+		}
+		resp, err := n.syncRequest(ctx, p, req)
+		if err != nil {
+			return append(append(still, batch...), missing...)
+		}
+		for i, idx := range batch {
+			if i < len(resp.Txs) && len(resp.Txs[i]) > 0 && consensus.TxHash(resp.Txs[i]) == hashes[idx] {
+				entries[idx] = resp.Txs[i]
+			} else {
+				still = append(still, idx)
+			}
+		}
+	}
+	return still
+}
+
+// serveTxs answers syncGetTxs from recent proposals and the pool.
+func (n *Libp2pNet) serveTxs(hashes []consensus.Hash) [][]byte {
+	hashes = hashes[:min(len(hashes), maxTxsPerRequest)]
+	out := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		out[i], _ = n.lookupTx(h)
+	}
+	return out
+}
@@ -3,17 +3,30 @@ package p2p
 import (
 	"bytes"
 	"encoding/gob"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
 )
 
 func init() {
 	gob.Register(ProposalWire{})
 	gob.Register(PrepareWire{})
 	gob.Register(VoteWire{})
+	gob.Register(TimeoutWire{})
+	gob.Register(EvidenceWire{})
+	gob.Register(SyncRequestWire{})
+	gob.Register(SyncResponseWire{})
 }
 
+// ProposalWire carries a proposal header-first: the block header and the
+// hashes of its payload entries, in order. Receivers rebuild the payload
+// from txs they hold and fetch the rest (syncGetTxs); see proposal.go.
 type ProposalWire struct {
-	Block    []byte // gob-encoded consensus.Block
-	HighCert []byte // gob-encoded consensus.Certificate
+	Header     []byte           // gob-encoded consensus.BlockHeader
+	TxHashes   []consensus.Hash // payload entries (consensus.TxHash), in order
+	HighCert   []byte           // gob-encoded consensus.Certificate
+	TC         []byte           // gob-encoded consensus.TimeoutCert (optional)
+	HighDouble []byte           // gob-encoded consensus.DoubleCert (optional)
+	Sig        []byte           // proposer's signature over ProposalSignBytes
 }
 
 type PrepareWire struct {
@@ -25,6 +38,34 @@ type VoteWire struct {
 	Vote []byte // gob-encoded consensus.Vote
 }
 
+type TimeoutWire struct {
+	Timeout []byte // gob-encoded consensus.Timeout
+}
+
+type EvidenceWire struct {
+	Evidence []byte // gob-encoded consensus.Evidence
+}
+
+// Sync request kinds
+const (
+	syncGetBlocks uint8 = 1
+	syncGetCert   uint8 = 2
+	syncGetTxs    uint8 = 3
+)
+
+type SyncRequestWire struct {
+	Kind     uint8
+	From, To uint64           // syncGetBlocks: height range
+	View     uint64           // syncGetCert
+	TxHashes []consensus.Hash // syncGetTxs
+}
+
+type SyncResponseWire struct {
+	Range []byte   // gob-encoded consensus.BlockRange (syncGetBlocks)
+	Cert  []byte   // gob-encoded consensus.Certificate (syncGetCert, empty if unknown)
+	Txs   [][]byte // syncGetTxs: per requested hash, nil if unknown
+}
+
 func gobEncode(v any) ([]byte, error) {
 	var buf bytes.Buffer
 	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
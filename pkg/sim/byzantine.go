@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// Behaviour is how a validator's outbound traffic is tampered with. The node
+// runs the normal engine; its network endpoint does the misbehaving.
+type Behaviour int
+
+const (
+	Honest Behaviour = iota
+	// Equivocate: as leader, send a conflicting block for the same view
+	// (different payload, same parent) to every other validator in ID order.
+	// Those validators then also get the original, as gossip would relay it.
+	Equivocate
+	// WithholdVotes: never send a vote
+	WithholdVotes
+	// WrongAppHash: vote with a corrupted AppHash
+	WrongAppHash
+	// DoubleVote: follow every vote with a second one for another block
+	DoubleVote
+)
+
+func (b Behaviour) String() string {
+	switch b {
+	case Honest:
+		return "honest"
+	case Equivocate:
+		return "equivocate"
+	case WithholdVotes:
+		return "withhold-votes"
+	case WrongAppHash:
+		return "wrong-apphash"
+	case DoubleVote:
+		return "double-vote"
+	}
+	return "unknown"
+}
+
+// SetBehaviour makes the endpoint misbehave. Set it before the run starts.
+func (ep *Endpoint) SetBehaviour(b Behaviour) { ep.behaviour = b }
+
+// misbehaveProposal returns the proposals dst receives from this endpoint,
+// in order.
+func (ep *Endpoint) misbehaveProposal(dst consensus.NodeID, p consensus.Propose) []consensus.Propose {
+	if ep.behaviour != Equivocate || dst == ep.id || ep.net.index(dst)%2 == 0 {
+		return []consensus.Propose{p}
+	}
+	q := p
+	b := p.Block
+	b.Payload = consensus.EncodePayload(append(consensus.PayloadEntries(b.Payload), []byte("equivocation")))
+	q.Block = b
+	// Signatures are not checked without BLS: the variant needs no re-signing
+	return []consensus.Propose{q, p}
+}
+
+// misbehaveVote returns the votes actually sent (none to withhold).
+func (ep *Endpoint) misbehaveVote(v consensus.Vote) []consensus.Vote {
+	switch ep.behaviour {
+	case WithholdVotes:
+		return nil
+	case WrongAppHash:
+		v.AppHash[0] ^= 0xff
+	case DoubleVote:
+		w := v
+		w.H[0] ^= 0xff
+		return []consensus.Vote{v, w}
+	}
+	return []consensus.Vote{v}
+}
+
+// index is id's position in the sorted validator list.
+func (n *Network) index(id consensus.NodeID) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, x := range n.ids {
+		if x == id {
+			return i
+		}
+	}
+	return -1
+}
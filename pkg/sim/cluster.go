@@ -0,0 +1,249 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/storage"
+	"github.com/uhyunpark/hyperlicked/pkg/util"
+)
+
+// Config describes a simulated validator set.
+type Config struct {
+	N      int
+	Seed   int64
+	Faults Faults
+	Timers consensus.PacemakerTimers // zero = 200ms + 100ms
+	// Byzantine maps validator index (0-based) to its behaviour
+	Byzantine map[int]Behaviour
+	// Settle overrides Network.Settle (zero = default)
+	Settle time.Duration
+	// Powers is the genesis voting power per validator index (nil = 1 each)
+	Powers []uint64
+	// EpochLength enables validator set changes (see consensus/epoch.go)
+	EpochLength consensus.Height
+	// Elector builds each engine's leader elector (nil = round-robin over
+	// the active validator set)
+	Elector func(e *consensus.Engine) consensus.LeaderElector
+}
+
+// Cluster runs N engines over a simulated network and records every commit
+// to check agreement (no two honest validators commit different blocks at a
+// height) and liveness (honest validators keep committing).
+type Cluster struct {
+	Seed    int64
+	IDs     []consensus.NodeID
+	Clock   *util.FakeClock
+	Net     *Network
+	Engines map[consensus.NodeID]*consensus.Engine
+	Apps    map[consensus.NodeID]*abci.MockApp
+	byz     map[consensus.NodeID]Behaviour
+	timers  consensus.PacemakerTimers
+	genesis *consensus.ValidatorSet
+	epoch   consensus.Height
+	elector func(e *consensus.Engine) consensus.LeaderElector
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	committed map[consensus.NodeID][]consensus.Hash // block hash per height-1
+	canonical []consensus.Hash                      // first honest commit per height-1
+	violation error
+	errs      map[consensus.NodeID]error
+}
+
+// simEpoch is the virtual start time of every simulation.
+var simEpoch = time.Unix(1_700_000_000, 0)
+
+func NewCluster(cfg Config) *Cluster {
+	if cfg.Timers == (consensus.PacemakerTimers{}) {
+		cfg.Timers = consensus.PacemakerTimers{Ppc: 200 * time.Millisecond, Delta: 100 * time.Millisecond}
+	}
+	clock := util.NewFakeClock(simEpoch)
+	c := &Cluster{
+		Seed:      cfg.Seed,
+		Clock:     clock,
+		Net:       NewNetwork(cfg.Seed, clock, cfg.Faults),
+		Engines:   make(map[consensus.NodeID]*consensus.Engine),
+		Apps:      make(map[consensus.NodeID]*abci.MockApp),
+		byz:       make(map[consensus.NodeID]Behaviour),
+		timers:    cfg.Timers,
+		epoch:     cfg.EpochLength,
+		elector:   cfg.Elector,
+		committed: make(map[consensus.NodeID][]consensus.Hash),
+		errs:      make(map[consensus.NodeID]error),
+	}
+	if cfg.Settle > 0 {
+		c.Net.Settle = cfg.Settle
+	}
+	vals := make([]consensus.Validator, cfg.N)
+	for i := 0; i < cfg.N; i++ {
+		c.IDs = append(c.IDs, consensus.NodeID(fmt.Sprintf("val%d", i+1)))
+		vals[i] = consensus.Validator{ID: c.IDs[i], Power: 1}
+		if i < len(cfg.Powers) {
+			vals[i].Power = cfg.Powers[i]
+		}
+	}
+	genesis, err := consensus.NewValidatorSet(0, vals)
+	if err != nil {
+		panic(fmt.Sprintf("sim: %v", err))
+	}
+	c.genesis = genesis
+	for i, id := range c.IDs {
+		ep := c.Net.Join(id)
+		if b := cfg.Byzantine[i]; b != Honest {
+			ep.SetBehaviour(b)
+			c.byz[id] = b
+		}
+		c.Engines[id] = c.newEngine(id, ep)
+	}
+	return c
+}
+
+func (c *Cluster) newEngine(id consensus.NodeID, ep *Endpoint) *consensus.Engine {
+	n := len(c.IDs)
+	state := &consensus.State{
+		Q:       consensus.Quorum{N: n, T: (n - 1) / 3},
+		SelfID:  id,
+		Blocks:  make(map[consensus.Hash]consensus.Block),
+		Genesis: consensus.GenesisBlock(),
+	}
+	pm := consensus.NewPacemaker(c.timers, c.Net.Clock(), state)
+	app := abci.NewMockApp()
+	c.Apps[id] = app
+	e := consensus.NewEngine(state, consensus.NewSafety(state), pm, &abci.Bridge{App: app},
+		ep, nil, crypto.DummySigner{})
+	e.Elector = consensus.ValidatorSetElector{Set: e.ActiveValidators}
+	e.Store = storage.NewInMemoryBlockStore()
+	e.ChainID = "sim"
+	e.Validators = c.IDs
+	e.GenesisSet = c.genesis
+	e.EpochLength = c.epoch
+	if c.elector != nil {
+		e.Elector = c.elector(e)
+	}
+	e.OnBlockCommit = func(h consensus.Height) { c.onCommit(id, e, h) }
+	return e
+}
+
+// Start runs every engine until Stop. Nothing happens until RunFor moves the
+// simulation forward.
+func (c *Cluster) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	for _, id := range c.IDs {
+		id, e := id, c.Engines[id]
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			if err := e.Run(ctx); err != nil && ctx.Err() == nil {
+				c.mu.Lock()
+				c.errs[id] = err
+				c.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// RunFor advances the simulation by d of virtual time.
+func (c *Cluster) RunFor(d time.Duration) { c.Net.RunFor(context.Background(), d) }
+
+// Stop ends every engine and waits for them to return.
+func (c *Cluster) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Honest returns the validators without a Byzantine behaviour.
+func (c *Cluster) Honest() []consensus.NodeID {
+	var out []consensus.NodeID
+	for _, id := range c.IDs {
+		if _, bad := c.byz[id]; !bad {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// onCommit records the blocks id committed up to height h and checks them
+// against the other honest validators.
+func (c *Cluster) onCommit(id consensus.NodeID, e *consensus.Engine, h consensus.Height) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from := consensus.Height(len(c.committed[id]) + 1)
+	e.Store.IterateCommitted(from, h, func(b consensus.Block) bool {
+		bh := consensus.HashOfBlock(b)
+		ht := len(c.committed[id]) + 1
+		if b.Height != consensus.Height(ht) {
+			c.fail(fmt.Errorf("%s: committed height %d after height %d", id, b.Height, ht-1))
+			return false
+		}
+		c.committed[id] = append(c.committed[id], bh)
+		if _, bad := c.byz[id]; bad {
+			return true
+		}
+		if ht > len(c.canonical) {
+			c.canonical = append(c.canonical, bh)
+		} else if c.canonical[ht-1] != bh {
+			c.fail(fmt.Errorf("%s: committed %x at height %d, another honest validator committed %x",
+				id, bh[:8], ht, c.canonical[ht-1][:8]))
+			return false
+		}
+		return true
+	})
+}
+
+// fail keeps the first invariant violation. Caller holds mu.
+func (c *Cluster) fail(err error) {
+	if c.violation == nil {
+		c.violation = fmt.Errorf("seed %d: %w", c.Seed, err)
+	}
+}
+
+// CheckAgreement returns the first safety violation seen so far, if any.
+func (c *Cluster) CheckAgreement() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.violation
+}
+
+// CheckLiveness fails unless every honest validator committed at least min
+// blocks and no engine stopped with an error.
+func (c *Cluster) CheckLiveness(min consensus.Height) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.IDs {
+		if err := c.errs[id]; err != nil {
+			return fmt.Errorf("seed %d: %s stopped: %w", c.Seed, id, err)
+		}
+	}
+	for _, id := range c.Honest() {
+		if got := consensus.Height(len(c.committed[id])); got < min {
+			return fmt.Errorf("seed %d: %s committed %d blocks, want >= %d", c.Seed, id, got, min)
+		}
+	}
+	return nil
+}
+
+// Errors returns the errors engines stopped with.
+func (c *Cluster) Errors() map[consensus.NodeID]error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[consensus.NodeID]error, len(c.errs))
+	for id, err := range c.errs {
+		out[id] = err
+	}
+	return out
+}
+
+// Committed returns the block hashes id committed, by height.
+func (c *Cluster) Committed(id consensus.NodeID) []consensus.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]consensus.Hash(nil), c.committed[id]...)
+}
@@ -0,0 +1,495 @@
+// Package sim is a deterministic in-process network for consensus testing.
+//
+// Messages between validators travel over a util.FakeClock: every message is
+// given a delay, and may be dropped or duplicated, by a per-link RNG derived
+// from a single seed. Random delays reorder messages. Partitions cut links.
+// The Network is its own scheduler (RunFor). It delivers messages and fires
+// timers one at a time in virtual-time order, so a run is reproducible from
+// its seed. Cluster runs N engines on top of it and checks invariants.
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/util"
+)
+
+// Faults configures what happens to each message between two distinct
+// validators. Messages to self are delivered without delay or loss.
+type Faults struct {
+	MinDelay, MaxDelay time.Duration // latency, uniform in [MinDelay, MaxDelay]
+	Drop               float64       // probability a message is lost
+	Duplicate          float64       // probability a message is delivered twice
+}
+
+// Stats counts what the network did to the messages sent so far.
+type Stats struct {
+	Sent, Delivered, Dropped, Duplicated int
+}
+
+// defaultSettle is how long the nodes must stay quiet (real time) before the
+// next event is delivered.
+const defaultSettle = 200 * time.Microsecond
+
+// settleYields is the minimum number of scheduler yields in a quiet period,
+// so runnable goroutines get to run however short Settle is.
+const settleYields = 100
+
+// Network connects the Endpoints of a simulation.
+type Network struct {
+	clock  *util.FakeClock
+	seed   int64
+	faults Faults
+
+	// Settle is the real-time quiet period awaited between events
+	Settle time.Duration
+
+	// activity counts everything the nodes do (sends, timers, vote waits);
+	// the scheduler waits for it to stop changing before the next event
+	activity atomic.Uint64
+
+	mu        sync.Mutex
+	nodes     map[consensus.NodeID]*Endpoint
+	ids       []consensus.NodeID // sorted
+	links     map[link]*linkState
+	queue     envelopes
+	partition map[consensus.NodeID]int // group per node; nil = fully connected
+	stats     Stats
+}
+
+type link struct{ from, to consensus.NodeID }
+
+// linkState is the fault RNG and message counter of one directed link.
+// Per-link streams keep a message's fate independent of the order in which
+// different nodes happen to send.
+type linkState struct {
+	rng *rand.Rand
+	seq uint64
+}
+
+func NewNetwork(seed int64, clock *util.FakeClock, faults Faults) *Network {
+	return &Network{
+		clock:  clock,
+		seed:   seed,
+		faults: faults,
+		Settle: defaultSettle,
+		nodes:  make(map[consensus.NodeID]*Endpoint),
+		links:  make(map[link]*linkState),
+	}
+}
+
+// Join creates the endpoint of validator id.
+func (n *Network) Join(id consensus.NodeID) *Endpoint {
+	ep := &Endpoint{
+		net:    n,
+		id:     id,
+		votes:  make(map[consensus.View]map[consensus.Hash][]consensus.Vote),
+		voteCh: make(chan struct{}, 1),
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nodes[id] = ep
+	n.ids = append(n.ids, id)
+	sort.Slice(n.ids, func(i, j int) bool { return n.ids[i] < n.ids[j] })
+	return ep
+}
+
+// Clock returns the clock engines must use: the network's FakeClock, with
+// every timer counted as activity.
+func (n *Network) Clock() util.Clock { return simClock{n} }
+
+type simClock struct{ n *Network }
+
+func (c simClock) Now() time.Time { return c.n.clock.Now() }
+func (c simClock) After(d time.Duration) <-chan time.Time {
+	c.n.activity.Add(1)
+	return c.n.clock.After(d)
+}
+
+// Partition splits the validators into groups that cannot reach each other;
+// validators not listed form one more group. Messages already in flight are
+// still delivered.
+func (n *Network) Partition(groups ...[]consensus.NodeID) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partition = make(map[consensus.NodeID]int)
+	for i, g := range groups {
+		for _, id := range g {
+			n.partition[id] = i + 1
+		}
+	}
+}
+
+// Heal removes the partition.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partition = nil
+}
+
+// Stats returns the message counters.
+func (n *Network) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// connected reports whether a message from a can reach b. Caller holds mu.
+func (n *Network) connected(a, b consensus.NodeID) bool {
+	return n.partition == nil || n.partition[a] == n.partition[b]
+}
+
+func (n *Network) linkState(from, to consensus.NodeID) *linkState {
+	l := link{from, to}
+	if ls, ok := n.links[l]; ok {
+		return ls
+	}
+	h := fnv.New64a()
+	h.Write([]byte(from))
+	h.Write([]byte{0})
+	h.Write([]byte(to))
+	ls := &linkState{rng: rand.New(rand.NewSource(n.seed ^ int64(h.Sum64())))}
+	n.links[l] = ls
+	return ls
+}
+
+// send schedules deliver at to, subject to partitions and faults.
+func (n *Network) send(from, to consensus.NodeID, deliver func(context.Context)) {
+	n.activity.Add(1)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.nodes[to]; !ok {
+		return
+	}
+	n.stats.Sent++
+	if !n.connected(from, to) {
+		n.stats.Dropped++
+		return
+	}
+	ls := n.linkState(from, to)
+	copies, delay := 1, time.Duration(0)
+	if from != to {
+		drop, dup := ls.rng.Float64(), ls.rng.Float64()
+		if drop < n.faults.Drop {
+			n.stats.Dropped++
+			return
+		}
+		if dup < n.faults.Duplicate {
+			copies = 2
+			n.stats.Duplicated++
+		}
+	}
+	now := n.clock.Now()
+	for i := 0; i < copies; i++ {
+		if from != to {
+			delay = n.faults.MinDelay
+			if span := n.faults.MaxDelay - n.faults.MinDelay; span > 0 {
+				delay += time.Duration(ls.rng.Int63n(int64(span) + 1))
+			}
+		}
+		ls.seq++
+		heap.Push(&n.queue, envelope{at: now.Add(delay), from: from, to: to, seq: ls.seq, deliver: deliver})
+	}
+}
+
+// RunFor runs the simulation until the clock has advanced by d (or ctx is
+// done). Events (message deliveries and timers) are processed one at a time
+// in virtual-time order; ties are broken by sender, receiver and the per-link
+// sequence number. Before each event the nodes are given Settle of real time
+// without any activity, so every event observes the effects of the previous one.
+func (n *Network) RunFor(ctx context.Context, d time.Duration) {
+	end := n.clock.Now().Add(d)
+	for ctx.Err() == nil {
+		n.settle()
+		at, ok := n.nextEvent()
+		if !ok || at.After(end) {
+			n.clock.AdvanceTo(end)
+			n.settle()
+			return
+		}
+		if t, ok := n.clock.NextTimer(); ok && !t.After(at) {
+			n.clock.AdvanceTo(at)
+			continue // let the woken goroutines run before delivering anything
+		}
+		n.clock.AdvanceTo(at)
+		if env, ok := n.popDue(); ok {
+			n.activity.Add(1)
+			env.deliver(ctx)
+			n.mu.Lock()
+			n.stats.Delivered++
+			n.mu.Unlock()
+		}
+	}
+}
+
+// nextEvent returns the time of the earliest pending message or timer.
+func (n *Network) nextEvent() (time.Time, bool) {
+	at, ok := n.clock.NextTimer()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.queue) > 0 && (!ok || n.queue[0].at.Before(at)) {
+		return n.queue[0].at, true
+	}
+	return at, ok
+}
+
+// popDue removes the first message due by now.
+func (n *Network) popDue() (envelope, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.queue) == 0 || n.queue[0].at.After(n.clock.Now()) {
+		return envelope{}, false
+	}
+	return heap.Pop(&n.queue).(envelope), true
+}
+
+// settle waits until no activity was seen for Settle. It yields instead of
+// sleeping: timer granularity would make every event cost a millisecond or more.
+func (n *Network) settle() {
+	last := n.activity.Load()
+	since, yields := time.Now(), 0
+	for yields < settleYields || time.Since(since) < n.Settle {
+		runtime.Gosched()
+		yields++
+		if cur := n.activity.Load(); cur != last {
+			last, since, yields = cur, time.Now(), 0
+		}
+	}
+}
+
+// peers returns the endpoints id can reach, other than itself, in ID order.
+func (n *Network) peers(id consensus.NodeID) []*Endpoint {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out []*Endpoint
+	for _, p := range n.ids {
+		if p != id && n.connected(id, p) {
+			out = append(out, n.nodes[p])
+		}
+	}
+	return out
+}
+
+// envelope is a message in flight.
+type envelope struct {
+	at       time.Time
+	from, to consensus.NodeID
+	seq      uint64
+	deliver  func(context.Context)
+}
+
+type envelopes []envelope
+
+func (q envelopes) Len() int { return len(q) }
+func (q envelopes) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	switch {
+	case !a.at.Equal(b.at):
+		return a.at.Before(b.at)
+	case a.from != b.from:
+		return a.from < b.from
+	case a.to != b.to:
+		return a.to < b.to
+	}
+	return a.seq < b.seq
+}
+func (q envelopes) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *envelopes) Push(x any)   { *q = append(*q, x.(envelope)) }
+func (q *envelopes) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
+
+// Endpoint is one validator's consensus.Network on a simulated Network.
+type Endpoint struct {
+	net       *Network
+	id        consensus.NodeID
+	behaviour Behaviour
+
+	muH      sync.RWMutex
+	handlers consensus.Handlers
+
+	muVotes sync.Mutex
+	votes   map[consensus.View]map[consensus.Hash][]consensus.Vote
+	voteCh  chan struct{}
+}
+
+func (ep *Endpoint) SetHandlers(h consensus.Handlers) {
+	ep.muH.Lock()
+	ep.handlers = h
+	ep.muH.Unlock()
+}
+
+func (ep *Endpoint) getHandlers() consensus.Handlers {
+	ep.muH.RLock()
+	defer ep.muH.RUnlock()
+	return ep.handlers
+}
+
+// broadcast sends msg(dst) to every validator, including self.
+func (ep *Endpoint) broadcast(msg func(dst *Endpoint) func(context.Context)) {
+	ep.net.mu.Lock()
+	ids := append([]consensus.NodeID(nil), ep.net.ids...)
+	ep.net.mu.Unlock()
+	for _, id := range ids {
+		ep.net.mu.Lock()
+		dst := ep.net.nodes[id]
+		ep.net.mu.Unlock()
+		ep.net.send(ep.id, id, msg(dst))
+	}
+}
+
+func (ep *Endpoint) BroadcastPropose(_ context.Context, p consensus.Propose) error {
+	ep.broadcast(func(dst *Endpoint) func(context.Context) {
+		ps := ep.misbehaveProposal(dst.id, p)
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnPropose != nil {
+				for _, p := range ps {
+					h.OnPropose(ctx, p)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func (ep *Endpoint) BroadcastPrepare(_ context.Context, cert consensus.Certificate) error {
+	ep.broadcast(func(dst *Endpoint) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnPrepare != nil {
+				h.OnPrepare(ctx, cert, consensus.Block{})
+			}
+		}
+	})
+	return nil
+}
+
+func (ep *Endpoint) BroadcastTimeout(_ context.Context, t consensus.Timeout) error {
+	ep.broadcast(func(dst *Endpoint) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnTimeout != nil {
+				h.OnTimeout(ctx, t)
+			}
+		}
+	})
+	return nil
+}
+
+// BroadcastEvidence gossips ev to every other validator.
+func (ep *Endpoint) BroadcastEvidence(_ context.Context, ev consensus.Evidence) error {
+	ep.broadcast(func(dst *Endpoint) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); dst != ep && h.OnEvidence != nil {
+				h.OnEvidence(ctx, ev)
+			}
+		}
+	})
+	return nil
+}
+
+func (ep *Endpoint) SendVote(_ context.Context, to consensus.NodeID, v consensus.Vote) error {
+	votes := ep.misbehaveVote(v)
+	if len(votes) == 0 {
+		return nil
+	}
+	ep.net.mu.Lock()
+	dst, known := ep.net.nodes[to]
+	ep.net.mu.Unlock()
+	if !known {
+		return errors.New("unknown peer")
+	}
+	ep.net.send(ep.id, to, func(context.Context) {
+		for _, v := range votes {
+			dst.addVote(v)
+		}
+	})
+	return nil
+}
+
+// addVote stores a delivered vote for CollectVotes (one per sender and view).
+func (ep *Endpoint) addVote(v consensus.Vote) {
+	if verify := ep.getHandlers().VerifyVote; verify != nil && !verify(v) {
+		return
+	}
+	ep.muVotes.Lock()
+	if ep.votes[v.View] == nil {
+		ep.votes[v.View] = make(map[consensus.Hash][]consensus.Vote)
+	}
+	for _, votes := range ep.votes[v.View] {
+		for _, got := range votes {
+			if got.From == v.From {
+				ep.muVotes.Unlock()
+				return
+			}
+		}
+	}
+	ep.votes[v.View][v.H] = append(ep.votes[v.View][v.H], v)
+	ep.muVotes.Unlock()
+	select {
+	case ep.voteCh <- struct{}{}:
+	default:
+	}
+}
+
+func (ep *Endpoint) CollectVotes(ctx context.Context, view consensus.View, h consensus.Hash, need int) ([]consensus.Vote, error) {
+	for {
+		ep.muVotes.Lock()
+		got := ep.votes[view][h]
+		if len(got) >= need {
+			out := append([]consensus.Vote(nil), got[:need]...)
+			ep.muVotes.Unlock()
+			return out, nil
+		}
+		ep.muVotes.Unlock()
+
+		ep.net.activity.Add(1)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ep.voteCh:
+		}
+	}
+}
+
+// GetBlocksByHeight asks every reachable peer and keeps the longest answer.
+// Sync requests are answered instantly and are not subject to Faults.
+func (ep *Endpoint) GetBlocksByHeight(_ context.Context, from, to consensus.Height) (consensus.BlockRange, error) {
+	ep.net.activity.Add(1)
+	var best consensus.BlockRange
+	for _, p := range ep.net.peers(ep.id) {
+		if serve := p.getHandlers().ServeBlocks; serve != nil {
+			if r := serve(from, to); len(r.Blocks) > len(best.Blocks) {
+				best = r
+			}
+		}
+	}
+	return best, nil
+}
+
+func (ep *Endpoint) GetCertByView(_ context.Context, v consensus.View) (consensus.Certificate, error) {
+	ep.net.activity.Add(1)
+	for _, p := range ep.net.peers(ep.id) {
+		if serve := p.getHandlers().ServeCert; serve != nil {
+			if c, ok := serve(v); ok {
+				return c, nil
+			}
+		}
+	}
+	return consensus.Certificate{}, errors.New("no peer has the certificate")
+}
+
+var _ consensus.Network = (*Endpoint)(nil)
+var _ consensus.SyncNetwork = (*Endpoint)(nil)
+var _ consensus.EvidenceNetwork = (*Endpoint)(nil)
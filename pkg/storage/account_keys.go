@@ -13,6 +13,9 @@ import (
 //   b:<hash>     → Block
 //   c:<view>     → Certificate
 //   cm           → Committed hash
+//   sf           → Safety state
+//   h:<height>   → Committed block hash at height
+//   tx:<hash>    → Committed tx location
 //
 // Account keys (new):
 //   acc:<address>          → Account
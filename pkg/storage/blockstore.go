@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/uhyunpark/hyperlicked/pkg/consensus"
@@ -11,12 +12,16 @@ type InMemoryBlockStore struct {
 	blocks     map[consensus.Hash]consensus.Block
 	certByView map[consensus.View]consensus.Certificate
 	committed  *consensus.Hash
+	byHeight   map[consensus.Height]consensus.Hash
+	txs        map[consensus.Hash]consensus.TxLocation
 }
 
 func NewInMemoryBlockStore() *InMemoryBlockStore {
 	return &InMemoryBlockStore{
 		blocks:     make(map[consensus.Hash]consensus.Block),
 		certByView: make(map[consensus.View]consensus.Certificate),
+		byHeight:   make(map[consensus.Height]consensus.Hash),
+		txs:        make(map[consensus.Hash]consensus.TxLocation),
 	}
 }
 
@@ -49,6 +54,19 @@ func (s *InMemoryBlockStore) GetCert(v consensus.View) (consensus.Certificate, b
 func (s *InMemoryBlockStore) SetCommitted(h consensus.Hash) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	var prev consensus.Hash
+	if s.committed != nil {
+		prev = *s.committed
+	}
+	get := func(h consensus.Hash) (consensus.Block, bool) { b, ok := s.blocks[h]; return b, ok }
+	indexed := func(ht consensus.Height) (consensus.Hash, bool) { h, ok := s.byHeight[ht]; return h, ok }
+	for _, b := range newlyCommitted(h, prev, get, indexed) {
+		bh := consensus.HashOfBlock(b)
+		s.byHeight[b.Height] = bh
+		for i, tx := range consensus.PayloadTxs(b.Payload) {
+			s.txs[consensus.TxHash(tx)] = consensus.TxLocation{Height: b.Height, Block: bh, Index: i, Tx: tx}
+		}
+	}
 	s.committed = &h
 }
 
@@ -60,3 +78,50 @@ func (s *InMemoryBlockStore) GetCommitted() (consensus.Hash, bool) {
 	}
 	return *s.committed, true
 }
+
+func (s *InMemoryBlockStore) LatestCommitted() (consensus.Block, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.committed == nil {
+		return consensus.Block{}, false
+	}
+	b, ok := s.blocks[*s.committed]
+	return b, ok
+}
+
+func (s *InMemoryBlockStore) GetBlockByHeight(ht consensus.Height) (consensus.Block, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.byHeight[ht]
+	if !ok {
+		return consensus.Block{}, false
+	}
+	b, ok := s.blocks[h]
+	return b, ok
+}
+
+func (s *InMemoryBlockStore) IterateCommitted(from, to consensus.Height, fn func(consensus.Block) bool) {
+	s.mu.Lock()
+	var blocks []consensus.Block
+	for ht := range s.byHeight {
+		if ht >= from && ht <= to {
+			blocks = append(blocks, s.blocks[s.byHeight[ht]])
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Height < blocks[j].Height })
+	for _, b := range blocks {
+		if !fn(b) {
+			return
+		}
+	}
+}
+
+func (s *InMemoryBlockStore) GetTx(h consensus.Hash) (consensus.TxLocation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loc, ok := s.txs[h]
+	return loc, ok
+}
+
+var _ consensus.BlockStore = (*InMemoryBlockStore)(nil)
@@ -0,0 +1,37 @@
+package storage
+
+import "github.com/uhyunpark/hyperlicked/pkg/consensus"
+
+// newlyCommitted returns the blocks committed by moving the committed head
+// from prev to h, oldest first: h and its ancestors down to (excluding) prev,
+// an already indexed block, genesis (height 0) or the first block not in the
+// store.
+func newlyCommitted(h, prev consensus.Hash, get func(consensus.Hash) (consensus.Block, bool),
+	indexed func(consensus.Height) (consensus.Hash, bool)) []consensus.Block {
+	var rev []consensus.Block
+	for h != prev {
+		b, ok := get(h)
+		if !ok || b.Height == 0 {
+			break
+		}
+		if got, ok := indexed(b.Height); ok && got == h {
+			break
+		}
+		rev = append(rev, b)
+		h = b.Parent
+	}
+	out := make([]consensus.Block, 0, len(rev))
+	for i := len(rev) - 1; i >= 0; i-- {
+		out = append(out, rev[i])
+	}
+	return out
+}
+
+// txAt returns the i-th transaction of b.
+func txAt(b consensus.Block, i int) ([]byte, bool) {
+	txs := consensus.PayloadTxs(b.Payload)
+	if i < 0 || i >= len(txs) {
+		return nil, false
+	}
+	return txs[i], true
+}
@@ -24,10 +24,14 @@ func NewPebbleStore(path string) (*PebbleStore, error) {
 }
 func (s *PebbleStore) Close() error { return s.db.Close() }
 
-// keys: b:<32-byte-hash>, c:<8-byte-view>, cm:committed
-func kBlock(h consensus.Hash) []byte { return append([]byte("b:"), h[:]...) }
-func kCert(v consensus.View) []byte  { return append([]byte("c:"), viewKey(v)...) }
-func kCommitted() []byte             { return []byte("cm") }
+// keys: b:<32-byte-hash>, c:<8-byte-view>, cm:committed, sf:safety state,
+// h:<8-byte-height> committed hash at height, tx:<32-byte-tx-hash> location
+func kBlock(h consensus.Hash) []byte     { return append([]byte("b:"), h[:]...) }
+func kCert(v consensus.View) []byte      { return append([]byte("c:"), viewKey(v)...) }
+func kCommitted() []byte                 { return []byte("cm") }
+func kSafety() []byte                    { return []byte("sf") }
+func kHeight(ht consensus.Height) []byte { return append([]byte("h:"), viewKey(consensus.View(ht))...) }
+func kTx(h consensus.Hash) []byte        { return append([]byte("tx:"), h[:]...) }
 
 func (s *PebbleStore) SaveBlock(b consensus.Block) {
 	key := kBlock(consensus.HashOfBlock(b))
@@ -82,8 +86,38 @@ func (s *PebbleStore) GetCert(v consensus.View) (consensus.Certificate, bool) {
 	return out, true
 }
 
+// txRef is the stored form of a TxLocation (the tx bytes live in the block).
+type txRef struct {
+	Height consensus.Height
+	Block  consensus.Hash
+	Index  int
+}
+
+// SetCommitted writes the new head and the height/tx index entries of every
+// block it commits in one synced batch.
 func (s *PebbleStore) SetCommitted(h consensus.Hash) {
-	if err := s.db.Set(kCommitted(), h[:], pebble.Sync); err != nil {
+	prev, _ := s.GetCommitted()
+	batch := s.db.NewBatch()
+	defer batch.Close()
+	for _, b := range newlyCommitted(h, prev, s.GetBlock, s.hashAt) {
+		bh := consensus.HashOfBlock(b)
+		if err := batch.Set(kHeight(b.Height), bh[:], nil); err != nil {
+			panic(err)
+		}
+		for i, tx := range consensus.PayloadTxs(b.Payload) {
+			val, err := encodeGob(txRef{Height: b.Height, Block: bh, Index: i})
+			if err != nil {
+				panic(fmt.Errorf("encode tx ref: %w", err))
+			}
+			if err := batch.Set(kTx(consensus.TxHash(tx)), val, nil); err != nil {
+				panic(err)
+			}
+		}
+	}
+	if err := batch.Set(kCommitted(), h[:], nil); err != nil {
+		panic(err)
+	}
+	if err := batch.Commit(pebble.Sync); err != nil {
 		panic(err)
 	}
 }
@@ -102,7 +136,121 @@ func (s *PebbleStore) GetCommitted() (consensus.Hash, bool) {
 	return out, true
 }
 
+func (s *PebbleStore) LatestCommitted() (consensus.Block, bool) {
+	h, ok := s.GetCommitted()
+	if !ok {
+		return consensus.Block{}, false
+	}
+	return s.GetBlock(h)
+}
+
+// hashAt returns the committed block hash at height ht.
+func (s *PebbleStore) hashAt(ht consensus.Height) (consensus.Hash, bool) {
+	val, closer, err := s.db.Get(kHeight(ht))
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return consensus.Hash{}, false
+		}
+		panic(err)
+	}
+	defer closer.Close()
+	var out consensus.Hash
+	copy(out[:], val)
+	return out, true
+}
+
+func (s *PebbleStore) GetBlockByHeight(ht consensus.Height) (consensus.Block, bool) {
+	h, ok := s.hashAt(ht)
+	if !ok {
+		return consensus.Block{}, false
+	}
+	return s.GetBlock(h)
+}
+
+func (s *PebbleStore) IterateCommitted(from, to consensus.Height, fn func(consensus.Block) bool) {
+	if to < from {
+		return
+	}
+	opts := &pebble.IterOptions{LowerBound: kHeight(from)}
+	if to < ^consensus.Height(0) {
+		opts.UpperBound = kHeight(to + 1)
+	} else {
+		opts.UpperBound = keyUpperBound([]byte("h:"))
+	}
+	iter, err := s.db.NewIter(opts)
+	if err != nil {
+		panic(err)
+	}
+	defer iter.Close()
+	for iter.First(); iter.Valid(); iter.Next() {
+		var h consensus.Hash
+		copy(h[:], iter.Value())
+		b, ok := s.GetBlock(h)
+		if !ok {
+			continue
+		}
+		if !fn(b) {
+			return
+		}
+	}
+}
+
+func (s *PebbleStore) GetTx(h consensus.Hash) (consensus.TxLocation, bool) {
+	val, closer, err := s.db.Get(kTx(h))
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return consensus.TxLocation{}, false
+		}
+		panic(err)
+	}
+	var ref txRef
+	err = decodeGob(val, &ref)
+	closer.Close()
+	if err != nil {
+		panic(err)
+	}
+	b, ok := s.GetBlock(ref.Block)
+	if !ok {
+		return consensus.TxLocation{}, false
+	}
+	tx, ok := txAt(b, ref.Index)
+	if !ok {
+		return consensus.TxLocation{}, false
+	}
+	return consensus.TxLocation{Height: ref.Height, Block: ref.Block, Index: ref.Index, Tx: tx}, true
+}
+
+// SaveSafety writes the voting state with pebble.Sync, so it is on disk
+// before the vote it guards is sent.
+func (s *PebbleStore) SaveSafety(st consensus.SafetyState) error {
+	val, err := encodeGob(st)
+	if err != nil {
+		return fmt.Errorf("encode safety state: %w", err)
+	}
+	if err := s.db.Set(kSafety(), val, pebble.Sync); err != nil {
+		return fmt.Errorf("save safety state: %w", err)
+	}
+	return nil
+}
+
+func (s *PebbleStore) LoadSafety() (consensus.SafetyState, bool, error) {
+	val, closer, err := s.db.Get(kSafety())
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return consensus.SafetyState{}, false, nil
+		}
+		return consensus.SafetyState{}, false, fmt.Errorf("load safety state: %w", err)
+	}
+	defer closer.Close()
+	var out consensus.SafetyState
+	if err := decodeGob(val, &out); err != nil {
+		return consensus.SafetyState{}, false, fmt.Errorf("decode safety state: %w", err)
+	}
+	return out, true, nil
+}
+
 var _ consensus.BlockStore = (*PebbleStore)(nil)
+var _ consensus.SafetyStore = (*PebbleStore)(nil)
 
 // ============================================================================
 // Account Persistence Methods
@@ -1,34 +1,279 @@
 package storage
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/uhyunpark/hyperlicked/pkg/consensus"
 )
 
+// Consensus WAL on disk.
+//
+// A WAL is a directory of segment files wal-<16-digit index>.log. A segment
+// is a sequence of frames:
+//
+//	u32 length | u32 crc32c(payload) | payload
+//	payload = u8 record type | gob(record body)
+//
+// A crash can leave a partial or unchecksummed frame at the end of the last
+// segment (torn tail); OpenFileWAL truncates the last segment at the first bad
+// frame. Damage in an older segment is real corruption and is reported by
+// Replay as ErrWALCorrupt.
+
+const (
+	DefaultWALSegmentSize = 64 << 20
+	walFrameHeader        = 8
+	maxWALRecord          = 256 << 20 // larger lengths can only be garbage
+)
+
+var ErrWALCorrupt = errors.New("wal: corrupt record")
+
+var walCRC = crc32.MakeTable(crc32.Castagnoli)
+
 type NopWAL struct{}
 
-func NewNopWAL() *NopWAL          { return &NopWAL{} }
-func (w *NopWAL) Append(_ string) {}
+func NewNopWAL() *NopWAL                                { return &NopWAL{} }
+func (w *NopWAL) Write(_ consensus.Record) error        { return nil }
+func (w *NopWAL) Replay(_ func(consensus.Record)) error { return nil }
 
 type FileWAL struct {
-	mu sync.Mutex
-	f  *os.File
+	mu      sync.Mutex
+	dir     string
+	segSize int64
+	seg     int // index of the active segment
+	f       *os.File
+	size    int64
 }
 
-func NewFileWAL(path string) (*FileWAL, error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+// OpenFileWAL opens (or creates) the WAL in dir, truncating a torn tail in
+// the last segment. Segments rotate once they reach segmentSize bytes.
+func OpenFileWAL(dir string, segmentSize int64) (*FileWAL, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultWALSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	segs, err := walSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	w := &FileWAL{dir: dir, segSize: segmentSize}
+	if len(segs) == 0 {
+		return w, w.openSegment(0)
+	}
+
+	last := segs[len(segs)-1]
+	data, err := os.ReadFile(w.segPath(last))
 	if err != nil {
 		return nil, err
 	}
-	return &FileWAL{f: f}, nil
+	good, _ := scanFrames(data, nil)
+	if err := w.openSegment(last); err != nil {
+		return nil, err
+	}
+	if good < int64(len(data)) {
+		if err := w.f.Truncate(good); err != nil {
+			w.f.Close()
+			return nil, fmt.Errorf("wal: truncate torn tail: %w", err)
+		}
+	}
+	if _, err := w.f.Seek(good, io.SeekStart); err != nil {
+		w.f.Close()
+		return nil, err
+	}
+	w.size = good
+	return w, nil
+}
+
+// Write appends r and fsyncs.
+func (w *FileWAL) Write(r consensus.Record) error {
+	payload, err := encodeRecord(r)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, walFrameHeader+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, walCRC))
+	copy(frame[walFrameHeader:], payload)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size > 0 && w.size+int64(len(frame)) > w.segSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.f.Write(frame); err != nil {
+		return fmt.Errorf("wal: write: %w", err)
+	}
+	w.size += int64(len(frame))
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("wal: sync: %w", err)
+	}
+	return nil
 }
-func (w *FileWAL) Append(line string) {
+
+// Replay calls fn for every record in every segment, oldest first.
+func (w *FileWAL) Replay(fn func(consensus.Record)) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	fmt.Fprintln(w.f, line)
+	segs, err := walSegments(w.dir)
+	if err != nil {
+		return err
+	}
+	for i, seg := range segs {
+		data, err := os.ReadFile(w.segPath(seg))
+		if err != nil {
+			return err
+		}
+		good, err := scanFrames(data, fn)
+		if err != nil {
+			return fmt.Errorf("segment %d: %w", seg, err)
+		}
+		if good < int64(len(data)) && i < len(segs)-1 {
+			return fmt.Errorf("%w: segment %d truncated at offset %d", ErrWALCorrupt, seg, good)
+		}
+	}
+	return nil
+}
+
+func (w *FileWAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+func (w *FileWAL) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	return w.openSegment(w.seg + 1)
+}
+
+func (w *FileWAL) openSegment(idx int) error {
+	f, err := os.OpenFile(w.segPath(idx), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.f, w.seg, w.size = f, idx, 0
+	return nil
+}
+
+func (w *FileWAL) segPath(idx int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("wal-%016d.log", idx))
+}
+
+// walSegments lists segment indexes in dir, ascending.
+func walSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []int
+	for _, e := range entries {
+		var idx int
+		if _, err := fmt.Sscanf(e.Name(), "wal-%016d.log", &idx); err == nil {
+			out = append(out, idx)
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// scanFrames decodes frames from data, calling fn (if non-nil) for each
+// record. It returns the offset just past the last intact frame. An
+// incomplete or checksum-failing frame ends the scan without error (it is
+// either a torn tail or detected by the caller); a frame that checksums but
+// does not decode is an error.
+func scanFrames(data []byte, fn func(consensus.Record)) (int64, error) {
+	var off int64
+	for {
+		rest := data[off:]
+		if len(rest) < walFrameHeader {
+			return off, nil
+		}
+		size := binary.BigEndian.Uint32(rest[0:4])
+		sum := binary.BigEndian.Uint32(rest[4:8])
+		if size == 0 || size > maxWALRecord || int64(len(rest)) < walFrameHeader+int64(size) {
+			return off, nil
+		}
+		payload := rest[walFrameHeader : walFrameHeader+size]
+		if crc32.Checksum(payload, walCRC) != sum {
+			return off, nil
+		}
+		if fn != nil {
+			r, err := decodeRecord(payload)
+			if err != nil {
+				return off, err
+			}
+			fn(r)
+		}
+		off += walFrameHeader + int64(size)
+	}
+}
+
+func encodeRecord(r consensus.Record) ([]byte, error) {
+	var body any
+	switch r.Type {
+	case consensus.RecordProposal:
+		body = r.Proposal
+	case consensus.RecordVote:
+		body = r.Vote
+	case consensus.RecordTimeout:
+		body = r.Timeout
+	case consensus.RecordCert:
+		body = r.Cert
+	case consensus.RecordCommit:
+		body = r.Commit
+	case consensus.RecordValidatorUpdates:
+		body = r.Updates
+	default:
+		return nil, fmt.Errorf("wal: unknown record type %d", r.Type)
+	}
+	b, err := encodeGob(body)
+	if err != nil {
+		return nil, fmt.Errorf("wal: encode %s: %w", r.Type, err)
+	}
+	return append([]byte{byte(r.Type)}, b...), nil
+}
+
+func decodeRecord(payload []byte) (consensus.Record, error) {
+	r := consensus.Record{Type: consensus.RecordType(payload[0])}
+	var body any
+	switch r.Type {
+	case consensus.RecordProposal:
+		r.Proposal = new(consensus.Propose)
+		body = r.Proposal
+	case consensus.RecordVote:
+		r.Vote = new(consensus.Vote)
+		body = r.Vote
+	case consensus.RecordTimeout:
+		r.Timeout = new(consensus.Timeout)
+		body = r.Timeout
+	case consensus.RecordCert:
+		r.Cert = new(consensus.Certificate)
+		body = r.Cert
+	case consensus.RecordCommit:
+		r.Commit = new(consensus.CommitInfo)
+		body = r.Commit
+	case consensus.RecordValidatorUpdates:
+		r.Updates = new(consensus.BlockValidatorUpdates)
+		body = r.Updates
+	default:
+		return r, fmt.Errorf("%w: unknown record type %d", ErrWALCorrupt, payload[0])
+	}
+	if err := decodeGob(payload[1:], body); err != nil {
+		return r, fmt.Errorf("%w: decode %s: %v", ErrWALCorrupt, r.Type, err)
+	}
+	return r, nil
 }
 
 var _ consensus.WAL = (*NopWAL)(nil)
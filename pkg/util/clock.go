@@ -1,6 +1,9 @@
 package util
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type Clock interface {
 	After(d time.Duration) <-chan time.Time
@@ -11,3 +14,81 @@ type RealClock struct{}
 
 func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
 func (RealClock) Now() time.Time                         { return time.Now() }
+
+// FakeClock is a Clock that only moves when told to (simulations, tests).
+// Timers created by After fire in deadline order as Advance/AdvanceTo pass them.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func NewFakeClock(start time.Time) *FakeClock { return &FakeClock{now: start} }
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) { c.AdvanceTo(c.Now().Add(d)) }
+
+// AdvanceTo moves the clock to t (never backwards) and fires every timer due
+// by then, earliest first.
+func (c *FakeClock) AdvanceTo(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+	for {
+		i := c.earliest()
+		if i < 0 || c.timers[i].at.After(c.now) {
+			return
+		}
+		tm := c.timers[i]
+		c.timers = append(c.timers[:i], c.timers[i+1:]...)
+		tm.ch <- tm.at
+	}
+}
+
+// NextTimer returns the deadline of the earliest pending timer.
+func (c *FakeClock) NextTimer() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.earliest()
+	if i < 0 {
+		return time.Time{}, false
+	}
+	return c.timers[i].at, true
+}
+
+// earliest returns the index of the first timer to fire (-1 if none);
+// ties go to the timer created first. Caller holds mu.
+func (c *FakeClock) earliest() int {
+	best := -1
+	for i, tm := range c.timers {
+		if best < 0 || tm.at.Before(c.timers[best].at) {
+			best = i
+		}
+	}
+	return best
+}
@@ -406,3 +406,40 @@ func TestAccountTotalEquity(t *testing.T) {
 		t.Errorf("total equity = %d, want -900000", equity)
 	}
 }
+
+// TestAccountManagerRecordRejection tests that rejected orders are kept with
+// their reason, newest last, up to MaxRejectedOrders
+func TestAccountManagerRecordRejection(t *testing.T) {
+	am := newTestAccountManager(t)
+
+	for i := range core.MaxRejectedOrders + 4 {
+		err := am.RecordRejection(&core.AccountOrder{
+			ID:     fmt.Sprintf("o%d", i),
+			Owner:  alice,
+			Type:   "ALO",
+			Status: core.OrderRejected,
+			Reason: "post-only order would cross the book",
+		})
+		if err != nil {
+			t.Fatalf("RecordRejection failed: %v", err)
+		}
+	}
+
+	rejected := am.GetAccountReadOnly(alice).Rejected
+	if len(rejected) != core.MaxRejectedOrders {
+		t.Fatalf("kept %d rejected orders, want %d", len(rejected), core.MaxRejectedOrders)
+	}
+	if rejected[0].ID != "o4" || rejected[len(rejected)-1].ID != fmt.Sprintf("o%d", core.MaxRejectedOrders+3) {
+		t.Errorf("kept %s..%s, want the most recent", rejected[0].ID, rejected[len(rejected)-1].ID)
+	}
+	if rejected[0].Reason == "" || rejected[0].Status != core.OrderRejected {
+		t.Errorf("rejection recorded without status/reason: %+v", rejected[0])
+	}
+
+	// A fork's rejections stay out of the parent
+	fork := am.Fork()
+	fork.RecordRejection(&core.AccountOrder{ID: "forked", Owner: alice, Status: core.OrderRejected})
+	if got := am.GetAccountReadOnly(alice).Rejected; got[len(got)-1].ID == "forked" {
+		t.Error("fork's rejection leaked into the parent")
+	}
+}
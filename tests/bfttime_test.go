@@ -0,0 +1,177 @@
+// file: tests/bfttime_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// TestMedianTime: a QC's time is the stake-weighted median of its votes'.
+func TestMedianTime(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	vs, err := consensus.NewValidatorSet(0, []consensus.Validator{
+		{ID: "val1", Power: 1}, {ID: "val2", Power: 1}, {ID: "val3", Power: 5}, {ID: "val4", Power: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := consensus.Certificate{
+		Signers: consensus.SignerBitmap(ids, []consensus.NodeID{"val1", "val2", "val3"}),
+		Times:   []int64{10, 20, 30},
+	}
+	// val3 holds 5 of the 7 signing power: its time wins, not the middle one
+	if got, ok, err := cert.MedianTime(vs); err != nil || !ok || got.UnixNano() != 30 {
+		t.Fatalf("weighted median = %v, %v, %v; want 30ns", got.UnixNano(), ok, err)
+	}
+	equal, _ := consensus.EqualPowerSet(0, ids)
+	if got, _, _ := cert.MedianTime(equal); got.UnixNano() != 20 {
+		t.Fatalf("equal-power median = %d, want 20", got.UnixNano())
+	}
+	if _, ok, err := (consensus.Certificate{Signers: cert.Signers}).MedianTime(vs); ok || err != nil {
+		t.Fatalf("QC without times: ok %v, err %v", ok, err)
+	}
+	cert.Times = cert.Times[:2]
+	if _, _, err := cert.MedianTime(vs); !errors.Is(err, consensus.ErrCertBadTimes) {
+		t.Fatalf("short Times: %v, want ErrCertBadTimes", err)
+	}
+}
+
+// TestVerifyCertificateTimes: vote times are signed, so a QC's Times cannot
+// be changed, and must cover every signer.
+func TestVerifyCertificateTimes(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	pubKeys := c.engines["val1"].PubKeys
+	vs, _ := consensus.EqualPowerSet(0, ids)
+
+	const chainID = "test-chain"
+	by := []consensus.NodeID{"val1", "val2", "val4"}
+	var shares [][]byte
+	var times []int64
+	for i, id := range by {
+		vote := consensus.Vote{View: 5, Height: 3, H: consensus.Hash{0xab}, AppHash: consensus.Hash{0x42},
+			Time: time.Unix(100+int64(i), 0), From: id}
+		shares = append(shares, signers[id].Sign(vote.SignBytes(chainID)))
+		times = append(times, vote.Time.UnixNano())
+	}
+	good := consensus.Certificate{View: 5, Height: 3, H: consensus.Hash{0xab}, AppHash: consensus.Hash{0x42},
+		Sig: crypto.Aggregate(shares), Signers: consensus.SignerBitmap(ids, by), Times: times}
+	if err := consensus.VerifyCertificate(good, good.SignBytes(chainID), vs, pubKeys); err != nil {
+		t.Fatalf("valid QC rejected: %v", err)
+	}
+
+	tampered := good
+	tampered.Times = []int64{times[0], times[1], times[2] + int64(time.Hour)}
+	if err := consensus.VerifyCertificate(tampered, tampered.SignBytes(chainID), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("tampered time: %v, want ErrCertBadSig", err)
+	}
+	stripped := good
+	stripped.Times = nil
+	if err := consensus.VerifyCertificate(stripped, stripped.SignBytes(chainID), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("stripped times: %v, want ErrCertBadSig", err)
+	}
+	short := good
+	short.Times = times[:2]
+	if err := consensus.VerifyCertificate(short, short.SignBytes(chainID), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadTimes) {
+		t.Errorf("short times: %v, want ErrCertBadTimes", err)
+	}
+
+	// Votes for one block that differ only in time are not a double vote
+	a := signedVote(signers["val2"], chainID, "val2", 5, consensus.Hash{1}, consensus.Hash{9})
+	b := a
+	b.Time = time.Unix(200, 0)
+	b.SigShare = signers["val2"].Sign(b.SignBytes(chainID))
+	e := c.engines["val1"]
+	e.ChainID = chainID
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(a, b)); !errors.Is(err, consensus.ErrInvalidEvidence) {
+		t.Errorf("same block, different times: %v, want ErrInvalidEvidence", err)
+	}
+}
+
+// TestBFTTimeCluster: every committed block after the first carries the
+// median time of its parent's QC, and block times strictly increase.
+func TestBFTTimeCluster(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	for _, e := range c.engines {
+		e.MaxClockDrift = 5 * time.Second
+	}
+	c.start(t, ctx)
+	c.waitHeight(t, ids, 6, 10*time.Second)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	e := c.engines["val1"]
+	vs := e.ActiveValidators()
+	chain := committedChain(e)
+	if len(chain) < 5 {
+		t.Fatalf("committed chain of %d blocks", len(chain))
+	}
+	for i := 1; i < len(chain); i++ {
+		// committedChain walks from the head: chain[i] is chain[i-1]'s parent
+		b, parent := chain[i-1], chain[i]
+		if !b.Time.After(parent.Time) {
+			t.Fatalf("height %d: time %v not after parent's %v", b.Height, b.Time, parent.Time)
+		}
+		if parent.Height == 0 {
+			continue
+		}
+		qc, ok := e.Store.GetCert(parent.View)
+		if !ok || qc.H != b.Parent {
+			t.Fatalf("height %d: parent QC missing", b.Height)
+		}
+		median, ok, err := qc.MedianTime(vs)
+		if err != nil || !ok {
+			t.Fatalf("height %d: parent QC has no vote times (%v)", b.Height, err)
+		}
+		if !b.Time.Equal(median) {
+			t.Fatalf("height %d: time %v, QC median %v", b.Height, b.Time, median)
+		}
+	}
+}
+
+// TestBlockTimeDrift: a follower rejects a block stamped too far from its
+// clock.
+func TestBlockTimeDrift(t *testing.T) {
+	propose := func(at time.Time) (int, consensus.ProposalMetrics) {
+		ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+		genesis := consensus.Certificate{H: consensus.HashOfBlock(consensus.GenesisBlock())}
+		hub := newMemHub()
+		e := newMemEngine("val3", ids, hub.join("val3"))
+		e.MaxClockDrift = 5 * time.Second
+		leader := consensus.RoundRobinElector{IDs: ids}.LeaderOf(1)
+		leaderNet := hub.join(leader)
+		hub.nodes["val3"].getHandlers().OnPropose(context.Background(), consensus.Propose{
+			Block: consensus.Block{
+				Height: 1, View: 1, Parent: genesis.H, Proposer: leader,
+				Time: at, ValSetHash: e.ActiveValidators().Hash(),
+			},
+			HighCert: genesis,
+		})
+		return votesFor(leaderNet, 1), e.ProposalMetrics()
+	}
+	if n, m := propose(time.Now()); n != 1 || m.Accepted != 1 {
+		t.Fatalf("current time: %d votes, metrics %+v", n, m)
+	}
+	for name, at := range map[string]time.Time{
+		"future": time.Now().Add(time.Minute),
+		"past":   time.Now().Add(-time.Minute),
+	} {
+		if n, m := propose(at); n != 0 || m.Rejected[consensus.RejectTime] != 1 {
+			t.Errorf("%s: %d votes, metrics %+v", name, n, m)
+		}
+	}
+	// Without a bound only monotonicity is checked
+	if n, _ := proposeOnce(nil, nil, time.Now().Add(time.Hour)); n != 1 {
+		t.Fatalf("unbounded drift: %d votes, want 1", n)
+	}
+}
@@ -0,0 +1,167 @@
+// file: tests/blockstore_test.go
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/api"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/light"
+	"github.com/uhyunpark/hyperlicked/pkg/storage"
+)
+
+// buildChain saves a linear chain of n blocks (heights 1..n, two txs each)
+// off genesis and returns them.
+func buildChain(s consensus.BlockStore, n int) []consensus.Block {
+	parent := consensus.HashOfBlock(consensus.GenesisBlock())
+	var out []consensus.Block
+	for i := 1; i <= n; i++ {
+		b := consensus.Block{
+			Height: consensus.Height(i), View: consensus.View(i), Parent: parent,
+			Payload:  consensus.EncodePayload([][]byte{{byte('a' + i)}, {byte('A' + i)}}),
+			Proposer: "val1", Time: time.Unix(int64(i), 0),
+		}
+		s.SaveBlock(b)
+		parent = consensus.HashOfBlock(b)
+		out = append(out, b)
+	}
+	return out
+}
+
+func testCommittedIndex(t *testing.T, s consensus.BlockStore) {
+	chain := buildChain(s, 6)
+	if _, ok := s.LatestCommitted(); ok {
+		t.Fatal("latest committed before any commit")
+	}
+
+	// Commit 2, then jump to 5: 3 and 4 are committed implicitly
+	s.SetCommitted(consensus.HashOfBlock(chain[1]))
+	s.SetCommitted(consensus.HashOfBlock(chain[4]))
+
+	head, ok := s.LatestCommitted()
+	if !ok || head.Height != 5 {
+		t.Fatalf("latest committed = %d, %v; want 5", head.Height, ok)
+	}
+	for ht := consensus.Height(1); ht <= 5; ht++ {
+		b, ok := s.GetBlockByHeight(ht)
+		if !ok || consensus.HashOfBlock(b) != consensus.HashOfBlock(chain[ht-1]) {
+			t.Fatalf("height %d not indexed", ht)
+		}
+	}
+	if _, ok := s.GetBlockByHeight(6); ok {
+		t.Fatal("uncommitted block indexed")
+	}
+
+	var got []consensus.Height
+	s.IterateCommitted(2, 100, func(b consensus.Block) bool {
+		got = append(got, b.Height)
+		return len(got) < 3
+	})
+	if len(got) != 3 || got[0] != 2 || got[1] != 3 || got[2] != 4 {
+		t.Fatalf("iterate 2.. (stop after 3) = %v", got)
+	}
+
+	tx := consensus.PayloadTxs(chain[3].Payload)[1]
+	loc, ok := s.GetTx(consensus.TxHash(tx))
+	if !ok || loc.Height != 4 || loc.Index != 1 || loc.Block != consensus.HashOfBlock(chain[3]) || string(loc.Tx) != string(tx) {
+		t.Fatalf("GetTx = %+v, %v", loc, ok)
+	}
+	if _, ok := s.GetTx(consensus.TxHash(consensus.PayloadTxs(chain[5].Payload)[0])); ok {
+		t.Fatal("tx of uncommitted block indexed")
+	}
+}
+
+func TestInMemoryCommittedIndex(t *testing.T) {
+	testCommittedIndex(t, storage.NewInMemoryBlockStore())
+}
+
+func TestPebbleCommittedIndex(t *testing.T) {
+	dir := t.TempDir()
+	s, err := storage.NewPebbleStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCommittedIndex(t, s)
+	s.Close()
+
+	// The index survives a reopen
+	s, err = storage.NewPebbleStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if b, ok := s.GetBlockByHeight(3); !ok || b.Height != 3 {
+		t.Fatal("height index lost on reopen")
+	}
+}
+
+func TestBlocksAPI(t *testing.T) {
+	t.Chdir(t.TempDir()) // NewServer creates data/ and a tx log
+	store := storage.NewInMemoryBlockStore()
+	chain := buildChain(store, 150)
+	store.SetCommitted(consensus.HashOfBlock(chain[139]))
+	state := &consensus.State{Q: consensus.Quorum{N: 4, T: 1}, View: 142}
+
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	srv := api.NewServer(app)
+	srv.SetChain(store, state)
+	get := func(path string, out any) int {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if out != nil && rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+		}
+		return rec.Code
+	}
+
+	var blk api.BlockInfo
+	if code := get("/api/v1/blocks/7", &blk); code != http.StatusOK || blk.Height != 7 || blk.TxCount != 2 ||
+		blk.Hash != "0x"+consensus.HashOfBlock(chain[6]).String() {
+		t.Fatalf("GET /blocks/7 = %d %+v", code, blk)
+	}
+	if code := get("/api/v1/blocks/145", nil); code != http.StatusNotFound {
+		t.Fatalf("uncommitted height: %d, want 404", code)
+	}
+	if code := get("/api/v1/blocks/x", nil); code != http.StatusBadRequest {
+		t.Fatalf("bad height: %d, want 400", code)
+	}
+
+	var list []api.BlockInfo
+	if get("/api/v1/blocks?from=10&to=14", &list); len(list) != 5 || list[0].Height != 10 || list[4].Height != 14 {
+		t.Fatalf("range 10..14 = %d blocks", len(list))
+	}
+	list = nil
+	if get("/api/v1/blocks", &list); len(list) != 100 || list[99].Height != 140 || list[0].Height != 41 {
+		t.Fatalf("latest blocks = %d, last %+v", len(list), list[len(list)-1])
+	}
+
+	var status api.ChainStatus
+	get("/api/v1/chain/status", &status)
+	if status.Height != 140 || status.View != 142 || status.Validators != 4 || status.AvgBlockTime != 1000 {
+		t.Fatalf("chain status = %+v", status)
+	}
+
+	// Light headers: 7 is proven by the QCs of views 7 and 8, 9 has no QC
+	for _, b := range chain[6:8] {
+		store.SaveCert(consensus.Certificate{View: b.View, Height: b.Height, H: consensus.HashOfBlock(b)})
+	}
+	var sh light.SignedHeader
+	if code := get("/api/v1/light/headers/7", &sh); code != http.StatusOK || sh.Header.Height != 7 ||
+		len(sh.Chain) != 1 || sh.Commit.C1.H != consensus.HashOfBlock(chain[6]) || sh.Commit.C2.View != 8 {
+		t.Fatalf("GET /light/headers/7 = %d %+v", code, sh)
+	}
+	if code := get("/api/v1/light/headers/9", nil); code != http.StatusConflict {
+		t.Fatalf("header without QC: %d, want 409", code)
+	}
+	if code := get("/api/v1/light/headers/145", nil); code != http.StatusNotFound {
+		t.Fatalf("uncommitted header: %d, want 404", code)
+	}
+}
@@ -0,0 +1,282 @@
+// file: tests/commit_rule_test.go
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// noSyncNet hides the SyncNetwork methods of a memNet, so an engine driven by
+// hand never starts a background catch-up.
+type noSyncNet struct{ consensus.Network }
+
+// certTree is a randomly generated execution of the protocol: per view a
+// proposal and (maybe) its QC. Justifies respect the locking rule as honest
+// quorums would: a new QC's justify is never below the justify of an earlier
+// QC (2f+1 validators are locked on that, and every quorum contains one).
+type certTree struct {
+	ids       []consensus.NodeID
+	blocks    map[consensus.Hash]consensus.Block
+	certs     map[consensus.View]consensus.Certificate
+	proposals []consensus.Propose
+	committed []consensus.Hash // blocks committed by some DoubleCert, in view order
+}
+
+func genCertTree(rng *rand.Rand, ids []consensus.NodeID, views int) *certTree {
+	elec := consensus.RoundRobinElector{IDs: ids}
+	genesis := consensus.GenesisBlock()
+	gcert := consensus.Certificate{H: consensus.HashOfBlock(genesis)}
+	t := &certTree{
+		ids:    ids,
+		blocks: map[consensus.Hash]consensus.Block{gcert.H: genesis},
+		certs:  map[consensus.View]consensus.Certificate{0: gcert},
+	}
+	var lock consensus.View
+	var high *consensus.DoubleCert
+	for v := consensus.View(1); v <= consensus.View(views); v++ {
+		if rng.Intn(6) == 0 {
+			continue // leader crashed: no proposal
+		}
+		// Justify: any QC at or above the lock, usually the highest
+		var eligible []consensus.Certificate
+		for _, c := range t.certs {
+			if c.View >= lock {
+				eligible = append(eligible, c)
+			}
+		}
+		j := eligible[0]
+		for _, c := range eligible {
+			if c.View > j.View {
+				j = c
+			}
+		}
+		if rng.Intn(3) == 0 {
+			j = eligible[rng.Intn(len(eligible))] // fork off a lower QC
+		}
+		b := consensus.Block{
+			Height: j.Height + 1, View: v, Parent: j.H,
+			Payload: []byte(fmt.Sprintf("v%d", v)), Proposer: elec.LeaderOf(v), Time: time.Unix(int64(v), 0),
+		}
+		h := consensus.HashOfBlock(b)
+		t.blocks[h] = b
+		p := consensus.Propose{Block: b, HighCert: j}
+		if high != nil && rng.Intn(2) == 0 {
+			dc := *high
+			p.HighDouble = &dc
+		}
+		t.proposals = append(t.proposals, p)
+
+		if rng.Intn(4) == 0 {
+			continue // view failed: proposal never certified
+		}
+		c := consensus.Certificate{View: v, Height: b.Height, H: h, AppHash: consensus.Hash{byte(v)}, Sig: []byte("agg")}
+		t.certs[v] = c
+		if j.View > lock {
+			lock = j.View
+		}
+		if prev, ok := t.certs[v-1]; ok && v > 1 && b.Parent == prev.H {
+			t.committed = append(t.committed, prev.H)
+			high = &consensus.DoubleCert{C1: prev, C2: c}
+		}
+	}
+	return t
+}
+
+// extends reports whether block a is b or a descendant of b.
+func (t *certTree) extends(a, b consensus.Hash) bool {
+	for {
+		if a == b {
+			return true
+		}
+		blk, ok := t.blocks[a]
+		if !ok || blk.Height == 0 {
+			return false
+		}
+		a = blk.Parent
+	}
+}
+
+// head is the highest block committed by a DoubleCert (genesis if none).
+func (t *certTree) head() consensus.Hash {
+	head := consensus.HashOfBlock(consensus.GenesisBlock())
+	for _, h := range t.committed {
+		if t.blocks[h].Height > t.blocks[head].Height {
+			head = h
+		}
+	}
+	return head
+}
+
+// deliver feeds the tree's messages to a fresh observer in a random order,
+// dropping each with probability drop, and checks every commit.
+func (tr *certTree) deliver(t *testing.T, rng *rand.Rand, drop float64) *consensus.Engine {
+	t.Helper()
+	ctx := context.Background()
+	hub := newMemHub()
+	e := newMemEngine("val1", tr.ids, hub.join("val1"))
+	e.Net = noSyncNet{e.Net}
+
+	genesis := consensus.HashOfBlock(consensus.GenesisBlock())
+	last := genesis
+	e.OnBlockCommit = func(ht consensus.Height) {
+		head, _ := e.Store.GetCommitted()
+		if !tr.extends(head, last) {
+			t.Fatalf("commit at height %d does not extend the previous committed block", ht)
+		}
+		if !tr.extends(tr.head(), head) {
+			t.Fatalf("committed block at height %d is not on the DoubleCert-committed chain", ht)
+		}
+		last = head
+	}
+
+	var msgs []func()
+	for _, p := range tr.proposals {
+		p := p
+		msgs = append(msgs, func() { hub.nodes["val1"].getHandlers().OnPropose(ctx, p) })
+	}
+	for v, c := range tr.certs {
+		if v == 0 {
+			continue
+		}
+		c := c
+		blk := consensus.Block{}
+		if rng.Intn(2) == 0 {
+			blk = tr.blocks[c.H] // prepare carrying the block (libp2p) or not (memnet)
+		}
+		msgs = append(msgs, func() { hub.nodes["val1"].getHandlers().OnPrepare(ctx, c, blk) })
+	}
+	rng.Shuffle(len(msgs), func(i, j int) { msgs[i], msgs[j] = msgs[j], msgs[i] })
+	for _, m := range msgs {
+		if rng.Float64() >= drop {
+			m()
+		}
+	}
+	return e
+}
+
+// TestCommitRuleGeneratedTrees: the DoubleCert rule itself never commits two
+// conflicting blocks on executions that respect the locking rule.
+func TestCommitRuleGeneratedTrees(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	for seed := int64(1); seed <= 300; seed++ {
+		tr := genCertTree(rand.New(rand.NewSource(seed)), ids, 40)
+		head := tr.head()
+		for _, h := range tr.committed {
+			if !tr.extends(head, h) {
+				t.Fatalf("seed %d: DoubleCert-committed blocks conflict", seed)
+			}
+		}
+	}
+}
+
+// TestCommitSafetyUnderReordering: whatever order proposals and QCs arrive in,
+// an observer only commits along the committed chain, each commit extending
+// the previous one, and ends at the same head once everything arrived.
+func TestCommitSafetyUnderReordering(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	for seed := int64(1); seed <= 150; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		tr := genCertTree(rng, ids, 30)
+		e := tr.deliver(t, rng, 0)
+		got, ok := e.Store.GetCommitted()
+		if !ok {
+			got = consensus.HashOfBlock(consensus.GenesisBlock())
+		}
+		if want := tr.head(); got != want {
+			t.Fatalf("seed %d: committed head at height %d, want height %d", seed, e.State.Height, tr.blocks[want].Height)
+		}
+	}
+}
+
+// TestCommitSafetyWithDrops: with messages lost, commits may lag but never
+// leave the committed chain.
+func TestCommitSafetyWithDrops(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	for seed := int64(1); seed <= 150; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		tr := genCertTree(rng, ids, 30)
+		tr.deliver(t, rng, 0.3)
+	}
+}
+
+// TestCommitRequiresConsecutiveViews: QCs in views 1 and 3 on a parent/child
+// pair do not commit; the child's QC-holding child in view 4 commits both.
+func TestCommitRequiresConsecutiveViews(t *testing.T) {
+	ctx := context.Background()
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	hub := newMemHub()
+	e := newMemEngine("val1", ids, hub.join("val1"))
+	e.Net = noSyncNet{e.Net}
+	h := hub.nodes["val1"].getHandlers()
+	elec := consensus.RoundRobinElector{IDs: ids}
+
+	gcert := consensus.Certificate{H: consensus.HashOfBlock(consensus.GenesisBlock())}
+	extend := func(j consensus.Certificate, v consensus.View) (consensus.Block, consensus.Certificate) {
+		b := consensus.Block{Height: j.Height + 1, View: v, Parent: j.H, Proposer: elec.LeaderOf(v), Time: time.Unix(int64(v), 0)}
+		h.OnPropose(ctx, consensus.Propose{Block: b, HighCert: j})
+		c := consensus.Certificate{View: v, Height: b.Height, H: consensus.HashOfBlock(b), Sig: []byte("agg")}
+		h.OnPrepare(ctx, c, consensus.Block{})
+		return b, c
+	}
+
+	_, c1 := extend(gcert, 1)
+	_, c3 := extend(c1, 3) // view 2 failed
+	if e.State.Height != 0 {
+		t.Fatalf("committed height %d across a view gap", e.State.Height)
+	}
+	_, c4 := extend(c3, 4)
+	if e.State.Height != 2 {
+		t.Fatalf("committed height %d, want 2 (views 3,4 are consecutive)", e.State.Height)
+	}
+	if b, ok := e.Store.GetBlockByHeight(1); !ok || consensus.HashOfBlock(b) != c1.H {
+		t.Fatal("ancestor of the committed block not committed")
+	}
+	if dc := e.Safety.HighestDouble(); dc == nil || dc.C1.View != 3 || dc.C2.View != c4.View {
+		t.Fatalf("HighestDouble = %+v, want (3, 4)", dc)
+	}
+	if e.Safety.LockedView() < 3 {
+		t.Fatalf("locked view %d, want >= 3", e.Safety.LockedView())
+	}
+}
+
+// TestLeaderFastPath: holding the previous QC, a leader with transactions
+// proposes immediately; only empty blocks wait for MinEmptyInterval.
+func TestLeaderFastPath(t *testing.T) {
+	ctx := context.Background()
+	ids := []consensus.NodeID{"val1"}
+	hub := newMemHub()
+	e := newMemEngine("val1", ids, hub.join("val1"))
+	gcert := consensus.Certificate{H: consensus.HashOfBlock(consensus.GenesisBlock())}
+	e.Safety.OnPrepare(gcert, consensus.GenesisBlock())
+
+	app := abci.NewMockApp()
+	ldr := &consensus.Leader{
+		ID: "val1", Net: e.Net, Safety: e.Safety, App: &abci.Bridge{App: app},
+		MinEmptyInterval: 300 * time.Millisecond, LastBlock: time.Now(),
+	}
+
+	app.PushTx([]byte("tx1"))
+	start := time.Now()
+	b, _, err := ldr.Propose(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Payload) == 0 || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("block with txs delayed %v (payload %d bytes)", time.Since(start), len(b.Payload))
+	}
+
+	ldr.LastBlock = time.Now()
+	start = time.Now()
+	if b, _, err = ldr.Propose(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Payload) != 0 || time.Since(start) < 250*time.Millisecond {
+		t.Fatalf("empty block proposed after %v, want >= MinEmptyInterval", time.Since(start))
+	}
+}
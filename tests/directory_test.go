@@ -0,0 +1,167 @@
+// file: tests/directory_test.go
+package tests
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/p2p"
+)
+
+// testDirectory generates a libp2p identity per validator and the matching
+// directory (without addresses).
+func testDirectory(t *testing.T, ids []consensus.NodeID) (map[consensus.NodeID]p2pcrypto.PrivKey, *p2p.Directory) {
+	t.Helper()
+	keys := make(map[consensus.NodeID]p2pcrypto.PrivKey, len(ids))
+	var vals []p2p.ValidatorInfo
+	for _, id := range ids {
+		priv, _, err := p2pcrypto.GenerateEd25519Key(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pid, err := peer.IDFromPrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[id] = priv
+		vals = append(vals, p2p.ValidatorInfo{ID: id, PeerID: pid})
+	}
+	dir, err := p2p.NewDirectory(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys, dir
+}
+
+func TestLoadDirectory(t *testing.T) {
+	priv, _, _ := p2pcrypto.GenerateEd25519Key(nil)
+	pid, _ := peer.IDFromPrivateKey(priv)
+	bls, _ := crypto.NewBLSSignerFromSeed([]byte("directory-test-seed-0123456789ab")).Pubkey().MarshalBinary()
+
+	path := filepath.Join(t.TempDir(), "validators.json")
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(fmt.Sprintf(`{"validators":[{"id":"val1","peer_id":%q,"addrs":["/ip4/10.0.0.1/tcp/4001"],"bls_pubkey":%q}]}`,
+		pid.String(), hex.EncodeToString(bls)))
+
+	dir, err := p2p.LoadDirectory(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	v, ok := dir.Lookup("val1")
+	if !ok || v.PeerID != pid || len(v.Addrs) != 1 || v.BLSKey == nil {
+		t.Fatalf("entry not loaded: %+v", v)
+	}
+	if id, ok := dir.NodeOf(pid); !ok || id != "val1" {
+		t.Fatalf("NodeOf(%s) = %q", pid, id)
+	}
+
+	// One peer cannot stand for two validators
+	write(fmt.Sprintf(`{"validators":[{"id":"val1","peer_id":%q},{"id":"val2","peer_id":%q}]}`, pid.String(), pid.String()))
+	if _, err := p2p.LoadDirectory(path); !errors.Is(err, p2p.ErrDuplicateEntry) {
+		t.Fatalf("duplicate peer: got %v, want ErrDuplicateEntry", err)
+	}
+	write(`{"validators":[{"id":"val1","peer_id":"not-a-peer-id"}]}`)
+	if _, err := p2p.LoadDirectory(path); err == nil {
+		t.Fatal("bad peer id accepted")
+	}
+}
+
+// TestDirectoryVoteRouting: votes reach the addressed validator (not just any
+// connected peer) and a vote whose From does not match the sending peer is
+// dropped.
+func TestDirectoryVoteRouting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3"}
+	keys, dir := testDirectory(t, ids)
+	nets := make(map[consensus.NodeID]*p2p.Libp2pNet, len(ids))
+	for _, id := range ids {
+		n, err := p2p.NewLibp2pNet(ctx, p2p.Libp2pConfig{
+			ListenAddr: "/ip4/127.0.0.1/tcp/0",
+			SelfID:     id,
+			Quorum:     consensus.Quorum{N: 3},
+			Identity:   keys[id],
+			Directory:  dir,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		defer n.Host().Close()
+		nets[id] = n
+	}
+	for _, a := range ids {
+		for _, b := range ids {
+			if a < b {
+				hb := nets[b].Host()
+				if err := nets[a].Host().Connect(ctx, peer.AddrInfo{ID: hb.ID(), Addrs: hb.Addrs()}); err != nil {
+					t.Fatalf("connect %s-%s: %v", a, b, err)
+				}
+			}
+		}
+	}
+
+	// Outsider: valid libp2p identity, not in the directory
+	outsider, err := p2p.NewLibp2pNet(ctx, p2p.Libp2pConfig{ListenAddr: "/ip4/127.0.0.1/tcp/0", SelfID: "outsider", Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outsider.Host().Close()
+	h3 := nets["val3"].Host()
+	if err := outsider.Host().Connect(ctx, peer.AddrInfo{ID: h3.ID(), Addrs: h3.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	received := func(n *p2p.Libp2pNet, v consensus.View) int {
+		cctx, ccancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer ccancel()
+		got, _ := n.CollectVotes(cctx, v, consensus.Hash{byte(v)}, 1)
+		return len(got)
+	}
+
+	// val2 -> val3 arrives at val3 only
+	if err := nets["val2"].SendVote(ctx, "val3", consensus.Vote{View: 1, H: consensus.Hash{1}, From: "val2"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if received(nets["val3"], 1) != 1 {
+		t.Fatal("vote did not reach val3")
+	}
+	if received(nets["val1"], 1) != 0 {
+		t.Fatal("vote for val3 delivered to val1")
+	}
+
+	// val1 impersonates val2
+	if err := nets["val1"].SendVote(ctx, "val3", consensus.Vote{View: 2, H: consensus.Hash{2}, From: "val2"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if received(nets["val3"], 2) != 0 {
+		t.Fatal("vote with spoofed From accepted")
+	}
+
+	// A peer outside the directory cannot vote at all
+	if err := outsider.SendVote(ctx, "val3", consensus.Vote{View: 3, H: consensus.Hash{3}, From: "val2"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if received(nets["val3"], 3) != 0 {
+		t.Fatal("vote from unknown peer accepted")
+	}
+
+	if err := nets["val1"].SendVote(ctx, "val9", consensus.Vote{View: 4}); !errors.Is(err, p2p.ErrUnknownValidator) {
+		t.Fatalf("send to unknown validator: got %v, want ErrUnknownValidator", err)
+	}
+}
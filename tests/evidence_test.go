@@ -0,0 +1,182 @@
+// file: tests/evidence_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// signedVote builds a vote from id signed with its BLS key.
+func signedVote(s *crypto.BLSSigner, chainID string, id consensus.NodeID, v consensus.View, h, app consensus.Hash) consensus.Vote {
+	vote := consensus.Vote{View: v, Height: consensus.Height(v), H: h, AppHash: app, From: id}
+	vote.SigShare = s.Sign(vote.SignBytes(chainID))
+	return vote
+}
+
+// TestVerifyEvidence: each kind of evidence verifies when genuine and is
+// rejected when forged, identical, or about a non-validator.
+func TestVerifyEvidence(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	e := c.engines["val1"]
+	chain := e.ChainID
+
+	// DuplicateVote
+	a := signedVote(signers["val2"], chain, "val2", 5, consensus.Hash{1}, consensus.Hash{9})
+	b := signedVote(signers["val2"], chain, "val2", 5, consensus.Hash{2}, consensus.Hash{9})
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(a, b)); err != nil {
+		t.Fatalf("duplicate vote: %v", err)
+	}
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(a, a)); !errors.Is(err, consensus.ErrInvalidEvidence) {
+		t.Fatalf("identical votes accepted: %v", err)
+	}
+	forged := b
+	forged.SigShare = signers["val3"].Sign(b.SignBytes(chain))
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(a, forged)); err == nil {
+		t.Fatal("duplicate vote with a forged signature accepted")
+	}
+	other := signedVote(signers["val2"], chain, "val2", 6, consensus.Hash{2}, consensus.Hash{9})
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(a, other)); err == nil {
+		t.Fatal("votes from different views accepted")
+	}
+	outsider := crypto.NewBLSSignerFromSeed(make([]byte, 32))
+	x := signedVote(outsider, chain, "val9", 5, consensus.Hash{1}, consensus.Hash{9})
+	y := signedVote(outsider, chain, "val9", 5, consensus.Hash{2}, consensus.Hash{9})
+	if err := e.VerifyEvidence(consensus.NewDuplicateVote(x, y)); err == nil {
+		t.Fatal("evidence against a non-validator accepted")
+	}
+
+	// DuplicateProposal: view 2 is led by val2
+	sign := func(id consensus.NodeID, blk consensus.Block) consensus.SignedProposal {
+		return consensus.SignedProposal{Block: blk, Sig: signers[id].Sign(consensus.ProposalSignBytes(chain, blk))}
+	}
+	b1 := consensus.Block{Height: 2, View: 2, Payload: consensus.EncodePayload([][]byte{[]byte("a")}), Proposer: "val2", Time: time.Unix(1, 0)}
+	b2 := b1
+	b2.Payload = consensus.EncodePayload([][]byte{[]byte("b")})
+	if err := e.VerifyEvidence(consensus.NewDuplicateProposal(sign("val2", b1), sign("val2", b2))); err != nil {
+		t.Fatalf("duplicate proposal: %v", err)
+	}
+	if err := e.VerifyEvidence(consensus.NewDuplicateProposal(sign("val2", b1), sign("val3", b2))); err == nil {
+		t.Fatal("duplicate proposal with a foreign signature accepted")
+	}
+	b1.View, b2.View = 3, 3 // val3's view
+	if err := e.VerifyEvidence(consensus.NewDuplicateProposal(sign("val2", b1), sign("val2", b2))); err == nil {
+		t.Fatal("duplicate proposal by a non-leader accepted")
+	}
+
+	// ConflictingAppHash: val4 voted another state than the QC of val1..val3
+	blk, good, bad := consensus.Hash{7}, consensus.Hash{0xaa}, consensus.Hash{0xbb}
+	var shares [][]byte
+	for _, id := range ids[:3] {
+		shares = append(shares, signedVote(signers[id], chain, id, 8, blk, good).SigShare)
+	}
+	qc := consensus.Certificate{View: 8, Height: 8, H: blk, AppHash: good,
+		Sig: crypto.Aggregate(shares), Signers: consensus.SignerBitmap(ids, ids[:3])}
+	divergent := signedVote(signers["val4"], chain, "val4", 8, blk, bad)
+	if err := e.VerifyEvidence(consensus.NewConflictingAppHash(divergent, qc)); err != nil {
+		t.Fatalf("conflicting apphash: %v", err)
+	}
+	agreeing := signedVote(signers["val4"], chain, "val4", 8, blk, good)
+	if err := e.VerifyEvidence(consensus.NewConflictingAppHash(agreeing, qc)); err == nil {
+		t.Fatal("vote agreeing with the QC accepted as evidence")
+	}
+	weak := qc
+	weak.Signers = consensus.SignerBitmap(ids, ids[:2])
+	weak.Sig = crypto.Aggregate(shares[:2])
+	if err := e.VerifyEvidence(consensus.NewConflictingAppHash(divergent, weak)); err == nil {
+		t.Fatal("conflicting apphash against a sub-quorum QC accepted")
+	}
+}
+
+// TestEvidencePayload: evidence survives the payload round trip, is not
+// counted as a transaction, reaches the app as misbehaviour exactly once,
+// and apps cannot smuggle fake evidence into a payload.
+func TestEvidencePayload(t *testing.T) {
+	a := consensus.Vote{View: 3, H: consensus.Hash{1}, From: "val2", SigShare: []byte{0, 1, 0}}
+	b := consensus.Vote{View: 3, H: consensus.Hash{2}, From: "val2", SigShare: []byte{0, 2, 0}}
+	ev := consensus.NewDuplicateVote(a, b)
+
+	app := abci.NewMockApp()
+	app.PushTx([]byte("tx1"))
+	app.PushTx(append([]byte("evidence:"), "00ff"...))
+	bridge := &abci.Bridge{App: app}
+	payload := bridge.PreparePayload(consensus.GenesisBlock(), 1, []consensus.Evidence{ev, ev})
+
+	txs := consensus.PayloadTxs(payload)
+	if len(txs) != 1 || string(txs[0]) != "tx1" {
+		t.Fatalf("txs = %q, want [tx1]", txs)
+	}
+	got, err := consensus.PayloadEvidence(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Key() != ev.Key() || string(got[0].Votes[1].SigShare) != string(b.SigShare) {
+		t.Fatalf("evidence round trip: %+v", got)
+	}
+
+	b1 := consensus.Block{Height: 1, Payload: payload, Proposer: "val1"}
+	b2 := consensus.Block{Height: 2, Parent: consensus.HashOfBlock(b1), Payload: payload, Proposer: "val1"}
+	for _, blk := range []consensus.Block{b1, b2} {
+		if _, err := bridge.Execute(blk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bridge.Commit(b2); err != nil {
+		t.Fatal(err)
+	}
+	if h, ok := app.Jailed("val2"); !ok || h != 1 {
+		t.Fatalf("val2 jailed = %d, %v; want height 1", h, ok)
+	}
+	if _, ok := app.Jailed("val1"); ok {
+		t.Fatal("innocent proposer jailed")
+	}
+}
+
+// TestLeaderExcludesDivergentVote: a validator voting another AppHash no
+// longer stops the leader; its vote is left out of the QC and reported,
+// and the cluster keeps committing and eventually jails it.
+func TestLeaderExcludesDivergentVote(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	apps := make(map[consensus.NodeID]*abci.MockApp)
+	for _, id := range ids {
+		apps[id] = abci.NewMockApp()
+		c.engines[id].App = &abci.Bridge{App: apps[id]}
+	}
+	c.engines["val4"].App = corruptAppHash{c.engines["val4"].App}
+	c.start(t, ctx)
+	c.waitHeight(t, ids[:3], 8, 10*time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range ids[:3] {
+		for {
+			if _, ok := apps[id].Jailed("val4"); ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s did not jail val4 (pending evidence: %d)", id, len(c.engines[id].PendingEvidence()))
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+// corruptAppHash executes blocks like the wrapped hook but reports a
+// different state root, as a validator with a diverged state would.
+type corruptAppHash struct{ consensus.AppHook }
+
+func (c corruptAppHash) Execute(b consensus.Block) (consensus.Hash, error) {
+	h, err := c.AppHook.Execute(b)
+	h[31] ^= 0xff
+	return h, err
+}
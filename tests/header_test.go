@@ -0,0 +1,134 @@
+// file: tests/header_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/p2p"
+)
+
+// TestBlockHeaderPayloadHash: the block hash covers the payload through its
+// Merkle root, which the entry hashes alone determine.
+func TestBlockHeaderPayloadHash(t *testing.T) {
+	txs := [][]byte{[]byte("tx1"), []byte("tx2"), []byte("tx3")}
+	b := consensus.Block{Height: 3, View: 4, Parent: consensus.Hash{1}, Payload: consensus.EncodePayload(txs),
+		Proposer: "val1", Time: time.Unix(5, 0)}
+	hdr := b.Header()
+	if consensus.HashOfBlock(b) != consensus.HashOfHeader(hdr) {
+		t.Fatal("block hash differs from its header's")
+	}
+	hashes := []consensus.Hash{consensus.TxHash(txs[0]), consensus.TxHash(txs[1]), consensus.TxHash(txs[2])}
+	if hdr.PayloadHash != consensus.MerkleRoot(hashes) || hdr.PayloadHash == (consensus.Hash{}) {
+		t.Fatalf("PayloadHash %s is not the root of the tx hashes", hdr.PayloadHash)
+	}
+
+	// Any change to the entries, their order or their number changes the root
+	for name, other := range map[string][][]byte{
+		"changed":   {txs[0], []byte("txX"), txs[2]},
+		"reordered": {txs[1], txs[0], txs[2]},
+		"dropped":   txs[:2],
+		"appended":  append(append([][]byte(nil), txs...), []byte("tx4")),
+	} {
+		if consensus.PayloadHash(consensus.EncodePayload(other)) == hdr.PayloadHash {
+			t.Errorf("%s payload has the same PayloadHash", name)
+		}
+	}
+	inner := consensus.MerkleRoot(hashes[:2])
+	if consensus.MerkleRoot([]consensus.Hash{inner}) == inner {
+		t.Error("root does not bind the leaf count")
+	}
+
+	// Rebuilding from the header and the entries gives back the same block
+	rebuilt, err := hdr.WithPayload(txs)
+	if err != nil || consensus.HashOfBlock(rebuilt) != consensus.HashOfBlock(b) {
+		t.Fatalf("rebuilt block: %v", err)
+	}
+	if _, err := hdr.WithPayload(txs[:2]); !errors.Is(err, consensus.ErrPayloadMismatch) {
+		t.Fatalf("missing entry: %v, want ErrPayloadMismatch", err)
+	}
+	if _, err := hdr.WithPayload([][]byte{txs[0], []byte("tx2\x00tx3")}); !errors.Is(err, consensus.ErrPayloadMismatch) {
+		t.Fatalf("entry with a delimiter: %v, want ErrPayloadMismatch", err)
+	}
+}
+
+// TestHeaderFirstProposal: followers rebuild a proposal from its header and
+// tx hashes, taking what their mempool holds and fetching the rest.
+func TestHeaderFirstProposal(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3"}
+	keys, dir := testDirectory(t, ids)
+	txs := [][]byte{[]byte("tx1"), []byte("tx2"), []byte("tx3")}
+	pools := map[consensus.NodeID]*abci.MockApp{}
+	for _, id := range ids {
+		pools[id] = abci.NewMockApp()
+	}
+	pools["val2"].PushTx(txs[0]) // val2 holds two of three, val3 none
+	pools["val2"].PushTx(txs[2])
+
+	nets := make(map[consensus.NodeID]*p2p.Libp2pNet, len(ids))
+	type rebuilt struct {
+		by  consensus.NodeID
+		blk consensus.Block
+	}
+	got := make(chan rebuilt, 16)
+	for _, id := range ids {
+		n, err := p2p.NewLibp2pNet(ctx, p2p.Libp2pConfig{
+			ListenAddr: "/ip4/127.0.0.1/tcp/0",
+			SelfID:     id,
+			Quorum:     consensus.Quorum{N: 3},
+			Identity:   keys[id],
+			Directory:  dir,
+			TxPool:     pools[id],
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		defer n.Host().Close()
+		nets[id] = n
+		if id != "val1" {
+			n.SetHandlers(consensus.Handlers{OnPropose: func(_ context.Context, p consensus.Propose) {
+				got <- rebuilt{by: id, blk: p.Block}
+			}})
+		}
+	}
+	for _, a := range ids {
+		for _, b := range ids {
+			if a < b {
+				hb := nets[b].Host()
+				if err := nets[a].Host().Connect(ctx, peer.AddrInfo{ID: hb.ID(), Addrs: hb.Addrs()}); err != nil {
+					t.Fatalf("connect %s-%s: %v", a, b, err)
+				}
+			}
+		}
+	}
+
+	blk := consensus.Block{Height: 1, View: 1, Parent: consensus.HashOfBlock(consensus.GenesisBlock()),
+		Payload: consensus.EncodePayload(txs), Proposer: "val1", Time: time.Unix(1, 0)}
+	want := consensus.HashOfBlock(blk)
+	received := map[consensus.NodeID]bool{}
+	tick := time.NewTicker(300 * time.Millisecond)
+	defer tick.Stop()
+	_ = nets["val1"].BroadcastPropose(ctx, consensus.Propose{Block: blk})
+	for len(received) < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("proposal rebuilt by %v only", received)
+		case <-tick.C:
+			_ = nets["val1"].BroadcastPropose(ctx, consensus.Propose{Block: blk}) // mesh may still be forming
+		case r := <-got:
+			if consensus.HashOfBlock(r.blk) != want || len(consensus.PayloadTxs(r.blk.Payload)) != 3 {
+				t.Fatalf("%s rebuilt %+v", r.by, r.blk)
+			}
+			received[r.by] = true
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// file: tests/leader_election_test.go
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/sim"
+)
+
+// reputationSim runs N=4 with val4 crashed (cut off) for d of virtual time,
+// electing leaders by reputation if reputation is set, else round-robin.
+func reputationSim(t *testing.T, seed int64, reputation bool, d time.Duration) (*sim.Cluster, map[consensus.NodeID]*consensus.ReputationElector) {
+	t.Helper()
+	electors := make(map[consensus.NodeID]*consensus.ReputationElector)
+	cfg := sim.Config{N: 4, Seed: seed, Faults: sim.Faults{MaxDelay: 5 * time.Millisecond}}
+	if reputation {
+		cfg.Elector = func(e *consensus.Engine) consensus.LeaderElector {
+			r := consensus.NewReputationElector(e)
+			electors[e.ID] = r
+			return r
+		}
+	}
+	c := sim.NewCluster(cfg)
+	c.Net.Partition([]consensus.NodeID{"val1", "val2", "val3"}, []consensus.NodeID{"val4"})
+	c.Start(context.Background())
+	c.RunFor(d)
+	c.Stop()
+	if err := c.CheckAgreement(); err != nil {
+		t.Fatalf("agreement: %v (rerun with SIM_SEED=%d)", err, seed)
+	}
+	return c, electors
+}
+
+// TestSimReputationThroughput: with one crashed validator, round-robin times
+// out every fourth view; reputation stops electing it once a window of
+// blocks went by without its proposals or votes, and commits far more.
+func TestSimReputationThroughput(t *testing.T) {
+	for _, seed := range simSeeds(t, 3) {
+		rr, _ := reputationSim(t, seed, false, 4*time.Second)
+		rep, electors := reputationSim(t, seed, true, 4*time.Second)
+		rrN, repN := len(rr.Committed("val1")), len(rep.Committed("val1"))
+		t.Logf("seed %d: round-robin %d blocks, reputation %d blocks", seed, rrN, repN)
+		if repN < 3*rrN {
+			t.Fatalf("seed %d: reputation committed %d blocks, round-robin %d", seed, repN, rrN)
+		}
+
+		// val4 is penalised, the others are not
+		e := rep.Engines["val1"]
+		head, _ := e.Store.LatestCommitted()
+		weights := electors["val1"].Weights(head.View + 1)
+		if len(weights) != 4 {
+			t.Fatalf("weights: %+v", weights)
+		}
+		for _, w := range weights {
+			if penalised := w.Power < consensus.DefaultInactivePenalty; penalised != (w.ID == "val4") {
+				t.Fatalf("seed %d: weights %+v", seed, weights)
+			}
+		}
+	}
+}
+
+// TestReputationElectorAgreement: validators that committed the same chain
+// elect the same leaders, up to Lag views past their committed head.
+func TestReputationElectorAgreement(t *testing.T) {
+	c, electors := reputationSim(t, 1, true, 3*time.Second)
+	var minView consensus.View
+	for i, id := range c.IDs[:3] {
+		head, ok := c.Engines[id].Store.LatestCommitted()
+		if !ok {
+			t.Fatalf("%s committed nothing", id)
+		}
+		if i == 0 || head.View < minView {
+			minView = head.View
+		}
+	}
+	leaders := make(map[consensus.NodeID]int)
+	for v := consensus.View(1); v <= minView+consensus.DefaultReputationLag; v++ {
+		want := electors["val1"].LeaderOf(v)
+		for _, id := range c.IDs[1:3] {
+			if got := electors[id].LeaderOf(v); got != want {
+				t.Fatalf("view %d: val1 elects %s, %s elects %s", v, want, id, got)
+			}
+		}
+		leaders[want]++
+	}
+	if len(leaders) < 3 {
+		t.Fatalf("leaders %v: election not spread over the active validators", leaders)
+	}
+}
+
+// TestSimReputationRecovery: a penalised validator that comes back votes
+// again, regains its weight and leads views again.
+func TestSimReputationRecovery(t *testing.T) {
+	electors := make(map[consensus.NodeID]*consensus.ReputationElector)
+	c := sim.NewCluster(sim.Config{N: 4, Seed: 1, Faults: sim.Faults{MaxDelay: 5 * time.Millisecond},
+		Elector: func(e *consensus.Engine) consensus.LeaderElector {
+			r := consensus.NewReputationElector(e)
+			electors[e.ID] = r
+			return r
+		}})
+	c.Net.Partition([]consensus.NodeID{"val1", "val2", "val3"}, []consensus.NodeID{"val4"})
+	c.Start(context.Background())
+	c.RunFor(2 * time.Second)
+	healed := consensus.Height(len(c.Committed("val1")))
+	c.Net.Heal()
+	c.RunFor(3 * time.Second)
+	c.Stop()
+	if err := c.CheckAgreement(); err != nil {
+		t.Fatalf("agreement: %v", err)
+	}
+
+	e := c.Engines["val1"]
+	head, _ := e.Store.LatestCommitted()
+	led := 0
+	e.Store.IterateCommitted(healed+1, head.Height, func(b consensus.Block) bool {
+		if b.Proposer == "val4" {
+			led++
+		}
+		return true
+	})
+	if led == 0 {
+		t.Fatalf("val4 led none of the %d blocks after it came back", head.Height-healed)
+	}
+	for _, w := range electors["val1"].Weights(head.View + 1) {
+		if w.Power < consensus.DefaultInactivePenalty {
+			t.Fatalf("%s still penalised: %+v", w.ID, electors["val1"].Weights(head.View+1))
+		}
+	}
+}
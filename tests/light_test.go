@@ -0,0 +1,124 @@
+// file: tests/light_test.go
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/light"
+)
+
+// TestLightClientHandoff: a light client that trusts the genesis set follows
+// a BLS-signed chain across a validator set change (val1 raised to 3 of 6 in
+// epoch 2) by verifying the first header of every epoch on the way.
+func TestLightClientHandoff(t *testing.T) {
+	const epochLen = 3 // epoch 2 = heights 7..9
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newMemCluster(t, ids)
+	c.enableBLS()
+	pubKeys := c.engines["val1"].PubKeys
+	for _, e := range c.engines {
+		app := abci.NewMockApp()
+		app.SetValidatorPower(2, "val1", 3)
+		e.App = &abci.Bridge{App: app}
+		e.EpochLength = epochLen
+		// BLS pairings are slow without assembly; use devnet timers
+		e.PM.Timers = consensus.PacemakerTimers{Ppc: 150 * time.Millisecond, Delta: 50 * time.Millisecond}
+	}
+	c.start(t, ctx)
+	c.waitHeight(t, ids, 11, 60*time.Second)
+	cancel()
+
+	// Headers travel as JSON (GET /api/v1/light/headers/{height})
+	fetch := func(h consensus.Height) light.SignedHeader {
+		t.Helper()
+		sh, err := light.BuildSignedHeader(c.engines["val1"].Store, h)
+		if err != nil {
+			t.Fatalf("header %d: %v", h, err)
+		}
+		data, err := json.Marshal(sh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out light.SignedHeader
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatalf("header %d: %v", h, err)
+		}
+		return out
+	}
+
+	gen, err := consensus.EqualPowerSet(0, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted := light.Genesis("memnet", epochLen, gen, pubKeys)
+	const target = 8
+	if _, err := light.VerifyHeader(trusted, fetch(target)); !errors.Is(err, light.ErrUnknownValSet) {
+		t.Fatalf("skipping two epochs: %v, want ErrUnknownValSet", err)
+	}
+
+	path := light.HandoffHeights(trusted, target)
+	if len(path) != 3 || path[0] != 1 || path[1] != 4 || path[2] != 7 {
+		t.Fatalf("handoff heights %v, want [1 4 7]", path)
+	}
+	var headers []light.SignedHeader
+	for _, h := range path {
+		headers = append(headers, fetch(h))
+	}
+	got, err := light.VerifyChain(trusted, append(headers, fetch(target)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := c.engines["val1"].Store.GetBlockByHeight(target)
+	cert, _ := c.engines["val1"].Store.GetCert(want.View)
+	if got.Header.Height != target || got.AppHash != cert.AppHash {
+		t.Fatalf("trusted height %d app %s, want %d %s", got.Header.Height, got.AppHash, target, cert.AppHash)
+	}
+	if got.ValSet.Epoch != 2 || got.ValSet.Power("val1") != 3 || got.NextValSet == nil || got.NextValSet.Epoch != 3 {
+		t.Fatalf("trusted sets %+v, next %+v", got.ValSet, got.NextValSet)
+	}
+	if _, err := light.VerifyHeader(got, fetch(5)); !errors.Is(err, light.ErrNotNewer) {
+		t.Fatalf("older header: %v, want ErrNotNewer", err)
+	}
+	if next, err := light.VerifyHeader(got, fetch(10)); err != nil || next.ValSet.Epoch != 3 {
+		t.Fatalf("next epoch with its set trusted: %+v, %v", next.ValSet, err)
+	}
+
+	// Tampered proofs
+	afterFirst, _ := light.VerifyChain(trusted, headers[:1])
+	sh := fetch(4)
+	forged := *sh.Header.NextValSet
+	forged.Validators = []consensus.Validator{{ID: "val2", Power: 1}}
+	tampered := sh
+	tampered.Header.NextValSet = &forged
+	if _, err := light.VerifyHeader(afterFirst, tampered); !errors.Is(err, light.ErrInvalidHeader) {
+		t.Fatalf("forged next set: %v, want ErrInvalidHeader", err)
+	}
+	tampered = sh
+	tampered.Cert.AppHash = consensus.Hash{0xba}
+	if _, err := light.VerifyHeader(afterFirst, tampered); !errors.Is(err, light.ErrInvalidCert) {
+		t.Fatalf("forged AppHash: %v, want ErrInvalidCert", err)
+	}
+	tampered = sh
+	tampered.Commit.C2 = tampered.Commit.C1
+	if _, err := light.VerifyHeader(afterFirst, tampered); !errors.Is(err, light.ErrInvalidCommit) {
+		t.Fatalf("single QC as commit proof: %v, want ErrInvalidCommit", err)
+	}
+	tampered = sh
+	tampered.Commit.C2.Signers = consensus.SignerBitmap(ids, ids[:2])
+	if _, err := light.VerifyHeader(afterFirst, tampered); !errors.Is(err, light.ErrInvalidCommit) {
+		t.Fatalf("C2 without quorum: %v, want ErrInvalidCommit", err)
+	}
+
+	if _, err := light.BuildSignedHeader(c.engines["val1"].Store, 100000); !errors.Is(err, light.ErrNotFound) {
+		t.Fatalf("uncommitted height: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,259 @@
+// file: tests/memnet_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// memHub connects in-process memNets (one per validator).
+// Messages are delivered through a per-node inbox so each node handles
+// inbound messages sequentially, like the libp2p topic readers do.
+type memHub struct {
+	mu    sync.Mutex
+	nodes map[consensus.NodeID]*memNet
+	down  map[consensus.NodeID]bool
+}
+
+func newMemHub() *memHub {
+	return &memHub{
+		nodes: make(map[consensus.NodeID]*memNet),
+		down:  make(map[consensus.NodeID]bool),
+	}
+}
+
+// join creates the network endpoint for id.
+func (h *memHub) join(id consensus.NodeID) *memNet {
+	n := &memNet{
+		hub:    h,
+		id:     id,
+		inbox:  make(chan func(context.Context), 4096),
+		votes:  make(map[consensus.View]map[consensus.Hash][]consensus.Vote),
+		voteCh: make(chan struct{}, 100),
+	}
+	h.mu.Lock()
+	h.nodes[id] = n
+	h.mu.Unlock()
+	return n
+}
+
+// kill disconnects id: nothing it sends is delivered and nothing reaches it.
+func (h *memHub) kill(id consensus.NodeID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.down[id] = true
+}
+
+// revive reconnects a killed id.
+func (h *memHub) revive(id consensus.NodeID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.down, id)
+}
+
+// peers returns the live nodes other than self.
+func (h *memHub) peers(self consensus.NodeID) []*memNet {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []*memNet
+	for id, n := range h.nodes {
+		if id != self && !h.down[id] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (h *memHub) isDown(id consensus.NodeID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.down[id]
+}
+
+// broadcast delivers fn to every live node (including the sender).
+func (h *memHub) broadcast(from consensus.NodeID, fn func(n *memNet) func(context.Context)) {
+	if h.isDown(from) {
+		return
+	}
+	h.mu.Lock()
+	targets := make([]*memNet, 0, len(h.nodes))
+	for id, n := range h.nodes {
+		if !h.down[id] {
+			targets = append(targets, n)
+		}
+	}
+	h.mu.Unlock()
+	for _, n := range targets {
+		n.inbox <- fn(n)
+	}
+}
+
+// memNet implements consensus.Network over a memHub.
+type memNet struct {
+	hub   *memHub
+	id    consensus.NodeID
+	inbox chan func(context.Context)
+
+	muH      sync.RWMutex
+	handlers consensus.Handlers
+
+	muVotes sync.Mutex
+	votes   map[consensus.View]map[consensus.Hash][]consensus.Vote
+	voteCh  chan struct{}
+}
+
+// run dispatches inbound messages until ctx is done.
+func (n *memNet) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fn := <-n.inbox:
+			if n.hub.isDown(n.id) {
+				continue
+			}
+			fn(ctx)
+		}
+	}
+}
+
+func (n *memNet) SetHandlers(h consensus.Handlers) { n.muH.Lock(); n.handlers = h; n.muH.Unlock() }
+
+func (n *memNet) getHandlers() consensus.Handlers {
+	n.muH.RLock()
+	defer n.muH.RUnlock()
+	return n.handlers
+}
+
+func (n *memNet) BroadcastPropose(_ context.Context, p consensus.Propose) error {
+	n.hub.broadcast(n.id, func(dst *memNet) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnPropose != nil {
+				h.OnPropose(ctx, p)
+			}
+		}
+	})
+	return nil
+}
+
+func (n *memNet) BroadcastPrepare(_ context.Context, cert consensus.Certificate) error {
+	n.hub.broadcast(n.id, func(dst *memNet) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnPrepare != nil {
+				h.OnPrepare(ctx, cert, consensus.Block{})
+			}
+		}
+	})
+	return nil
+}
+
+func (n *memNet) BroadcastTimeout(_ context.Context, t consensus.Timeout) error {
+	n.hub.broadcast(n.id, func(dst *memNet) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); h.OnTimeout != nil {
+				h.OnTimeout(ctx, t)
+			}
+		}
+	})
+	return nil
+}
+
+func (n *memNet) BroadcastEvidence(_ context.Context, ev consensus.Evidence) error {
+	n.hub.broadcast(n.id, func(dst *memNet) func(context.Context) {
+		return func(ctx context.Context) {
+			if h := dst.getHandlers(); dst != n && h.OnEvidence != nil {
+				h.OnEvidence(ctx, ev)
+			}
+		}
+	})
+	return nil
+}
+
+func (n *memNet) SendVote(_ context.Context, to consensus.NodeID, v consensus.Vote) error {
+	if n.hub.isDown(n.id) || n.hub.isDown(to) {
+		return nil // silently lost, like a dead peer
+	}
+	n.hub.mu.Lock()
+	dst, ok := n.hub.nodes[to]
+	n.hub.mu.Unlock()
+	if !ok {
+		return errors.New("unknown peer")
+	}
+	if verify := dst.getHandlers().VerifyVote; verify != nil && !verify(v) {
+		return nil
+	}
+	dst.muVotes.Lock()
+	if dst.votes[v.View] == nil {
+		dst.votes[v.View] = make(map[consensus.Hash][]consensus.Vote)
+	}
+	for _, votes := range dst.votes[v.View] {
+		for _, got := range votes {
+			if got.From == v.From {
+				dst.muVotes.Unlock()
+				return nil
+			}
+		}
+	}
+	dst.votes[v.View][v.H] = append(dst.votes[v.View][v.H], v)
+	dst.muVotes.Unlock()
+	select {
+	case dst.voteCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (n *memNet) CollectVotes(ctx context.Context, view consensus.View, h consensus.Hash, need int) ([]consensus.Vote, error) {
+	for {
+		n.muVotes.Lock()
+		got := n.votes[view][h]
+		if len(got) >= need {
+			out := append([]consensus.Vote(nil), got[:need]...)
+			n.muVotes.Unlock()
+			return out, nil
+		}
+		n.muVotes.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-n.voteCh:
+		}
+	}
+}
+
+// GetBlocksByHeight asks every live peer and keeps the longest answer.
+func (n *memNet) GetBlocksByHeight(_ context.Context, from, to consensus.Height) (consensus.BlockRange, error) {
+	if n.hub.isDown(n.id) {
+		return consensus.BlockRange{}, errors.New("offline")
+	}
+	var best consensus.BlockRange
+	for _, p := range n.hub.peers(n.id) {
+		if serve := p.getHandlers().ServeBlocks; serve != nil {
+			if r := serve(from, to); len(r.Blocks) > len(best.Blocks) {
+				best = r
+			}
+		}
+	}
+	return best, nil
+}
+
+func (n *memNet) GetCertByView(_ context.Context, v consensus.View) (consensus.Certificate, error) {
+	if n.hub.isDown(n.id) {
+		return consensus.Certificate{}, errors.New("offline")
+	}
+	for _, p := range n.hub.peers(n.id) {
+		if serve := p.getHandlers().ServeCert; serve != nil {
+			if c, ok := serve(v); ok {
+				return c, nil
+			}
+		}
+	}
+	return consensus.Certificate{}, errors.New("no peer has the certificate")
+}
+
+var _ consensus.Network = (*memNet)(nil)
+var _ consensus.SyncNetwork = (*memNet)(nil)
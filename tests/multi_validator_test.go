@@ -23,6 +23,9 @@ func TestFourValidators(t *testing.T) {
 	// Validator IDs
 	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
 
+	// Validator directory: NodeID -> peer ID (addresses are exchanged below)
+	keys, dir := testDirectory(t, ids)
+
 	// Create 4 validators, each with their own state and app
 	engines := make([]*consensus.Engine, 4)
 	networks := make([]*p2p.Libp2pNet, 4)
@@ -51,11 +54,13 @@ func TestFourValidators(t *testing.T) {
 
 		// Create libp2p network for each validator
 		net, err := p2p.NewLibp2pNet(ctx, p2p.Libp2pConfig{
-			ListenAddr: "", // Random port
+			ListenAddr: "/ip4/127.0.0.1/tcp/0", // Random port
 			Bootstrap:  []string{},
 			SelfID:     id,
 			Quorum:     state.Q,
 			Logger:     nil,
+			Identity:   keys[id],
+			Directory:  dir,
 		})
 		if err != nil {
 			t.Fatalf("val%d: libp2p init failed: %v", i+1, err)
@@ -135,20 +140,34 @@ done:
 		}
 	}
 
-	// Verify all validators have same committed block hash
+	// Verify all validators committed the same block. They stop at slightly
+	// different heights, so compare them at the lowest committed height
+	heads := make([]consensus.Block, 4)
+	shared := consensus.Height(0)
+	for i, e := range engines {
+		head, ok := e.Store.LatestCommitted()
+		if !ok {
+			t.Fatalf("val%d: no committed block", i+1)
+		}
+		heads[i] = head
+		if i == 0 || head.Height < shared {
+			shared = head.Height
+		}
+	}
 	var commitHash consensus.Hash
-	for i := 0; i < 4; i++ {
-		h, ok := engines[i].Store.GetCommitted()
+	for i, e := range engines {
+		b, ok := e.Store.GetBlockByHeight(shared)
 		if !ok {
-			t.Errorf("val%d: no committed block", i+1)
+			t.Errorf("val%d: no committed block at height %d (head %d)", i+1, shared, heads[i].Height)
 			continue
 		}
+		h := consensus.HashOfBlock(b)
 		if i == 0 {
 			commitHash = h
 		} else if h != commitHash {
-			t.Errorf("val%d: committed hash mismatch: got %x, want %x", i+1, h[:8], commitHash[:8])
+			t.Errorf("val%d: committed hash mismatch at height %d: got %x, want %x", i+1, shared, h[:8], commitHash[:8])
 		}
 	}
 
-	t.Logf("✅ All 4 validators reached consensus on block %x", commitHash[:8])
+	t.Logf("✅ All 4 validators reached consensus on block %x at height %d", commitHash[:8], shared)
 }
@@ -0,0 +1,218 @@
+// file: tests/order_expiry_test.go
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/transaction"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// TestFillOrKill: a FOK order the levels it takes cannot fill leaves the
+// book untouched; one they can fills in full.
+func TestFillOrKill(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	book := orderbook.NewOrderBook()
+	for i, price := range []int64{50000, 51000, 52000} {
+		ask := &orderbook.Order{ID: fmt.Sprintf("ask%d", i), Symbol: "HYPL-USDC", Side: orderbook.Sell, Price: price, Qty: 100, Type: "GTC"}
+		if _, err := book.Place(ask, mkt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 300 rest in all, but only 200 at or below 51000
+	fok := &orderbook.Order{ID: "fok1", Symbol: "HYPL-USDC", Side: orderbook.Buy, Price: 51000, Qty: 250, Type: "FOK"}
+	if fills, err := book.Place(fok, mkt); err != orderbook.ErrWouldNotFill || len(fills) != 0 {
+		t.Fatalf("unfillable FOK: %d fills, %v", len(fills), err)
+	}
+	if asks := book.GetAskLevels(); len(asks) != 3 || asks[0].Qty != 100 {
+		t.Fatalf("book changed by a killed FOK: %+v", asks)
+	}
+
+	fok = &orderbook.Order{ID: "fok2", Symbol: "HYPL-USDC", Side: orderbook.Buy, Price: 51000, Qty: 150, Type: "FOK"}
+	fills, err := book.Place(fok, mkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 || fills[1].Qty != 50 {
+		t.Fatalf("FOK fills %+v, want 100 + 50", fills)
+	}
+	if len(book.GetBidLevels()) != 0 {
+		t.Fatal("FOK order rested")
+	}
+}
+
+// TestExpiredOrders: Expired lists the resting orders whose expiry has
+// passed, in matching priority.
+func TestExpiredOrders(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	book := orderbook.NewOrderBook()
+	for _, o := range []*orderbook.Order{
+		{ID: "ask-late", Side: orderbook.Sell, Price: 52000, Expiry: 200},
+		{ID: "ask", Side: orderbook.Sell, Price: 51000, Expiry: 100},
+		{ID: "bid", Side: orderbook.Buy, Price: 49000, Expiry: 100},
+		{ID: "gtc", Side: orderbook.Buy, Price: 49000},
+	} {
+		o.Symbol, o.Qty, o.Type = "HYPL-USDC", 100, "GTC"
+		if _, err := book.Place(o, mkt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := book.Expired(99); len(got) != 0 {
+		t.Fatalf("expired before their time: %+v", got)
+	}
+	got := book.Expired(150)
+	if len(got) != 2 || got[0].ID != "bid" || got[1].ID != "ask" {
+		t.Fatalf("expired %+v, want bid then ask", got)
+	}
+	if len(book.Expired(200)) != 3 {
+		t.Fatal("an order without expiry expired")
+	}
+}
+
+// TestSignedOrderExpiry: a signed order's deadline is its expiry. Past it
+// the order is rejected; resting, it is swept at the start of the first
+// block whose time reaches it, with the trigger orders, and the margin it
+// held is released.
+func TestSignedOrderExpiry(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	bridge := &abci.Bridge{App: app}
+
+	signer, _ := crypto.GenerateKey()
+	owner := signer.Address()
+	app.GetAccount(owner).USDCBalance = 1_000_000_000
+
+	eip712 := crypto.NewEIP712Signer(crypto.DefaultDomain())
+	nonce := int64(0)
+	orderTx := func(typ uint8, price, qty, deadline int64) []byte {
+		nonce++
+		order := &crypto.OrderEIP712{
+			Symbol: "BTC-USDT", Side: 1, Type: typ, Price: big.NewInt(price), Qty: big.NewInt(qty),
+			Nonce: big.NewInt(nonce), Deadline: big.NewInt(deadline), Leverage: 10, Owner: owner,
+		}
+		sig, err := eip712.SignOrder(signer, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type: transaction.TxTypeOrder, Order: transaction.FromEIP712Order(order),
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+	triggerTx := func(deadline int64) []byte {
+		nonce++
+		order := &crypto.TriggerOrderEIP712{
+			Symbol: "BTC-USDT", Side: 1, Kind: 1,
+			TriggerPrice: big.NewInt(60000), PriceRef: 2, Price: big.NewInt(0),
+			Qty: big.NewInt(10), Nonce: big.NewInt(nonce), Deadline: big.NewInt(deadline),
+			Leverage: 10, Owner: owner,
+		}
+		sig, err := eip712.SignTriggerOrder(signer, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type: transaction.TxTypeTrigger, Trigger: transaction.FromEIP712TriggerOrder(order),
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+	parent := consensus.GenesisBlock()
+	run := func(at int64, txs ...[]byte) {
+		t.Helper()
+		b := consensus.Block{Height: parent.Height + 1, View: parent.View + 1, Parent: consensus.HashOfBlock(parent),
+			Payload: consensus.EncodePayload(txs), Time: time.Unix(at, 0)}
+		if _, err := bridge.Execute(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := bridge.Commit(b); err != nil {
+			t.Fatal(err)
+		}
+		parent = b
+	}
+	bids := func() []core.PriceLevel { return app.GetOrderbook("BTC-USDT").GetBidLevels() }
+	triggers := func() int {
+		resp, err := app.Query(abci.RequestQuery{Path: "triggers/" + owner.Hex()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var orders []json.RawMessage
+		if err := json.Unmarshal(resp.Value, &orders); err != nil {
+			t.Fatal(err)
+		}
+		return len(orders)
+	}
+	mkt, err := app.GetMarket("BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := func() int64 { return app.GetAccount(owner).LockedCollateral }
+	if got := locked(); got != 0 {
+		t.Fatalf("locked %d before any order", got)
+	}
+
+	// Block at 100: two GTT bids and a trigger order rest; one bid past its
+	// deadline is rejected, and a FOK buy finds nothing to fill
+	run(100,
+		orderTx(1, 49000, 10, 110),
+		orderTx(1, 48000, 10, 0),
+		orderTx(1, 47000, 10, 100),
+		orderTx(6, 60000, 10, 0),
+		triggerTx(110),
+	)
+	if got := bids(); len(got) != 2 || triggers() != 1 {
+		t.Fatalf("bids %+v, %d trigger orders; want 2 bids and 1 trigger order", got, triggers())
+	}
+	rejected := app.GetAccount(owner).Rejected
+	if len(rejected) != 2 || rejected[0].Reason != core.ErrExpired.Error() || rejected[1].Reason != core.ErrWouldNotFill.Error() {
+		t.Fatalf("rejected orders %+v, want the expired bid and the FOK", rejected)
+	}
+	resting := mkt.RequiredInitialMargin(49000, 10) + mkt.RequiredInitialMargin(48000, 10)
+	if got := locked(); got != resting {
+		t.Fatalf("locked %d with two bids resting, want %d", got, resting)
+	}
+
+	// Block at 109: nothing has expired yet
+	run(109)
+	if len(bids()) != 2 || triggers() != 1 {
+		t.Fatal("orders swept before their deadline")
+	}
+
+	// Block at 110: the GTT bid and the trigger order are swept
+	run(110)
+	if got := bids(); len(got) != 1 || got[0].Price != 48000 {
+		t.Fatalf("bids after expiry %+v, want only the GTC at 48000", got)
+	}
+	if n := triggers(); n != 0 {
+		t.Fatalf("%d trigger orders left after expiry", n)
+	}
+	if got, want := locked(), mkt.RequiredInitialMargin(48000, 10); got != want {
+		t.Fatalf("locked %d after expiry, want %d for the GTC bid", got, want)
+	}
+
+	// Block at 111: the GTC bid fills and no longer holds margin
+	maker := common.HexToAddress("0xBB00000000000000000000000000000000000000")
+	app.GetAccount(maker).USDCBalance = 1_000_000_000
+	run(111, []byte(fmt.Sprintf("O:IOC:BTC-USDT:SELL:price=48000:qty=10:id=%s-s1", maker.Hex())))
+	pos := app.GetAccount(owner).GetPosition("BTC-USDT")
+	if pos == nil || pos.Size != 10 {
+		t.Fatalf("position %+v, want long 10", pos)
+	}
+	if got := locked(); got != 0 {
+		t.Fatalf("locked %d after the fill, want 0", got)
+	}
+}
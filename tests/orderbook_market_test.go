@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"errors"
+	"math"
 	"testing"
 
 	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
@@ -185,6 +187,87 @@ func TestOrderBookMarketMatching(t *testing.T) {
 	}
 }
 
+// TestPostOnlyOrders: an ALO order never takes liquidity. One that would
+// cross is rejected, or with Reprice rests one tick inside the spread.
+func TestPostOnlyOrders(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	book := orderbook.NewOrderBook()
+	place := func(id string, side orderbook.Side, price int64, typ string, reprice bool) ([]orderbook.Fill, error) {
+		return book.Place(&orderbook.Order{ID: id, Symbol: "HYPL-USDC", Side: side, Price: price, Qty: 100, Type: typ, Reprice: reprice}, mkt)
+	}
+	if _, err := place("bid1", orderbook.Buy, 49000, "GTC", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := place("ask1", orderbook.Sell, 51000, "GTC", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Inside the spread: rests like GTC
+	if fills, err := place("alo1", orderbook.Buy, 50000, "ALO", false); err != nil || len(fills) != 0 {
+		t.Fatalf("resting ALO: %d fills, %v", len(fills), err)
+	}
+	if bids := book.GetBidLevels(); bids[0].Price != 50000 {
+		t.Fatalf("best bid %d, want the ALO at 50000", bids[0].Price)
+	}
+
+	// Crossing: rejected, book untouched
+	for _, side := range []orderbook.Side{orderbook.Buy, orderbook.Sell} {
+		price := int64(51000)
+		if side == orderbook.Sell {
+			price = 50000
+		}
+		fills, err := place("alo_cross", side, price, "ALO", false)
+		if !errors.Is(err, orderbook.ErrWouldCross) || len(fills) != 0 {
+			t.Fatalf("crossing ALO %v: %d fills, %v; want ErrWouldCross", side, len(fills), err)
+		}
+	}
+	if asks := book.GetAskLevels(); len(asks) != 1 || asks[0].Qty != 100 {
+		t.Fatalf("asks changed by a rejected ALO: %+v", asks)
+	}
+	if bids := book.GetBidLevels(); len(bids) != 2 || bids[0].Qty != 100 {
+		t.Fatalf("bids changed by a rejected ALO: %+v", bids)
+	}
+
+	// Reprice: one tick better than the best opposite price, no fills
+	if fills, err := place("alo_buy", orderbook.Buy, 52000, "ALO", true); err != nil || len(fills) != 0 {
+		t.Fatalf("repriced buy: %d fills, %v", len(fills), err)
+	}
+	bestBid := 51000 - mkt.TickSize
+	if bids := book.GetBidLevels(); bids[0].Price != bestBid {
+		t.Fatalf("repriced buy rests at %d, want %d", bids[0].Price, bestBid)
+	}
+	if fills, err := place("alo_sell", orderbook.Sell, 40000, "ALO", true); err != nil || len(fills) != 0 {
+		t.Fatalf("repriced sell: %d fills, %v", len(fills), err)
+	}
+	if asks := book.GetAskLevels(); asks[0].Price != bestBid+mkt.TickSize {
+		t.Fatalf("repriced sell rests at %d, want %d", asks[0].Price, bestBid+mkt.TickSize)
+	}
+
+	// A repriced order is validated again: a buy moved below the minimum
+	// notional, or a sell with no price above the best bid, is rejected
+	for _, tc := range []struct {
+		name         string
+		maker, taker orderbook.Side
+		makerPrice   int64
+		makerQty     int64
+	}{
+		{"buy below min notional", orderbook.Sell, orderbook.Buy, 100, 100}, // 99 × 100 < MinNotional
+		{"sell above max price", orderbook.Buy, orderbook.Sell, math.MaxInt64, 1},
+	} {
+		edge := orderbook.NewOrderBook()
+		if _, err := edge.Place(&orderbook.Order{ID: "maker", Symbol: "HYPL-USDC", Side: tc.maker, Price: tc.makerPrice, Qty: tc.makerQty, Type: "GTC"}, mkt); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		_, err := edge.Place(&orderbook.Order{ID: "alo", Symbol: "HYPL-USDC", Side: tc.taker, Price: 100, Qty: 100, Type: "ALO", Reprice: true}, mkt)
+		if !errors.Is(err, orderbook.ErrWouldCross) {
+			t.Errorf("%s: %v, want ErrWouldCross", tc.name, err)
+		}
+		if n := len(edge.GetBidLevels()) + len(edge.GetAskLevels()); n != 1 {
+			t.Errorf("%s: %d levels after a rejected reprice, want 1", tc.name, n)
+		}
+	}
+}
+
 // TestMultiMarketValidation tests that different markets have different rules
 func TestMultiMarketValidation(t *testing.T) {
 	// Create two markets with different parameters
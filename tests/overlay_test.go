@@ -0,0 +1,116 @@
+// file: tests/overlay_test.go
+package tests
+
+import (
+	"testing"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// TestSpeculativeExecution: executing blocks leaves the committed state
+// alone until one commits; the forks it orphans are discarded and their txs
+// go back to the mempool.
+func TestSpeculativeExecution(t *testing.T) {
+	app := abci.NewMockApp()
+	bridge := &abci.Bridge{App: app}
+	ev := consensus.NewDuplicateVote(
+		consensus.Vote{View: 1, H: consensus.Hash{1}, From: "val2", SigShare: []byte{1}},
+		consensus.Vote{View: 1, H: consensus.Hash{2}, From: "val2", SigShare: []byte{2}},
+	)
+	genesis := consensus.HashOfBlock(consensus.GenesisBlock())
+	block := func(parent consensus.Hash, view consensus.View, entries ...[]byte) consensus.Block {
+		return consensus.Block{Height: 1, View: view, Parent: parent, Payload: consensus.EncodePayload(entries)}
+	}
+	a1 := block(genesis, 1, consensus.EvidenceTx(ev), []byte("txA"))
+	a2 := block(consensus.HashOfBlock(a1), 2)
+	a2.Height = 2
+	b1 := block(genesis, 3, []byte("txB"))
+	for _, b := range []consensus.Block{a1, a2, b1} {
+		if _, err := bridge.Execute(b); err != nil {
+			t.Fatalf("execute view %d: %v", b.View, err)
+		}
+	}
+	if _, ok := app.Jailed("val2"); ok || app.CommitCount() != 0 {
+		t.Fatal("execution changed the committed state")
+	}
+
+	if err := bridge.Commit(b1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := app.Jailed("val2"); ok || app.CommitCount() != 1 {
+		t.Fatalf("orphaned fork committed (commits %d)", app.CommitCount())
+	}
+	if _, ok := app.GetTx(consensus.TxHash([]byte("txA"))); !ok {
+		t.Fatal("orphaned tx not back in the mempool")
+	}
+	if _, ok := app.GetTx(consensus.TxHash([]byte("txB"))); ok {
+		t.Fatal("committed tx back in the mempool")
+	}
+	if _, err := bridge.Execute(consensus.Block{Height: 3, View: 4, Parent: consensus.HashOfBlock(a2)}); err == nil {
+		t.Fatal("executed on an orphaned fork")
+	}
+
+	// Rolled back executions are gone too
+	c2 := block(consensus.HashOfBlock(b1), 4)
+	c2.Height = 2
+	if _, err := bridge.Execute(c2); err != nil {
+		t.Fatal(err)
+	}
+	bridge.Rollback(consensus.HashOfBlock(c2))
+	if err := bridge.Commit(c2); err == nil {
+		t.Fatal("committed a rolled back block")
+	}
+}
+
+// TestAccountManagerFork: a fork's changes stay out of its parent until
+// merged.
+func TestAccountManagerFork(t *testing.T) {
+	am := newTestAccountManager(t)
+	if err := am.Deposit(alice, 1000); err != nil {
+		t.Fatal(err)
+	}
+	fork := am.Fork()
+	if err := fork.Deposit(alice, 500); err != nil {
+		t.Fatal(err)
+	}
+	if err := fork.Deposit(bob, 200); err != nil {
+		t.Fatal(err)
+	}
+	if got := am.GetAvailableBalance(alice); got != 1000 {
+		t.Fatalf("parent balance %d after fork deposit, want 1000", got)
+	}
+	if am.GetAccountReadOnly(bob) != nil {
+		t.Fatal("account created in the fork leaked into the parent")
+	}
+	if got := fork.GetAvailableBalance(alice); got != 1500 {
+		t.Fatalf("fork balance %d, want 1500", got)
+	}
+
+	if err := am.Merge(fork); err != nil {
+		t.Fatal(err)
+	}
+	if am.GetAvailableBalance(alice) != 1500 || am.GetAvailableBalance(bob) != 200 {
+		t.Fatalf("merged balances %d, %d", am.GetAvailableBalance(alice), am.GetAvailableBalance(bob))
+	}
+}
+
+// TestOrderBookClone: matching against a clone leaves the original intact.
+func TestOrderBookClone(t *testing.T) {
+	market, _ := core.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	ob := core.NewOrderBook()
+	ob.Place(&core.Order{ID: "ask1", Symbol: "HYPL-USDC", Side: core.Sell, Price: 51000, Qty: 100, Type: "GTC"}, market)
+
+	c := ob.Clone()
+	fills, _ := c.Place(&core.Order{ID: "bid1", Symbol: "HYPL-USDC", Side: core.Buy, Price: 51000, Qty: 60, Type: "IOC"}, market)
+	if len(fills) != 1 {
+		t.Fatalf("expected 1 fill, got %d", len(fills))
+	}
+	if asks := ob.GetAskLevels(); len(asks) != 1 || asks[0].Qty != 100 {
+		t.Fatalf("original asks %+v, want 100 at 51000", asks)
+	}
+	if asks := c.GetAskLevels(); len(asks) != 1 || asks[0].Qty != 40 {
+		t.Fatalf("clone asks %+v, want 40 at 51000", asks)
+	}
+}
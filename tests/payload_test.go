@@ -0,0 +1,93 @@
+// file: tests/payload_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// TestPayloadEncoding: binary, NUL-containing and empty txs survive the
+// payload encoding, and malformed payloads are rejected.
+func TestPayloadEncoding(t *testing.T) {
+	txs := [][]byte{[]byte("tx1"), {0x00, 0x01, 0x00}, {}, []byte("nul\x00inside")}
+	app := abci.NewMockApp()
+	for _, tx := range txs {
+		app.PushTx(tx)
+	}
+	payload := (&abci.Bridge{App: app}).PreparePayload(consensus.GenesisBlock(), 1, nil)
+	got, err := consensus.DecodePayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(txs) {
+		t.Fatalf("decoded %d entries %q, want %d", len(got), got, len(txs))
+	}
+	for i := range txs {
+		if string(got[i]) != string(txs[i]) {
+			t.Fatalf("entry %d = %q, want %q", i, got[i], txs[i])
+		}
+	}
+	if n := len(consensus.PayloadTxs(payload)); n != len(txs) {
+		t.Fatalf("PayloadTxs counts %d, want %d", n, len(txs))
+	}
+	if entries, err := consensus.DecodePayload(nil); err != nil || len(entries) != 0 {
+		t.Fatalf("empty payload: %q, %v", entries, err)
+	}
+
+	for name, p := range map[string][]byte{
+		"unknown version":  {0x02, 0x01, 0x00},
+		"zero count":       {consensus.PayloadVersion, 0x00},
+		"count too large":  {consensus.PayloadVersion, 0x05, 0x00},
+		"truncated entry":  {consensus.PayloadVersion, 0x01, 0x03, 'a'},
+		"trailing bytes":   {consensus.PayloadVersion, 0x01, 0x01, 'a', 'b'},
+		"overlong varint":  {consensus.PayloadVersion, 0x01, 0x81, 0x00, 'a'},
+		"legacy delimited": []byte("tx1\x00tx2\x00"),
+	} {
+		if _, err := consensus.DecodePayload(p); !errors.Is(err, consensus.ErrMalformedPayload) {
+			t.Errorf("%s: %v, want ErrMalformedPayload", name, err)
+		}
+		if consensus.PayloadHash(p) == consensus.PayloadHash(nil) {
+			t.Errorf("%s: shares the empty payload's PayloadHash", name)
+		}
+	}
+}
+
+// TestMalformedPayloadNoVote: a validator does not vote for a proposal whose
+// payload does not decode.
+func TestMalformedPayloadNoVote(t *testing.T) {
+	if n, _ := proposeOnce(nil, consensus.EncodePayload([][]byte{[]byte("tx\x001")}), time.Now()); n != 1 {
+		t.Fatalf("well-formed payload got %d votes, want 1", n)
+	}
+	n, m := proposeOnce(nil, []byte("tx1\x00"), time.Now())
+	if n != 0 || m.Rejected[consensus.RejectPayload] != 1 || m.Accepted != 0 {
+		t.Fatalf("malformed payload got %d votes (metrics %+v), want 0", n, m)
+	}
+}
+
+// proposeOnce hands a fresh validator (val3 of four, running app, the
+// MockApp if nil) a view-1 block with payload and time extending genesis,
+// and returns the votes it sent and its proposal metrics.
+func proposeOnce(app abci.Application, payload []byte, at time.Time) (int, consensus.ProposalMetrics) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	genesis := consensus.Certificate{H: consensus.HashOfBlock(consensus.GenesisBlock())}
+	hub := newMemHub()
+	e := newMemEngine("val3", ids, hub.join("val3"))
+	if app != nil {
+		e.App = &abci.Bridge{App: app}
+	}
+	leader := consensus.RoundRobinElector{IDs: ids}.LeaderOf(1)
+	leaderNet := hub.join(leader)
+	hub.nodes["val3"].getHandlers().OnPropose(context.Background(), consensus.Propose{
+		Block: consensus.Block{
+			Height: 1, View: 1, Parent: genesis.H, Payload: payload, Proposer: leader,
+			Time: at, ValSetHash: e.ActiveValidators().Hash(),
+		},
+		HighCert: genesis,
+	})
+	return votesFor(leaderNet, 1), e.ProposalMetrics()
+}
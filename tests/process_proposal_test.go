@@ -0,0 +1,90 @@
+// file: tests/process_proposal_test.go
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// rejectingApp is a MockApp whose ProcessProposal rejects txs starting
+// with "bad".
+type rejectingApp struct{ *abci.MockApp }
+
+func (a rejectingApp) ProcessProposal(req abci.RequestProcessProposal) abci.ResponseProcessProposal {
+	for _, tx := range req.Txs {
+		if bytes.HasPrefix(tx, []byte("bad")) {
+			return abci.ResponseProcessProposal{Reason: "bad tx"}
+		}
+	}
+	return abci.ResponseProcessProposal{Accept: true}
+}
+
+// TestProcessProposal: the bridge rejects blocks that go back in time,
+// exceed the tx byte limit or hold txs the app rejects.
+func TestProcessProposal(t *testing.T) {
+	parent := consensus.Block{Height: 1, Time: time.Unix(100, 0)}
+	block := func(at int64, txs ...string) consensus.Block {
+		entries := make([][]byte, len(txs))
+		for i, tx := range txs {
+			entries[i] = []byte(tx)
+		}
+		return consensus.Block{Height: 2, Payload: consensus.EncodePayload(entries), Time: time.Unix(at, 0)}
+	}
+	bridge := &abci.Bridge{App: rejectingApp{abci.NewMockApp()}, MaxTxBytes: 8}
+	if err := bridge.ProcessProposal(parent, block(100, "tx1", "tx2")); err != nil {
+		t.Fatalf("valid block: %v", err)
+	}
+	for name, b := range map[string]consensus.Block{
+		"older than parent": block(99, "tx1"),
+		"too many bytes":    block(101, "tx1", "tx2", "tx3"),
+		"app rejects a tx":  block(101, "tx1", "bad"),
+		"malformed payload": {Height: 2, Payload: []byte("tx1\x00"), Time: time.Unix(101, 0)},
+	} {
+		if err := bridge.ProcessProposal(parent, b); !errors.Is(err, consensus.ErrProposalRejected) {
+			t.Errorf("%s: %v, want ErrProposalRejected", name, err)
+		}
+	}
+
+	// The engine withholds its vote from a rejected block and counts it
+	app := rejectingApp{abci.NewMockApp()}
+	n, m := proposeOnce(app, consensus.EncodePayload([][]byte{[]byte("bad")}), time.Now())
+	if n != 0 || m.Rejected[consensus.RejectApp] != 1 || m.Accepted != 0 {
+		t.Fatalf("rejected block: %d votes, metrics %+v", n, m)
+	}
+	n, m = proposeOnce(app, consensus.EncodePayload([][]byte{[]byte("good")}), time.Now())
+	if n != 1 || m.Accepted != 1 || m.RejectedTotal() != 0 {
+		t.Fatalf("accepted block: %d votes, metrics %+v", n, m)
+	}
+}
+
+// TestCheckTx: the perp app's stateless tx checks.
+func TestCheckTx(t *testing.T) {
+	v := perp.NewTxVerifier()
+	gen := perp.NewSignedTxGenerator(2, []string{"BTC-USDT"})
+	signed := gen.GenerateSignedOrder()
+	if err := v.CheckTx(signed); err != nil {
+		t.Fatalf("signed order: %v", err)
+	}
+	if err := v.CheckTx([]byte("O:GTC:BTC-USDT:BUY:price=50000:qty=100:id=test_o1")); err != nil {
+		t.Fatalf("legacy order: %v", err)
+	}
+	forged := bytes.Replace(signed, []byte(`"symbol":"BTC-USDT"`), []byte(`"symbol":"ETH-USDT"`), 1)
+	if bytes.Equal(forged, signed) {
+		t.Fatalf("unexpected order encoding %s", signed)
+	}
+	for name, tx := range map[string][]byte{
+		"not a tx":         []byte("garbage"),
+		"forged signature": forged,
+		"missing fields":   []byte(`{"type":"order","order":{"symbol":"BTC-USDT"},"signature":"0x00"}`),
+	} {
+		if err := v.CheckTx(tx); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// file: tests/qc_verify_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+func TestVerifyCertificate(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	pubKeys := c.engines["val1"].PubKeys
+	vs, err := consensus.EqualPowerSet(0, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const chainID = "test-chain"
+	h := consensus.Hash{0xab}
+	app := consensus.Hash{0x42}
+	msg := consensus.VoteSignBytes(chainID, 5, 3, h, app)
+	qc := func(by ...consensus.NodeID) consensus.Certificate {
+		var shares [][]byte
+		for _, id := range by {
+			shares = append(shares, signers[id].Sign(msg))
+		}
+		return consensus.Certificate{View: 5, Height: 3, H: h, AppHash: app, Sig: crypto.Aggregate(shares), Signers: consensus.SignerBitmap(ids, by)}
+	}
+
+	good := qc("val1", "val3", "val4")
+	if err := consensus.VerifyCertificate(good, good.SignBytes(chainID), vs, pubKeys); err != nil {
+		t.Fatalf("valid QC rejected: %v", err)
+	}
+
+	// Only 2 signers: not more than 2/3 of the power
+	if err := consensus.VerifyCertificate(qc("val1", "val2"), msg, vs, pubKeys); !errors.Is(err, consensus.ErrCertNoQuorum) {
+		t.Errorf("2-signer QC: got %v, want ErrCertNoQuorum", err)
+	}
+
+	// Bitmap claims val2 but val2 never signed
+	forged := good
+	forged.Signers = consensus.SignerBitmap(ids, []consensus.NodeID{"val1", "val2", "val3"})
+	if err := consensus.VerifyCertificate(forged, msg, vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("wrong bitmap: got %v, want ErrCertBadSig", err)
+	}
+
+	// Signature over a different block
+	other := good
+	other.H = consensus.Hash{0xcd}
+	if err := consensus.VerifyCertificate(other, other.SignBytes(chainID), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("wrong message: got %v, want ErrCertBadSig", err)
+	}
+
+	// AppHash is signed: a QC cannot be relabelled with another state root
+	tampered := good
+	tampered.AppHash = consensus.Hash{0x66}
+	if err := consensus.VerifyCertificate(tampered, tampered.SignBytes(chainID), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("tampered AppHash: got %v, want ErrCertBadSig", err)
+	}
+
+	// Same QC replayed on another chain
+	if err := consensus.VerifyCertificate(good, good.SignBytes("other-chain"), vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSig) {
+		t.Errorf("cross-chain replay: got %v, want ErrCertBadSig", err)
+	}
+
+	// Bit set beyond the validator set
+	junk := good
+	junk.Signers = []byte{0xff}
+	if err := consensus.VerifyCertificate(junk, msg, vs, pubKeys); !errors.Is(err, consensus.ErrCertBadSigner) {
+		t.Errorf("out-of-range bit: got %v, want ErrCertBadSigner", err)
+	}
+
+	got, err := consensus.CertSigners(ids, good.Signers)
+	if err != nil || len(got) != 3 || got[0] != "val1" || got[1] != "val3" || got[2] != "val4" {
+		t.Errorf("CertSigners = %v, %v", got, err)
+	}
+}
+
+// TestVerifyTimeoutHighCert: a timeout's signature covers the HighCert it
+// carries, so another certificate of the same view cannot be swapped in.
+func TestVerifyTimeoutHighCert(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	pubKeys := c.engines["val1"].PubKeys
+
+	const chainID = "test-chain"
+	high := consensus.Certificate{View: 5, Height: 3, H: consensus.Hash{0xab}, AppHash: consensus.Hash{0x42}}
+	timeout := consensus.Timeout{View: 9, HighCert: high, From: "val2"}
+	timeout.Sig = signers["val2"].Sign(timeout.SignBytes(chainID))
+	if err := consensus.VerifyTimeout(timeout, chainID, pubKeys); err != nil {
+		t.Fatalf("valid timeout rejected: %v", err)
+	}
+
+	for name, swap := range map[string]func(*consensus.Certificate){
+		"block":    func(c *consensus.Certificate) { c.H = consensus.Hash{0xcd} },
+		"height":   func(c *consensus.Certificate) { c.Height = 4 },
+		"app hash": func(c *consensus.Certificate) { c.AppHash = consensus.Hash{0x66} },
+	} {
+		substituted := timeout
+		swap(&substituted.HighCert)
+		if err := consensus.VerifyTimeout(substituted, chainID, pubKeys); !errors.Is(err, consensus.ErrTimeoutBadSig) {
+			t.Errorf("HighCert with another %s: got %v, want ErrTimeoutBadSig", name, err)
+		}
+	}
+
+	// Signed by someone else, or by no known validator
+	forged := timeout
+	forged.Sig = signers["val3"].Sign(timeout.SignBytes(chainID))
+	if err := consensus.VerifyTimeout(forged, chainID, pubKeys); !errors.Is(err, consensus.ErrTimeoutBadSig) {
+		t.Errorf("foreign signature: got %v, want ErrTimeoutBadSig", err)
+	}
+	forged.From = "val9"
+	if err := consensus.VerifyTimeout(forged, chainID, pubKeys); !errors.Is(err, consensus.ErrCertBadSigner) {
+		t.Errorf("unknown sender: got %v, want ErrCertBadSigner", err)
+	}
+}
+
+// TestBLSClusterRejectsForgery: with BLS enabled the cluster makes progress,
+// forged vote shares never count and a forged QC is not adopted.
+func TestBLSClusterRejectsForgery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	for _, e := range c.engines {
+		// BLS pairings are slow without assembly; use devnet timers
+		e.PM.Timers = consensus.PacemakerTimers{Ppc: 150 * time.Millisecond, Delta: 50 * time.Millisecond}
+	}
+	c.start(t, ctx)
+	c.waitHeight(t, ids, 3, 10*time.Second)
+
+	// A vote share signed with someone else's key must be rejected
+	verify := c.hub.nodes["val1"].getHandlers().VerifyVote
+	vote := consensus.Vote{View: 99, Height: 7, H: consensus.Hash{0x01}, AppHash: consensus.Hash{0x02}, From: "val2"}
+	msg := vote.SignBytes(c.engines["val1"].ChainID)
+	vote.SigShare = signers["val3"].Sign(msg)
+	if verify(vote) {
+		t.Fatal("vote with foreign signature accepted")
+	}
+	vote.SigShare = signers["val2"].Sign(msg)
+	if !verify(vote) {
+		t.Fatal("valid vote rejected")
+	}
+	// Share over the block hash alone no longer verifies
+	vote.SigShare = signers["val2"].Sign(vote.H[:])
+	if verify(vote) {
+		t.Fatal("vote signed over block hash only accepted")
+	}
+	// Changing the AppHash invalidates the share
+	vote.SigShare = signers["val2"].Sign(msg)
+	vote.AppHash = consensus.Hash{0x03}
+	if verify(vote) {
+		t.Fatal("vote with altered AppHash accepted")
+	}
+
+	// A peer on the gossip topic forges a QC for a far-future view
+	fake := consensus.Certificate{
+		View:    1 << 20,
+		H:       consensus.Hash{0xee},
+		Sig:     signers["val1"].Sign([]byte("garbage")),
+		Signers: consensus.SignerBitmap(ids, ids),
+	}
+	c.hub.broadcast("val1", func(dst *memNet) func(context.Context) {
+		return func(ctx context.Context) { dst.getHandlers().OnPrepare(ctx, fake, consensus.Block{}) }
+	})
+	time.Sleep(200 * time.Millisecond)
+
+	for _, id := range ids {
+		e := c.engines[id]
+		if e.Safety.HighestCert().View >= fake.View {
+			t.Fatalf("%s adopted forged QC", id)
+		}
+		if _, ok := e.Store.GetCert(fake.View); ok {
+			t.Fatalf("%s stored forged QC", id)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// file: tests/reduce_only_test.go
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/transaction"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// TestReducible: only orders against the position reduce it, by at most its
+// size.
+func TestReducible(t *testing.T) {
+	acc := core.NewAccount(alice)
+	if got := acc.Reducible("BTC-USDT", -10); got != 0 {
+		t.Fatalf("reducible without a position: %d", got)
+	}
+	acc.Positions["BTC-USDT"] = &core.Position{Symbol: "BTC-USDT", Size: 100}
+	for _, tt := range []struct{ delta, want int64 }{{-40, 40}, {-150, 100}, {30, 0}} {
+		if got := acc.Reducible("BTC-USDT", tt.delta); got != tt.want {
+			t.Errorf("reducible(%d) on a long of 100: %d, want %d", tt.delta, got, tt.want)
+		}
+	}
+}
+
+// TestResizeReduceOnlyOrders: Resize keeps an order's priority and cancels
+// it at zero; ReduceOnlyOrders lists an owner's resting reduce-only orders
+// in matching order.
+func TestResizeReduceOnlyOrders(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	book := orderbook.NewOrderBook()
+	owner := alice.Hex()
+	for _, o := range []*orderbook.Order{
+		{ID: "far", Side: orderbook.Sell, Price: 60000, Qty: 100, ReduceOnly: true, OwnerHex: owner},
+		{ID: "near", Side: orderbook.Sell, Price: 50000, Qty: 100, ReduceOnly: true, OwnerHex: owner},
+		{ID: "plain", Side: orderbook.Sell, Price: 50000, Qty: 100, OwnerHex: owner},
+		{ID: "other", Side: orderbook.Sell, Price: 50000, Qty: 100, ReduceOnly: true, OwnerHex: bob.Hex()},
+	} {
+		o.Symbol, o.Type = "HYPL-USDC", "GTC"
+		if _, err := book.Place(o, mkt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := book.ReduceOnlyOrders(owner)
+	if len(got) != 2 || got[0].ID != "near" || got[1].ID != "far" {
+		t.Fatalf("reduce-only orders %+v, want near then far", got)
+	}
+
+	if !book.Resize("near", 30) || !book.Resize("far", 0) || book.Resize("nope", 10) {
+		t.Fatal("resize results")
+	}
+	fills, err := book.Place(&orderbook.Order{ID: "buy", Symbol: "HYPL-USDC", Side: orderbook.Buy, Price: 60000, Qty: 1000, Type: "IOC"}, mkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 3 || fills[0].MakerID != "near" || fills[0].Qty != 30 {
+		t.Fatalf("fills %+v, want the resized order first and the cancelled one gone", fills)
+	}
+}
+
+// TestSignedReduceOnlyOrders: reduce-only orders are clamped to the
+// position, rejected when they would not reduce it, and shrink as it
+// shrinks; a CLOSE order flattens it.
+func TestSignedReduceOnlyOrders(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	bridge := &abci.Bridge{App: app}
+
+	signer, _ := crypto.GenerateKey()
+	owner := signer.Address()
+	app.GetAccount(owner).USDCBalance = 1_000_000_000
+	maker := common.HexToAddress("0xBB00000000000000000000000000000000000000")
+	app.GetAccount(maker).USDCBalance = 1_000_000_000
+
+	eip712 := crypto.NewEIP712Signer(crypto.DefaultDomain())
+	nonce := int64(0)
+	orderTx := func(side, typ uint8, price, qty int64, reduceOnly bool) []byte {
+		nonce++
+		order := &crypto.OrderEIP712{
+			Symbol: "BTC-USDT", Side: side, Type: typ, Price: big.NewInt(price), Qty: big.NewInt(qty),
+			Nonce: big.NewInt(nonce), Deadline: big.NewInt(0), Leverage: 10, ReduceOnly: reduceOnly, Owner: owner,
+		}
+		sig, err := eip712.SignOrder(signer, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type: transaction.TxTypeOrder, Order: transaction.FromEIP712Order(order),
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+	makerTx := func(side string, price, qty int64, id string) []byte {
+		return []byte(fmt.Sprintf("O:GTC:BTC-USDT:%s:price=%d:qty=%d:id=%s-%s", side, price, qty, maker.Hex(), id))
+	}
+	parent := consensus.GenesisBlock()
+	run := func(txs ...[]byte) {
+		t.Helper()
+		for _, tx := range txs {
+			if err := app.CheckTx(abci.RequestCheckTx{Tx: tx}).Reason; err != "" {
+				t.Fatalf("tx rejected: %s", err)
+			}
+		}
+		b := consensus.Block{Height: parent.Height + 1, View: parent.View + 1, Parent: consensus.HashOfBlock(parent),
+			Payload: consensus.EncodePayload(txs), Time: time.Unix(100+int64(parent.Height), 0)}
+		if _, err := bridge.Execute(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := bridge.Commit(b); err != nil {
+			t.Fatal(err)
+		}
+		parent = b
+	}
+	position := func() int64 {
+		if pos := app.GetAccount(owner).GetPosition("BTC-USDT"); pos != nil {
+			return pos.Size
+		}
+		return 0
+	}
+	askAt := func(price int64) int64 {
+		for _, l := range app.GetOrderbook("BTC-USDT").GetAskLevels() {
+			if l.Price == price {
+				return l.Qty
+			}
+		}
+		return 0
+	}
+
+	// Block 1: buy 100 from the maker, then rest a reduce-only sell of 150
+	// above the market: it is clamped to the long of 100
+	run(
+		makerTx("SELL", 50000, 100, "a1"),
+		orderTx(1, 1, 50000, 100, false),
+		orderTx(2, 1, 51000, 150, true),
+	)
+	if got := position(); got != 100 {
+		t.Fatalf("position %d, want 100", got)
+	}
+	if got := askAt(51000); got != 100 {
+		t.Fatalf("reduce-only ask %d, want clamped to 100", got)
+	}
+	if pos := app.GetAccount(maker).GetPosition("BTC-USDT"); pos == nil || pos.Size != -100 {
+		t.Fatalf("maker position %+v, want short 100", pos)
+	}
+
+	// Block 2: a plain sell of 60 shrinks the position; the resting
+	// reduce-only order shrinks with it. A reduce-only buy would grow the
+	// long and is rejected
+	run(
+		makerTx("BUY", 49000, 60, "b1"),
+		orderTx(2, 2, 49000, 60, false),
+		orderTx(1, 1, 49000, 10, true),
+	)
+	if got := position(); got != 40 {
+		t.Fatalf("position %d, want 40", got)
+	}
+	if got := askAt(51000); got != 40 {
+		t.Fatalf("reduce-only ask %d, want shrunk to 40", got)
+	}
+	rejected := app.GetAccount(owner).Rejected
+	if len(rejected) != 1 || rejected[0].Reason != core.ErrReduceOnly.Error() {
+		t.Fatalf("rejected orders %+v, want the reduce-only buy", rejected)
+	}
+
+	// Block 3: CLOSE sells the rest whatever its signed side and qty; the
+	// resting reduce-only order is cancelled
+	run(
+		makerTx("BUY", 48000, 100, "b2"),
+		orderTx(1, 5, 0, 0, false),
+	)
+	if got := position(); got != 0 {
+		t.Fatalf("position %d after CLOSE, want 0", got)
+	}
+	if got := askAt(51000); got != 0 {
+		t.Fatalf("reduce-only ask of %d left on a flat position", got)
+	}
+}
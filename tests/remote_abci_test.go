@@ -0,0 +1,143 @@
+// file: tests/remote_abci_test.go
+package tests
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/abci/remote"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+)
+
+// serveRemote serves app on a unix socket and returns a client for it.
+func serveRemote(t *testing.T, app abci.Application) (*remote.Client, func()) {
+	t.Helper()
+	addr := "unix://" + filepath.Join(t.TempDir(), "abci.sock")
+	lis, err := remote.Listen(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := remote.NewGRPCServer(app)
+	go srv.Serve(lis)
+	client, err := remote.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Timeout = 2 * time.Second
+	t.Cleanup(func() { client.Close(); srv.Stop() })
+	return client, srv.Stop
+}
+
+// TestRemoteApplication: an app behind a unix socket executes blocks,
+// evidence included, exactly like the same app in process.
+func TestRemoteApplication(t *testing.T) {
+	local, served := abci.NewMockApp(), abci.NewMockApp()
+	served.SetValidatorPower(2, "val5", 7)
+	local.SetValidatorPower(2, "val5", 7)
+	client, stop := serveRemote(t, served)
+	direct, viaSocket := &abci.Bridge{App: local}, &abci.Bridge{App: client}
+
+	ev := consensus.NewDuplicateVote(
+		consensus.Vote{View: 1, H: consensus.Hash{1}, From: "val2", SigShare: []byte{1}},
+		consensus.Vote{View: 1, H: consensus.Hash{2}, From: "val2", SigShare: []byte{2}},
+	)
+	b1 := consensus.Block{Height: 1, View: 1, Parent: consensus.HashOfBlock(consensus.GenesisBlock()),
+		Payload: consensus.EncodePayload([][]byte{consensus.EvidenceTx(ev), []byte("tx1")}), Time: time.Unix(100, 0)}
+	b2 := consensus.Block{Height: 2, View: 2, Parent: consensus.HashOfBlock(b1),
+		Payload: consensus.EncodePayload([][]byte{[]byte("tx2")}), Time: time.Unix(101, 0)}
+	for _, b := range []consensus.Block{b1, b2} {
+		want, err := direct.Execute(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := viaSocket.Execute(b)
+		if err != nil {
+			t.Fatalf("remote execute h=%d: %v", b.Height, err)
+		}
+		if got != want {
+			t.Fatalf("h=%d: remote AppHash %s, local %s", b.Height, got, want)
+		}
+		if !slices.Equal(viaSocket.LastValidatorUpdates(), direct.LastValidatorUpdates()) {
+			t.Fatalf("h=%d: remote updates %v, local %v", b.Height, viaSocket.LastValidatorUpdates(), direct.LastValidatorUpdates())
+		}
+	}
+	if err := viaSocket.Commit(b2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := served.Jailed("val2"); !ok || served.CommitCount() != 2 {
+		t.Fatalf("commit did not reach the app (commits %d)", served.CommitCount())
+	}
+
+	// Errors cross the socket
+	if _, err := viaSocket.Execute(consensus.Block{Height: 9, Parent: consensus.Hash{0xee}}); err == nil {
+		t.Fatal("executed on an unknown parent")
+	}
+	if err := viaSocket.Commit(consensus.Block{Height: 9}); err == nil {
+		t.Fatal("committed an unknown block")
+	}
+	if _, err := client.Query(abci.RequestQuery{Path: "jailed"}); err == nil {
+		t.Fatal("MockApp answered a query")
+	}
+
+	// An unreachable app gets no vote
+	stop()
+	if err := viaSocket.ProcessProposal(b1, b2); err == nil {
+		t.Fatal("proposal accepted with the app down")
+	}
+}
+
+// TestRemotePerpApp: the perp app served over a unix socket admits txs,
+// proposes, executes and commits them, and answers queries.
+func TestRemotePerpApp(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	client, _ := serveRemote(t, app)
+
+	tx := []byte("O:GTC:BTC-USDT:BUY:price=50000:qty=100:id=remote_o1")
+	if resp := client.CheckTx(abci.RequestCheckTx{Tx: tx}); !resp.Accept {
+		t.Fatalf("order rejected: %s", resp.Reason)
+	}
+	if resp := client.CheckTx(abci.RequestCheckTx{Tx: []byte("garbage")}); resp.Accept || resp.Reason == "" {
+		t.Fatalf("garbage tx: %+v", resp)
+	}
+
+	bridge := &abci.Bridge{App: client}
+	payload := bridge.PreparePayload(consensus.GenesisBlock(), 1, nil)
+	if txs := consensus.PayloadTxs(payload); len(txs) != 1 || string(txs[0]) != string(tx) {
+		t.Fatalf("proposed %d txs, want the checked tx", len(txs))
+	}
+	b := consensus.Block{Height: 1, View: 1, Parent: consensus.HashOfBlock(consensus.GenesisBlock()),
+		Payload: payload, Time: time.Unix(100, 0)}
+	if err := bridge.ProcessProposal(consensus.GenesisBlock(), b); err != nil {
+		t.Fatalf("own proposal rejected: %v", err)
+	}
+	if h, err := bridge.Execute(b); err != nil || h == (consensus.Hash{}) {
+		t.Fatalf("execute: AppHash %s, %v", h, err)
+	}
+	if err := bridge.Commit(b); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Query(abci.RequestQuery{Path: "orderbook/BTC-USDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var book perp.BookQuery
+	if err := json.Unmarshal(resp.Value, &book); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bids) != 1 || book.Bids[0].Price != 50000 {
+		t.Fatalf("committed order missing from the book: %s", resp.Value)
+	}
+	for _, path := range []string{"orderbook/NOPE-USDT", "account/nope", "unknown"} {
+		if _, err := client.Query(abci.RequestQuery{Path: path}); err == nil {
+			t.Errorf("query %s answered", path)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// file: tests/self_trade_test.go
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/transaction"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// TestSelfTradePrevention: a buy of 150 meets alice's ask of 100 at 50000
+// ahead of bob's ask of 100 at 51000, in each mode.
+func TestSelfTradePrevention(t *testing.T) {
+	tests := []struct {
+		mode       market.SelfTradePrevention
+		fills      []int64 // qty matched with bob
+		asks       []orderbook.PriceLevel
+		restingBid int64
+	}{
+		{market.STPNone, []int64{100, 50}, []orderbook.PriceLevel{{Price: 51000, Qty: 50}}, 0},
+		{market.STPCancelNewest, nil, []orderbook.PriceLevel{{Price: 50000, Qty: 100}, {Price: 51000, Qty: 100}}, 0},
+		{market.STPCancelOldest, []int64{100}, nil, 50},
+		{market.STPCancelBoth, nil, []orderbook.PriceLevel{{Price: 51000, Qty: 100}}, 0},
+		{market.STPDecrementAndCancel, []int64{50}, []orderbook.PriceLevel{{Price: 51000, Qty: 50}}, 0},
+	}
+	for _, tt := range tests {
+		mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+		mkt.SelfTradePrevention = tt.mode
+		book := orderbook.NewOrderBook()
+		for _, o := range []*orderbook.Order{
+			{ID: "alice-ask", Price: 50000, OwnerHex: alice.Hex()},
+			{ID: "bob-ask", Price: 51000, OwnerHex: bob.Hex()},
+		} {
+			o.Symbol, o.Side, o.Qty, o.Type = "HYPL-USDC", orderbook.Sell, 100, "GTC"
+			if _, err := book.Place(o, mkt); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		buy := &orderbook.Order{ID: "alice-bid", Symbol: "HYPL-USDC", Side: orderbook.Buy, Price: 51000, Qty: 150, Type: "GTC", OwnerHex: alice.Hex()}
+		fills, selfTrades, err := book.PlaceWithSelfTrades(buy, mkt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mode, err)
+		}
+		var qtys []int64
+		for _, f := range fills {
+			if f.MakerID != "bob-ask" && tt.mode != market.STPNone {
+				t.Fatalf("%s: self-trade filled: %+v", tt.mode, f)
+			}
+			qtys = append(qtys, f.Qty)
+		}
+		if !slices.Equal(qtys, tt.fills) {
+			t.Errorf("%s: fills %v, want %v", tt.mode, qtys, tt.fills)
+		}
+		if tt.mode == market.STPNone {
+			if len(selfTrades) != 0 {
+				t.Errorf("none: prevented %+v", selfTrades)
+			}
+		} else if len(selfTrades) != 1 || selfTrades[0].MakerID != "alice-ask" || selfTrades[0].Mode != tt.mode || selfTrades[0].Qty != 100 {
+			t.Errorf("%s: prevented %+v, want alice's ask", tt.mode, selfTrades)
+		}
+		if asks := book.GetAskLevels(); !slices.Equal(asks, tt.asks) {
+			t.Errorf("%s: asks %+v, want %+v", tt.mode, asks, tt.asks)
+		}
+		var bid int64
+		if bids := book.GetBidLevels(); len(bids) > 0 {
+			bid = bids[0].Qty
+		}
+		if bid != tt.restingBid {
+			t.Errorf("%s: resting bid %d, want %d", tt.mode, bid, tt.restingBid)
+		}
+	}
+}
+
+// TestSelfTradePreventionOverrides: an order's mode overrides its market's
+// unless left unset, STPNone lets it match its owner's orders, orders
+// without an owner never self-trade, and a FOK order that prevention would
+// cancel is rejected.
+func TestSelfTradePreventionOverrides(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	if mkt.SelfTradePrevention != market.STPCancelNewest {
+		t.Fatalf("default mode %s, want cancel-newest", mkt.SelfTradePrevention)
+	}
+	book := orderbook.NewOrderBook()
+	place := func(o *orderbook.Order) ([]orderbook.Fill, []orderbook.SelfTrade, error) {
+		o.Symbol, o.Price, o.Qty = "HYPL-USDC", 50000, 100
+		if o.Type == "" {
+			o.Type = "GTC"
+		}
+		return book.PlaceWithSelfTrades(o, mkt)
+	}
+
+	// Anonymous orders match each other
+	if _, _, err := place(&orderbook.Order{ID: "a1", Side: orderbook.Sell}); err != nil {
+		t.Fatal(err)
+	}
+	if fills, selfTrades, _ := place(&orderbook.Order{ID: "a2", Side: orderbook.Buy}); len(fills) != 1 || len(selfTrades) != 0 {
+		t.Fatalf("anonymous orders: %d fills, %d prevented", len(fills), len(selfTrades))
+	}
+
+	if _, _, err := place(&orderbook.Order{ID: "ask", Side: orderbook.Sell, OwnerHex: alice.Hex()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := place(&orderbook.Order{ID: "fok", Side: orderbook.Buy, Type: "FOK", OwnerHex: alice.Hex()}); err != orderbook.ErrWouldNotFill {
+		t.Fatalf("self-trading FOK: %v", err)
+	}
+	// Owner addresses compare case-insensitively
+	_, selfTrades, err := place(&orderbook.Order{ID: "bid", Side: orderbook.Buy, OwnerHex: strings.ToLower(alice.Hex()), STP: market.STPCancelOldest})
+	if err != nil || len(selfTrades) != 1 || selfTrades[0].Mode != market.STPCancelOldest {
+		t.Fatalf("order override: %+v, %v", selfTrades, err)
+	}
+	if asks, bids := book.GetAskLevels(), book.GetBidLevels(); len(asks) != 0 || len(bids) != 1 {
+		t.Fatalf("after cancel-oldest: asks %+v, bids %+v", asks, bids)
+	}
+
+	// STPNone matches the owner's own bid; the market's cancel-newest would not
+	fills, selfTrades, err := place(&orderbook.Order{ID: "own", Side: orderbook.Sell, OwnerHex: alice.Hex(), STP: market.STPNone})
+	if err != nil || len(fills) != 1 || len(selfTrades) != 0 {
+		t.Fatalf("order STPNone: %d fills, %+v, %v", len(fills), selfTrades, err)
+	}
+	if _, _, err := place(&orderbook.Order{ID: "bad", Side: orderbook.Sell, STP: market.STPDecrementAndCancel + 1}); err != orderbook.ErrSelfTradeMode {
+		t.Fatalf("unknown mode: %v", err)
+	}
+}
+
+// TestSelfTradePreventionUnset: a market whose params leave the mode unset
+// matches its owners' self-crosses, as it did before self-trade prevention.
+func TestSelfTradePreventionUnset(t *testing.T) {
+	params := market.DefaultHYPLUSDC
+	params.SelfTradePrevention = market.STPMarketDefault
+	mkt, err := market.NewMarket("HYPL-USDC", "HYPL", "USDC", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mkt.SelfTradePrevention != market.STPNone {
+		t.Fatalf("unset mode became %s, want none", mkt.SelfTradePrevention)
+	}
+	book := orderbook.NewOrderBook()
+	for _, o := range []*orderbook.Order{
+		{ID: "ask", Side: orderbook.Sell, OwnerHex: alice.Hex()},
+		{ID: "bid", Side: orderbook.Buy, OwnerHex: alice.Hex()},
+	} {
+		o.Symbol, o.Price, o.Qty, o.Type = "HYPL-USDC", 50000, 100, "GTC"
+		fills, selfTrades, err := book.PlaceWithSelfTrades(o, mkt)
+		if err != nil || len(selfTrades) != 0 {
+			t.Fatalf("%s: prevented %+v, %v", o.ID, selfTrades, err)
+		}
+		if o.ID == "bid" && (len(fills) != 1 || fills[0].MakerID != "ask") {
+			t.Fatalf("self-cross: fills %+v, want the ask", fills)
+		}
+	}
+}
+
+// TestSignedSelfTradePrevention: the signed stp field is covered by the
+// signature; 0 takes the market's mode and STPNone lets the owner's orders
+// match.
+func TestSignedSelfTradePrevention(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+
+	signer, _ := crypto.GenerateKey()
+	owner := signer.Address()
+	app.GetAccount(owner).USDCBalance = 1_000_000_000
+
+	eip712 := crypto.NewEIP712Signer(crypto.DefaultDomain())
+	nonce := int64(0)
+	order := func(side uint8, stp market.SelfTradePrevention) *transaction.SignedTransaction {
+		nonce++
+		o := &crypto.OrderEIP712{
+			Symbol: "BTC-USDT", Side: side, Type: 1, Price: big.NewInt(50000), Qty: big.NewInt(10),
+			Nonce: big.NewInt(nonce), Deadline: big.NewInt(0), Leverage: 10, STP: uint8(stp), Owner: owner,
+		}
+		sig, err := eip712.SignOrder(signer, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &transaction.SignedTransaction{
+			Type: transaction.TxTypeOrder, Order: transaction.FromEIP712Order(o),
+			Signature: fmt.Sprintf("0x%x", sig),
+		}
+	}
+	encode := func(txs ...*transaction.SignedTransaction) [][]byte {
+		var out [][]byte
+		for _, tx := range txs {
+			b, _ := json.Marshal(tx)
+			out = append(out, b)
+		}
+		return out
+	}
+
+	tampered := order(2, market.STPNone)
+	tampered.Order.STP = uint8(market.STPCancelOldest)
+	verifier := transaction.NewVerifier(crypto.DefaultDomain())
+	if _, valid, _ := verifier.VerifyOrderTransaction(tampered); valid {
+		t.Fatal("signature verified with a changed stp")
+	}
+
+	// Height 1: the bid leaves stp unset and meets the market's
+	// cancel-newest. Height 2: with STPNone it fills against the ask
+	resp, err := app.Execute(abci.RequestExecute{Hash: consensus.Hash{1}, Height: 1, Timestamp: 100,
+		Txs: encode(order(2, market.STPMarketDefault), order(1, market.STPMarketDefault))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(resp.Events, func(ev string) bool { return strings.Contains(ev, "mode=cancel-newest") }) {
+		t.Fatalf("events %q, want a cancel-newest self-trade", resp.Events)
+	}
+	resp, err = app.Execute(abci.RequestExecute{Hash: consensus.Hash{2}, Parent: consensus.Hash{1}, Height: 2, Timestamp: 101,
+		Txs: encode(order(1, market.STPNone))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.ContainsFunc(resp.Events, func(ev string) bool { return strings.HasPrefix(ev, "self_trade ") }) {
+		t.Fatalf("events %q, want no self-trade with STPNone", resp.Events)
+	}
+	for _, h := range []consensus.Hash{{1}, {2}} {
+		if err := app.Commit(abci.RequestCommit{Hash: h}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if asks := app.GetOrderbook("BTC-USDT").GetAskLevels(); len(asks) != 0 {
+		t.Fatalf("asks %+v, want the ask filled by its owner's bid", asks)
+	}
+}
+
+// TestSelfTradeEvents: the perp app emits an event for each prevented
+// match.
+func TestSelfTradeEvents(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+
+	owner := "owner=" + alice.Hex()
+	txs := [][]byte{
+		[]byte("O:GTC:BTC-USDT:SELL:price=50000:qty=100:id=ask:" + owner),
+		[]byte("O:GTC:BTC-USDT:BUY:price=50000:qty=100:id=bid:" + owner),
+	}
+	app.GetAccount(alice).USDCBalance = 1_000_000_000
+	resp, err := app.Execute(abci.RequestExecute{Hash: consensus.Hash{1}, Height: 1, Timestamp: 100, Txs: txs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, ev := range resp.Events {
+		if strings.HasPrefix(ev, "self_trade ") {
+			events = append(events, ev)
+		}
+	}
+	if len(events) != 1 || !strings.Contains(events[0], "taker=bid maker=ask mode=cancel-newest") {
+		t.Fatalf("events %q, want one cancel-newest self-trade", resp.Events)
+	}
+}
@@ -0,0 +1,139 @@
+// file: tests/sim_test.go
+package tests
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/sim"
+)
+
+// simSeeds returns the seeds to run: SIM_SEED=<n> reproduces a single failure.
+func simSeeds(t *testing.T, n int) []int64 {
+	t.Helper()
+	if s := os.Getenv("SIM_SEED"); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			t.Fatalf("SIM_SEED: %v", err)
+		}
+		return []int64{seed}
+	}
+	seeds := make([]int64, n)
+	for i := range seeds {
+		seeds[i] = int64(i + 1)
+	}
+	return seeds
+}
+
+// runSim runs cfg for d of virtual time, calling during (if set) halfway.
+func runSim(t *testing.T, cfg sim.Config, d time.Duration, during func(c *sim.Cluster)) *sim.Cluster {
+	t.Helper()
+	c := sim.NewCluster(cfg)
+	c.Start(context.Background())
+	if during != nil {
+		c.RunFor(d / 2)
+		during(c)
+		c.RunFor(d / 2)
+	} else {
+		c.RunFor(d)
+	}
+	c.Stop()
+	if err := c.CheckAgreement(); err != nil {
+		t.Fatalf("agreement: %v (rerun with SIM_SEED=%d)", err, cfg.Seed)
+	}
+	return c
+}
+
+var lossyLinks = sim.Faults{MinDelay: time.Millisecond, MaxDelay: 30 * time.Millisecond, Drop: 0.05, Duplicate: 0.05}
+
+// TestSimFaultyNetwork: delays, reordering, loss and duplication slow
+// consensus down but never break agreement, and it keeps committing.
+func TestSimFaultyNetwork(t *testing.T) {
+	for _, seed := range simSeeds(t, 5) {
+		c := runSim(t, sim.Config{N: 4, Seed: seed, Faults: lossyLinks}, 3*time.Second, nil)
+		if err := c.CheckLiveness(5); err != nil {
+			t.Fatalf("liveness: %v", err)
+		}
+		if st := c.Net.Stats(); st.Dropped == 0 || st.Duplicated == 0 {
+			t.Fatalf("seed %d: faults not exercised: %+v", seed, st)
+		}
+	}
+}
+
+// TestSimPartitionHeals: a 2/2 split halts commits without forking; once
+// healed the validators catch up and continue.
+func TestSimPartitionHeals(t *testing.T) {
+	for _, seed := range simSeeds(t, 3) {
+		var atSplit consensus.Height
+		c := runSim(t, sim.Config{N: 4, Seed: seed, Faults: sim.Faults{MaxDelay: 10 * time.Millisecond}}, 6*time.Second,
+			func(c *sim.Cluster) {
+				c.Net.Partition([]consensus.NodeID{"val1", "val2"}, []consensus.NodeID{"val3", "val4"})
+				before := len(c.Committed("val1"))
+				c.RunFor(2 * time.Second)
+				if got := len(c.Committed("val1")); got > before+1 {
+					t.Fatalf("seed %d: %d commits without a quorum", seed, got-before)
+				}
+				atSplit = consensus.Height(len(c.Committed("val1")))
+				c.Net.Heal()
+			})
+		if err := c.CheckLiveness(atSplit + 3); err != nil {
+			t.Fatalf("liveness after heal: %v", err)
+		}
+	}
+}
+
+// TestSimByzantine: one Byzantine validator out of four can neither break
+// agreement nor stop progress, and provable misbehaviour gets it jailed by
+// every honest validator's app.
+func TestSimByzantine(t *testing.T) {
+	for _, b := range []sim.Behaviour{sim.Equivocate, sim.WithholdVotes, sim.WrongAppHash, sim.DoubleVote} {
+		t.Run(b.String(), func(t *testing.T) {
+			for _, seed := range simSeeds(t, 3) {
+				c := runSim(t, sim.Config{
+					N: 4, Seed: seed, Faults: sim.Faults{MinDelay: time.Millisecond, MaxDelay: 20 * time.Millisecond},
+					Byzantine: map[int]sim.Behaviour{0: b},
+				}, 3*time.Second, nil)
+				if err := c.CheckLiveness(3); err != nil {
+					t.Fatalf("liveness: %v", err)
+				}
+				if b == sim.WithholdVotes {
+					continue // nothing to prove
+				}
+				for _, id := range c.Honest() {
+					if _, ok := c.Apps[id].Jailed(c.IDs[0]); !ok {
+						t.Fatalf("seed %d: %s did not jail %s", seed, id, c.IDs[0])
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestSimDeterministic: the same seed yields the same run.
+func TestSimDeterministic(t *testing.T) {
+	cfg := sim.Config{N: 4, Seed: 42, Faults: lossyLinks}
+	ca, cb := runSim(t, cfg, time.Second, nil), runSim(t, cfg, time.Second, nil)
+	if sa, sb := ca.Net.Stats(), cb.Net.Stats(); sa != sb {
+		t.Fatalf("message stats differ: %+v vs %+v", sa, sb)
+	}
+	a, b := ca.Committed("val2"), cb.Committed("val2")
+	if len(a) == 0 {
+		t.Fatal("nothing committed")
+	}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			t.Fatalf("height %d differs between runs with the same seed", i+1)
+		}
+	}
+	if len(a) != len(b) {
+		t.Fatalf("runs committed %d and %d blocks", len(a), len(b))
+	}
+}
@@ -0,0 +1,283 @@
+// file: tests/trigger_test.go
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/market"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/orderbook"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/transaction"
+	"github.com/uhyunpark/hyperlicked/pkg/app/core/trigger"
+	"github.com/uhyunpark/hyperlicked/pkg/app/perp"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+)
+
+// TestTriggerConditions: stops fire when the price moves against the
+// position they protect, take-profits when it moves in its favour.
+func TestTriggerConditions(t *testing.T) {
+	tests := []struct {
+		kind       trigger.Kind
+		side       orderbook.Side
+		ref        trigger.PriceRef
+		mark, last int64
+		want       bool
+	}{
+		{trigger.StopMarket, orderbook.Sell, trigger.MarkPrice, 99, 0, true},
+		{trigger.StopMarket, orderbook.Sell, trigger.MarkPrice, 101, 0, false},
+		{trigger.StopLimit, orderbook.Buy, trigger.MarkPrice, 100, 0, true},
+		{trigger.StopLimit, orderbook.Buy, trigger.MarkPrice, 99, 0, false},
+		{trigger.TakeProfitMarket, orderbook.Sell, trigger.MarkPrice, 101, 0, true},
+		{trigger.TakeProfitMarket, orderbook.Sell, trigger.MarkPrice, 99, 0, false},
+		{trigger.TakeProfitLimit, orderbook.Buy, trigger.MarkPrice, 99, 0, true},
+		{trigger.TakeProfitLimit, orderbook.Buy, trigger.MarkPrice, 101, 0, false},
+		// The reference price decides; an unknown one never fires
+		{trigger.StopMarket, orderbook.Sell, trigger.LastPrice, 99, 101, false},
+		{trigger.StopMarket, orderbook.Sell, trigger.LastPrice, 101, 99, true},
+		{trigger.StopMarket, orderbook.Sell, trigger.MarkPrice, 0, 99, false},
+	}
+	for i, tt := range tests {
+		o := &trigger.Order{Kind: tt.kind, Side: tt.side, Ref: tt.ref, TriggerPrice: 100, Qty: 1}
+		if !o.IsMarket() {
+			o.Price = 100
+		}
+		if err := o.Validate(); err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if got := o.Triggered(tt.mark, tt.last); got != tt.want {
+			t.Errorf("case %d (kind %d side %d mark %d last %d): triggered %v, want %v",
+				i, tt.kind, tt.side, tt.mark, tt.last, got, tt.want)
+		}
+	}
+	if err := (&trigger.Order{Kind: trigger.StopLimit, Side: orderbook.Buy, Ref: trigger.MarkPrice, TriggerPrice: 100, Qty: 1}).Validate(); err == nil {
+		t.Error("stop-limit without a limit price validated")
+	}
+	if err := (&trigger.Order{Kind: trigger.StopMarket, Side: orderbook.Buy, Ref: trigger.MarkPrice, TriggerPrice: 100, Price: 1, Qty: 1}).Validate(); err == nil {
+		t.Error("stop-market with a price validated")
+	}
+}
+
+// TestTriggerStore: due orders come oldest first, cancels need the owner
+// and clones are independent.
+func TestTriggerStore(t *testing.T) {
+	s := trigger.NewStore()
+	for _, id := range []string{"c", "a", "b"} {
+		o := &trigger.Order{ID: id, Owner: alice, Symbol: "BTC-USDT", Side: orderbook.Sell, Kind: trigger.StopMarket, Ref: trigger.LastPrice, TriggerPrice: 100, Qty: 1}
+		if err := s.Add(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add(&trigger.Order{ID: "a"}); err != trigger.ErrDuplicate {
+		t.Fatalf("duplicate add: %v", err)
+	}
+	due := s.Due("BTC-USDT", 0, 90)
+	if len(due) != 3 || due[0].ID != "c" || due[1].ID != "a" || due[2].ID != "b" {
+		t.Fatalf("due out of arrival order: %v", due)
+	}
+	if len(s.Due("BTC-USDT", 0, 110)) != 0 || len(s.Due("ETH-USDT", 0, 90)) != 0 {
+		t.Fatal("orders due above their stop, or on another market")
+	}
+
+	c := s.Clone()
+	if s.Cancel("a", bob) {
+		t.Fatal("cancelled someone else's trigger order")
+	}
+	if !c.Cancel("a", alice) || c.Len() != 2 || s.Len() != 3 {
+		t.Fatalf("clone cancel: clone %d, store %d orders", c.Len(), s.Len())
+	}
+}
+
+// TestMarketOrder: a MARKET order sweeps the book at any price and never
+// rests.
+func TestMarketOrder(t *testing.T) {
+	mkt, _ := market.NewMarketWithDefaults("HYPL-USDC", "HYPL", "USDC")
+	book := orderbook.NewOrderBook()
+	for i, price := range []int64{50000, 60000} {
+		ask := &orderbook.Order{ID: fmt.Sprintf("ask%d", i), Symbol: "HYPL-USDC", Side: orderbook.Sell, Price: price, Qty: 100, Type: "GTC"}
+		if _, err := book.Place(ask, mkt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buy := &orderbook.Order{ID: "mkt", Symbol: "HYPL-USDC", Side: orderbook.Buy, Qty: 300, Type: "MARKET"}
+	fills, err := book.Place(buy, mkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 || fills[1].Price != 60000 {
+		t.Fatalf("market buy fills %+v, want both asks", fills)
+	}
+	if len(book.GetAskLevels()) != 0 || len(book.GetBidLevels()) != 0 {
+		t.Fatal("market order rested")
+	}
+}
+
+// TestSignedMarketOrderMargin: a signed MARKET order cannot carry a price,
+// and is margined at the price it would fill at, not at one it signs.
+func TestSignedMarketOrderMargin(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	bridge := &abci.Bridge{App: app}
+
+	signer, _ := crypto.GenerateKey()
+	owner := signer.Address()
+	app.GetAccount(owner).USDCBalance = 10_000 // 100 lots at 50000 need 100_000
+
+	eip712 := crypto.NewEIP712Signer(crypto.DefaultDomain())
+	marketTx := func(nonce, price int64) []byte {
+		order := &crypto.OrderEIP712{
+			Symbol: "BTC-USDT", Side: 1, Type: 4, Price: big.NewInt(price), Qty: big.NewInt(100),
+			Nonce: big.NewInt(nonce), Deadline: big.NewInt(0), Leverage: 10, Owner: owner,
+		}
+		sig, err := eip712.SignOrder(signer, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type: transaction.TxTypeOrder, Order: transaction.FromEIP712Order(order),
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+
+	if resp := app.CheckTx(abci.RequestCheckTx{Tx: marketTx(1, 1)}); resp.Accept {
+		t.Fatal("MARKET order with a price admitted")
+	}
+	txs := [][]byte{
+		[]byte("O:GTC:BTC-USDT:SELL:price=50000:qty=100:id=ask"),
+		marketTx(2, 0),
+	}
+	b := consensus.Block{Height: 1, View: 1, Parent: consensus.HashOfBlock(consensus.GenesisBlock()),
+		Payload: consensus.EncodePayload(txs), Time: time.Unix(100, 0)}
+	if _, err := bridge.Execute(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := bridge.Commit(b); err != nil {
+		t.Fatal(err)
+	}
+	acc := app.GetAccount(owner)
+	if acc.TradeCount != 0 || len(acc.Rejected) != 1 || !strings.Contains(acc.Rejected[0].Reason, "insufficient margin") {
+		t.Fatalf("MARKET buy without the margin: %d trades, rejected %+v", acc.TradeCount, acc.Rejected)
+	}
+	if asks := app.GetOrderbook("BTC-USDT").GetAskLevels(); len(asks) != 1 || asks[0].Qty != 100 {
+		t.Fatalf("asks %+v, want the ask untouched", asks)
+	}
+}
+
+// TestSignedTriggerOrders: a signed stop order waits off the book, fires
+// as a market order in the block whose trades reach its trigger price, and
+// a signed cancel removes one before it fires.
+func TestSignedTriggerOrders(t *testing.T) {
+	t.Chdir(t.TempDir()) // the app keeps its accounts in ./data
+	app := perp.NewApp()
+	t.Cleanup(func() { app.Close() })
+	bridge := &abci.Bridge{App: app}
+
+	signer, _ := crypto.GenerateKey()
+	owner := signer.Address()
+	app.GetAccount(owner).USDCBalance = 1_000_000_000
+	maker := common.HexToAddress("0xBB00000000000000000000000000000000000000").Hex()
+
+	eip712 := crypto.NewEIP712Signer(crypto.DefaultDomain())
+	triggerTx := func(nonce int64, kind uint8, price int64) []byte {
+		order := &crypto.TriggerOrderEIP712{
+			Symbol: "BTC-USDT", Side: 1, Kind: kind,
+			TriggerPrice: big.NewInt(50100), PriceRef: 2, Price: big.NewInt(price),
+			Qty: big.NewInt(50), Nonce: big.NewInt(nonce), Deadline: big.NewInt(0),
+			Leverage: 10, Owner: owner,
+		}
+		sig, err := eip712.SignTriggerOrder(signer, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type: transaction.TxTypeTrigger, Trigger: transaction.FromEIP712TriggerOrder(order),
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+	cancelTx := func(nonce int64, id string) []byte {
+		hash := ethCrypto.Keccak256([]byte(fmt.Sprintf("CANCEL:BTC-USDT:%s:%d", id, nonce)))
+		sig, err := signer.Sign(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(&transaction.SignedTransaction{
+			Type:      transaction.TxTypeCancel,
+			Cancel:    &transaction.CancelPayload{OrderID: id, Symbol: "BTC-USDT", Nonce: fmt.Sprint(nonce), Owner: owner.Hex()},
+			Signature: fmt.Sprintf("0x%x", sig),
+		})
+		return b
+	}
+	parent := consensus.GenesisBlock()
+	run := func(txs ...[]byte) {
+		t.Helper()
+		for _, tx := range txs {
+			if err := app.CheckTx(abci.RequestCheckTx{Tx: tx}).Reason; err != "" {
+				t.Fatalf("tx rejected: %s", err)
+			}
+		}
+		b := consensus.Block{Height: parent.Height + 1, View: parent.View + 1, Parent: consensus.HashOfBlock(parent),
+			Payload: consensus.EncodePayload(txs), Time: time.Unix(100+int64(parent.Height), 0)}
+		if _, err := bridge.Execute(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := bridge.Commit(b); err != nil {
+			t.Fatal(err)
+		}
+		parent = b
+	}
+	pending := func() []trigger.Order {
+		t.Helper()
+		resp, err := app.Query(abci.RequestQuery{Path: "triggers/" + owner.Hex()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var orders []trigger.Order
+		if err := json.Unmarshal(resp.Value, &orders); err != nil {
+			t.Fatal(err)
+		}
+		return orders
+	}
+
+	// Block 1: a stop-market and a stop-limit buy over an ask at 50200;
+	// nothing has traded, so neither fires
+	run(
+		[]byte("O:GTC:BTC-USDT:SELL:price=50200:qty=100:id="+maker+"-ask"),
+		triggerTx(1, 1, 0),
+		triggerTx(2, 2, 50150),
+	)
+	if got := pending(); len(got) != 2 {
+		t.Fatalf("%d trigger orders pending, want 2", len(got))
+	}
+
+	// Block 2: the stop-limit is cancelled; a trade at 50100 fires the
+	// stop-market, which buys from the ask
+	stopLimit := fmt.Sprintf("%s-trg-2", owner.Hex())
+	run(
+		cancelTx(3, stopLimit),
+		[]byte("O:GTC:BTC-USDT:SELL:price=50100:qty=10:id=s1"),
+		[]byte("O:IOC:BTC-USDT:BUY:price=50100:qty=10:id=b1"),
+	)
+	if got := pending(); len(got) != 0 {
+		t.Fatalf("trigger orders still pending: %+v", got)
+	}
+	asks := app.GetOrderbook("BTC-USDT").GetAskLevels()
+	if len(asks) != 1 || asks[0].Price != 50200 || asks[0].Qty != 50 {
+		t.Fatalf("asks after the stop fired: %+v, want 50 left at 50200", asks)
+	}
+	if bids := app.GetOrderbook("BTC-USDT").GetBidLevels(); len(bids) != 0 {
+		t.Fatalf("a trigger order rested on the book: %+v", bids)
+	}
+	if acc := app.GetAccount(owner); acc.TradeCount != 1 {
+		t.Fatalf("owner trade count %d, want 1", acc.TradeCount)
+	}
+}
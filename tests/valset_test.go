@@ -0,0 +1,154 @@
+// file: tests/valset_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/sim"
+)
+
+// TestValidatorSetQuorum: quorums are measured in voting power, and Apply
+// keeps the order of surviving validators.
+func TestValidatorSetQuorum(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	eq, err := consensus.EqualPowerSet(0, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq.QuorumPower() != 3 || eq.MaxFaultyPower() != 1 || eq.MinQuorumSize() != 3 {
+		t.Fatalf("N=4: quorum %d, faulty %d, min size %d", eq.QuorumPower(), eq.MaxFaultyPower(), eq.MinQuorumSize())
+	}
+
+	// val1 holds 5 of 8: alone it is not a quorum (needs 6), with any other it is
+	ws, err := consensus.NewValidatorSet(0, []consensus.Validator{
+		{ID: "val1", Power: 5}, {ID: "val2", Power: 1}, {ID: "val3", Power: 1}, {ID: "val4", Power: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.QuorumPower() != 6 || ws.MinQuorumSize() != 2 {
+		t.Fatalf("weighted: quorum %d, min size %d", ws.QuorumPower(), ws.MinQuorumSize())
+	}
+	if ws.HasQuorum([]consensus.NodeID{"val1"}) || !ws.HasQuorum([]consensus.NodeID{"val1", "val4"}) {
+		t.Fatal("weighted quorum miscounted")
+	}
+	if ws.HasQuorum([]consensus.NodeID{"val2", "val3", "val4", "val2", "val9"}) {
+		t.Fatal("duplicate or unknown signers counted")
+	}
+
+	next, err := ws.Apply(1, []consensus.ValidatorUpdate{{ID: "val2", Power: 0}, {ID: "val5", Power: 2}, {ID: "val3", Power: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []consensus.Validator{{ID: "val1", Power: 5}, {ID: "val3", Power: 4}, {ID: "val4", Power: 1}, {ID: "val5", Power: 2}}
+	if len(next.Validators) != len(want) {
+		t.Fatalf("apply: %+v", next.Validators)
+	}
+	for i := range want {
+		if next.Validators[i] != want[i] {
+			t.Fatalf("apply: %+v, want %+v", next.Validators, want)
+		}
+	}
+	if next.Epoch != 1 || next.Hash() == ws.Hash() {
+		t.Fatal("applied set not distinguished by epoch and hash")
+	}
+	if _, err := ws.Apply(1, []consensus.ValidatorUpdate{
+		{ID: "val1", Power: 0}, {ID: "val2", Power: 0}, {ID: "val3", Power: 0}, {ID: "val4", Power: 0},
+	}); !errors.Is(err, consensus.ErrValSetEmpty) {
+		t.Fatalf("emptying the set: %v", err)
+	}
+	if _, err := consensus.NewValidatorSet(0, []consensus.Validator{{ID: "val1", Power: 1}, {ID: "val1", Power: 2}}); !errors.Is(err, consensus.ErrValSetDuplicate) {
+		t.Fatalf("duplicate validator: %v", err)
+	}
+	if _, err := consensus.NewValidatorSet(0, []consensus.Validator{{ID: "val1", Power: consensus.MaxTotalPower}, {ID: "val2", Power: 1}}); !errors.Is(err, consensus.ErrValSetPower) {
+		t.Fatalf("power overflow: %v", err)
+	}
+}
+
+// TestVerifyCertificateWeighted: a QC is valid when its signers hold a
+// quorum of power, whatever their number.
+func TestVerifyCertificateWeighted(t *testing.T) {
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	signers := c.enableBLS()
+	pubKeys := c.engines["val1"].PubKeys
+	vs, err := consensus.NewValidatorSet(0, []consensus.Validator{
+		{ID: "val1", Power: 7}, {ID: "val2", Power: 1}, {ID: "val3", Power: 1}, {ID: "val4", Power: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, app := consensus.Hash{4}, consensus.Hash{5}
+	msg := consensus.VoteSignBytes("test", 4, 4, h, app)
+	cert := func(by ...consensus.NodeID) consensus.Certificate {
+		var shares [][]byte
+		for _, id := range by {
+			shares = append(shares, signers[id].Sign(msg))
+		}
+		return consensus.Certificate{View: 4, Height: 4, H: h, AppHash: app,
+			Sig: crypto.Aggregate(shares), Signers: consensus.SignerBitmap(vs.IDs(), by)}
+	}
+	if err := consensus.VerifyCertificate(cert("val1"), msg, vs, pubKeys); err != nil {
+		t.Fatalf("heavy validator alone: %v", err)
+	}
+	if err := consensus.VerifyCertificate(cert("val2", "val3", "val4"), msg, vs, pubKeys); err == nil {
+		t.Fatal("three light validators (3 of 10) accepted as a quorum")
+	}
+}
+
+// TestSimValidatorSetChange: validator updates emitted in epoch 0 take
+// effect in epoch 2. val4 is removed and val1 raised to 3 of 5, after which
+// val1 and val2 alone keep the chain going.
+func TestSimValidatorSetChange(t *testing.T) {
+	const epochLen = 4 // epoch 2 starts at height 9
+	for _, seed := range simSeeds(t, 2) {
+		c := sim.NewCluster(sim.Config{N: 4, Seed: seed, EpochLength: epochLen,
+			Faults: sim.Faults{MaxDelay: 10 * time.Millisecond}})
+		for _, app := range c.Apps {
+			app.SetValidatorPower(2, "val4", 0)
+			app.SetValidatorPower(2, "val1", 3)
+		}
+		c.Start(context.Background())
+		c.RunFor(4 * time.Second)
+
+		e := c.Engines["val1"]
+		if err := c.CheckLiveness(3 * epochLen); err != nil {
+			t.Fatalf("liveness: %v", err)
+		}
+		vs := e.ActiveValidators()
+		if vs.Epoch < 2 || vs.Has("val4") || vs.Power("val1") != 3 || vs.TotalPower() != 5 {
+			t.Fatalf("seed %d: active set %+v", seed, vs)
+		}
+		first, ok := e.Store.GetBlockByHeight(1)
+		if !ok {
+			t.Fatal("height 1 missing")
+		}
+		later, ok := e.Store.GetBlockByHeight(2*epochLen + 1)
+		if !ok {
+			t.Fatal("first block of epoch 2 missing")
+		}
+		if first.ValSetHash == later.ValSetHash {
+			t.Fatalf("seed %d: epoch 2 block still names the genesis set", seed)
+		}
+		if got, err := e.ValidatorSetOf(later); err != nil || got.Power("val1") != 3 {
+			t.Fatalf("seed %d: set of height %d = %+v, %v", seed, later.Height, got, err)
+		}
+
+		// val1+val2 now hold 4 of 5 >= quorum 4; with the genesis set they could not commit
+		c.Net.Partition([]consensus.NodeID{"val1", "val2"}, []consensus.NodeID{"val3", "val4"})
+		before := len(c.Committed("val1"))
+		c.RunFor(4 * time.Second)
+		c.Stop()
+		if err := c.CheckAgreement(); err != nil {
+			t.Fatalf("agreement: %v (rerun with SIM_SEED=%d)", err, seed)
+		}
+		if got := len(c.Committed("val1")); got < before+3 {
+			t.Fatalf("seed %d: %d commits after the partition, want >= 3", seed, got-before)
+		}
+	}
+}
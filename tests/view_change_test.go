@@ -0,0 +1,262 @@
+// file: tests/view_change_test.go
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/abci"
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/crypto"
+	"github.com/uhyunpark/hyperlicked/pkg/storage"
+	"github.com/uhyunpark/hyperlicked/pkg/util"
+)
+
+// memCluster runs N engines over a memHub with round-robin leaders.
+type memCluster struct {
+	ids     []consensus.NodeID
+	hub     *memHub
+	engines map[consensus.NodeID]*consensus.Engine
+	cancels map[consensus.NodeID]context.CancelFunc
+}
+
+func newMemCluster(t *testing.T, ids []consensus.NodeID) *memCluster {
+	t.Helper()
+	c := &memCluster{
+		ids:     ids,
+		hub:     newMemHub(),
+		engines: make(map[consensus.NodeID]*consensus.Engine),
+		cancels: make(map[consensus.NodeID]context.CancelFunc),
+	}
+	for _, id := range ids {
+		c.engines[id] = newMemEngine(id, ids, c.hub.join(id))
+	}
+	return c
+}
+
+// newMemEngine builds a round-robin engine for id over net (no BLS, in-memory store).
+func newMemEngine(id consensus.NodeID, ids []consensus.NodeID, net *memNet) *consensus.Engine {
+	n := len(ids)
+	state := &consensus.State{
+		Q:       consensus.Quorum{N: n, T: (n - 1) / 3},
+		SelfID:  id,
+		Blocks:  make(map[consensus.Hash]consensus.Block),
+		Genesis: consensus.GenesisBlock(),
+	}
+	pm := consensus.NewPacemaker(
+		consensus.PacemakerTimers{Ppc: 50 * time.Millisecond, Delta: 50 * time.Millisecond},
+		util.RealClock{},
+		state,
+	)
+	e := consensus.NewEngine(state, consensus.NewSafety(state), pm, &abci.Bridge{App: abci.NewMockApp()},
+		net, consensus.RoundRobinElector{IDs: ids}, crypto.DummySigner{})
+	e.Store = storage.NewInMemoryBlockStore()
+	e.ChainID = "memnet"
+	return e
+}
+
+func (c *memCluster) start(t *testing.T, ctx context.Context) {
+	t.Helper()
+	for _, id := range c.ids {
+		c.startNode(t, ctx, id)
+	}
+}
+
+// startNode runs id's network dispatcher and engine until ctx is done or id is killed.
+func (c *memCluster) startNode(t *testing.T, ctx context.Context, id consensus.NodeID) {
+	t.Helper()
+	nctx, cancel := context.WithCancel(ctx)
+	c.cancels[id] = cancel
+	go c.hub.nodes[id].run(nctx)
+	go func() {
+		if err := c.engines[id].Run(nctx); err != nil && nctx.Err() == nil {
+			t.Errorf("%s: engine error: %v", id, err)
+		}
+	}()
+}
+
+// enableBLS gives every engine its own BLS key plus the full validator key set,
+// so votes, QCs and timeouts are signed and verified.
+func (c *memCluster) enableBLS() map[consensus.NodeID]*crypto.BLSSigner {
+	signers := make(map[consensus.NodeID]*crypto.BLSSigner, len(c.ids))
+	pubKeys := make(map[consensus.NodeID]*crypto.BLSPubKey, len(c.ids))
+	for i, id := range c.ids {
+		seed := make([]byte, 32)
+		copy(seed, fmt.Sprintf("bls-seed-%d", i))
+		signers[id] = crypto.NewBLSSignerFromSeed(seed)
+		pubKeys[id] = signers[id].Pubkey()
+	}
+	for id, e := range c.engines {
+		e.EnableBLS = true
+		e.Signer = signers[id]
+		e.PubKeys = pubKeys
+		e.Validators = c.ids
+	}
+	return signers
+}
+
+// kill crashes a validator: its engine stops and its links go dark.
+func (c *memCluster) kill(id consensus.NodeID) {
+	c.hub.kill(id)
+	c.cancels[id]()
+}
+
+// waitHeight blocks until every listed engine has committed height h.
+func (c *memCluster) waitHeight(t *testing.T, ids []consensus.NodeID, h consensus.Height, timeout time.Duration) {
+	t.Helper()
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-deadline:
+			for _, id := range ids {
+				t.Logf("%s: height=%d view=%d", id, c.engines[id].State.Height, c.engines[id].State.View)
+			}
+			t.Fatalf("timeout waiting for height %d", h)
+		case <-ticker.C:
+			ready := true
+			for _, id := range ids {
+				if c.engines[id].State.Height < h {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				return
+			}
+		}
+	}
+}
+
+// committedChain walks parent links from the committed head of e's store.
+func committedChain(e *consensus.Engine) []consensus.Block {
+	head, ok := e.Store.GetCommitted()
+	if !ok {
+		return nil
+	}
+	var chain []consensus.Block
+	for {
+		b, ok := e.Store.GetBlock(head)
+		if !ok || b.Height == 0 {
+			return chain
+		}
+		chain = append(chain, b)
+		head = b.Parent
+	}
+}
+
+// TestViewChangeLeaderCrash: the leader of the upcoming view is killed; the
+// remaining 3 of 4 validators must time out, form a TC and keep committing
+// under the next leader.
+func TestViewChangeLeaderCrash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	c := newMemCluster(t, ids)
+	c.start(t, ctx)
+
+	c.waitHeight(t, ids, 2, 5*time.Second)
+
+	// Kill the leader of the view after the current one
+	victimView := c.engines["val1"].State.View + 1
+	victim := consensus.RoundRobinElector{IDs: ids}.LeaderOf(victimView)
+	c.kill(victim)
+	t.Logf("killed %s (leader of view %d)", victim, victimView)
+
+	var alive []consensus.NodeID
+	for _, id := range ids {
+		if id != victim {
+			alive = append(alive, id)
+		}
+	}
+	target := c.engines[alive[0]].State.Height + 6
+	c.waitHeight(t, alive, target, 10*time.Second)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// Progress must have been made past the victim's views
+	for _, id := range alive {
+		e := c.engines[id]
+		if e.State.View <= victimView+consensus.View(len(ids)) {
+			t.Errorf("%s: view %d did not move past victim view %d", id, e.State.View, victimView)
+		}
+	}
+
+	// Committed chains must agree: every node's chain is a prefix of the longest
+	var ref []consensus.Block
+	for _, id := range alive {
+		if chain := committedChain(c.engines[id]); len(chain) > len(ref) {
+			ref = chain
+		}
+	}
+	inRef := make(map[consensus.Hash]bool, len(ref))
+	for _, b := range ref {
+		inRef[consensus.HashOfBlock(b)] = true
+	}
+	for _, id := range alive {
+		for _, b := range committedChain(c.engines[id]) {
+			if !inRef[consensus.HashOfBlock(b)] {
+				t.Fatalf("%s: committed block at view %d not on the common chain", id, b.View)
+			}
+		}
+	}
+
+	// Blocks proposed after the crash must come from live leaders only
+	for _, b := range ref {
+		if b.View > victimView && b.Proposer == victim {
+			t.Fatalf("committed block at view %d proposed by crashed %s", b.View, victim)
+		}
+	}
+}
+
+// TestPacemakerBackoff: consecutive failed views double the timeout, a QC resets it.
+func TestPacemakerBackoff(t *testing.T) {
+	st := &consensus.State{Genesis: consensus.GenesisBlock()}
+	pm := consensus.NewPacemaker(
+		consensus.PacemakerTimers{Ppc: 150 * time.Millisecond, Delta: 50 * time.Millisecond},
+		util.RealClock{}, st)
+
+	if got := pm.ViewTimeout(); got != 200*time.Millisecond {
+		t.Fatalf("base timeout = %v, want 200ms", got)
+	}
+	pm.OnViewFailed()
+	pm.OnViewFailed()
+	if got := pm.ViewTimeout(); got != 800*time.Millisecond {
+		t.Fatalf("after 2 failures timeout = %v, want 800ms", got)
+	}
+	for i := 0; i < 20; i++ {
+		pm.OnViewFailed()
+	}
+	if got := pm.ViewTimeout(); got != 200*time.Millisecond<<6 {
+		t.Fatalf("backoff not capped: %v", got)
+	}
+	pm.OnQC()
+	if got := pm.ViewTimeout(); got != 200*time.Millisecond {
+		t.Fatalf("QC did not reset backoff: %v", got)
+	}
+}
+
+// TestTimeoutCertHighCert: the TC exposes the highest cert among its timeouts.
+func TestTimeoutCertHighCert(t *testing.T) {
+	tc := consensus.TimeoutCert{View: 7, Timeouts: []consensus.Timeout{
+		{View: 7, From: "val1", HighCert: consensus.Certificate{View: 4}},
+		{View: 7, From: "val3", HighCert: consensus.Certificate{View: 6}},
+		{View: 7, From: "val4", HighCert: consensus.Certificate{View: 5}},
+	}}
+	if got := tc.HighCert().View; got != 6 {
+		t.Fatalf("HighCert view = %d, want 6", got)
+	}
+
+	st := &consensus.State{Genesis: consensus.GenesisBlock()}
+	sf := consensus.NewSafety(st)
+	if sf.CanVote(consensus.Propose{HighCert: consensus.Certificate{View: 5}, TC: &tc}) {
+		t.Fatal("expected CanVote=false for proposal below TC high cert")
+	}
+	if !sf.CanVote(consensus.Propose{HighCert: consensus.Certificate{View: 6}, TC: &tc}) {
+		t.Fatal("expected CanVote=true for proposal extending TC high cert")
+	}
+}
@@ -0,0 +1,236 @@
+// file: tests/wal_test.go
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uhyunpark/hyperlicked/pkg/consensus"
+	"github.com/uhyunpark/hyperlicked/pkg/storage"
+)
+
+func walRecords(t *testing.T, w consensus.WAL) []consensus.Record {
+	t.Helper()
+	var out []consensus.Record
+	if err := w.Replay(func(r consensus.Record) { out = append(out, r) }); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	return out
+}
+
+func walSegmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWALReplayRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	w, err := storage.OpenFileWAL(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blk := consensus.Block{Height: 3, View: 4, Payload: []byte("tx"), Proposer: "val4", Time: time.Unix(10, 0)}
+	cert := consensus.Certificate{View: 4, Height: 3, H: consensus.HashOfBlock(blk), AppHash: consensus.Hash{9}, Sig: []byte("agg")}
+	in := []consensus.Record{
+		{Type: consensus.RecordProposal, Proposal: &consensus.Propose{Block: blk, HighCert: cert}},
+		{Type: consensus.RecordVote, Vote: &consensus.Vote{View: 4, Height: 3, H: cert.H, From: "val2"}},
+		{Type: consensus.RecordCert, Cert: &cert},
+		{Type: consensus.RecordTimeout, Timeout: &consensus.Timeout{View: 5, HighCert: cert, From: "val2"}},
+		{Type: consensus.RecordCommit, Commit: &consensus.CommitInfo{Height: 3, View: 4, H: cert.H, AppHash: cert.AppHash}},
+	}
+	for _, r := range in {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("write %s: %v", r.Type, err)
+		}
+	}
+	w.Close()
+
+	w, err = storage.OpenFileWAL(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	out := walRecords(t, w)
+	if len(out) != len(in) {
+		t.Fatalf("replayed %d records, want %d", len(out), len(in))
+	}
+	for i, r := range out {
+		if r.Type != in[i].Type {
+			t.Fatalf("record %d: type %s, want %s", i, r.Type, in[i].Type)
+		}
+	}
+	if got := out[0].Proposal.Block; consensus.HashOfBlock(got) != cert.H {
+		t.Error("proposal block changed across replay")
+	}
+	if out[1].Vote.From != "val2" || out[3].Timeout.View != 5 || out[4].Commit.AppHash != cert.AppHash {
+		t.Error("record contents changed across replay")
+	}
+}
+
+// TestWALTornTail: a partially written frame at the end is dropped on open
+// and later writes append after the last intact record.
+func TestWALTornTail(t *testing.T) {
+	for name, tail := range map[string][]byte{
+		"short header":  {0x00, 0x00, 0x01},
+		"short payload": {0x00, 0x00, 0x00, 0x40, 0xde, 0xad, 0xbe, 0xef, 0x01, 0x02},
+		"bad checksum":  {0x00, 0x00, 0x00, 0x02, 0xde, 0xad, 0xbe, 0xef, 0x02, 0x00},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := storage.OpenFileWAL(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for v := consensus.View(1); v <= 3; v++ {
+				if err := w.Write(consensus.Record{Type: consensus.RecordVote, Vote: &consensus.Vote{View: v}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w.Close()
+
+			seg := walSegmentFiles(t, dir)[0]
+			f, err := os.OpenFile(seg, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(tail)
+			f.Close()
+
+			w, err = storage.OpenFileWAL(dir, 0)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer w.Close()
+			if got := len(walRecords(t, w)); got != 3 {
+				t.Fatalf("replayed %d records, want 3", got)
+			}
+			if err := w.Write(consensus.Record{Type: consensus.RecordVote, Vote: &consensus.Vote{View: 4}}); err != nil {
+				t.Fatal(err)
+			}
+			out := walRecords(t, w)
+			if len(out) != 4 || out[3].Vote.View != 4 {
+				t.Fatalf("write after truncation not replayed: %d records", len(out))
+			}
+		})
+	}
+}
+
+func TestWALSegmentRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := storage.OpenFileWAL(dir, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := consensus.View(1); v <= 50; v++ {
+		if err := w.Write(consensus.Record{Type: consensus.RecordVote, Vote: &consensus.Vote{View: v, From: "val1"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	if n := len(walSegmentFiles(t, dir)); n < 3 {
+		t.Fatalf("expected rotation into several segments, got %d", n)
+	}
+	w, err = storage.OpenFileWAL(dir, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := walRecords(t, w)
+	w.Close()
+	if len(out) != 50 {
+		t.Fatalf("replayed %d records, want 50", len(out))
+	}
+	for i, r := range out {
+		if r.Vote.View != consensus.View(i+1) {
+			t.Fatalf("record %d out of order: view %d", i, r.Vote.View)
+		}
+	}
+
+	// Damage inside an older segment is corruption, not a torn tail
+	first := walSegmentFiles(t, dir)[0]
+	data, _ := os.ReadFile(first)
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(first, data, 0o644)
+	w, err = storage.OpenFileWAL(dir, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Replay(func(consensus.Record) {}); !errors.Is(err, storage.ErrWALCorrupt) {
+		t.Fatalf("replay of damaged segment: got %v, want ErrWALCorrupt", err)
+	}
+}
+
+// TestWALRecoverEngine: a validator whose only persistent state is the WAL is
+// crashed and rebuilt from it, resuming exactly where it stopped.
+func TestWALRecoverEngine(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	ids := []consensus.NodeID{"val1", "val2", "val3", "val4"}
+	victim := consensus.NodeID("val3")
+	dir := t.TempDir()
+	wal, err := storage.OpenFileWAL(dir, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newMemCluster(t, ids)
+	c.engines[victim].WAL = wal
+	c.start(t, ctx)
+	c.waitHeight(t, ids, 4, 5*time.Second)
+	c.kill(victim)
+	time.Sleep(100 * time.Millisecond) // let in-flight handlers drain
+
+	before := c.engines[victim]
+	wantHeight := before.State.Height
+	wantVoted := before.Safety.LastVoted()
+	wantHigh := before.Safety.HighestCert()
+	wantLock := before.State.Locked
+	wantCommitted, _ := before.Store.GetCommitted()
+	wal.Close()
+
+	wal, err = storage.OpenFileWAL(dir, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	restarted := newMemEngine(victim, ids, newMemHub().join(victim)) // empty in-memory store
+	restarted.WAL = wal
+	if err := restarted.Recover(); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+
+	if restarted.State.Height != wantHeight {
+		t.Errorf("height %d, want %d", restarted.State.Height, wantHeight)
+	}
+	if got := restarted.Safety.LastVoted(); got != wantVoted {
+		t.Errorf("last voted %d, want %d", got, wantVoted)
+	}
+	if got := restarted.Safety.HighestCert(); got.View != wantHigh.View || got.H != wantHigh.H {
+		t.Errorf("high cert view %d, want %d", got.View, wantHigh.View)
+	}
+	if wantLock != nil {
+		if restarted.State.Locked == nil || restarted.State.Locked.Cert.H != wantLock.Cert.H {
+			t.Error("lock not recovered")
+		}
+	}
+	if got, ok := restarted.Store.GetCommitted(); !ok || got != wantCommitted {
+		t.Error("committed head not recovered")
+	}
+	if _, ok := restarted.Store.GetBlock(wantHigh.H); !ok && wantHigh.View > 0 {
+		t.Error("high cert block not recovered")
+	}
+	if restarted.Safety.CanVoteInView(wantVoted) {
+		t.Errorf("restarted validator may vote again in view %d", wantVoted)
+	}
+}
@@ -64,6 +64,8 @@ export function TradePanel() {
         nonce: nonce.toString(),
         deadline: '0', // No expiry
         leverage,
+        reduceOnly: false,
+        stp: 0, // market's self-trade prevention
         owner: wallet.address
       }
 
@@ -62,12 +62,14 @@ export interface SignedTransaction {
   order?: {
     symbol: string
     side: number      // 1=Buy, 2=Sell
-    type: number      // 1=GTC, 2=IOC, 3=ALO
+    type: number      // 1=GTC, 2=IOC, 3=ALO, 4=MARKET, 5=CLOSE, 6=FOK
     price: string     // BigInt as string
-    qty: string       // BigInt as string
+    qty: string       // BigInt as string (0 for CLOSE)
     nonce: string     // BigInt as string
     deadline: string  // BigInt as string
     leverage: number
+    reduceOnly: boolean
+    stp: number
     owner: string     // Address
   }
   cancel?: {
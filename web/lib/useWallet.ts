@@ -28,6 +28,8 @@ export const EIP712_ORDER_TYPES = {
     { name: 'nonce', type: 'uint256' },
     { name: 'deadline', type: 'uint256' },
     { name: 'leverage', type: 'uint8' },
+    { name: 'reduceOnly', type: 'bool' },
+    { name: 'stp', type: 'uint8' },
     { name: 'owner', type: 'address' }
   ]
 }
@@ -68,12 +70,14 @@ export interface WalletState {
 export interface OrderToSign {
   symbol: string
   side: number // 1=Buy, 2=Sell
-  type: number // 1=GTC, 2=IOC, 3=ALO
+  type: number // 1=GTC, 2=IOC, 3=ALO, 4=MARKET, 5=CLOSE, 6=FOK
   price: string // BigInt as string
-  qty: string // BigInt as string
+  qty: string // BigInt as string (0 for CLOSE)
   nonce: string // BigInt as string
-  deadline: string // BigInt as string (0 = no expiry)
+  deadline: string // BigInt as string, Unix seconds (0 = no expiry)
   leverage: number
+  reduceOnly: boolean // only shrink the position, never open or flip it
+  stp: number // self-trade prevention: 0 = market's mode, 1 = none, 2-5 = cancel newest/oldest/both, decrement
   owner: string // Address
 }
 